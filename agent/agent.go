@@ -0,0 +1,512 @@
+/*
+Package agent implements the switch side of the openflow channel.
+
+An Agent exposes a Datapath to one or more controllers: it dials their
+addresses and redials lost connections with exponential backoff, performs
+the hello exchange, answers echo and features requests, probes idle
+connections with echo requests, and hands every other message to the
+Datapath. Asynchronous messages of the Datapath are sent with Agent.Send
+to every connected controller.
+
+When the last controller is lost, the Datapath is told to enter the fail
+mode of the agent: in fail secure mode it keeps forwarding with its flow
+table and drops messages for the controller, in fail standalone mode it
+forwards as a learning switch until a controller connects again.
+
+Auxiliary connections, as in OpenFlow 1.3, are opened to each dialed
+controller once its main connection is up. They tell their auxiliary id
+in the features reply and carry the packet ins, packets of a pair of
+ethernet addresses always take the same connection so that they stay in
+order. They are closed with the main connection. This is a goflow extension of
+OpenFlow 1.0, the controller must set Controller.Auxiliary to accept them.
+*/
+package agent
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"hash/fnv"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	ErrClosed       = errors.New("agent closed")
+	ErrNotConnected = errors.New("no controller connected")
+)
+
+// FailMode is how a datapath forwards without controller
+type FailMode int
+
+const (
+	// FailSecure keeps the flow table, OpenFlow 1.0 switches use their
+	// emergency flows
+	FailSecure FailMode = iota
+	// FailStandalone forwards as a learning switch
+	FailStandalone
+)
+
+func (m FailMode) String() string {
+	if m == FailStandalone {
+		return "standalone"
+	}
+	return "secure"
+}
+
+// Datapath is the device an agent exposes to controllers
+type Datapath interface {
+	// Features returns the features reply answering the request xid
+	Features(xid uint32) openflow.FeatureReply
+	// Handle processes a message of a controller other than hello, echo
+	// and features requests and returns the messages answering it
+	Handle(data []byte) []openflow.MessageDecoder
+	// ControllerConnected is called when a controller connects while
+	// none was connected
+	ControllerConnected()
+	// ControllerLost is called when the last controller is lost
+	ControllerLost(mode FailMode)
+}
+
+// outQueueLength is how many messages may wait to be written to a
+// controller before senders block
+const outQueueLength = 256
+
+// maxWriteLength bounds the queued messages written together
+const maxWriteLength = 64 * 1024
+
+// Agent connects a datapath to controllers, its fields must be set
+// before connecting
+type Agent struct {
+	// EchoInterval is the keepalive period, a controller silent for three
+	// periods is disconnected
+	EchoInterval time.Duration
+	// MinBackoff and MaxBackoff bound the wait before redialing
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// FailMode is entered when the last controller is lost
+	FailMode FailMode
+	// Dialer connects to a controller address, it dials TCP by default
+	Dialer func(addr string) (net.Conn, error)
+	// Auxiliary is the number of auxiliary connections opened to each
+	// dialed controller
+	Auxiliary int
+
+	dp       Datapath
+	xid      uint32
+	done     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	channels map[*channel]bool
+}
+
+// New returns an agent for dp with default timers in fail secure mode
+func New(dp Datapath) *Agent {
+	return &Agent{
+		EchoInterval: 15 * time.Second,
+		MinBackoff:   time.Second,
+		MaxBackoff:   8 * time.Second,
+		Dialer: func(addr string) (net.Conn, error) {
+			return net.DialTimeout("tcp", addr, 10*time.Second)
+		},
+		dp:       dp,
+		done:     make(chan struct{}),
+		channels: make(map[*channel]bool),
+	}
+}
+
+// channel is the connection to a controller, messages are written in
+// order by a goroutine so that reading is never blocked by writes
+type channel struct {
+	conn net.Conn
+	out  chan []byte
+	done chan struct{}
+	// aux is the auxiliary id and main the main channel of auxiliary
+	// channels, auxiliaries are the usable auxiliary channels of a main
+	// channel
+	aux         uint8
+	main        *channel
+	auxiliaries []*channel
+}
+
+func newChannel(conn net.Conn) *channel {
+	return &channel{
+		conn: conn,
+		out:  make(chan []byte, outQueueLength),
+		done: make(chan struct{}),
+	}
+}
+
+// write writes the queued messages, the messages queued meanwhile are
+// written together up to maxWriteLength bytes
+func (ch *channel) write() {
+	var buf []byte
+	for {
+		select {
+		case data := <-ch.out:
+			buf = append(buf[:0], data...)
+		drain:
+			for len(buf) < maxWriteLength {
+				select {
+				case data := <-ch.out:
+					buf = append(buf, data...)
+				default:
+					break drain
+				}
+			}
+			if _, err := ch.conn.Write(buf); err != nil {
+				ch.conn.Close()
+				return
+			}
+		case <-ch.done:
+			return
+		}
+	}
+}
+
+// queue queues an encoded message, it fails once the channel closed
+func (ch *channel) queue(data []byte) error {
+	select {
+	case ch.out <- data:
+		return nil
+	case <-ch.done:
+		return ErrNotConnected
+	}
+}
+
+func encode(msg openflow.MessageDecoder) ([]byte, error) {
+	m, ok := msg.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, openflow.ErrUnsupportedMessage
+	}
+	return m.MarshalBinary()
+}
+
+// Dial connects to every controller address in the background, lost
+// connections are redialed until the agent closes
+func (a *Agent) Dial(addrs ...string) {
+	for _, addr := range addrs {
+		a.wg.Add(1)
+		go a.dial(addr)
+	}
+}
+
+func (a *Agent) dial(addr string) {
+	defer a.wg.Done()
+	backoff := a.MinBackoff
+	for {
+		if conn, err := a.Dialer(addr); err == nil {
+			if ready, _ := a.serve(newChannel(conn), addr); ready {
+				backoff = a.MinBackoff
+			}
+		}
+		select {
+		case <-a.done:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > a.MaxBackoff {
+			backoff = a.MaxBackoff
+		}
+	}
+}
+
+// dialAuxiliary keeps an auxiliary connection to the controller of main
+// until main closes
+func (a *Agent) dialAuxiliary(addr string, main *channel, id uint8) {
+	defer a.wg.Done()
+	for {
+		if conn, err := a.Dialer(addr); err == nil {
+			ch := newChannel(conn)
+			ch.aux = id
+			ch.main = main
+			a.serve(ch, addr)
+		}
+		select {
+		case <-main.done:
+			return
+		case <-time.After(a.MinBackoff):
+		}
+	}
+}
+
+// ServeConn runs the channel to a controller on conn until it closes,
+// no auxiliary connection is opened
+func (a *Agent) ServeConn(conn net.Conn) error {
+	_, err := a.serve(newChannel(conn), "")
+	return err
+}
+
+// serve runs a channel and tells whether the controller said hello,
+// auxiliary connections are dialed to addr
+func (a *Agent) serve(ch *channel, addr string) (bool, error) {
+	conn := ch.conn
+	// the hello comes first
+	hello, _ := encode(v10.NewHello(a.nextXID()))
+	ch.out <- hello
+	a.mu.Lock()
+	select {
+	case <-a.done:
+		a.mu.Unlock()
+		conn.Close()
+		return false, ErrClosed
+	default:
+	}
+	if _, ok := a.channels[ch.main]; ch.main != nil && !ok {
+		a.mu.Unlock()
+		conn.Close()
+		return false, ErrNotConnected
+	}
+	a.channels[ch] = false
+	a.mu.Unlock()
+	go ch.write()
+	stop := make(chan struct{})
+	go a.keepalive(ch, stop)
+	ready, auxiliary := false, false
+	defer func() {
+		close(stop)
+		a.remove(ch)
+		close(ch.done)
+		conn.Close()
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(3 * a.EchoInterval))
+		data, err := openflow.ReadMessage(conn)
+		if err != nil {
+			select {
+			case <-a.done:
+				return ready, nil
+			default:
+			}
+			if err == io.EOF {
+				return ready, nil
+			}
+			return ready, err
+		}
+		if data[1] == v10.OFPT_HELLO && !ready {
+			if data[0] < openflow.OF10_VERSION {
+				reply(ch, errorReply(data, v10.OFPET_HELLO_FAILED, v10.OFPHFC_INCOMPATIBLE))
+				continue
+			}
+			ready = true
+			if ch.main == nil {
+				a.connected(ch)
+			}
+			continue
+		}
+		if err := reply(ch, a.handle(ch, data)...); err != nil {
+			return ready, err
+		}
+		if data[1] != v10.OFPT_FEATURES_REQUEST {
+			continue
+		}
+		if ch.main != nil {
+			// the controller knows the auxiliary id, packet ins may follow
+			a.attach(ch)
+		} else if !auxiliary {
+			// auxiliary connections join the datapath once the controller
+			// knows it
+			auxiliary = true
+			for i := 1; i <= a.Auxiliary && addr != ""; i++ {
+				a.wg.Add(1)
+				go a.dialAuxiliary(addr, ch, uint8(i))
+			}
+		}
+	}
+}
+
+func reply(ch *channel, msgs ...openflow.MessageDecoder) error {
+	for _, msg := range msgs {
+		if msg == nil {
+			continue
+		}
+		data, err := encode(msg)
+		if err != nil {
+			continue
+		}
+		if err := ch.queue(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handle answers the messages of the channel state machine and hands
+// the others to the datapath
+func (a *Agent) handle(ch *channel, data []byte) []openflow.MessageDecoder {
+	if data[0] != openflow.OF10_VERSION {
+		return a.dp.Handle(data)
+	}
+	xid := binary.BigEndian.Uint32(data[4:8])
+	switch data[1] {
+	case v10.OFPT_HELLO, v10.OFPT_ECHO_REPLY:
+		return nil
+	case v10.OFPT_ECHO_REQUEST:
+		echo := v10.NewEchoReply(xid)
+		if len(data) > openflow.OF_HEADER_SIZE {
+			echo.SetData(data[openflow.OF_HEADER_SIZE:])
+		}
+		return []openflow.MessageDecoder{echo}
+	case v10.OFPT_FEATURES_REQUEST:
+		features := a.dp.Features(xid)
+		features.SetAuxiliaryID(ch.aux)
+		return []openflow.MessageDecoder{features}
+	}
+	return a.dp.Handle(data)
+}
+
+// errorReply returns the error answering the request in data
+func errorReply(data []byte, typ, code uint16) openflow.Error {
+	e := v10.NewError(binary.BigEndian.Uint32(data[4:8]))
+	e.SetType(typ)
+	e.SetCode(code)
+	e.SetData(append([]byte(nil), data...))
+	return e
+}
+
+// keepalive sends echo requests until the channel closes
+func (a *Agent) keepalive(ch *channel, stop chan struct{}) {
+	ticker := time.NewTicker(a.EchoInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			data, _ := encode(v10.NewEchoRequest(a.nextXID()))
+			if ch.queue(data) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (a *Agent) nextXID() uint32 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.xid++
+	return a.xid
+}
+
+// ready returns the number of main channels which said hello
+func (a *Agent) ready() int {
+	n := 0
+	for ch, ok := range a.channels {
+		if ok && ch.main == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (a *Agent) connected(ch *channel) {
+	a.mu.Lock()
+	first := a.ready() == 0
+	a.channels[ch] = true
+	a.mu.Unlock()
+	if first {
+		a.dp.ControllerConnected()
+	}
+}
+
+// attach makes an auxiliary channel usable for packet ins
+func (a *Agent) attach(ch *channel) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if usable, ok := a.channels[ch]; ok && !usable {
+		a.channels[ch] = true
+		ch.main.auxiliaries = append(ch.main.auxiliaries, ch)
+	}
+}
+
+func (a *Agent) remove(ch *channel) {
+	a.mu.Lock()
+	was := a.channels[ch]
+	delete(a.channels, ch)
+	if main := ch.main; main != nil {
+		for i, aux := range main.auxiliaries {
+			if aux == ch {
+				main.auxiliaries = append(main.auxiliaries[:i], main.auxiliaries[i+1:]...)
+				break
+			}
+		}
+	} else {
+		// auxiliary connections do not outlive the main connection
+		for aux := range a.channels {
+			if aux.main == ch {
+				aux.conn.Close()
+			}
+		}
+	}
+	lost := was && ch.main == nil && a.ready() == 0
+	a.mu.Unlock()
+	if lost {
+		a.dp.ControllerLost(a.FailMode)
+	}
+}
+
+// Connected returns the number of connected controllers
+func (a *Agent) Connected() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.ready()
+}
+
+// Send sends an asynchronous message to every connected controller,
+// packet ins go on auxiliary connections when there are some
+func (a *Agent) Send(msg openflow.MessageDecoder) error {
+	data, err := encode(msg)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	var channels []*channel
+	for ch, ok := range a.channels {
+		if ok && ch.main == nil {
+			channels = append(channels, route(ch, data))
+		}
+	}
+	a.mu.Unlock()
+	if len(channels) == 0 {
+		return ErrNotConnected
+	}
+	err = ErrNotConnected
+	for _, ch := range channels {
+		if ch.queue(data) == nil {
+			err = nil
+		}
+	}
+	return err
+}
+
+// route returns the channel of a main channel carrying a message, packet
+// ins are spread over the auxiliary channels by ethernet addresses
+func route(main *channel, data []byte) *channel {
+	// the frame follows the 18 bytes of the packet in header
+	if data[1] != v10.OFPT_PACKET_IN || len(main.auxiliaries) == 0 || len(data) < 30 {
+		return main
+	}
+	h := fnv.New32a()
+	h.Write(data[18:30])
+	return main.auxiliaries[h.Sum32()%uint32(len(main.auxiliaries))]
+}
+
+// Close disconnects every controller and stops redialing
+func (a *Agent) Close() error {
+	a.once.Do(func() {
+		close(a.done)
+	})
+	a.mu.Lock()
+	for ch := range a.channels {
+		ch.conn.Close()
+	}
+	a.mu.Unlock()
+	a.wg.Wait()
+	return nil
+}
@@ -0,0 +1,234 @@
+package agent
+
+import (
+	"encoding/binary"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// datapath records the state changes of the agent
+type datapath struct {
+	states chan string
+}
+
+func (d *datapath) Features(xid uint32) openflow.FeatureReply {
+	reply := v10.NewFeatureReply(xid)
+	reply.SetDPID(7)
+	return reply
+}
+
+func (d *datapath) Handle(data []byte) []openflow.MessageDecoder {
+	if data[1] == v10.OFPT_BARRIER_REQUEST {
+		return []openflow.MessageDecoder{v10.NewBarrierReply(binary.BigEndian.Uint32(data[4:8]))}
+	}
+	return nil
+}
+
+func (d *datapath) ControllerConnected() {
+	d.states <- "connected"
+}
+
+func (d *datapath) ControllerLost(mode FailMode) {
+	d.states <- "lost " + mode.String()
+}
+
+func (d *datapath) expect(t *testing.T, state string) {
+	select {
+	case s := <-d.states:
+		if s != state {
+			t.Fatalf("got state %q, expected %q", s, state)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("no state %q", state)
+	}
+}
+
+func waitDatapath(t *testing.T, c *controller.Controller) *controller.Datapath {
+	for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if dp, ok := c.Datapath(7); ok {
+			return dp
+		}
+	}
+	t.Fatal("datapath not connected")
+	return nil
+}
+
+func TestDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	c := controller.New()
+	go c.Serve(ln)
+
+	d := &datapath{states: make(chan string, 16)}
+	a := New(d)
+	a.MinBackoff = 10 * time.Millisecond
+	a.MaxBackoff = 50 * time.Millisecond
+	a.FailMode = FailStandalone
+	defer a.Close()
+	if err := a.Send(v10.NewPacketIn(0)); err != ErrNotConnected {
+		t.Errorf("send without controller answered %v", err)
+	}
+	a.Dial(ln.Addr().String())
+	d.expect(t, "connected")
+	dp := waitDatapath(t, c)
+	if err := dp.Barrier(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the agent redials a lost controller
+	dp.Close()
+	d.expect(t, "lost standalone")
+	d.expect(t, "connected")
+	if a.Connected() != 1 {
+		t.Errorf("%d controllers connected", a.Connected())
+	}
+}
+
+func TestKeepalive(t *testing.T) {
+	d := &datapath{states: make(chan string, 16)}
+	a := New(d)
+	a.EchoInterval = 20 * time.Millisecond
+	defer a.Close()
+	conn, peer := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- a.ServeConn(conn)
+	}()
+	openflow.WriteMessage(peer, v10.NewHello(1))
+	echo := false
+	for !echo {
+		data, err := openflow.ReadMessage(peer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		echo = data[1] == v10.OFPT_ECHO_REQUEST
+	}
+	d.expect(t, "connected")
+
+	// a silent controller is disconnected
+	go func() {
+		for {
+			if _, err := openflow.ReadMessage(peer); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("silent controller not timed out")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("silent controller still connected")
+	}
+	d.expect(t, "lost secure")
+}
+
+// packetIns counts the packet ins written on each dialed connection
+type packetIns struct {
+	mu     sync.Mutex
+	counts []int
+}
+
+type countingConn struct {
+	net.Conn
+	ins *packetIns
+	i   int
+}
+
+func (c *countingConn) Write(data []byte) (int, error) {
+	if data[1] == v10.OFPT_PACKET_IN {
+		c.ins.mu.Lock()
+		c.ins.counts[c.i]++
+		c.ins.mu.Unlock()
+	}
+	return c.Conn.Write(data)
+}
+
+func (p *packetIns) dial(addr string) (net.Conn, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.counts = append(p.counts, 0)
+	return &countingConn{Conn: conn, ins: p, i: len(p.counts) - 1}, nil
+}
+
+type packetInApp chan *controller.Datapath
+
+func (a packetInApp) PacketIn(dp *controller.Datapath, msg openflow.PacketIn) {
+	a <- dp
+}
+
+func TestAuxiliary(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	c := controller.New()
+	c.Auxiliary = true
+	app := make(packetInApp, 16)
+	c.Register(app)
+	go c.Serve(ln)
+
+	d := &datapath{states: make(chan string, 16)}
+	ins := &packetIns{}
+	a := New(d)
+	a.MinBackoff = 10 * time.Millisecond
+	a.Auxiliary = 2
+	a.Dialer = ins.dial
+	defer a.Close()
+	a.Dial(ln.Addr().String())
+	d.expect(t, "connected")
+	dp := waitDatapath(t, c)
+	for deadline := time.Now().Add(3 * time.Second); len(dp.Auxiliaries()) < 2; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("auxiliary connections %v", dp.Auxiliaries())
+		}
+	}
+
+	// packet ins go on auxiliary connections and reach the datapath
+	for i := 0; i < 4; i++ {
+		pi := v10.NewPacketIn(0)
+		pi.SetData(make([]byte, 64))
+		if err := a.Send(pi); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got := <-app:
+			if got != dp {
+				t.Error("packet in delivered to another datapath")
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("packet in not delivered")
+		}
+	}
+	ins.mu.Lock()
+	// the main connection is dialed first
+	if ins.counts[0] != 0 {
+		t.Errorf("packet ins per connection %v", ins.counts)
+	}
+	ins.mu.Unlock()
+
+	// auxiliary connections are reopened with the main connection
+	dp.Close()
+	d.expect(t, "lost secure")
+	d.expect(t, "connected")
+	dp = waitDatapath(t, c)
+	for deadline := time.Now().Add(3 * time.Second); len(dp.Auxiliaries()) < 2; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("auxiliary connections %v after reconnection", dp.Auxiliaries())
+		}
+	}
+}
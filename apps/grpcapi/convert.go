@@ -0,0 +1,545 @@
+//go:build grpc
+
+package grpcapi
+
+import (
+	"fmt"
+	"github.com/ksang/goflow/apps/grpcapi/pb"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"net"
+)
+
+func u32(v uint32) *uint32 {
+	return &v
+}
+
+// nwPrefix returns the prefix length of a network address of a match
+func nwPrefix(m openflow.Match, shift uint) int {
+	wildcarded := int(m.Wildcards()>>shift) & 0x3f
+	if wildcarded > 32 {
+		wildcarded = 32
+	}
+	return 32 - wildcarded
+}
+
+func matchToPB(m openflow.Match) *pb.Match {
+	v := &pb.Match{}
+	if m == nil {
+		return v
+	}
+	if w, p := m.InPort(); !w {
+		v.InPort = u32(uint32(p))
+	}
+	if w, mac := m.DLSrc(); !w {
+		v.DlSrc = []byte(mac)
+	}
+	if w, mac := m.DLDst(); !w {
+		v.DlDst = []byte(mac)
+	}
+	if w, vlan := m.DLVlan(); !w {
+		v.DlVlan = u32(uint32(vlan))
+	}
+	if w, pcp := m.DLPCP(); !w {
+		v.DlVlanPcp = u32(uint32(pcp))
+	}
+	if w, t := m.DLType(); !w {
+		v.DlType = u32(uint32(t))
+	}
+	if w, tos := m.NWTos(); !w {
+		v.NwTos = u32(uint32(tos))
+	}
+	if w, proto := m.NWProto(); !w {
+		v.NwProto = u32(uint32(proto))
+	}
+	if bits := nwPrefix(m, v10.OFPFW_NW_SRC_SHIFT); bits > 0 {
+		s := fmt.Sprintf("%s/%d", m.NWSrc(), bits)
+		v.NwSrc = &s
+	}
+	if bits := nwPrefix(m, v10.OFPFW_NW_DST_SHIFT); bits > 0 {
+		s := fmt.Sprintf("%s/%d", m.NWDst(), bits)
+		v.NwDst = &s
+	}
+	if w, p := m.TPSrc(); !w {
+		v.TpSrc = u32(uint32(p))
+	}
+	if w, p := m.TPDst(); !w {
+		v.TpDst = u32(uint32(p))
+	}
+	return v
+}
+
+// parseNW parses an address with an optional prefix length
+func parseNW(s string) (net.IP, int, error) {
+	if ip := net.ParseIP(s).To4(); ip != nil {
+		return ip, 32, nil
+	}
+	ip, ipnet, err := net.ParseCIDR(s)
+	if err != nil || ip.To4() == nil {
+		return nil, 0, openflow.ErrInvalidIPAddress
+	}
+	bits, _ := ipnet.Mask.Size()
+	return ip.To4(), bits, nil
+}
+
+func macFromPB(b []byte) (net.HardwareAddr, error) {
+	if len(b) != 6 {
+		return nil, openflow.ErrInvalidMACAddress
+	}
+	return net.HardwareAddr(b), nil
+}
+
+func matchFromPB(v *pb.Match) (openflow.Match, error) {
+	m := v10.NewMatch()
+	if v == nil {
+		return m, nil
+	}
+	if v.InPort != nil {
+		m.SetInPort(uint16(*v.InPort))
+	}
+	if v.DlSrc != nil {
+		mac, err := macFromPB(v.DlSrc)
+		if err != nil {
+			return nil, err
+		}
+		m.SetDLSrc(mac)
+	}
+	if v.DlDst != nil {
+		mac, err := macFromPB(v.DlDst)
+		if err != nil {
+			return nil, err
+		}
+		m.SetDLDst(mac)
+	}
+	if v.DlVlan != nil {
+		if err := m.SetDLVlan(uint16(*v.DlVlan)); err != nil {
+			return nil, err
+		}
+	}
+	if v.DlVlanPcp != nil {
+		m.SetDLPCP(uint8(*v.DlVlanPcp))
+	}
+	if v.DlType != nil {
+		if err := m.SetDLType(uint16(*v.DlType)); err != nil {
+			return nil, err
+		}
+	}
+	if v.NwTos != nil {
+		m.SetNWTos(uint8(*v.NwTos))
+	}
+	if v.NwProto != nil {
+		if err := m.SetNWProto(uint8(*v.NwProto)); err != nil {
+			return nil, err
+		}
+	}
+	if v.NwSrc != nil {
+		ip, bits, err := parseNW(*v.NwSrc)
+		if err != nil {
+			return nil, err
+		}
+		m.SetNWSrc(ip)
+		m.SetWildcardNWSrc(bits)
+	}
+	if v.NwDst != nil {
+		ip, bits, err := parseNW(*v.NwDst)
+		if err != nil {
+			return nil, err
+		}
+		m.SetNWDst(ip)
+		m.SetWildcardNWDst(bits)
+	}
+	if v.TpSrc != nil {
+		m.SetTPSrc(uint16(*v.TpSrc))
+	}
+	if v.TpDst != nil {
+		m.SetTPDst(uint16(*v.TpDst))
+	}
+	return m, nil
+}
+
+func actionToPB(a openflow.Action) (*pb.Action, error) {
+	v := &pb.Action{}
+	switch a.Type() {
+	case v10.OFPAT_OUTPUT:
+		out := a.(v10.ActionOutput)
+		v.Action = &pb.Action_Output{Output: &pb.OutputAction{Port: uint32(out.Port()), MaxLen: uint32(out.MaxLen())}}
+	case v10.OFPAT_SET_VLAN_VID:
+		v.Action = &pb.Action_SetVlanVid{SetVlanVid: uint32(a.(v10.ActionSetVLANVID).VLANVID())}
+	case v10.OFPAT_SET_VLAN_PCP:
+		v.Action = &pb.Action_SetVlanPcp{SetVlanPcp: uint32(a.(v10.ActionSetVLANPCP).VLANPCP())}
+	case v10.OFPAT_STRIP_VLAN:
+		v.Action = &pb.Action_StripVlan{StripVlan: true}
+	case v10.OFPAT_SET_DL_SRC:
+		v.Action = &pb.Action_SetDlSrc{SetDlSrc: a.(v10.ActionSetDLSrc).DLSrc()}
+	case v10.OFPAT_SET_DL_DST:
+		v.Action = &pb.Action_SetDlDst{SetDlDst: a.(v10.ActionSetDLDst).DLDst()}
+	case v10.OFPAT_SET_NW_SRC:
+		v.Action = &pb.Action_SetNwSrc{SetNwSrc: a.(v10.ActionSetNWSrc).NWSrc().To4()}
+	case v10.OFPAT_SET_NW_DST:
+		v.Action = &pb.Action_SetNwDst{SetNwDst: a.(v10.ActionSetNWDst).NWDst().To4()}
+	case v10.OFPAT_SET_NW_TOS:
+		v.Action = &pb.Action_SetNwTos{SetNwTos: uint32(a.(v10.ActionSetNWTos).NWTos())}
+	case v10.OFPAT_SET_TP_SRC:
+		v.Action = &pb.Action_SetTpSrc{SetTpSrc: uint32(a.(v10.ActionSetTPSrc).Port())}
+	case v10.OFPAT_SET_TP_DST:
+		v.Action = &pb.Action_SetTpDst{SetTpDst: uint32(a.(v10.ActionSetTPDst).Port())}
+	case v10.OFPAT_ENQUEUE:
+		enq := a.(v10.ActionEnqueue)
+		v.Action = &pb.Action_Enqueue{Enqueue: &pb.EnqueueAction{Port: uint32(enq.Port()), QueueId: enq.QueueID()}}
+	default:
+		return nil, openflow.ErrUnsupportedMessage
+	}
+	return v, nil
+}
+
+func actionsToPB(actions []openflow.Action) ([]*pb.Action, error) {
+	v := make([]*pb.Action, 0, len(actions))
+	for _, a := range actions {
+		act, err := actionToPB(a)
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, act)
+	}
+	return v, nil
+}
+
+func actionFromPB(v *pb.Action) (openflow.Action, error) {
+	switch act := v.GetAction().(type) {
+	case *pb.Action_Output:
+		out := v10.NewActionOutput()
+		if err := out.SetPort(uint16(act.Output.GetPort())); err != nil {
+			return nil, err
+		}
+		out.SetMaxLen(uint16(act.Output.GetMaxLen()))
+		return out, nil
+	case *pb.Action_SetVlanVid:
+		a := v10.NewActionSetVLANVID()
+		a.SetVLANVID(uint16(act.SetVlanVid))
+		return a, nil
+	case *pb.Action_SetVlanPcp:
+		a := v10.NewActionSetVLANPCP()
+		a.SetVLANPCP(uint8(act.SetVlanPcp))
+		return a, nil
+	case *pb.Action_StripVlan:
+		return v10.NewActionStripVLAN(), nil
+	case *pb.Action_SetDlSrc:
+		mac, err := macFromPB(act.SetDlSrc)
+		if err != nil {
+			return nil, err
+		}
+		a := v10.NewActionSetDLSrc()
+		a.SetDLSrc(mac)
+		return a, nil
+	case *pb.Action_SetDlDst:
+		mac, err := macFromPB(act.SetDlDst)
+		if err != nil {
+			return nil, err
+		}
+		a := v10.NewActionSetDLDst()
+		a.SetDLDst(mac)
+		return a, nil
+	case *pb.Action_SetNwSrc:
+		if len(act.SetNwSrc) != 4 {
+			return nil, openflow.ErrInvalidIPAddress
+		}
+		a := v10.NewActionSetNWSrc()
+		a.SetNWSrc(net.IP(act.SetNwSrc))
+		return a, nil
+	case *pb.Action_SetNwDst:
+		if len(act.SetNwDst) != 4 {
+			return nil, openflow.ErrInvalidIPAddress
+		}
+		a := v10.NewActionSetNWDst()
+		a.SetNWDst(net.IP(act.SetNwDst))
+		return a, nil
+	case *pb.Action_SetNwTos:
+		a := v10.NewActionSetNWTos()
+		a.SetNWTos(uint8(act.SetNwTos))
+		return a, nil
+	case *pb.Action_SetTpSrc:
+		a := v10.NewActionSetTPSrc()
+		if err := a.SetPort(uint16(act.SetTpSrc)); err != nil {
+			return nil, err
+		}
+		return a, nil
+	case *pb.Action_SetTpDst:
+		a := v10.NewActionSetTPDst()
+		if err := a.SetPort(uint16(act.SetTpDst)); err != nil {
+			return nil, err
+		}
+		return a, nil
+	case *pb.Action_Enqueue:
+		a := v10.NewActionEnqueue()
+		if err := a.SetPort(uint16(act.Enqueue.GetPort())); err != nil {
+			return nil, err
+		}
+		a.SetQueueID(act.Enqueue.GetQueueId())
+		return a, nil
+	}
+	return nil, openflow.ErrInvalidValueProvided
+}
+
+func actionsFromPB(v []*pb.Action) ([]openflow.Action, error) {
+	actions := make([]openflow.Action, 0, len(v))
+	for _, act := range v {
+		a, err := actionFromPB(act)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
+
+func flowModFromPB(v *pb.FlowMod) (openflow.FlowMod, error) {
+	if v.Command > pb.FlowCommand_DELETE_STRICT {
+		return nil, openflow.ErrInvalidValueProvided
+	}
+	match, err := matchFromPB(v.Match)
+	if err != nil {
+		return nil, err
+	}
+	actions, err := actionsFromPB(v.Actions)
+	if err != nil {
+		return nil, err
+	}
+	fm := v10.NewFlowMod(0)
+	fm.SetMatch(match)
+	if err := fm.SetCookie(v.Cookie); err != nil {
+		return nil, err
+	}
+	fm.SetCommand(openflow.FlowCommand(v.Command))
+	fm.SetIdleTimeout(uint16(v.IdleTimeout))
+	fm.SetHardTimeout(uint16(v.HardTimeout))
+	fm.SetPriority(uint16(v.Priority))
+	fm.SetBufferID(v.BufferId)
+	fm.SetOutPort(uint16(v.OutPort))
+	fm.SetFlags(openflow.FlowFlag(v.Flags))
+	for _, a := range actions {
+		fm.AddAction(a)
+	}
+	return fm, nil
+}
+
+func packetOutFromPB(v *pb.PacketOut) (openflow.PacketOut, error) {
+	actions, err := actionsFromPB(v.Actions)
+	if err != nil {
+		return nil, err
+	}
+	po := v10.NewPacketOut(0)
+	po.SetBufferID(v.BufferId)
+	if err := po.SetInPort(uint16(v.InPort)); err != nil {
+		return nil, err
+	}
+	for _, a := range actions {
+		po.AddAction(a)
+	}
+	po.SetData(v.Data)
+	return po, nil
+}
+
+func portToPB(p openflow.Port) *pb.Port {
+	return &pb.Port{
+		PortNo:     uint32(p.PortID()),
+		HwAddr:     p.HWAddr(),
+		Name:       p.Name(),
+		Config:     uint32(p.Config()),
+		State:      uint32(p.State()),
+		Curr:       uint32(p.Curr()),
+		Advertised: uint32(p.Advertised()),
+		Supported:  uint32(p.Supported()),
+		Peer:       uint32(p.Peer()),
+	}
+}
+
+func datapathToPB(dp *controller.Datapath) *pb.Datapath {
+	f := dp.Features()
+	v := &pb.Datapath{
+		Dpid:         dp.ID(),
+		NBuffers:     f.NumBuffers(),
+		NTables:      uint32(f.NumTables()),
+		Capabilities: uint32(f.Capabilities()),
+		Actions:      uint32(f.Actions()),
+	}
+	if addr := dp.RemoteAddr(); addr != nil {
+		v.Address = addr.String()
+	}
+	for _, p := range dp.Ports() {
+		v.Ports = append(v.Ports, portToPB(p))
+	}
+	return v
+}
+
+func eventToPB(ev *Event) *pb.Event {
+	v := &pb.Event{
+		Dpid: ev.Datapath.ID(),
+		Kind: pb.EventKind(ev.Kind),
+	}
+	switch msg := ev.Message.(type) {
+	case openflow.PacketIn:
+		v.Message = &pb.Event_PacketIn{PacketIn: &pb.PacketIn{
+			BufferId: msg.BufferID(),
+			TotalLen: uint32(msg.TotalLength()),
+			InPort:   uint32(msg.InPort()),
+			Reason:   uint32(msg.Reason()),
+			Data:     msg.Data(),
+		}}
+	case openflow.PortStatus:
+		v.Message = &pb.Event_PortStatus{PortStatus: &pb.PortStatus{
+			Reason: uint32(msg.Reason()),
+			Port:   portToPB(msg.Port()),
+		}}
+	case openflow.FlowRemoved:
+		v.Message = &pb.Event_FlowRemoved{FlowRemoved: &pb.FlowRemoved{
+			Match:        matchToPB(msg.Match()),
+			Cookie:       msg.Cookie(),
+			Priority:     uint32(msg.Priority()),
+			Reason:       uint32(msg.Reason()),
+			DurationSec:  msg.DurationSec(),
+			DurationNsec: msg.DurationNanoSec(),
+			IdleTimeout:  uint32(msg.IdleTimeout()),
+			PacketCount:  msg.PacketCount(),
+			ByteCount:    msg.ByteCount(),
+		}}
+	case openflow.Error:
+		v.Message = &pb.Event_Error{Error: &pb.Error{
+			Type: uint32(msg.Type()),
+			Code: uint32(msg.Code()),
+			Data: msg.Data(),
+		}}
+	}
+	if ev.Kind == SwitchConnected {
+		v.Message = &pb.Event_SwitchConnected{SwitchConnected: datapathToPB(ev.Datapath)}
+	}
+	return v
+}
+
+// statsRequestFromPB returns the request of a stats type
+func statsRequestFromPB(v *pb.StatsRequest) (openflow.StatsRequest, error) {
+	switch v.Type {
+	case pb.StatsType_FLOW, pb.StatsType_AGGREGATE:
+		req := v10.NewStatsReuqestFlow(0)
+		if v.Type == pb.StatsType_AGGREGATE {
+			req = v10.NewStatsReuqestAggregate(0)
+		}
+		match, err := matchFromPB(v.Match)
+		if err != nil {
+			return nil, err
+		}
+		req.SetMatch(match)
+		req.SetTableID(0xff)
+		if v.TableId != nil {
+			req.SetTableID(uint8(*v.TableId))
+		}
+		req.SetOutPort(uint16(openflow.None))
+		if v.OutPort != nil {
+			req.SetOutPort(uint16(*v.OutPort))
+		}
+		return req, nil
+	case pb.StatsType_TABLE:
+		return v10.NewStatsReuqestTable(0), nil
+	case pb.StatsType_PORT:
+		req := v10.NewStatsReuqestPort(0)
+		port := uint32(openflow.None)
+		if v.PortNo != nil {
+			port = *v.PortNo
+		}
+		if err := req.SetPortNumber(uint16(port)); err != nil {
+			return nil, err
+		}
+		return req, nil
+	case pb.StatsType_QUEUE:
+		req := v10.NewStatsReuqestQueue(0)
+		port, queue := uint32(openflow.All), uint32(v10.OFPQ_ALL)
+		if v.PortNo != nil {
+			port = *v.PortNo
+		}
+		if v.QueueId != nil {
+			queue = *v.QueueId
+		}
+		if err := req.SetPortNumber(uint16(port)); err != nil {
+			return nil, err
+		}
+		req.SetQueueID(queue)
+		return req, nil
+	}
+	return nil, openflow.ErrInvalidValueProvided
+}
+
+// statsReplyToPB merges stats replies
+func statsReplyToPB(replies []openflow.MessageDecoder) (*pb.StatsReply, error) {
+	v := &pb.StatsReply{}
+	for _, msg := range replies {
+		switch reply := msg.(type) {
+		case v10.StatsReplyFlow:
+			for _, f := range reply.Flows() {
+				actions, err := actionsToPB(f.Actions())
+				if err != nil {
+					return nil, err
+				}
+				v.Flows = append(v.Flows, &pb.FlowStats{
+					TableId:      uint32(f.TableID()),
+					Match:        matchToPB(f.Match()),
+					DurationSec:  f.DurationSec(),
+					DurationNsec: f.DurationNanoSec(),
+					Priority:     uint32(f.Priority()),
+					IdleTimeout:  uint32(f.IdleTimeout()),
+					HardTimeout:  uint32(f.HardTimeout()),
+					Cookie:       f.Cookie(),
+					PacketCount:  f.PacketCount(),
+					ByteCount:    f.ByteCount(),
+					Actions:      actions,
+				})
+			}
+		case v10.StatsReplyAggregate:
+			v.Aggregate = &pb.AggregateStats{
+				PacketCount: reply.PacketCount(),
+				ByteCount:   reply.ByteCount(),
+				FlowCount:   reply.FlowCount(),
+			}
+		case v10.StatsReplyTable:
+			for _, t := range reply.Tables() {
+				v.Tables = append(v.Tables, &pb.TableStats{
+					TableId:      uint32(t.TableID()),
+					Name:         t.Name(),
+					Wildcards:    t.Wildcards(),
+					MaxEntries:   t.MaxEntries(),
+					ActiveCount:  t.ActiveCount(),
+					LookupCount:  t.LookupCount(),
+					MatchedCount: t.MatchedCount(),
+				})
+			}
+		case v10.StatsReplyPort:
+			for _, p := range reply.Ports() {
+				v.Ports = append(v.Ports, &pb.PortStats{
+					PortNo:     uint32(p.PortNumber()),
+					RxPackets:  p.RxPackets(),
+					TxPackets:  p.TxPackets(),
+					RxBytes:    p.RxBytes(),
+					TxBytes:    p.TxBytes(),
+					RxDropped:  p.RxDropped(),
+					TxDropped:  p.TxDropped(),
+					RxErrors:   p.RxErrors(),
+					TxErrors:   p.TxErrors(),
+					RxFrameErr: p.RxFrameErr(),
+					RxOverErr:  p.RxOverErr(),
+					RxCrcErr:   p.RxCRCErr(),
+					Collisions: p.Collisions(),
+				})
+			}
+		case v10.StatsReplyQueue:
+			for _, q := range reply.Queues() {
+				v.Queues = append(v.Queues, &pb.QueueStats{
+					PortNo:    uint32(q.PortNumber()),
+					QueueId:   q.QueueID(),
+					TxBytes:   q.TxBytes(),
+					TxPackets: q.TxPackets(),
+					TxErrors:  q.TxErrors(),
+				})
+			}
+		}
+	}
+	return v, nil
+}
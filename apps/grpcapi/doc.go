@@ -0,0 +1,27 @@
+/*
+Package grpcapi provides a gRPC northbound API to the controller.
+
+The service and its messages are defined in pb/northbound.proto, they
+mirror the OpenFlow 1.0 messages. Applications in other languages list
+datapaths, read stats, and open a bidirectional stream receiving packet
+ins and other events while sending flow mods and packet outs back.
+
+The server and the code generated from the proto file, which is checked
+in under pb, depend on google.golang.org/grpc and google.golang.org/protobuf
+and are only built with the grpc build tag. With both modules available,
+the server is tested with:
+
+	go test -tags grpc github.com/ksang/goflow/apps/grpcapi/...
+
+After changing the proto file, the code is generated again with protoc,
+protoc-gen-go and protoc-gen-go-grpc:
+
+	go generate github.com/ksang/goflow/apps/grpcapi
+
+The event Hub, which fans controller events out to subscribers, has no
+such dependency.
+*/
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative pb/northbound.proto
+//go:generate sed -i "1i //go:build grpc\\n" pb/northbound.pb.go pb/northbound_grpc.pb.go
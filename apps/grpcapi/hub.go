@@ -0,0 +1,158 @@
+package grpcapi
+
+import (
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"sync"
+)
+
+// EventKind tells the kind of a controller event
+type EventKind int
+
+const (
+	SwitchConnected EventKind = iota
+	SwitchDisconnected
+	PacketIn
+	PortStatus
+	FlowRemoved
+	Error
+)
+
+// Event is a controller event, Message is nil for switch events
+type Event struct {
+	Kind     EventKind
+	Datapath *controller.Datapath
+	Message  openflow.MessageDecoder
+}
+
+// Filter selects events, empty fields select everything
+type Filter struct {
+	DPIDs []uint64
+	Kinds []EventKind
+}
+
+func (f *Filter) selects(ev *Event) bool {
+	ok := len(f.DPIDs) == 0
+	for _, id := range f.DPIDs {
+		ok = ok || id == ev.Datapath.ID()
+	}
+	if !ok {
+		return false
+	}
+	ok = len(f.Kinds) == 0
+	for _, k := range f.Kinds {
+		ok = ok || k == ev.Kind
+	}
+	return ok
+}
+
+// Subscription receives the events selected by its filter
+type Subscription struct {
+	// C is closed when the subscription is cancelled
+	C <-chan *Event
+
+	c       chan *Event
+	mu      sync.Mutex
+	filter  Filter
+	dropped uint64
+}
+
+// SetFilter replaces the filter of the subscription
+func (s *Subscription) SetFilter(f Filter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filter = f
+}
+
+// Dropped returns and resets the number of events dropped because the
+// subscriber did not keep up
+func (s *Subscription) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.dropped
+	s.dropped = 0
+	return n
+}
+
+func (s *Subscription) offer(ev *Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.filter.selects(ev) {
+		return
+	}
+	select {
+	case s.c <- ev:
+	default:
+		s.dropped++
+	}
+}
+
+// Hub fans controller events out to subscribers, register it on a
+// controller. Events are never blocked by slow subscribers, they are
+// dropped instead.
+type Hub struct {
+	// Buffer is the number of events queued per subscription
+	Buffer int
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		Buffer: 1024,
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe returns a subscription to the events selected by f
+func (h *Hub) Subscribe(f Filter) *Subscription {
+	c := make(chan *Event, h.Buffer)
+	s := &Subscription{C: c, c: c, filter: f}
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+// Cancel stops a subscription and closes its channel
+func (h *Hub) Cancel(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		delete(h.subs, s)
+		close(s.c)
+	}
+}
+
+func (h *Hub) publish(ev *Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		s.offer(ev)
+	}
+}
+
+func (h *Hub) SwitchConnected(dp *controller.Datapath) {
+	h.publish(&Event{Kind: SwitchConnected, Datapath: dp})
+}
+
+func (h *Hub) SwitchDisconnected(dp *controller.Datapath) {
+	h.publish(&Event{Kind: SwitchDisconnected, Datapath: dp})
+}
+
+func (h *Hub) PacketIn(dp *controller.Datapath, msg openflow.PacketIn) {
+	h.publish(&Event{Kind: PacketIn, Datapath: dp, Message: msg})
+}
+
+func (h *Hub) PortStatus(dp *controller.Datapath, msg openflow.PortStatus) {
+	h.publish(&Event{Kind: PortStatus, Datapath: dp, Message: msg})
+}
+
+func (h *Hub) FlowRemoved(dp *controller.Datapath, msg openflow.FlowRemoved) {
+	h.publish(&Event{Kind: FlowRemoved, Datapath: dp, Message: msg})
+}
+
+func (h *Hub) Error(dp *controller.Datapath, msg openflow.Error) {
+	h.publish(&Event{Kind: Error, Datapath: dp, Message: msg})
+}
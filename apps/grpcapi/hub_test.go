@@ -0,0 +1,78 @@
+package grpcapi
+
+import (
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/controller/controllertest"
+	"github.com/ksang/goflow/openflow/v10"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, sub *Subscription) *Event {
+	select {
+	case ev := <-sub.C:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	return nil
+}
+
+func TestHub(t *testing.T) {
+	c := controller.New()
+	h := NewHub()
+	c.Register(h)
+	all := h.Subscribe(Filter{})
+	packets := h.Subscribe(Filter{DPIDs: []uint64{1}, Kinds: []EventKind{PacketIn}})
+
+	s, err := controllertest.Connect(c, 1, controllertest.Port(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if ev := receive(t, all); ev.Kind != SwitchConnected || ev.Datapath.ID() != 1 {
+		t.Errorf("unexpected event %v", ev)
+	}
+	pi := v10.NewPacketIn(0)
+	pi.SetInPort(1)
+	s.Send(pi)
+	if ev := receive(t, all); ev.Kind != PacketIn {
+		t.Errorf("unexpected event %v", ev)
+	}
+	if ev := receive(t, packets); ev.Kind != PacketIn || ev.Message == nil {
+		t.Errorf("unexpected event %v", ev)
+	}
+
+	h.Cancel(all)
+	if _, ok := <-all.C; ok {
+		t.Error("cancelled subscription not closed")
+	}
+}
+
+func TestHubDrops(t *testing.T) {
+	h := NewHub()
+	h.Buffer = 1
+	sub := h.Subscribe(Filter{})
+	c := controller.New()
+	c.Register(h)
+	s, err := controllertest.Connect(c, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for i := 0; i < 3; i++ {
+		s.Send(v10.NewPacketIn(0))
+	}
+	deadline := time.Now().Add(time.Second)
+	var dropped uint64
+	for dropped < 3 && time.Now().Before(deadline) {
+		dropped += sub.Dropped()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if dropped != 3 {
+		t.Errorf("dropped %d events, expected 3", dropped)
+	}
+	if n := sub.Dropped(); n != 0 {
+		t.Errorf("dropped count not reset, %d", n)
+	}
+}
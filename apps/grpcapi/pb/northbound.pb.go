@@ -0,0 +1,3004 @@
+//go:build grpc
+
+// Northbound API of the goflow controller.
+//
+// Messages mirror the OpenFlow 1.0 messages of the openflow/v10 package,
+// unset optional match fields are wildcarded.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: pb/northbound.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type FlowCommand int32
+
+const (
+	FlowCommand_ADD           FlowCommand = 0
+	FlowCommand_MODIFY        FlowCommand = 1
+	FlowCommand_MODIFY_STRICT FlowCommand = 2
+	FlowCommand_DELETE        FlowCommand = 3
+	FlowCommand_DELETE_STRICT FlowCommand = 4
+)
+
+// Enum value maps for FlowCommand.
+var (
+	FlowCommand_name = map[int32]string{
+		0: "ADD",
+		1: "MODIFY",
+		2: "MODIFY_STRICT",
+		3: "DELETE",
+		4: "DELETE_STRICT",
+	}
+	FlowCommand_value = map[string]int32{
+		"ADD":           0,
+		"MODIFY":        1,
+		"MODIFY_STRICT": 2,
+		"DELETE":        3,
+		"DELETE_STRICT": 4,
+	}
+)
+
+func (x FlowCommand) Enum() *FlowCommand {
+	p := new(FlowCommand)
+	*p = x
+	return p
+}
+
+func (x FlowCommand) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FlowCommand) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_northbound_proto_enumTypes[0].Descriptor()
+}
+
+func (FlowCommand) Type() protoreflect.EnumType {
+	return &file_pb_northbound_proto_enumTypes[0]
+}
+
+func (x FlowCommand) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FlowCommand.Descriptor instead.
+func (FlowCommand) EnumDescriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{0}
+}
+
+type StatsType int32
+
+const (
+	StatsType_FLOW      StatsType = 0
+	StatsType_AGGREGATE StatsType = 1
+	StatsType_TABLE     StatsType = 2
+	StatsType_PORT      StatsType = 3
+	StatsType_QUEUE     StatsType = 4
+)
+
+// Enum value maps for StatsType.
+var (
+	StatsType_name = map[int32]string{
+		0: "FLOW",
+		1: "AGGREGATE",
+		2: "TABLE",
+		3: "PORT",
+		4: "QUEUE",
+	}
+	StatsType_value = map[string]int32{
+		"FLOW":      0,
+		"AGGREGATE": 1,
+		"TABLE":     2,
+		"PORT":      3,
+		"QUEUE":     4,
+	}
+)
+
+func (x StatsType) Enum() *StatsType {
+	p := new(StatsType)
+	*p = x
+	return p
+}
+
+func (x StatsType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StatsType) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_northbound_proto_enumTypes[1].Descriptor()
+}
+
+func (StatsType) Type() protoreflect.EnumType {
+	return &file_pb_northbound_proto_enumTypes[1]
+}
+
+func (x StatsType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StatsType.Descriptor instead.
+func (StatsType) EnumDescriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{1}
+}
+
+type EventKind int32
+
+const (
+	EventKind_SWITCH_CONNECTED    EventKind = 0
+	EventKind_SWITCH_DISCONNECTED EventKind = 1
+	EventKind_PACKET_IN           EventKind = 2
+	EventKind_PORT_STATUS         EventKind = 3
+	EventKind_FLOW_REMOVED        EventKind = 4
+	EventKind_ERROR               EventKind = 5
+)
+
+// Enum value maps for EventKind.
+var (
+	EventKind_name = map[int32]string{
+		0: "SWITCH_CONNECTED",
+		1: "SWITCH_DISCONNECTED",
+		2: "PACKET_IN",
+		3: "PORT_STATUS",
+		4: "FLOW_REMOVED",
+		5: "ERROR",
+	}
+	EventKind_value = map[string]int32{
+		"SWITCH_CONNECTED":    0,
+		"SWITCH_DISCONNECTED": 1,
+		"PACKET_IN":           2,
+		"PORT_STATUS":         3,
+		"FLOW_REMOVED":        4,
+		"ERROR":               5,
+	}
+)
+
+func (x EventKind) Enum() *EventKind {
+	p := new(EventKind)
+	*p = x
+	return p
+}
+
+func (x EventKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_northbound_proto_enumTypes[2].Descriptor()
+}
+
+func (EventKind) Type() protoreflect.EnumType {
+	return &file_pb_northbound_proto_enumTypes[2]
+}
+
+func (x EventKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventKind.Descriptor instead.
+func (EventKind) EnumDescriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{2}
+}
+
+type Match struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	InPort    *uint32                `protobuf:"varint,1,opt,name=in_port,json=inPort,proto3,oneof" json:"in_port,omitempty"`
+	DlSrc     []byte                 `protobuf:"bytes,2,opt,name=dl_src,json=dlSrc,proto3,oneof" json:"dl_src,omitempty"`
+	DlDst     []byte                 `protobuf:"bytes,3,opt,name=dl_dst,json=dlDst,proto3,oneof" json:"dl_dst,omitempty"`
+	DlVlan    *uint32                `protobuf:"varint,4,opt,name=dl_vlan,json=dlVlan,proto3,oneof" json:"dl_vlan,omitempty"`
+	DlVlanPcp *uint32                `protobuf:"varint,5,opt,name=dl_vlan_pcp,json=dlVlanPcp,proto3,oneof" json:"dl_vlan_pcp,omitempty"`
+	DlType    *uint32                `protobuf:"varint,6,opt,name=dl_type,json=dlType,proto3,oneof" json:"dl_type,omitempty"`
+	NwTos     *uint32                `protobuf:"varint,7,opt,name=nw_tos,json=nwTos,proto3,oneof" json:"nw_tos,omitempty"`
+	NwProto   *uint32                `protobuf:"varint,8,opt,name=nw_proto,json=nwProto,proto3,oneof" json:"nw_proto,omitempty"`
+	// CIDR notation, a bare address matches exactly
+	NwSrc         *string `protobuf:"bytes,9,opt,name=nw_src,json=nwSrc,proto3,oneof" json:"nw_src,omitempty"`
+	NwDst         *string `protobuf:"bytes,10,opt,name=nw_dst,json=nwDst,proto3,oneof" json:"nw_dst,omitempty"`
+	TpSrc         *uint32 `protobuf:"varint,11,opt,name=tp_src,json=tpSrc,proto3,oneof" json:"tp_src,omitempty"`
+	TpDst         *uint32 `protobuf:"varint,12,opt,name=tp_dst,json=tpDst,proto3,oneof" json:"tp_dst,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Match) Reset() {
+	*x = Match{}
+	mi := &file_pb_northbound_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Match) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Match) ProtoMessage() {}
+
+func (x *Match) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Match.ProtoReflect.Descriptor instead.
+func (*Match) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Match) GetInPort() uint32 {
+	if x != nil && x.InPort != nil {
+		return *x.InPort
+	}
+	return 0
+}
+
+func (x *Match) GetDlSrc() []byte {
+	if x != nil {
+		return x.DlSrc
+	}
+	return nil
+}
+
+func (x *Match) GetDlDst() []byte {
+	if x != nil {
+		return x.DlDst
+	}
+	return nil
+}
+
+func (x *Match) GetDlVlan() uint32 {
+	if x != nil && x.DlVlan != nil {
+		return *x.DlVlan
+	}
+	return 0
+}
+
+func (x *Match) GetDlVlanPcp() uint32 {
+	if x != nil && x.DlVlanPcp != nil {
+		return *x.DlVlanPcp
+	}
+	return 0
+}
+
+func (x *Match) GetDlType() uint32 {
+	if x != nil && x.DlType != nil {
+		return *x.DlType
+	}
+	return 0
+}
+
+func (x *Match) GetNwTos() uint32 {
+	if x != nil && x.NwTos != nil {
+		return *x.NwTos
+	}
+	return 0
+}
+
+func (x *Match) GetNwProto() uint32 {
+	if x != nil && x.NwProto != nil {
+		return *x.NwProto
+	}
+	return 0
+}
+
+func (x *Match) GetNwSrc() string {
+	if x != nil && x.NwSrc != nil {
+		return *x.NwSrc
+	}
+	return ""
+}
+
+func (x *Match) GetNwDst() string {
+	if x != nil && x.NwDst != nil {
+		return *x.NwDst
+	}
+	return ""
+}
+
+func (x *Match) GetTpSrc() uint32 {
+	if x != nil && x.TpSrc != nil {
+		return *x.TpSrc
+	}
+	return 0
+}
+
+func (x *Match) GetTpDst() uint32 {
+	if x != nil && x.TpDst != nil {
+		return *x.TpDst
+	}
+	return 0
+}
+
+type Action struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Action:
+	//
+	//	*Action_Output
+	//	*Action_SetVlanVid
+	//	*Action_SetVlanPcp
+	//	*Action_StripVlan
+	//	*Action_SetDlSrc
+	//	*Action_SetDlDst
+	//	*Action_SetNwSrc
+	//	*Action_SetNwDst
+	//	*Action_SetNwTos
+	//	*Action_SetTpSrc
+	//	*Action_SetTpDst
+	//	*Action_Enqueue
+	Action        isAction_Action `protobuf_oneof:"action"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Action) Reset() {
+	*x = Action{}
+	mi := &file_pb_northbound_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Action) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Action) ProtoMessage() {}
+
+func (x *Action) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Action.ProtoReflect.Descriptor instead.
+func (*Action) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Action) GetAction() isAction_Action {
+	if x != nil {
+		return x.Action
+	}
+	return nil
+}
+
+func (x *Action) GetOutput() *OutputAction {
+	if x != nil {
+		if x, ok := x.Action.(*Action_Output); ok {
+			return x.Output
+		}
+	}
+	return nil
+}
+
+func (x *Action) GetSetVlanVid() uint32 {
+	if x != nil {
+		if x, ok := x.Action.(*Action_SetVlanVid); ok {
+			return x.SetVlanVid
+		}
+	}
+	return 0
+}
+
+func (x *Action) GetSetVlanPcp() uint32 {
+	if x != nil {
+		if x, ok := x.Action.(*Action_SetVlanPcp); ok {
+			return x.SetVlanPcp
+		}
+	}
+	return 0
+}
+
+func (x *Action) GetStripVlan() bool {
+	if x != nil {
+		if x, ok := x.Action.(*Action_StripVlan); ok {
+			return x.StripVlan
+		}
+	}
+	return false
+}
+
+func (x *Action) GetSetDlSrc() []byte {
+	if x != nil {
+		if x, ok := x.Action.(*Action_SetDlSrc); ok {
+			return x.SetDlSrc
+		}
+	}
+	return nil
+}
+
+func (x *Action) GetSetDlDst() []byte {
+	if x != nil {
+		if x, ok := x.Action.(*Action_SetDlDst); ok {
+			return x.SetDlDst
+		}
+	}
+	return nil
+}
+
+func (x *Action) GetSetNwSrc() []byte {
+	if x != nil {
+		if x, ok := x.Action.(*Action_SetNwSrc); ok {
+			return x.SetNwSrc
+		}
+	}
+	return nil
+}
+
+func (x *Action) GetSetNwDst() []byte {
+	if x != nil {
+		if x, ok := x.Action.(*Action_SetNwDst); ok {
+			return x.SetNwDst
+		}
+	}
+	return nil
+}
+
+func (x *Action) GetSetNwTos() uint32 {
+	if x != nil {
+		if x, ok := x.Action.(*Action_SetNwTos); ok {
+			return x.SetNwTos
+		}
+	}
+	return 0
+}
+
+func (x *Action) GetSetTpSrc() uint32 {
+	if x != nil {
+		if x, ok := x.Action.(*Action_SetTpSrc); ok {
+			return x.SetTpSrc
+		}
+	}
+	return 0
+}
+
+func (x *Action) GetSetTpDst() uint32 {
+	if x != nil {
+		if x, ok := x.Action.(*Action_SetTpDst); ok {
+			return x.SetTpDst
+		}
+	}
+	return 0
+}
+
+func (x *Action) GetEnqueue() *EnqueueAction {
+	if x != nil {
+		if x, ok := x.Action.(*Action_Enqueue); ok {
+			return x.Enqueue
+		}
+	}
+	return nil
+}
+
+type isAction_Action interface {
+	isAction_Action()
+}
+
+type Action_Output struct {
+	Output *OutputAction `protobuf:"bytes,1,opt,name=output,proto3,oneof"`
+}
+
+type Action_SetVlanVid struct {
+	SetVlanVid uint32 `protobuf:"varint,2,opt,name=set_vlan_vid,json=setVlanVid,proto3,oneof"`
+}
+
+type Action_SetVlanPcp struct {
+	SetVlanPcp uint32 `protobuf:"varint,3,opt,name=set_vlan_pcp,json=setVlanPcp,proto3,oneof"`
+}
+
+type Action_StripVlan struct {
+	StripVlan bool `protobuf:"varint,4,opt,name=strip_vlan,json=stripVlan,proto3,oneof"`
+}
+
+type Action_SetDlSrc struct {
+	SetDlSrc []byte `protobuf:"bytes,5,opt,name=set_dl_src,json=setDlSrc,proto3,oneof"`
+}
+
+type Action_SetDlDst struct {
+	SetDlDst []byte `protobuf:"bytes,6,opt,name=set_dl_dst,json=setDlDst,proto3,oneof"`
+}
+
+type Action_SetNwSrc struct {
+	SetNwSrc []byte `protobuf:"bytes,7,opt,name=set_nw_src,json=setNwSrc,proto3,oneof"`
+}
+
+type Action_SetNwDst struct {
+	SetNwDst []byte `protobuf:"bytes,8,opt,name=set_nw_dst,json=setNwDst,proto3,oneof"`
+}
+
+type Action_SetNwTos struct {
+	SetNwTos uint32 `protobuf:"varint,9,opt,name=set_nw_tos,json=setNwTos,proto3,oneof"`
+}
+
+type Action_SetTpSrc struct {
+	SetTpSrc uint32 `protobuf:"varint,10,opt,name=set_tp_src,json=setTpSrc,proto3,oneof"`
+}
+
+type Action_SetTpDst struct {
+	SetTpDst uint32 `protobuf:"varint,11,opt,name=set_tp_dst,json=setTpDst,proto3,oneof"`
+}
+
+type Action_Enqueue struct {
+	Enqueue *EnqueueAction `protobuf:"bytes,12,opt,name=enqueue,proto3,oneof"`
+}
+
+func (*Action_Output) isAction_Action() {}
+
+func (*Action_SetVlanVid) isAction_Action() {}
+
+func (*Action_SetVlanPcp) isAction_Action() {}
+
+func (*Action_StripVlan) isAction_Action() {}
+
+func (*Action_SetDlSrc) isAction_Action() {}
+
+func (*Action_SetDlDst) isAction_Action() {}
+
+func (*Action_SetNwSrc) isAction_Action() {}
+
+func (*Action_SetNwDst) isAction_Action() {}
+
+func (*Action_SetNwTos) isAction_Action() {}
+
+func (*Action_SetTpSrc) isAction_Action() {}
+
+func (*Action_SetTpDst) isAction_Action() {}
+
+func (*Action_Enqueue) isAction_Action() {}
+
+type OutputAction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Port          uint32                 `protobuf:"varint,1,opt,name=port,proto3" json:"port,omitempty"`
+	MaxLen        uint32                 `protobuf:"varint,2,opt,name=max_len,json=maxLen,proto3" json:"max_len,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputAction) Reset() {
+	*x = OutputAction{}
+	mi := &file_pb_northbound_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputAction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputAction) ProtoMessage() {}
+
+func (x *OutputAction) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputAction.ProtoReflect.Descriptor instead.
+func (*OutputAction) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OutputAction) GetPort() uint32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *OutputAction) GetMaxLen() uint32 {
+	if x != nil {
+		return x.MaxLen
+	}
+	return 0
+}
+
+type EnqueueAction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Port          uint32                 `protobuf:"varint,1,opt,name=port,proto3" json:"port,omitempty"`
+	QueueId       uint32                 `protobuf:"varint,2,opt,name=queue_id,json=queueId,proto3" json:"queue_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnqueueAction) Reset() {
+	*x = EnqueueAction{}
+	mi := &file_pb_northbound_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnqueueAction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnqueueAction) ProtoMessage() {}
+
+func (x *EnqueueAction) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnqueueAction.ProtoReflect.Descriptor instead.
+func (*EnqueueAction) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *EnqueueAction) GetPort() uint32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *EnqueueAction) GetQueueId() uint32 {
+	if x != nil {
+		return x.QueueId
+	}
+	return 0
+}
+
+type FlowMod struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Dpid        uint64                 `protobuf:"varint,1,opt,name=dpid,proto3" json:"dpid,omitempty"`
+	Match       *Match                 `protobuf:"bytes,2,opt,name=match,proto3" json:"match,omitempty"`
+	Cookie      uint64                 `protobuf:"varint,3,opt,name=cookie,proto3" json:"cookie,omitempty"`
+	Command     FlowCommand            `protobuf:"varint,4,opt,name=command,proto3,enum=goflow.northbound.FlowCommand" json:"command,omitempty"`
+	IdleTimeout uint32                 `protobuf:"varint,5,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
+	HardTimeout uint32                 `protobuf:"varint,6,opt,name=hard_timeout,json=hardTimeout,proto3" json:"hard_timeout,omitempty"`
+	Priority    uint32                 `protobuf:"varint,7,opt,name=priority,proto3" json:"priority,omitempty"`
+	BufferId    uint32                 `protobuf:"varint,8,opt,name=buffer_id,json=bufferId,proto3" json:"buffer_id,omitempty"`
+	OutPort     uint32                 `protobuf:"varint,9,opt,name=out_port,json=outPort,proto3" json:"out_port,omitempty"`
+	// OFPFF_* bits
+	Flags         uint32    `protobuf:"varint,10,opt,name=flags,proto3" json:"flags,omitempty"`
+	Actions       []*Action `protobuf:"bytes,11,rep,name=actions,proto3" json:"actions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlowMod) Reset() {
+	*x = FlowMod{}
+	mi := &file_pb_northbound_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlowMod) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlowMod) ProtoMessage() {}
+
+func (x *FlowMod) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlowMod.ProtoReflect.Descriptor instead.
+func (*FlowMod) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FlowMod) GetDpid() uint64 {
+	if x != nil {
+		return x.Dpid
+	}
+	return 0
+}
+
+func (x *FlowMod) GetMatch() *Match {
+	if x != nil {
+		return x.Match
+	}
+	return nil
+}
+
+func (x *FlowMod) GetCookie() uint64 {
+	if x != nil {
+		return x.Cookie
+	}
+	return 0
+}
+
+func (x *FlowMod) GetCommand() FlowCommand {
+	if x != nil {
+		return x.Command
+	}
+	return FlowCommand_ADD
+}
+
+func (x *FlowMod) GetIdleTimeout() uint32 {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return 0
+}
+
+func (x *FlowMod) GetHardTimeout() uint32 {
+	if x != nil {
+		return x.HardTimeout
+	}
+	return 0
+}
+
+func (x *FlowMod) GetPriority() uint32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *FlowMod) GetBufferId() uint32 {
+	if x != nil {
+		return x.BufferId
+	}
+	return 0
+}
+
+func (x *FlowMod) GetOutPort() uint32 {
+	if x != nil {
+		return x.OutPort
+	}
+	return 0
+}
+
+func (x *FlowMod) GetFlags() uint32 {
+	if x != nil {
+		return x.Flags
+	}
+	return 0
+}
+
+func (x *FlowMod) GetActions() []*Action {
+	if x != nil {
+		return x.Actions
+	}
+	return nil
+}
+
+type PacketOut struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dpid          uint64                 `protobuf:"varint,1,opt,name=dpid,proto3" json:"dpid,omitempty"`
+	BufferId      uint32                 `protobuf:"varint,2,opt,name=buffer_id,json=bufferId,proto3" json:"buffer_id,omitempty"`
+	InPort        uint32                 `protobuf:"varint,3,opt,name=in_port,json=inPort,proto3" json:"in_port,omitempty"`
+	Actions       []*Action              `protobuf:"bytes,4,rep,name=actions,proto3" json:"actions,omitempty"`
+	Data          []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PacketOut) Reset() {
+	*x = PacketOut{}
+	mi := &file_pb_northbound_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PacketOut) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PacketOut) ProtoMessage() {}
+
+func (x *PacketOut) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PacketOut.ProtoReflect.Descriptor instead.
+func (*PacketOut) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PacketOut) GetDpid() uint64 {
+	if x != nil {
+		return x.Dpid
+	}
+	return 0
+}
+
+func (x *PacketOut) GetBufferId() uint32 {
+	if x != nil {
+		return x.BufferId
+	}
+	return 0
+}
+
+func (x *PacketOut) GetInPort() uint32 {
+	if x != nil {
+		return x.InPort
+	}
+	return 0
+}
+
+func (x *PacketOut) GetActions() []*Action {
+	if x != nil {
+		return x.Actions
+	}
+	return nil
+}
+
+func (x *PacketOut) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type PacketIn struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BufferId      uint32                 `protobuf:"varint,1,opt,name=buffer_id,json=bufferId,proto3" json:"buffer_id,omitempty"`
+	TotalLen      uint32                 `protobuf:"varint,2,opt,name=total_len,json=totalLen,proto3" json:"total_len,omitempty"`
+	InPort        uint32                 `protobuf:"varint,3,opt,name=in_port,json=inPort,proto3" json:"in_port,omitempty"`
+	Reason        uint32                 `protobuf:"varint,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	Data          []byte                 `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PacketIn) Reset() {
+	*x = PacketIn{}
+	mi := &file_pb_northbound_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PacketIn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PacketIn) ProtoMessage() {}
+
+func (x *PacketIn) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PacketIn.ProtoReflect.Descriptor instead.
+func (*PacketIn) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PacketIn) GetBufferId() uint32 {
+	if x != nil {
+		return x.BufferId
+	}
+	return 0
+}
+
+func (x *PacketIn) GetTotalLen() uint32 {
+	if x != nil {
+		return x.TotalLen
+	}
+	return 0
+}
+
+func (x *PacketIn) GetInPort() uint32 {
+	if x != nil {
+		return x.InPort
+	}
+	return 0
+}
+
+func (x *PacketIn) GetReason() uint32 {
+	if x != nil {
+		return x.Reason
+	}
+	return 0
+}
+
+func (x *PacketIn) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type Port struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PortNo        uint32                 `protobuf:"varint,1,opt,name=port_no,json=portNo,proto3" json:"port_no,omitempty"`
+	HwAddr        []byte                 `protobuf:"bytes,2,opt,name=hw_addr,json=hwAddr,proto3" json:"hw_addr,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Config        uint32                 `protobuf:"varint,4,opt,name=config,proto3" json:"config,omitempty"`
+	State         uint32                 `protobuf:"varint,5,opt,name=state,proto3" json:"state,omitempty"`
+	Curr          uint32                 `protobuf:"varint,6,opt,name=curr,proto3" json:"curr,omitempty"`
+	Advertised    uint32                 `protobuf:"varint,7,opt,name=advertised,proto3" json:"advertised,omitempty"`
+	Supported     uint32                 `protobuf:"varint,8,opt,name=supported,proto3" json:"supported,omitempty"`
+	Peer          uint32                 `protobuf:"varint,9,opt,name=peer,proto3" json:"peer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Port) Reset() {
+	*x = Port{}
+	mi := &file_pb_northbound_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Port) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Port) ProtoMessage() {}
+
+func (x *Port) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Port.ProtoReflect.Descriptor instead.
+func (*Port) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Port) GetPortNo() uint32 {
+	if x != nil {
+		return x.PortNo
+	}
+	return 0
+}
+
+func (x *Port) GetHwAddr() []byte {
+	if x != nil {
+		return x.HwAddr
+	}
+	return nil
+}
+
+func (x *Port) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Port) GetConfig() uint32 {
+	if x != nil {
+		return x.Config
+	}
+	return 0
+}
+
+func (x *Port) GetState() uint32 {
+	if x != nil {
+		return x.State
+	}
+	return 0
+}
+
+func (x *Port) GetCurr() uint32 {
+	if x != nil {
+		return x.Curr
+	}
+	return 0
+}
+
+func (x *Port) GetAdvertised() uint32 {
+	if x != nil {
+		return x.Advertised
+	}
+	return 0
+}
+
+func (x *Port) GetSupported() uint32 {
+	if x != nil {
+		return x.Supported
+	}
+	return 0
+}
+
+func (x *Port) GetPeer() uint32 {
+	if x != nil {
+		return x.Peer
+	}
+	return 0
+}
+
+type PortStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// OFPPR_* reason
+	Reason        uint32 `protobuf:"varint,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	Port          *Port  `protobuf:"bytes,2,opt,name=port,proto3" json:"port,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PortStatus) Reset() {
+	*x = PortStatus{}
+	mi := &file_pb_northbound_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PortStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PortStatus) ProtoMessage() {}
+
+func (x *PortStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PortStatus.ProtoReflect.Descriptor instead.
+func (*PortStatus) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *PortStatus) GetReason() uint32 {
+	if x != nil {
+		return x.Reason
+	}
+	return 0
+}
+
+func (x *PortStatus) GetPort() *Port {
+	if x != nil {
+		return x.Port
+	}
+	return nil
+}
+
+type FlowRemoved struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Match         *Match                 `protobuf:"bytes,1,opt,name=match,proto3" json:"match,omitempty"`
+	Cookie        uint64                 `protobuf:"varint,2,opt,name=cookie,proto3" json:"cookie,omitempty"`
+	Priority      uint32                 `protobuf:"varint,3,opt,name=priority,proto3" json:"priority,omitempty"`
+	Reason        uint32                 `protobuf:"varint,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	DurationSec   uint32                 `protobuf:"varint,5,opt,name=duration_sec,json=durationSec,proto3" json:"duration_sec,omitempty"`
+	DurationNsec  uint32                 `protobuf:"varint,6,opt,name=duration_nsec,json=durationNsec,proto3" json:"duration_nsec,omitempty"`
+	IdleTimeout   uint32                 `protobuf:"varint,7,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
+	PacketCount   uint64                 `protobuf:"varint,8,opt,name=packet_count,json=packetCount,proto3" json:"packet_count,omitempty"`
+	ByteCount     uint64                 `protobuf:"varint,9,opt,name=byte_count,json=byteCount,proto3" json:"byte_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlowRemoved) Reset() {
+	*x = FlowRemoved{}
+	mi := &file_pb_northbound_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlowRemoved) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlowRemoved) ProtoMessage() {}
+
+func (x *FlowRemoved) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlowRemoved.ProtoReflect.Descriptor instead.
+func (*FlowRemoved) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *FlowRemoved) GetMatch() *Match {
+	if x != nil {
+		return x.Match
+	}
+	return nil
+}
+
+func (x *FlowRemoved) GetCookie() uint64 {
+	if x != nil {
+		return x.Cookie
+	}
+	return 0
+}
+
+func (x *FlowRemoved) GetPriority() uint32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *FlowRemoved) GetReason() uint32 {
+	if x != nil {
+		return x.Reason
+	}
+	return 0
+}
+
+func (x *FlowRemoved) GetDurationSec() uint32 {
+	if x != nil {
+		return x.DurationSec
+	}
+	return 0
+}
+
+func (x *FlowRemoved) GetDurationNsec() uint32 {
+	if x != nil {
+		return x.DurationNsec
+	}
+	return 0
+}
+
+func (x *FlowRemoved) GetIdleTimeout() uint32 {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return 0
+}
+
+func (x *FlowRemoved) GetPacketCount() uint64 {
+	if x != nil {
+		return x.PacketCount
+	}
+	return 0
+}
+
+func (x *FlowRemoved) GetByteCount() uint64 {
+	if x != nil {
+		return x.ByteCount
+	}
+	return 0
+}
+
+type Error struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          uint32                 `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
+	Code          uint32                 `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_pb_northbound_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Error) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *Error) GetCode() uint32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Error) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type Datapath struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dpid          uint64                 `protobuf:"varint,1,opt,name=dpid,proto3" json:"dpid,omitempty"`
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	NBuffers      uint32                 `protobuf:"varint,3,opt,name=n_buffers,json=nBuffers,proto3" json:"n_buffers,omitempty"`
+	NTables       uint32                 `protobuf:"varint,4,opt,name=n_tables,json=nTables,proto3" json:"n_tables,omitempty"`
+	Capabilities  uint32                 `protobuf:"varint,5,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	Actions       uint32                 `protobuf:"varint,6,opt,name=actions,proto3" json:"actions,omitempty"`
+	Ports         []*Port                `protobuf:"bytes,7,rep,name=ports,proto3" json:"ports,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Datapath) Reset() {
+	*x = Datapath{}
+	mi := &file_pb_northbound_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Datapath) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Datapath) ProtoMessage() {}
+
+func (x *Datapath) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Datapath.ProtoReflect.Descriptor instead.
+func (*Datapath) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Datapath) GetDpid() uint64 {
+	if x != nil {
+		return x.Dpid
+	}
+	return 0
+}
+
+func (x *Datapath) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Datapath) GetNBuffers() uint32 {
+	if x != nil {
+		return x.NBuffers
+	}
+	return 0
+}
+
+func (x *Datapath) GetNTables() uint32 {
+	if x != nil {
+		return x.NTables
+	}
+	return 0
+}
+
+func (x *Datapath) GetCapabilities() uint32 {
+	if x != nil {
+		return x.Capabilities
+	}
+	return 0
+}
+
+func (x *Datapath) GetActions() uint32 {
+	if x != nil {
+		return x.Actions
+	}
+	return 0
+}
+
+func (x *Datapath) GetPorts() []*Port {
+	if x != nil {
+		return x.Ports
+	}
+	return nil
+}
+
+type ListDatapathsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDatapathsRequest) Reset() {
+	*x = ListDatapathsRequest{}
+	mi := &file_pb_northbound_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDatapathsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDatapathsRequest) ProtoMessage() {}
+
+func (x *ListDatapathsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDatapathsRequest.ProtoReflect.Descriptor instead.
+func (*ListDatapathsRequest) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{12}
+}
+
+type ListDatapathsReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Datapaths     []*Datapath            `protobuf:"bytes,1,rep,name=datapaths,proto3" json:"datapaths,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDatapathsReply) Reset() {
+	*x = ListDatapathsReply{}
+	mi := &file_pb_northbound_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDatapathsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDatapathsReply) ProtoMessage() {}
+
+func (x *ListDatapathsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDatapathsReply.ProtoReflect.Descriptor instead.
+func (*ListDatapathsReply) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListDatapathsReply) GetDatapaths() []*Datapath {
+	if x != nil {
+		return x.Datapaths
+	}
+	return nil
+}
+
+type StatsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Dpid  uint64                 `protobuf:"varint,1,opt,name=dpid,proto3" json:"dpid,omitempty"`
+	Type  StatsType              `protobuf:"varint,2,opt,name=type,proto3,enum=goflow.northbound.StatsType" json:"type,omitempty"`
+	// flow and aggregate requests, all flows of all tables when unset
+	Match   *Match  `protobuf:"bytes,3,opt,name=match,proto3" json:"match,omitempty"`
+	TableId *uint32 `protobuf:"varint,4,opt,name=table_id,json=tableId,proto3,oneof" json:"table_id,omitempty"`
+	OutPort *uint32 `protobuf:"varint,5,opt,name=out_port,json=outPort,proto3,oneof" json:"out_port,omitempty"`
+	// port and queue requests, all ports when unset
+	PortNo *uint32 `protobuf:"varint,6,opt,name=port_no,json=portNo,proto3,oneof" json:"port_no,omitempty"`
+	// queue requests, all queues when unset
+	QueueId       *uint32 `protobuf:"varint,7,opt,name=queue_id,json=queueId,proto3,oneof" json:"queue_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_pb_northbound_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *StatsRequest) GetDpid() uint64 {
+	if x != nil {
+		return x.Dpid
+	}
+	return 0
+}
+
+func (x *StatsRequest) GetType() StatsType {
+	if x != nil {
+		return x.Type
+	}
+	return StatsType_FLOW
+}
+
+func (x *StatsRequest) GetMatch() *Match {
+	if x != nil {
+		return x.Match
+	}
+	return nil
+}
+
+func (x *StatsRequest) GetTableId() uint32 {
+	if x != nil && x.TableId != nil {
+		return *x.TableId
+	}
+	return 0
+}
+
+func (x *StatsRequest) GetOutPort() uint32 {
+	if x != nil && x.OutPort != nil {
+		return *x.OutPort
+	}
+	return 0
+}
+
+func (x *StatsRequest) GetPortNo() uint32 {
+	if x != nil && x.PortNo != nil {
+		return *x.PortNo
+	}
+	return 0
+}
+
+func (x *StatsRequest) GetQueueId() uint32 {
+	if x != nil && x.QueueId != nil {
+		return *x.QueueId
+	}
+	return 0
+}
+
+type FlowStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TableId       uint32                 `protobuf:"varint,1,opt,name=table_id,json=tableId,proto3" json:"table_id,omitempty"`
+	Match         *Match                 `protobuf:"bytes,2,opt,name=match,proto3" json:"match,omitempty"`
+	DurationSec   uint32                 `protobuf:"varint,3,opt,name=duration_sec,json=durationSec,proto3" json:"duration_sec,omitempty"`
+	DurationNsec  uint32                 `protobuf:"varint,4,opt,name=duration_nsec,json=durationNsec,proto3" json:"duration_nsec,omitempty"`
+	Priority      uint32                 `protobuf:"varint,5,opt,name=priority,proto3" json:"priority,omitempty"`
+	IdleTimeout   uint32                 `protobuf:"varint,6,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
+	HardTimeout   uint32                 `protobuf:"varint,7,opt,name=hard_timeout,json=hardTimeout,proto3" json:"hard_timeout,omitempty"`
+	Cookie        uint64                 `protobuf:"varint,8,opt,name=cookie,proto3" json:"cookie,omitempty"`
+	PacketCount   uint64                 `protobuf:"varint,9,opt,name=packet_count,json=packetCount,proto3" json:"packet_count,omitempty"`
+	ByteCount     uint64                 `protobuf:"varint,10,opt,name=byte_count,json=byteCount,proto3" json:"byte_count,omitempty"`
+	Actions       []*Action              `protobuf:"bytes,11,rep,name=actions,proto3" json:"actions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlowStats) Reset() {
+	*x = FlowStats{}
+	mi := &file_pb_northbound_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlowStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlowStats) ProtoMessage() {}
+
+func (x *FlowStats) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlowStats.ProtoReflect.Descriptor instead.
+func (*FlowStats) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *FlowStats) GetTableId() uint32 {
+	if x != nil {
+		return x.TableId
+	}
+	return 0
+}
+
+func (x *FlowStats) GetMatch() *Match {
+	if x != nil {
+		return x.Match
+	}
+	return nil
+}
+
+func (x *FlowStats) GetDurationSec() uint32 {
+	if x != nil {
+		return x.DurationSec
+	}
+	return 0
+}
+
+func (x *FlowStats) GetDurationNsec() uint32 {
+	if x != nil {
+		return x.DurationNsec
+	}
+	return 0
+}
+
+func (x *FlowStats) GetPriority() uint32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *FlowStats) GetIdleTimeout() uint32 {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return 0
+}
+
+func (x *FlowStats) GetHardTimeout() uint32 {
+	if x != nil {
+		return x.HardTimeout
+	}
+	return 0
+}
+
+func (x *FlowStats) GetCookie() uint64 {
+	if x != nil {
+		return x.Cookie
+	}
+	return 0
+}
+
+func (x *FlowStats) GetPacketCount() uint64 {
+	if x != nil {
+		return x.PacketCount
+	}
+	return 0
+}
+
+func (x *FlowStats) GetByteCount() uint64 {
+	if x != nil {
+		return x.ByteCount
+	}
+	return 0
+}
+
+func (x *FlowStats) GetActions() []*Action {
+	if x != nil {
+		return x.Actions
+	}
+	return nil
+}
+
+type AggregateStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PacketCount   uint64                 `protobuf:"varint,1,opt,name=packet_count,json=packetCount,proto3" json:"packet_count,omitempty"`
+	ByteCount     uint64                 `protobuf:"varint,2,opt,name=byte_count,json=byteCount,proto3" json:"byte_count,omitempty"`
+	FlowCount     uint32                 `protobuf:"varint,3,opt,name=flow_count,json=flowCount,proto3" json:"flow_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AggregateStats) Reset() {
+	*x = AggregateStats{}
+	mi := &file_pb_northbound_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AggregateStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AggregateStats) ProtoMessage() {}
+
+func (x *AggregateStats) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AggregateStats.ProtoReflect.Descriptor instead.
+func (*AggregateStats) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AggregateStats) GetPacketCount() uint64 {
+	if x != nil {
+		return x.PacketCount
+	}
+	return 0
+}
+
+func (x *AggregateStats) GetByteCount() uint64 {
+	if x != nil {
+		return x.ByteCount
+	}
+	return 0
+}
+
+func (x *AggregateStats) GetFlowCount() uint32 {
+	if x != nil {
+		return x.FlowCount
+	}
+	return 0
+}
+
+type TableStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TableId       uint32                 `protobuf:"varint,1,opt,name=table_id,json=tableId,proto3" json:"table_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Wildcards     uint32                 `protobuf:"varint,3,opt,name=wildcards,proto3" json:"wildcards,omitempty"`
+	MaxEntries    uint32                 `protobuf:"varint,4,opt,name=max_entries,json=maxEntries,proto3" json:"max_entries,omitempty"`
+	ActiveCount   uint32                 `protobuf:"varint,5,opt,name=active_count,json=activeCount,proto3" json:"active_count,omitempty"`
+	LookupCount   uint64                 `protobuf:"varint,6,opt,name=lookup_count,json=lookupCount,proto3" json:"lookup_count,omitempty"`
+	MatchedCount  uint64                 `protobuf:"varint,7,opt,name=matched_count,json=matchedCount,proto3" json:"matched_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TableStats) Reset() {
+	*x = TableStats{}
+	mi := &file_pb_northbound_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TableStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TableStats) ProtoMessage() {}
+
+func (x *TableStats) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TableStats.ProtoReflect.Descriptor instead.
+func (*TableStats) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *TableStats) GetTableId() uint32 {
+	if x != nil {
+		return x.TableId
+	}
+	return 0
+}
+
+func (x *TableStats) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TableStats) GetWildcards() uint32 {
+	if x != nil {
+		return x.Wildcards
+	}
+	return 0
+}
+
+func (x *TableStats) GetMaxEntries() uint32 {
+	if x != nil {
+		return x.MaxEntries
+	}
+	return 0
+}
+
+func (x *TableStats) GetActiveCount() uint32 {
+	if x != nil {
+		return x.ActiveCount
+	}
+	return 0
+}
+
+func (x *TableStats) GetLookupCount() uint64 {
+	if x != nil {
+		return x.LookupCount
+	}
+	return 0
+}
+
+func (x *TableStats) GetMatchedCount() uint64 {
+	if x != nil {
+		return x.MatchedCount
+	}
+	return 0
+}
+
+type PortStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PortNo        uint32                 `protobuf:"varint,1,opt,name=port_no,json=portNo,proto3" json:"port_no,omitempty"`
+	RxPackets     uint64                 `protobuf:"varint,2,opt,name=rx_packets,json=rxPackets,proto3" json:"rx_packets,omitempty"`
+	TxPackets     uint64                 `protobuf:"varint,3,opt,name=tx_packets,json=txPackets,proto3" json:"tx_packets,omitempty"`
+	RxBytes       uint64                 `protobuf:"varint,4,opt,name=rx_bytes,json=rxBytes,proto3" json:"rx_bytes,omitempty"`
+	TxBytes       uint64                 `protobuf:"varint,5,opt,name=tx_bytes,json=txBytes,proto3" json:"tx_bytes,omitempty"`
+	RxDropped     uint64                 `protobuf:"varint,6,opt,name=rx_dropped,json=rxDropped,proto3" json:"rx_dropped,omitempty"`
+	TxDropped     uint64                 `protobuf:"varint,7,opt,name=tx_dropped,json=txDropped,proto3" json:"tx_dropped,omitempty"`
+	RxErrors      uint64                 `protobuf:"varint,8,opt,name=rx_errors,json=rxErrors,proto3" json:"rx_errors,omitempty"`
+	TxErrors      uint64                 `protobuf:"varint,9,opt,name=tx_errors,json=txErrors,proto3" json:"tx_errors,omitempty"`
+	RxFrameErr    uint64                 `protobuf:"varint,10,opt,name=rx_frame_err,json=rxFrameErr,proto3" json:"rx_frame_err,omitempty"`
+	RxOverErr     uint64                 `protobuf:"varint,11,opt,name=rx_over_err,json=rxOverErr,proto3" json:"rx_over_err,omitempty"`
+	RxCrcErr      uint64                 `protobuf:"varint,12,opt,name=rx_crc_err,json=rxCrcErr,proto3" json:"rx_crc_err,omitempty"`
+	Collisions    uint64                 `protobuf:"varint,13,opt,name=collisions,proto3" json:"collisions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PortStats) Reset() {
+	*x = PortStats{}
+	mi := &file_pb_northbound_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PortStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PortStats) ProtoMessage() {}
+
+func (x *PortStats) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PortStats.ProtoReflect.Descriptor instead.
+func (*PortStats) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PortStats) GetPortNo() uint32 {
+	if x != nil {
+		return x.PortNo
+	}
+	return 0
+}
+
+func (x *PortStats) GetRxPackets() uint64 {
+	if x != nil {
+		return x.RxPackets
+	}
+	return 0
+}
+
+func (x *PortStats) GetTxPackets() uint64 {
+	if x != nil {
+		return x.TxPackets
+	}
+	return 0
+}
+
+func (x *PortStats) GetRxBytes() uint64 {
+	if x != nil {
+		return x.RxBytes
+	}
+	return 0
+}
+
+func (x *PortStats) GetTxBytes() uint64 {
+	if x != nil {
+		return x.TxBytes
+	}
+	return 0
+}
+
+func (x *PortStats) GetRxDropped() uint64 {
+	if x != nil {
+		return x.RxDropped
+	}
+	return 0
+}
+
+func (x *PortStats) GetTxDropped() uint64 {
+	if x != nil {
+		return x.TxDropped
+	}
+	return 0
+}
+
+func (x *PortStats) GetRxErrors() uint64 {
+	if x != nil {
+		return x.RxErrors
+	}
+	return 0
+}
+
+func (x *PortStats) GetTxErrors() uint64 {
+	if x != nil {
+		return x.TxErrors
+	}
+	return 0
+}
+
+func (x *PortStats) GetRxFrameErr() uint64 {
+	if x != nil {
+		return x.RxFrameErr
+	}
+	return 0
+}
+
+func (x *PortStats) GetRxOverErr() uint64 {
+	if x != nil {
+		return x.RxOverErr
+	}
+	return 0
+}
+
+func (x *PortStats) GetRxCrcErr() uint64 {
+	if x != nil {
+		return x.RxCrcErr
+	}
+	return 0
+}
+
+func (x *PortStats) GetCollisions() uint64 {
+	if x != nil {
+		return x.Collisions
+	}
+	return 0
+}
+
+type QueueStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PortNo        uint32                 `protobuf:"varint,1,opt,name=port_no,json=portNo,proto3" json:"port_no,omitempty"`
+	QueueId       uint32                 `protobuf:"varint,2,opt,name=queue_id,json=queueId,proto3" json:"queue_id,omitempty"`
+	TxBytes       uint64                 `protobuf:"varint,3,opt,name=tx_bytes,json=txBytes,proto3" json:"tx_bytes,omitempty"`
+	TxPackets     uint64                 `protobuf:"varint,4,opt,name=tx_packets,json=txPackets,proto3" json:"tx_packets,omitempty"`
+	TxErrors      uint64                 `protobuf:"varint,5,opt,name=tx_errors,json=txErrors,proto3" json:"tx_errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueueStats) Reset() {
+	*x = QueueStats{}
+	mi := &file_pb_northbound_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueueStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueueStats) ProtoMessage() {}
+
+func (x *QueueStats) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueueStats.ProtoReflect.Descriptor instead.
+func (*QueueStats) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *QueueStats) GetPortNo() uint32 {
+	if x != nil {
+		return x.PortNo
+	}
+	return 0
+}
+
+func (x *QueueStats) GetQueueId() uint32 {
+	if x != nil {
+		return x.QueueId
+	}
+	return 0
+}
+
+func (x *QueueStats) GetTxBytes() uint64 {
+	if x != nil {
+		return x.TxBytes
+	}
+	return 0
+}
+
+func (x *QueueStats) GetTxPackets() uint64 {
+	if x != nil {
+		return x.TxPackets
+	}
+	return 0
+}
+
+func (x *QueueStats) GetTxErrors() uint64 {
+	if x != nil {
+		return x.TxErrors
+	}
+	return 0
+}
+
+type StatsReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Flows         []*FlowStats           `protobuf:"bytes,1,rep,name=flows,proto3" json:"flows,omitempty"`
+	Aggregate     *AggregateStats        `protobuf:"bytes,2,opt,name=aggregate,proto3" json:"aggregate,omitempty"`
+	Tables        []*TableStats          `protobuf:"bytes,3,rep,name=tables,proto3" json:"tables,omitempty"`
+	Ports         []*PortStats           `protobuf:"bytes,4,rep,name=ports,proto3" json:"ports,omitempty"`
+	Queues        []*QueueStats          `protobuf:"bytes,5,rep,name=queues,proto3" json:"queues,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsReply) Reset() {
+	*x = StatsReply{}
+	mi := &file_pb_northbound_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsReply) ProtoMessage() {}
+
+func (x *StatsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsReply.ProtoReflect.Descriptor instead.
+func (*StatsReply) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *StatsReply) GetFlows() []*FlowStats {
+	if x != nil {
+		return x.Flows
+	}
+	return nil
+}
+
+func (x *StatsReply) GetAggregate() *AggregateStats {
+	if x != nil {
+		return x.Aggregate
+	}
+	return nil
+}
+
+func (x *StatsReply) GetTables() []*TableStats {
+	if x != nil {
+		return x.Tables
+	}
+	return nil
+}
+
+func (x *StatsReply) GetPorts() []*PortStats {
+	if x != nil {
+		return x.Ports
+	}
+	return nil
+}
+
+func (x *StatsReply) GetQueues() []*QueueStats {
+	if x != nil {
+		return x.Queues
+	}
+	return nil
+}
+
+// Subscribe replaces the event filter of the stream, empty lists select
+// everything
+type Subscribe struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dpids         []uint64               `protobuf:"varint,1,rep,packed,name=dpids,proto3" json:"dpids,omitempty"`
+	Kinds         []EventKind            `protobuf:"varint,2,rep,packed,name=kinds,proto3,enum=goflow.northbound.EventKind" json:"kinds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subscribe) Reset() {
+	*x = Subscribe{}
+	mi := &file_pb_northbound_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscribe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscribe) ProtoMessage() {}
+
+func (x *Subscribe) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscribe.ProtoReflect.Descriptor instead.
+func (*Subscribe) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Subscribe) GetDpids() []uint64 {
+	if x != nil {
+		return x.Dpids
+	}
+	return nil
+}
+
+func (x *Subscribe) GetKinds() []EventKind {
+	if x != nil {
+		return x.Kinds
+	}
+	return nil
+}
+
+type ClientMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// echoed by the acknowledgement
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*ClientMessage_Subscribe
+	//	*ClientMessage_FlowMod
+	//	*ClientMessage_PacketOut
+	Message isClientMessage_Message `protobuf_oneof:"message"`
+	// ack asks for an acknowledgement once the switch processed the flow
+	// mod or packet out, errors of the switch are then reported in it
+	Ack           bool `protobuf:"varint,5,opt,name=ack,proto3" json:"ack,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientMessage) Reset() {
+	*x = ClientMessage{}
+	mi := &file_pb_northbound_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientMessage) ProtoMessage() {}
+
+func (x *ClientMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientMessage.ProtoReflect.Descriptor instead.
+func (*ClientMessage) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ClientMessage) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ClientMessage) GetMessage() isClientMessage_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetSubscribe() *Subscribe {
+	if x != nil {
+		if x, ok := x.Message.(*ClientMessage_Subscribe); ok {
+			return x.Subscribe
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetFlowMod() *FlowMod {
+	if x != nil {
+		if x, ok := x.Message.(*ClientMessage_FlowMod); ok {
+			return x.FlowMod
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetPacketOut() *PacketOut {
+	if x != nil {
+		if x, ok := x.Message.(*ClientMessage_PacketOut); ok {
+			return x.PacketOut
+		}
+	}
+	return nil
+}
+
+func (x *ClientMessage) GetAck() bool {
+	if x != nil {
+		return x.Ack
+	}
+	return false
+}
+
+type isClientMessage_Message interface {
+	isClientMessage_Message()
+}
+
+type ClientMessage_Subscribe struct {
+	Subscribe *Subscribe `protobuf:"bytes,2,opt,name=subscribe,proto3,oneof"`
+}
+
+type ClientMessage_FlowMod struct {
+	FlowMod *FlowMod `protobuf:"bytes,3,opt,name=flow_mod,json=flowMod,proto3,oneof"`
+}
+
+type ClientMessage_PacketOut struct {
+	PacketOut *PacketOut `protobuf:"bytes,4,opt,name=packet_out,json=packetOut,proto3,oneof"`
+}
+
+func (*ClientMessage_Subscribe) isClientMessage_Message() {}
+
+func (*ClientMessage_FlowMod) isClientMessage_Message() {}
+
+func (*ClientMessage_PacketOut) isClientMessage_Message() {}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Dpid  uint64                 `protobuf:"varint,1,opt,name=dpid,proto3" json:"dpid,omitempty"`
+	Kind  EventKind              `protobuf:"varint,2,opt,name=kind,proto3,enum=goflow.northbound.EventKind" json:"kind,omitempty"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*Event_SwitchConnected
+	//	*Event_PacketIn
+	//	*Event_PortStatus
+	//	*Event_FlowRemoved
+	//	*Event_Error
+	Message       isEvent_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_pb_northbound_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Event) GetDpid() uint64 {
+	if x != nil {
+		return x.Dpid
+	}
+	return 0
+}
+
+func (x *Event) GetKind() EventKind {
+	if x != nil {
+		return x.Kind
+	}
+	return EventKind_SWITCH_CONNECTED
+}
+
+func (x *Event) GetMessage() isEvent_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *Event) GetSwitchConnected() *Datapath {
+	if x != nil {
+		if x, ok := x.Message.(*Event_SwitchConnected); ok {
+			return x.SwitchConnected
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetPacketIn() *PacketIn {
+	if x != nil {
+		if x, ok := x.Message.(*Event_PacketIn); ok {
+			return x.PacketIn
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetPortStatus() *PortStatus {
+	if x != nil {
+		if x, ok := x.Message.(*Event_PortStatus); ok {
+			return x.PortStatus
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetFlowRemoved() *FlowRemoved {
+	if x != nil {
+		if x, ok := x.Message.(*Event_FlowRemoved); ok {
+			return x.FlowRemoved
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetError() *Error {
+	if x != nil {
+		if x, ok := x.Message.(*Event_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+type isEvent_Message interface {
+	isEvent_Message()
+}
+
+type Event_SwitchConnected struct {
+	SwitchConnected *Datapath `protobuf:"bytes,3,opt,name=switch_connected,json=switchConnected,proto3,oneof"`
+}
+
+type Event_PacketIn struct {
+	PacketIn *PacketIn `protobuf:"bytes,4,opt,name=packet_in,json=packetIn,proto3,oneof"`
+}
+
+type Event_PortStatus struct {
+	PortStatus *PortStatus `protobuf:"bytes,5,opt,name=port_status,json=portStatus,proto3,oneof"`
+}
+
+type Event_FlowRemoved struct {
+	FlowRemoved *FlowRemoved `protobuf:"bytes,6,opt,name=flow_removed,json=flowRemoved,proto3,oneof"`
+}
+
+type Event_Error struct {
+	Error *Error `protobuf:"bytes,7,opt,name=error,proto3,oneof"`
+}
+
+func (*Event_SwitchConnected) isEvent_Message() {}
+
+func (*Event_PacketIn) isEvent_Message() {}
+
+func (*Event_PortStatus) isEvent_Message() {}
+
+func (*Event_FlowRemoved) isEvent_Message() {}
+
+func (*Event_Error) isEvent_Message() {}
+
+type Ack struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// empty on success
+	Error         string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ack) Reset() {
+	*x = Ack{}
+	mi := &file_pb_northbound_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ack) ProtoMessage() {}
+
+func (x *Ack) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ack.ProtoReflect.Descriptor instead.
+func (*Ack) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *Ack) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Ack) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ServerMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*ServerMessage_Event
+	//	*ServerMessage_Ack
+	//	*ServerMessage_Dropped
+	Message       isServerMessage_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerMessage) Reset() {
+	*x = ServerMessage{}
+	mi := &file_pb_northbound_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerMessage) ProtoMessage() {}
+
+func (x *ServerMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_northbound_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerMessage.ProtoReflect.Descriptor instead.
+func (*ServerMessage) Descriptor() ([]byte, []int) {
+	return file_pb_northbound_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ServerMessage) GetMessage() isServerMessage_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetEvent() *Event {
+	if x != nil {
+		if x, ok := x.Message.(*ServerMessage_Event); ok {
+			return x.Event
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetAck() *Ack {
+	if x != nil {
+		if x, ok := x.Message.(*ServerMessage_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetDropped() uint64 {
+	if x != nil {
+		if x, ok := x.Message.(*ServerMessage_Dropped); ok {
+			return x.Dropped
+		}
+	}
+	return 0
+}
+
+type isServerMessage_Message interface {
+	isServerMessage_Message()
+}
+
+type ServerMessage_Event struct {
+	Event *Event `protobuf:"bytes,1,opt,name=event,proto3,oneof"`
+}
+
+type ServerMessage_Ack struct {
+	Ack *Ack `protobuf:"bytes,2,opt,name=ack,proto3,oneof"`
+}
+
+type ServerMessage_Dropped struct {
+	// events dropped because the client did not keep up
+	Dropped uint64 `protobuf:"varint,3,opt,name=dropped,proto3,oneof"`
+}
+
+func (*ServerMessage_Event) isServerMessage_Message() {}
+
+func (*ServerMessage_Ack) isServerMessage_Message() {}
+
+func (*ServerMessage_Dropped) isServerMessage_Message() {}
+
+var File_pb_northbound_proto protoreflect.FileDescriptor
+
+const file_pb_northbound_proto_rawDesc = "" +
+	"\n" +
+	"\x13pb/northbound.proto\x12\x11goflow.northbound\"\xf8\x03\n" +
+	"\x05Match\x12\x1c\n" +
+	"\ain_port\x18\x01 \x01(\rH\x00R\x06inPort\x88\x01\x01\x12\x1a\n" +
+	"\x06dl_src\x18\x02 \x01(\fH\x01R\x05dlSrc\x88\x01\x01\x12\x1a\n" +
+	"\x06dl_dst\x18\x03 \x01(\fH\x02R\x05dlDst\x88\x01\x01\x12\x1c\n" +
+	"\adl_vlan\x18\x04 \x01(\rH\x03R\x06dlVlan\x88\x01\x01\x12#\n" +
+	"\vdl_vlan_pcp\x18\x05 \x01(\rH\x04R\tdlVlanPcp\x88\x01\x01\x12\x1c\n" +
+	"\adl_type\x18\x06 \x01(\rH\x05R\x06dlType\x88\x01\x01\x12\x1a\n" +
+	"\x06nw_tos\x18\a \x01(\rH\x06R\x05nwTos\x88\x01\x01\x12\x1e\n" +
+	"\bnw_proto\x18\b \x01(\rH\aR\anwProto\x88\x01\x01\x12\x1a\n" +
+	"\x06nw_src\x18\t \x01(\tH\bR\x05nwSrc\x88\x01\x01\x12\x1a\n" +
+	"\x06nw_dst\x18\n" +
+	" \x01(\tH\tR\x05nwDst\x88\x01\x01\x12\x1a\n" +
+	"\x06tp_src\x18\v \x01(\rH\n" +
+	"R\x05tpSrc\x88\x01\x01\x12\x1a\n" +
+	"\x06tp_dst\x18\f \x01(\rH\vR\x05tpDst\x88\x01\x01B\n" +
+	"\n" +
+	"\b_in_portB\t\n" +
+	"\a_dl_srcB\t\n" +
+	"\a_dl_dstB\n" +
+	"\n" +
+	"\b_dl_vlanB\x0e\n" +
+	"\f_dl_vlan_pcpB\n" +
+	"\n" +
+	"\b_dl_typeB\t\n" +
+	"\a_nw_tosB\v\n" +
+	"\t_nw_protoB\t\n" +
+	"\a_nw_srcB\t\n" +
+	"\a_nw_dstB\t\n" +
+	"\a_tp_srcB\t\n" +
+	"\a_tp_dst\"\xd4\x03\n" +
+	"\x06Action\x129\n" +
+	"\x06output\x18\x01 \x01(\v2\x1f.goflow.northbound.OutputActionH\x00R\x06output\x12\"\n" +
+	"\fset_vlan_vid\x18\x02 \x01(\rH\x00R\n" +
+	"setVlanVid\x12\"\n" +
+	"\fset_vlan_pcp\x18\x03 \x01(\rH\x00R\n" +
+	"setVlanPcp\x12\x1f\n" +
+	"\n" +
+	"strip_vlan\x18\x04 \x01(\bH\x00R\tstripVlan\x12\x1e\n" +
+	"\n" +
+	"set_dl_src\x18\x05 \x01(\fH\x00R\bsetDlSrc\x12\x1e\n" +
+	"\n" +
+	"set_dl_dst\x18\x06 \x01(\fH\x00R\bsetDlDst\x12\x1e\n" +
+	"\n" +
+	"set_nw_src\x18\a \x01(\fH\x00R\bsetNwSrc\x12\x1e\n" +
+	"\n" +
+	"set_nw_dst\x18\b \x01(\fH\x00R\bsetNwDst\x12\x1e\n" +
+	"\n" +
+	"set_nw_tos\x18\t \x01(\rH\x00R\bsetNwTos\x12\x1e\n" +
+	"\n" +
+	"set_tp_src\x18\n" +
+	" \x01(\rH\x00R\bsetTpSrc\x12\x1e\n" +
+	"\n" +
+	"set_tp_dst\x18\v \x01(\rH\x00R\bsetTpDst\x12<\n" +
+	"\aenqueue\x18\f \x01(\v2 .goflow.northbound.EnqueueActionH\x00R\aenqueueB\b\n" +
+	"\x06action\";\n" +
+	"\fOutputAction\x12\x12\n" +
+	"\x04port\x18\x01 \x01(\rR\x04port\x12\x17\n" +
+	"\amax_len\x18\x02 \x01(\rR\x06maxLen\">\n" +
+	"\rEnqueueAction\x12\x12\n" +
+	"\x04port\x18\x01 \x01(\rR\x04port\x12\x19\n" +
+	"\bqueue_id\x18\x02 \x01(\rR\aqueueId\"\x84\x03\n" +
+	"\aFlowMod\x12\x12\n" +
+	"\x04dpid\x18\x01 \x01(\x04R\x04dpid\x12.\n" +
+	"\x05match\x18\x02 \x01(\v2\x18.goflow.northbound.MatchR\x05match\x12\x16\n" +
+	"\x06cookie\x18\x03 \x01(\x04R\x06cookie\x128\n" +
+	"\acommand\x18\x04 \x01(\x0e2\x1e.goflow.northbound.FlowCommandR\acommand\x12!\n" +
+	"\fidle_timeout\x18\x05 \x01(\rR\vidleTimeout\x12!\n" +
+	"\fhard_timeout\x18\x06 \x01(\rR\vhardTimeout\x12\x1a\n" +
+	"\bpriority\x18\a \x01(\rR\bpriority\x12\x1b\n" +
+	"\tbuffer_id\x18\b \x01(\rR\bbufferId\x12\x19\n" +
+	"\bout_port\x18\t \x01(\rR\aoutPort\x12\x14\n" +
+	"\x05flags\x18\n" +
+	" \x01(\rR\x05flags\x123\n" +
+	"\aactions\x18\v \x03(\v2\x19.goflow.northbound.ActionR\aactions\"\x9e\x01\n" +
+	"\tPacketOut\x12\x12\n" +
+	"\x04dpid\x18\x01 \x01(\x04R\x04dpid\x12\x1b\n" +
+	"\tbuffer_id\x18\x02 \x01(\rR\bbufferId\x12\x17\n" +
+	"\ain_port\x18\x03 \x01(\rR\x06inPort\x123\n" +
+	"\aactions\x18\x04 \x03(\v2\x19.goflow.northbound.ActionR\aactions\x12\x12\n" +
+	"\x04data\x18\x05 \x01(\fR\x04data\"\x89\x01\n" +
+	"\bPacketIn\x12\x1b\n" +
+	"\tbuffer_id\x18\x01 \x01(\rR\bbufferId\x12\x1b\n" +
+	"\ttotal_len\x18\x02 \x01(\rR\btotalLen\x12\x17\n" +
+	"\ain_port\x18\x03 \x01(\rR\x06inPort\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\rR\x06reason\x12\x12\n" +
+	"\x04data\x18\x05 \x01(\fR\x04data\"\xe0\x01\n" +
+	"\x04Port\x12\x17\n" +
+	"\aport_no\x18\x01 \x01(\rR\x06portNo\x12\x17\n" +
+	"\ahw_addr\x18\x02 \x01(\fR\x06hwAddr\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x16\n" +
+	"\x06config\x18\x04 \x01(\rR\x06config\x12\x14\n" +
+	"\x05state\x18\x05 \x01(\rR\x05state\x12\x12\n" +
+	"\x04curr\x18\x06 \x01(\rR\x04curr\x12\x1e\n" +
+	"\n" +
+	"advertised\x18\a \x01(\rR\n" +
+	"advertised\x12\x1c\n" +
+	"\tsupported\x18\b \x01(\rR\tsupported\x12\x12\n" +
+	"\x04peer\x18\t \x01(\rR\x04peer\"Q\n" +
+	"\n" +
+	"PortStatus\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\rR\x06reason\x12+\n" +
+	"\x04port\x18\x02 \x01(\v2\x17.goflow.northbound.PortR\x04port\"\xb6\x02\n" +
+	"\vFlowRemoved\x12.\n" +
+	"\x05match\x18\x01 \x01(\v2\x18.goflow.northbound.MatchR\x05match\x12\x16\n" +
+	"\x06cookie\x18\x02 \x01(\x04R\x06cookie\x12\x1a\n" +
+	"\bpriority\x18\x03 \x01(\rR\bpriority\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\rR\x06reason\x12!\n" +
+	"\fduration_sec\x18\x05 \x01(\rR\vdurationSec\x12#\n" +
+	"\rduration_nsec\x18\x06 \x01(\rR\fdurationNsec\x12!\n" +
+	"\fidle_timeout\x18\a \x01(\rR\vidleTimeout\x12!\n" +
+	"\fpacket_count\x18\b \x01(\x04R\vpacketCount\x12\x1d\n" +
+	"\n" +
+	"byte_count\x18\t \x01(\x04R\tbyteCount\"C\n" +
+	"\x05Error\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\rR\x04type\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\rR\x04code\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"\xdd\x01\n" +
+	"\bDatapath\x12\x12\n" +
+	"\x04dpid\x18\x01 \x01(\x04R\x04dpid\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x1b\n" +
+	"\tn_buffers\x18\x03 \x01(\rR\bnBuffers\x12\x19\n" +
+	"\bn_tables\x18\x04 \x01(\rR\anTables\x12\"\n" +
+	"\fcapabilities\x18\x05 \x01(\rR\fcapabilities\x12\x18\n" +
+	"\aactions\x18\x06 \x01(\rR\aactions\x12-\n" +
+	"\x05ports\x18\a \x03(\v2\x17.goflow.northbound.PortR\x05ports\"\x16\n" +
+	"\x14ListDatapathsRequest\"O\n" +
+	"\x12ListDatapathsReply\x129\n" +
+	"\tdatapaths\x18\x01 \x03(\v2\x1b.goflow.northbound.DatapathR\tdatapaths\"\xb5\x02\n" +
+	"\fStatsRequest\x12\x12\n" +
+	"\x04dpid\x18\x01 \x01(\x04R\x04dpid\x120\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x1c.goflow.northbound.StatsTypeR\x04type\x12.\n" +
+	"\x05match\x18\x03 \x01(\v2\x18.goflow.northbound.MatchR\x05match\x12\x1e\n" +
+	"\btable_id\x18\x04 \x01(\rH\x00R\atableId\x88\x01\x01\x12\x1e\n" +
+	"\bout_port\x18\x05 \x01(\rH\x01R\aoutPort\x88\x01\x01\x12\x1c\n" +
+	"\aport_no\x18\x06 \x01(\rH\x02R\x06portNo\x88\x01\x01\x12\x1e\n" +
+	"\bqueue_id\x18\a \x01(\rH\x03R\aqueueId\x88\x01\x01B\v\n" +
+	"\t_table_idB\v\n" +
+	"\t_out_portB\n" +
+	"\n" +
+	"\b_port_noB\v\n" +
+	"\t_queue_id\"\x8f\x03\n" +
+	"\tFlowStats\x12\x19\n" +
+	"\btable_id\x18\x01 \x01(\rR\atableId\x12.\n" +
+	"\x05match\x18\x02 \x01(\v2\x18.goflow.northbound.MatchR\x05match\x12!\n" +
+	"\fduration_sec\x18\x03 \x01(\rR\vdurationSec\x12#\n" +
+	"\rduration_nsec\x18\x04 \x01(\rR\fdurationNsec\x12\x1a\n" +
+	"\bpriority\x18\x05 \x01(\rR\bpriority\x12!\n" +
+	"\fidle_timeout\x18\x06 \x01(\rR\vidleTimeout\x12!\n" +
+	"\fhard_timeout\x18\a \x01(\rR\vhardTimeout\x12\x16\n" +
+	"\x06cookie\x18\b \x01(\x04R\x06cookie\x12!\n" +
+	"\fpacket_count\x18\t \x01(\x04R\vpacketCount\x12\x1d\n" +
+	"\n" +
+	"byte_count\x18\n" +
+	" \x01(\x04R\tbyteCount\x123\n" +
+	"\aactions\x18\v \x03(\v2\x19.goflow.northbound.ActionR\aactions\"q\n" +
+	"\x0eAggregateStats\x12!\n" +
+	"\fpacket_count\x18\x01 \x01(\x04R\vpacketCount\x12\x1d\n" +
+	"\n" +
+	"byte_count\x18\x02 \x01(\x04R\tbyteCount\x12\x1d\n" +
+	"\n" +
+	"flow_count\x18\x03 \x01(\rR\tflowCount\"\xe5\x01\n" +
+	"\n" +
+	"TableStats\x12\x19\n" +
+	"\btable_id\x18\x01 \x01(\rR\atableId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1c\n" +
+	"\twildcards\x18\x03 \x01(\rR\twildcards\x12\x1f\n" +
+	"\vmax_entries\x18\x04 \x01(\rR\n" +
+	"maxEntries\x12!\n" +
+	"\factive_count\x18\x05 \x01(\rR\vactiveCount\x12!\n" +
+	"\flookup_count\x18\x06 \x01(\x04R\vlookupCount\x12#\n" +
+	"\rmatched_count\x18\a \x01(\x04R\fmatchedCount\"\x90\x03\n" +
+	"\tPortStats\x12\x17\n" +
+	"\aport_no\x18\x01 \x01(\rR\x06portNo\x12\x1d\n" +
+	"\n" +
+	"rx_packets\x18\x02 \x01(\x04R\trxPackets\x12\x1d\n" +
+	"\n" +
+	"tx_packets\x18\x03 \x01(\x04R\ttxPackets\x12\x19\n" +
+	"\brx_bytes\x18\x04 \x01(\x04R\arxBytes\x12\x19\n" +
+	"\btx_bytes\x18\x05 \x01(\x04R\atxBytes\x12\x1d\n" +
+	"\n" +
+	"rx_dropped\x18\x06 \x01(\x04R\trxDropped\x12\x1d\n" +
+	"\n" +
+	"tx_dropped\x18\a \x01(\x04R\ttxDropped\x12\x1b\n" +
+	"\trx_errors\x18\b \x01(\x04R\brxErrors\x12\x1b\n" +
+	"\ttx_errors\x18\t \x01(\x04R\btxErrors\x12 \n" +
+	"\frx_frame_err\x18\n" +
+	" \x01(\x04R\n" +
+	"rxFrameErr\x12\x1e\n" +
+	"\vrx_over_err\x18\v \x01(\x04R\trxOverErr\x12\x1c\n" +
+	"\n" +
+	"rx_crc_err\x18\f \x01(\x04R\brxCrcErr\x12\x1e\n" +
+	"\n" +
+	"collisions\x18\r \x01(\x04R\n" +
+	"collisions\"\x97\x01\n" +
+	"\n" +
+	"QueueStats\x12\x17\n" +
+	"\aport_no\x18\x01 \x01(\rR\x06portNo\x12\x19\n" +
+	"\bqueue_id\x18\x02 \x01(\rR\aqueueId\x12\x19\n" +
+	"\btx_bytes\x18\x03 \x01(\x04R\atxBytes\x12\x1d\n" +
+	"\n" +
+	"tx_packets\x18\x04 \x01(\x04R\ttxPackets\x12\x1b\n" +
+	"\ttx_errors\x18\x05 \x01(\x04R\btxErrors\"\xa3\x02\n" +
+	"\n" +
+	"StatsReply\x122\n" +
+	"\x05flows\x18\x01 \x03(\v2\x1c.goflow.northbound.FlowStatsR\x05flows\x12?\n" +
+	"\taggregate\x18\x02 \x01(\v2!.goflow.northbound.AggregateStatsR\taggregate\x125\n" +
+	"\x06tables\x18\x03 \x03(\v2\x1d.goflow.northbound.TableStatsR\x06tables\x122\n" +
+	"\x05ports\x18\x04 \x03(\v2\x1c.goflow.northbound.PortStatsR\x05ports\x125\n" +
+	"\x06queues\x18\x05 \x03(\v2\x1d.goflow.northbound.QueueStatsR\x06queues\"U\n" +
+	"\tSubscribe\x12\x14\n" +
+	"\x05dpids\x18\x01 \x03(\x04R\x05dpids\x122\n" +
+	"\x05kinds\x18\x02 \x03(\x0e2\x1c.goflow.northbound.EventKindR\x05kinds\"\xf2\x01\n" +
+	"\rClientMessage\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12<\n" +
+	"\tsubscribe\x18\x02 \x01(\v2\x1c.goflow.northbound.SubscribeH\x00R\tsubscribe\x127\n" +
+	"\bflow_mod\x18\x03 \x01(\v2\x1a.goflow.northbound.FlowModH\x00R\aflowMod\x12=\n" +
+	"\n" +
+	"packet_out\x18\x04 \x01(\v2\x1c.goflow.northbound.PacketOutH\x00R\tpacketOut\x12\x10\n" +
+	"\x03ack\x18\x05 \x01(\bR\x03ackB\t\n" +
+	"\amessage\"\x97\x03\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04dpid\x18\x01 \x01(\x04R\x04dpid\x120\n" +
+	"\x04kind\x18\x02 \x01(\x0e2\x1c.goflow.northbound.EventKindR\x04kind\x12H\n" +
+	"\x10switch_connected\x18\x03 \x01(\v2\x1b.goflow.northbound.DatapathH\x00R\x0fswitchConnected\x12:\n" +
+	"\tpacket_in\x18\x04 \x01(\v2\x1b.goflow.northbound.PacketInH\x00R\bpacketIn\x12@\n" +
+	"\vport_status\x18\x05 \x01(\v2\x1d.goflow.northbound.PortStatusH\x00R\n" +
+	"portStatus\x12C\n" +
+	"\fflow_removed\x18\x06 \x01(\v2\x1e.goflow.northbound.FlowRemovedH\x00R\vflowRemoved\x120\n" +
+	"\x05error\x18\a \x01(\v2\x18.goflow.northbound.ErrorH\x00R\x05errorB\t\n" +
+	"\amessage\"+\n" +
+	"\x03Ack\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\x94\x01\n" +
+	"\rServerMessage\x120\n" +
+	"\x05event\x18\x01 \x01(\v2\x18.goflow.northbound.EventH\x00R\x05event\x12*\n" +
+	"\x03ack\x18\x02 \x01(\v2\x16.goflow.northbound.AckH\x00R\x03ack\x12\x1a\n" +
+	"\adropped\x18\x03 \x01(\x04H\x00R\adroppedB\t\n" +
+	"\amessage*T\n" +
+	"\vFlowCommand\x12\a\n" +
+	"\x03ADD\x10\x00\x12\n" +
+	"\n" +
+	"\x06MODIFY\x10\x01\x12\x11\n" +
+	"\rMODIFY_STRICT\x10\x02\x12\n" +
+	"\n" +
+	"\x06DELETE\x10\x03\x12\x11\n" +
+	"\rDELETE_STRICT\x10\x04*D\n" +
+	"\tStatsType\x12\b\n" +
+	"\x04FLOW\x10\x00\x12\r\n" +
+	"\tAGGREGATE\x10\x01\x12\t\n" +
+	"\x05TABLE\x10\x02\x12\b\n" +
+	"\x04PORT\x10\x03\x12\t\n" +
+	"\x05QUEUE\x10\x04*w\n" +
+	"\tEventKind\x12\x14\n" +
+	"\x10SWITCH_CONNECTED\x10\x00\x12\x17\n" +
+	"\x13SWITCH_DISCONNECTED\x10\x01\x12\r\n" +
+	"\tPACKET_IN\x10\x02\x12\x0f\n" +
+	"\vPORT_STATUS\x10\x03\x12\x10\n" +
+	"\fFLOW_REMOVED\x10\x04\x12\t\n" +
+	"\x05ERROR\x10\x052\x8c\x02\n" +
+	"\n" +
+	"Northbound\x12_\n" +
+	"\rListDatapaths\x12'.goflow.northbound.ListDatapathsRequest\x1a%.goflow.northbound.ListDatapathsReply\x12J\n" +
+	"\bGetStats\x12\x1f.goflow.northbound.StatsRequest\x1a\x1d.goflow.northbound.StatsReply\x12Q\n" +
+	"\aConnect\x12 .goflow.northbound.ClientMessage\x1a .goflow.northbound.ServerMessage(\x010\x01B)Z'github.com/ksang/goflow/apps/grpcapi/pbb\x06proto3"
+
+var (
+	file_pb_northbound_proto_rawDescOnce sync.Once
+	file_pb_northbound_proto_rawDescData []byte
+)
+
+func file_pb_northbound_proto_rawDescGZIP() []byte {
+	file_pb_northbound_proto_rawDescOnce.Do(func() {
+		file_pb_northbound_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pb_northbound_proto_rawDesc), len(file_pb_northbound_proto_rawDesc)))
+	})
+	return file_pb_northbound_proto_rawDescData
+}
+
+var file_pb_northbound_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_pb_northbound_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
+var file_pb_northbound_proto_goTypes = []any{
+	(FlowCommand)(0),             // 0: goflow.northbound.FlowCommand
+	(StatsType)(0),               // 1: goflow.northbound.StatsType
+	(EventKind)(0),               // 2: goflow.northbound.EventKind
+	(*Match)(nil),                // 3: goflow.northbound.Match
+	(*Action)(nil),               // 4: goflow.northbound.Action
+	(*OutputAction)(nil),         // 5: goflow.northbound.OutputAction
+	(*EnqueueAction)(nil),        // 6: goflow.northbound.EnqueueAction
+	(*FlowMod)(nil),              // 7: goflow.northbound.FlowMod
+	(*PacketOut)(nil),            // 8: goflow.northbound.PacketOut
+	(*PacketIn)(nil),             // 9: goflow.northbound.PacketIn
+	(*Port)(nil),                 // 10: goflow.northbound.Port
+	(*PortStatus)(nil),           // 11: goflow.northbound.PortStatus
+	(*FlowRemoved)(nil),          // 12: goflow.northbound.FlowRemoved
+	(*Error)(nil),                // 13: goflow.northbound.Error
+	(*Datapath)(nil),             // 14: goflow.northbound.Datapath
+	(*ListDatapathsRequest)(nil), // 15: goflow.northbound.ListDatapathsRequest
+	(*ListDatapathsReply)(nil),   // 16: goflow.northbound.ListDatapathsReply
+	(*StatsRequest)(nil),         // 17: goflow.northbound.StatsRequest
+	(*FlowStats)(nil),            // 18: goflow.northbound.FlowStats
+	(*AggregateStats)(nil),       // 19: goflow.northbound.AggregateStats
+	(*TableStats)(nil),           // 20: goflow.northbound.TableStats
+	(*PortStats)(nil),            // 21: goflow.northbound.PortStats
+	(*QueueStats)(nil),           // 22: goflow.northbound.QueueStats
+	(*StatsReply)(nil),           // 23: goflow.northbound.StatsReply
+	(*Subscribe)(nil),            // 24: goflow.northbound.Subscribe
+	(*ClientMessage)(nil),        // 25: goflow.northbound.ClientMessage
+	(*Event)(nil),                // 26: goflow.northbound.Event
+	(*Ack)(nil),                  // 27: goflow.northbound.Ack
+	(*ServerMessage)(nil),        // 28: goflow.northbound.ServerMessage
+}
+var file_pb_northbound_proto_depIdxs = []int32{
+	5,  // 0: goflow.northbound.Action.output:type_name -> goflow.northbound.OutputAction
+	6,  // 1: goflow.northbound.Action.enqueue:type_name -> goflow.northbound.EnqueueAction
+	3,  // 2: goflow.northbound.FlowMod.match:type_name -> goflow.northbound.Match
+	0,  // 3: goflow.northbound.FlowMod.command:type_name -> goflow.northbound.FlowCommand
+	4,  // 4: goflow.northbound.FlowMod.actions:type_name -> goflow.northbound.Action
+	4,  // 5: goflow.northbound.PacketOut.actions:type_name -> goflow.northbound.Action
+	10, // 6: goflow.northbound.PortStatus.port:type_name -> goflow.northbound.Port
+	3,  // 7: goflow.northbound.FlowRemoved.match:type_name -> goflow.northbound.Match
+	10, // 8: goflow.northbound.Datapath.ports:type_name -> goflow.northbound.Port
+	14, // 9: goflow.northbound.ListDatapathsReply.datapaths:type_name -> goflow.northbound.Datapath
+	1,  // 10: goflow.northbound.StatsRequest.type:type_name -> goflow.northbound.StatsType
+	3,  // 11: goflow.northbound.StatsRequest.match:type_name -> goflow.northbound.Match
+	3,  // 12: goflow.northbound.FlowStats.match:type_name -> goflow.northbound.Match
+	4,  // 13: goflow.northbound.FlowStats.actions:type_name -> goflow.northbound.Action
+	18, // 14: goflow.northbound.StatsReply.flows:type_name -> goflow.northbound.FlowStats
+	19, // 15: goflow.northbound.StatsReply.aggregate:type_name -> goflow.northbound.AggregateStats
+	20, // 16: goflow.northbound.StatsReply.tables:type_name -> goflow.northbound.TableStats
+	21, // 17: goflow.northbound.StatsReply.ports:type_name -> goflow.northbound.PortStats
+	22, // 18: goflow.northbound.StatsReply.queues:type_name -> goflow.northbound.QueueStats
+	2,  // 19: goflow.northbound.Subscribe.kinds:type_name -> goflow.northbound.EventKind
+	24, // 20: goflow.northbound.ClientMessage.subscribe:type_name -> goflow.northbound.Subscribe
+	7,  // 21: goflow.northbound.ClientMessage.flow_mod:type_name -> goflow.northbound.FlowMod
+	8,  // 22: goflow.northbound.ClientMessage.packet_out:type_name -> goflow.northbound.PacketOut
+	2,  // 23: goflow.northbound.Event.kind:type_name -> goflow.northbound.EventKind
+	14, // 24: goflow.northbound.Event.switch_connected:type_name -> goflow.northbound.Datapath
+	9,  // 25: goflow.northbound.Event.packet_in:type_name -> goflow.northbound.PacketIn
+	11, // 26: goflow.northbound.Event.port_status:type_name -> goflow.northbound.PortStatus
+	12, // 27: goflow.northbound.Event.flow_removed:type_name -> goflow.northbound.FlowRemoved
+	13, // 28: goflow.northbound.Event.error:type_name -> goflow.northbound.Error
+	26, // 29: goflow.northbound.ServerMessage.event:type_name -> goflow.northbound.Event
+	27, // 30: goflow.northbound.ServerMessage.ack:type_name -> goflow.northbound.Ack
+	15, // 31: goflow.northbound.Northbound.ListDatapaths:input_type -> goflow.northbound.ListDatapathsRequest
+	17, // 32: goflow.northbound.Northbound.GetStats:input_type -> goflow.northbound.StatsRequest
+	25, // 33: goflow.northbound.Northbound.Connect:input_type -> goflow.northbound.ClientMessage
+	16, // 34: goflow.northbound.Northbound.ListDatapaths:output_type -> goflow.northbound.ListDatapathsReply
+	23, // 35: goflow.northbound.Northbound.GetStats:output_type -> goflow.northbound.StatsReply
+	28, // 36: goflow.northbound.Northbound.Connect:output_type -> goflow.northbound.ServerMessage
+	34, // [34:37] is the sub-list for method output_type
+	31, // [31:34] is the sub-list for method input_type
+	31, // [31:31] is the sub-list for extension type_name
+	31, // [31:31] is the sub-list for extension extendee
+	0,  // [0:31] is the sub-list for field type_name
+}
+
+func init() { file_pb_northbound_proto_init() }
+func file_pb_northbound_proto_init() {
+	if File_pb_northbound_proto != nil {
+		return
+	}
+	file_pb_northbound_proto_msgTypes[0].OneofWrappers = []any{}
+	file_pb_northbound_proto_msgTypes[1].OneofWrappers = []any{
+		(*Action_Output)(nil),
+		(*Action_SetVlanVid)(nil),
+		(*Action_SetVlanPcp)(nil),
+		(*Action_StripVlan)(nil),
+		(*Action_SetDlSrc)(nil),
+		(*Action_SetDlDst)(nil),
+		(*Action_SetNwSrc)(nil),
+		(*Action_SetNwDst)(nil),
+		(*Action_SetNwTos)(nil),
+		(*Action_SetTpSrc)(nil),
+		(*Action_SetTpDst)(nil),
+		(*Action_Enqueue)(nil),
+	}
+	file_pb_northbound_proto_msgTypes[14].OneofWrappers = []any{}
+	file_pb_northbound_proto_msgTypes[22].OneofWrappers = []any{
+		(*ClientMessage_Subscribe)(nil),
+		(*ClientMessage_FlowMod)(nil),
+		(*ClientMessage_PacketOut)(nil),
+	}
+	file_pb_northbound_proto_msgTypes[23].OneofWrappers = []any{
+		(*Event_SwitchConnected)(nil),
+		(*Event_PacketIn)(nil),
+		(*Event_PortStatus)(nil),
+		(*Event_FlowRemoved)(nil),
+		(*Event_Error)(nil),
+	}
+	file_pb_northbound_proto_msgTypes[25].OneofWrappers = []any{
+		(*ServerMessage_Event)(nil),
+		(*ServerMessage_Ack)(nil),
+		(*ServerMessage_Dropped)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_northbound_proto_rawDesc), len(file_pb_northbound_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   26,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pb_northbound_proto_goTypes,
+		DependencyIndexes: file_pb_northbound_proto_depIdxs,
+		EnumInfos:         file_pb_northbound_proto_enumTypes,
+		MessageInfos:      file_pb_northbound_proto_msgTypes,
+	}.Build()
+	File_pb_northbound_proto = out.File
+	file_pb_northbound_proto_goTypes = nil
+	file_pb_northbound_proto_depIdxs = nil
+}
@@ -0,0 +1,211 @@
+//go:build grpc
+
+// Northbound API of the goflow controller.
+//
+// Messages mirror the OpenFlow 1.0 messages of the openflow/v10 package,
+// unset optional match fields are wildcarded.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: pb/northbound.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Northbound_ListDatapaths_FullMethodName = "/goflow.northbound.Northbound/ListDatapaths"
+	Northbound_GetStats_FullMethodName      = "/goflow.northbound.Northbound/GetStats"
+	Northbound_Connect_FullMethodName       = "/goflow.northbound.Northbound/Connect"
+)
+
+// NorthboundClient is the client API for Northbound service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NorthboundClient interface {
+	// ListDatapaths returns the connected datapaths
+	ListDatapaths(ctx context.Context, in *ListDatapathsRequest, opts ...grpc.CallOption) (*ListDatapathsReply, error)
+	// GetStats sends a stats request and merges its replies
+	GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsReply, error)
+	// Connect streams the subscribed events to the client, and sends the
+	// flow mods and packet outs of the client to the switches in order.
+	// Subscriptions and failed messages are acknowledged, other messages
+	// only when they ask for it, once the switch processed them.
+	Connect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ClientMessage, ServerMessage], error)
+}
+
+type northboundClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNorthboundClient(cc grpc.ClientConnInterface) NorthboundClient {
+	return &northboundClient{cc}
+}
+
+func (c *northboundClient) ListDatapaths(ctx context.Context, in *ListDatapathsRequest, opts ...grpc.CallOption) (*ListDatapathsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDatapathsReply)
+	err := c.cc.Invoke(ctx, Northbound_ListDatapaths_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *northboundClient) GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsReply)
+	err := c.cc.Invoke(ctx, Northbound_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *northboundClient) Connect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ClientMessage, ServerMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Northbound_ServiceDesc.Streams[0], Northbound_Connect_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ClientMessage, ServerMessage]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Northbound_ConnectClient = grpc.BidiStreamingClient[ClientMessage, ServerMessage]
+
+// NorthboundServer is the server API for Northbound service.
+// All implementations must embed UnimplementedNorthboundServer
+// for forward compatibility.
+type NorthboundServer interface {
+	// ListDatapaths returns the connected datapaths
+	ListDatapaths(context.Context, *ListDatapathsRequest) (*ListDatapathsReply, error)
+	// GetStats sends a stats request and merges its replies
+	GetStats(context.Context, *StatsRequest) (*StatsReply, error)
+	// Connect streams the subscribed events to the client, and sends the
+	// flow mods and packet outs of the client to the switches in order.
+	// Subscriptions and failed messages are acknowledged, other messages
+	// only when they ask for it, once the switch processed them.
+	Connect(grpc.BidiStreamingServer[ClientMessage, ServerMessage]) error
+	mustEmbedUnimplementedNorthboundServer()
+}
+
+// UnimplementedNorthboundServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedNorthboundServer struct{}
+
+func (UnimplementedNorthboundServer) ListDatapaths(context.Context, *ListDatapathsRequest) (*ListDatapathsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDatapaths not implemented")
+}
+func (UnimplementedNorthboundServer) GetStats(context.Context, *StatsRequest) (*StatsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedNorthboundServer) Connect(grpc.BidiStreamingServer[ClientMessage, ServerMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedNorthboundServer) mustEmbedUnimplementedNorthboundServer() {}
+func (UnimplementedNorthboundServer) testEmbeddedByValue()                    {}
+
+// UnsafeNorthboundServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NorthboundServer will
+// result in compilation errors.
+type UnsafeNorthboundServer interface {
+	mustEmbedUnimplementedNorthboundServer()
+}
+
+func RegisterNorthboundServer(s grpc.ServiceRegistrar, srv NorthboundServer) {
+	// If the following call pancis, it indicates UnimplementedNorthboundServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Northbound_ServiceDesc, srv)
+}
+
+func _Northbound_ListDatapaths_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDatapathsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NorthboundServer).ListDatapaths(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Northbound_ListDatapaths_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NorthboundServer).ListDatapaths(ctx, req.(*ListDatapathsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Northbound_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NorthboundServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Northbound_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NorthboundServer).GetStats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Northbound_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NorthboundServer).Connect(&grpc.GenericServerStream[ClientMessage, ServerMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Northbound_ConnectServer = grpc.BidiStreamingServer[ClientMessage, ServerMessage]
+
+// Northbound_ServiceDesc is the grpc.ServiceDesc for Northbound service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Northbound_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "goflow.northbound.Northbound",
+	HandlerType: (*NorthboundServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListDatapaths",
+			Handler:    _Northbound_ListDatapaths_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _Northbound_GetStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Connect",
+			Handler:       _Northbound_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "pb/northbound.proto",
+}
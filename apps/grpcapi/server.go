@@ -0,0 +1,295 @@
+//go:build grpc
+
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"github.com/ksang/goflow/apps/grpcapi/pb"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+var ErrUnknownDatapath = errors.New("unknown datapath")
+
+// Server implements the Northbound service of pb/northbound.proto
+type Server struct {
+	pb.UnimplementedNorthboundServer
+
+	// DropInterval is how often the number of dropped events is reported
+	// to a stream
+	DropInterval time.Duration
+
+	controller *controller.Controller
+	hub        *Hub
+}
+
+// NewServer returns the service of c, its event hub is registered on c
+func NewServer(c *controller.Controller) *Server {
+	s := &Server{
+		DropInterval: time.Second,
+		controller:   c,
+		hub:          NewHub(),
+	}
+	c.Register(s.hub)
+	return s
+}
+
+// Register registers the service on a gRPC server
+func (s *Server) Register(g *grpc.Server) {
+	pb.RegisterNorthboundServer(g, s)
+}
+
+// Serve serves the service on l until it fails
+func (s *Server) Serve(l net.Listener) error {
+	g := grpc.NewServer()
+	s.Register(g)
+	return g.Serve(l)
+}
+
+// grpcError converts controller errors to gRPC errors
+func grpcError(err error) error {
+	if _, ok := err.(*controller.RequestError); ok {
+		return status.Error(codes.Aborted, err.Error())
+	}
+	switch err {
+	case controller.ErrTimeout:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case controller.ErrClosed:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func (s *Server) datapath(dpid uint64) (*controller.Datapath, error) {
+	dp, ok := s.controller.Datapath(dpid)
+	if !ok {
+		return nil, status.Error(codes.NotFound, ErrUnknownDatapath.Error())
+	}
+	return dp, nil
+}
+
+// ListDatapaths returns the connected datapaths
+func (s *Server) ListDatapaths(ctx context.Context, req *pb.ListDatapathsRequest) (*pb.ListDatapathsReply, error) {
+	reply := &pb.ListDatapathsReply{}
+	for _, dp := range s.controller.Datapaths() {
+		reply.Datapaths = append(reply.Datapaths, datapathToPB(dp))
+	}
+	return reply, nil
+}
+
+// GetStats sends a stats request and merges its replies
+func (s *Server) GetStats(ctx context.Context, req *pb.StatsRequest) (*pb.StatsReply, error) {
+	dp, err := s.datapath(req.Dpid)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := statsRequestFromPB(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	replies, err := dp.Request(msg)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	reply, err := statsReplyToPB(replies)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return reply, nil
+}
+
+// stream serializes the sends of a Connect stream
+type stream struct {
+	mu sync.Mutex
+	pb.Northbound_ConnectServer
+}
+
+func (st *stream) send(msg *pb.ServerMessage) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.Send(msg)
+}
+
+// workerQueue bounds the client messages of a datapath waiting for its
+// worker, the stream is not read while it is full
+const workerQueue = 64
+
+// request is a client message for a datapath
+type request struct {
+	id  uint64
+	ack bool
+	msg openflow.MessageDecoder
+}
+
+// Connect streams subscribed events and handles client messages. No
+// event is streamed before the first Subscribe message. The messages of
+// a datapath are sent in order by a worker of its own, so that waiting
+// for a switch does not delay the messages for other switches.
+func (s *Server) Connect(srv pb.Northbound_ConnectServer) error {
+	st := &stream{Northbound_ConnectServer: srv}
+	var sub *Subscription
+	subc := make(chan *Subscription, 1)
+	done := make(chan struct{})
+	defer close(done)
+	// acknowledgements are not sent once the stream returned
+	var pending sync.WaitGroup
+	defer pending.Wait()
+	workers := make(map[*controller.Datapath]chan request)
+	defer func() {
+		for _, w := range workers {
+			close(w)
+		}
+	}()
+	defer func() {
+		if sub != nil {
+			s.hub.Cancel(sub)
+		}
+	}()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- s.forward(st, subc, done)
+	}()
+
+	for {
+		msg, err := srv.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case err := <-errc:
+			return err
+		default:
+		}
+		if v := msg.GetSubscribe(); v != nil {
+			f := Filter{DPIDs: v.Dpids}
+			for _, k := range v.Kinds {
+				f.Kinds = append(f.Kinds, EventKind(k))
+			}
+			if sub == nil {
+				sub = s.hub.Subscribe(f)
+				subc <- sub
+			} else {
+				sub.SetFilter(f)
+			}
+			if err := st.send(ack(msg.Id, nil)); err != nil {
+				return err
+			}
+			continue
+		}
+		dp, m, err := s.message(msg)
+		if err != nil {
+			if err := st.send(ack(msg.Id, err)); err != nil {
+				return err
+			}
+			continue
+		}
+		w, ok := workers[dp]
+		if !ok {
+			w = make(chan request, workerQueue)
+			workers[dp] = w
+			pending.Add(1)
+			go func() {
+				defer pending.Done()
+				s.work(st, dp, w)
+			}()
+		}
+		w <- request{id: msg.Id, ack: msg.Ack, msg: m}
+	}
+}
+
+// work sends the requests for a datapath in order, requests are
+// acknowledged when they fail or ask for it
+func (s *Server) work(st *stream, dp *controller.Datapath, requests <-chan request) {
+	for r := range requests {
+		r.msg.SetTransactionID(dp.NextXID())
+		var err error
+		if r.ack {
+			err = dp.Check(r.msg)
+		} else {
+			err = dp.Send(r.msg)
+		}
+		if err != nil || r.ack {
+			st.send(ack(r.id, err))
+		}
+	}
+}
+
+// forward streams events and the number of dropped events until done
+func (s *Server) forward(st *stream, subc <-chan *Subscription, done <-chan struct{}) error {
+	var sub *Subscription
+	select {
+	case sub = <-subc:
+	case <-done:
+		return nil
+	}
+	ticker := time.NewTicker(s.DropInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case ev, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+			msg := &pb.ServerMessage{Message: &pb.ServerMessage_Event{Event: eventToPB(ev)}}
+			if err := st.send(msg); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if n := sub.Dropped(); n > 0 {
+				msg := &pb.ServerMessage{Message: &pb.ServerMessage_Dropped{Dropped: n}}
+				if err := st.send(msg); err != nil {
+					return err
+				}
+			}
+		case <-done:
+			return nil
+		}
+	}
+}
+
+func ack(id uint64, err error) *pb.ServerMessage {
+	a := &pb.Ack{Id: id}
+	if err != nil {
+		a.Error = err.Error()
+	}
+	return &pb.ServerMessage{Message: &pb.ServerMessage_Ack{Ack: a}}
+}
+
+// message converts a flow mod or packet out and returns its datapath
+func (s *Server) message(msg *pb.ClientMessage) (*controller.Datapath, openflow.MessageDecoder, error) {
+	var (
+		dpid uint64
+		m    openflow.MessageDecoder
+		err  error
+	)
+	switch v := msg.Message.(type) {
+	case *pb.ClientMessage_FlowMod:
+		dpid = v.FlowMod.Dpid
+		m, err = flowModFromPB(v.FlowMod)
+	case *pb.ClientMessage_PacketOut:
+		dpid = v.PacketOut.Dpid
+		m, err = packetOutFromPB(v.PacketOut)
+	default:
+		err = openflow.ErrUnsupportedMessage
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	dp, ok := s.controller.Datapath(dpid)
+	if !ok {
+		return nil, nil, ErrUnknownDatapath
+	}
+	return dp, m, nil
+}
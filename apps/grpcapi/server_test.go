@@ -0,0 +1,147 @@
+//go:build grpc
+
+package grpcapi
+
+import (
+	"context"
+	"github.com/ksang/goflow/apps/grpcapi/pb"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/controller/controllertest"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+)
+
+func setup(t *testing.T) (pb.NorthboundClient, *controllertest.Switch, func()) {
+	c := controller.New()
+	srv := NewServer(c)
+	l := bufconn.Listen(1 << 20)
+	g := grpc.NewServer()
+	srv.Register(g)
+	go g.Serve(l)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := controllertest.Connect(c, 1, controllertest.Port(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pb.NewNorthboundClient(conn), s, func() {
+		s.Close()
+		conn.Close()
+		g.Stop()
+	}
+}
+
+func TestListDatapaths(t *testing.T) {
+	client, _, stop := setup(t)
+	defer stop()
+	reply, err := client.ListDatapaths(context.Background(), &pb.ListDatapathsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reply.Datapaths) != 1 || reply.Datapaths[0].Dpid != 1 || len(reply.Datapaths[0].Ports) != 1 {
+		t.Errorf("unexpected datapaths %v", reply)
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	client, s, stop := setup(t)
+	defer stop()
+	done := make(chan *pb.StatsReply)
+	go func() {
+		reply, err := client.GetStats(context.Background(), &pb.StatsRequest{Dpid: 1, Type: pb.StatsType_PORT})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- reply
+	}()
+	msg, err := s.Receive(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := v10.NewStatsReplyPort(msg.TransactionID())
+	p := v10.NewPortStats()
+	p.SetPortNumber(1)
+	p.SetRxBytes(42)
+	reply.AddPort(p)
+	s.Send(reply)
+	if r := <-done; r == nil || len(r.Ports) != 1 || r.Ports[0].RxBytes != 42 {
+		t.Errorf("unexpected stats %v", r)
+	}
+}
+
+func TestConnect(t *testing.T) {
+	client, s, stop := setup(t)
+	defer stop()
+	stream, err := client.Connect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := &pb.Subscribe{Kinds: []pb.EventKind{pb.EventKind_PACKET_IN}}
+	stream.Send(&pb.ClientMessage{Id: 1, Message: &pb.ClientMessage_Subscribe{Subscribe: sub}})
+	if msg, err := stream.Recv(); err != nil || msg.GetAck().GetId() != 1 {
+		t.Fatalf("unexpected subscribe ack %v %v", msg, err)
+	}
+
+	pi := v10.NewPacketIn(0)
+	pi.SetInPort(1)
+	pi.SetData([]byte{1, 2, 3})
+	s.Send(pi)
+	msg, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev := msg.GetEvent(); ev.GetKind() != pb.EventKind_PACKET_IN || ev.GetPacketIn().GetInPort() != 1 {
+		t.Errorf("unexpected event %v", msg)
+	}
+
+	nwSrc := "10.0.0.0/8"
+	fm := &pb.FlowMod{
+		Dpid:     1,
+		Match:    &pb.Match{NwSrc: &nwSrc},
+		Priority: 10,
+		Actions:  []*pb.Action{{Action: &pb.Action_Output{Output: &pb.OutputAction{Port: 2}}}},
+	}
+	stream.Send(&pb.ClientMessage{Id: 2, Message: &pb.ClientMessage_FlowMod{FlowMod: fm}, Ack: true})
+	sent, err := s.Receive(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, ok := sent.(openflow.FlowMod)
+	if !ok || f.Priority() != 10 || f.Match().NWSrc().String() != "10.0.0.0" || len(f.Actions()) != 1 {
+		t.Errorf("unexpected flow mod %v", sent)
+	}
+	if msg, err := stream.Recv(); err != nil || msg.GetAck().GetId() != 2 || msg.GetAck().GetError() != "" {
+		t.Errorf("unexpected flow mod ack %v %v", msg, err)
+	}
+
+	// messages of a datapath are sent in order, only those asking for it
+	// are acknowledged
+	stream.Send(&pb.ClientMessage{Id: 3, Message: &pb.ClientMessage_FlowMod{FlowMod: fm}})
+	stream.Send(&pb.ClientMessage{Id: 4, Message: &pb.ClientMessage_PacketOut{PacketOut: &pb.PacketOut{Dpid: 1}}, Ack: true})
+	for _, typ := range []uint8{v10.OFPT_FLOW_MOD, v10.OFPT_PACKET_OUT} {
+		if sent, err := s.Receive(time.Second); err != nil || sent.MsgType() != typ {
+			t.Errorf("received %v %v, expected type %d", sent, err, typ)
+		}
+	}
+	if msg, err := stream.Recv(); err != nil || msg.GetAck().GetId() != 4 || msg.GetAck().GetError() != "" {
+		t.Errorf("unexpected packet out ack %v %v", msg, err)
+	}
+
+	stream.Send(&pb.ClientMessage{Id: 5, Message: &pb.ClientMessage_PacketOut{PacketOut: &pb.PacketOut{Dpid: 2}}})
+	if msg, err := stream.Recv(); err != nil || msg.GetAck().GetError() != ErrUnknownDatapath.Error() {
+		t.Errorf("unexpected packet out ack %v %v", msg, err)
+	}
+	stream.CloseSend()
+}
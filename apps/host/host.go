@@ -0,0 +1,360 @@
+/*
+Package host tracks where hosts attach to the network.
+
+Hosts are learned from the ARP, DHCP and IP packets received by the
+controller on edge ports, ports connecting two switches are ignored.
+*/
+package host
+
+import (
+	"bytes"
+	"github.com/ksang/goflow/apps/topology"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/packet"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Host is a host seen by the controller
+type Host struct {
+	MAC net.HardwareAddr
+	IPs []net.IP
+	// VLAN is packet.NoVLAN for untagged hosts
+	VLAN     uint16
+	Location topology.Endpoint
+	LastSeen time.Time
+}
+
+func (h *Host) copy() Host {
+	c := *h
+	c.IPs = append([]net.IP(nil), h.IPs...)
+	return c
+}
+
+func (h *Host) hasIP(ip net.IP) bool {
+	for _, i := range h.IPs {
+		if i.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Host) removeIP(ip net.IP) {
+	for i, addr := range h.IPs {
+		if addr.Equal(ip) {
+			h.IPs = append(h.IPs[:i:i], h.IPs[i+1:]...)
+			return
+		}
+	}
+}
+
+type HostAddedHandler interface {
+	HostAdded(h Host)
+}
+
+// HostMovedHandler is notified when a host shows up on another port or VLAN
+type HostMovedHandler interface {
+	HostMoved(h Host, from topology.Endpoint)
+}
+
+// HostRemovedHandler is notified when a host times out or its port goes down
+type HostRemovedHandler interface {
+	HostRemoved(h Host)
+}
+
+// Topology tells which ports connect switches, topology.Discovery
+// implements it
+type Topology interface {
+	IsSwitchPort(ep topology.Endpoint) bool
+}
+
+type macKey [6]byte
+
+func keyOf(mac net.HardwareAddr) macKey {
+	var k macKey
+	copy(k[:], mac)
+	return k
+}
+
+type event struct {
+	kind int
+	host Host
+	from topology.Endpoint
+}
+
+const (
+	hostAdded = iota
+	hostMoved
+	hostRemoved
+)
+
+// Tracker is the host tracking application, register it on a controller,
+// and on the topology discovery to forget hosts learned on ports later
+// found to be links. Handlers are called from the datapath event
+// goroutines, possibly concurrently.
+type Tracker struct {
+	// Timeout after which a silent host is removed, hosts never age out
+	// when zero
+	Timeout time.Duration
+
+	topo     Topology
+	mu       sync.Mutex
+	hosts    map[macKey]*Host
+	stops    map[uint64]chan struct{}
+	handlers []interface{}
+}
+
+// New returns a tracker removing hosts silent for five minutes, topo may be
+// nil in single switch networks
+func New(topo Topology) *Tracker {
+	return &Tracker{
+		Timeout: 5 * time.Minute,
+		topo:    topo,
+		hosts:   make(map[macKey]*Host),
+		stops:   make(map[uint64]chan struct{}),
+	}
+}
+
+// Register adds a host event handler
+func (t *Tracker) Register(handler interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handlers = append(t.handlers, handler)
+}
+
+// Hosts returns the known hosts ordered by MAC address
+func (t *Tracker) Hosts() []Host {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	hosts := make([]Host, 0, len(t.hosts))
+	for _, h := range t.hosts {
+		hosts = append(hosts, h.copy())
+	}
+	sort.Slice(hosts, func(i, j int) bool { return bytes.Compare(hosts[i].MAC, hosts[j].MAC) < 0 })
+	return hosts
+}
+
+// Host returns a host by MAC address
+func (t *Tracker) Host(mac net.HardwareAddr) (Host, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if h, ok := t.hosts[keyOf(mac)]; ok {
+		return h.copy(), true
+	}
+	return Host{}, false
+}
+
+// HostByIP returns the host an IP address was last seen from
+func (t *Tracker) HostByIP(ip net.IP) (Host, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, h := range t.hosts {
+		if h.hasIP(ip) {
+			return h.copy(), true
+		}
+	}
+	return Host{}, false
+}
+
+func (t *Tracker) SwitchConnected(dp *controller.Datapath) {
+	stop := make(chan struct{})
+	t.mu.Lock()
+	t.stops[dp.ID()] = stop
+	t.mu.Unlock()
+	go t.ageLoop(dp.ID(), stop)
+}
+
+func (t *Tracker) SwitchDisconnected(dp *controller.Datapath) {
+	t.mu.Lock()
+	if stop, ok := t.stops[dp.ID()]; ok {
+		close(stop)
+		delete(t.stops, dp.ID())
+	}
+	t.mu.Unlock()
+	t.remove(func(h *Host) bool { return h.Location.DPID == dp.ID() })
+}
+
+func (t *Tracker) ageLoop(dpid uint64, stop chan struct{}) {
+	if t.Timeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(t.Timeout / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.expire(dpid, time.Now().Add(-t.Timeout))
+		case <-stop:
+			return
+		}
+	}
+}
+
+// expire removes the hosts of a datapath not seen since deadline
+func (t *Tracker) expire(dpid uint64, deadline time.Time) {
+	t.remove(func(h *Host) bool {
+		return h.Location.DPID == dpid && h.LastSeen.Before(deadline)
+	})
+}
+
+func (t *Tracker) PortStatus(dp *controller.Datapath, msg openflow.PortStatus) {
+	p := msg.Port()
+	if msg.Reason() != openflow.PortDeleted && p.State()&openflow.LinkDown == 0 && p.Config()&openflow.PortDown == 0 {
+		return
+	}
+	ep := topology.Endpoint{DPID: dp.ID(), Port: uint16(p.PortID())}
+	t.remove(func(h *Host) bool { return h.Location == ep })
+}
+
+// LinkAdded forgets the hosts learned on the ports of a new link
+func (t *Tracker) LinkAdded(l topology.Link) {
+	t.remove(func(h *Host) bool { return h.Location == l.Src || h.Location == l.Dst })
+}
+
+func (t *Tracker) remove(match func(*Host) bool) {
+	var events []event
+	t.mu.Lock()
+	for k, h := range t.hosts {
+		if match(h) {
+			events = append(events, event{kind: hostRemoved, host: h.copy()})
+			delete(t.hosts, k)
+		}
+	}
+	t.mu.Unlock()
+	sort.Slice(events, func(i, j int) bool { return bytes.Compare(events[i].host.MAC, events[j].host.MAC) < 0 })
+	t.notify(events)
+}
+
+func (t *Tracker) PacketIn(dp *controller.Datapath, msg openflow.PacketIn) {
+	ep := topology.Endpoint{DPID: dp.ID(), Port: msg.InPort()}
+	if ep.Port > uint16(openflow.Max) || t.topo != nil && t.topo.IsSwitchPort(ep) {
+		return
+	}
+	var eth packet.Ethernet
+	if err := eth.UnmarshalBinary(msg.Data()); err != nil {
+		return
+	}
+	if eth.EtherType == packet.EtherTypeLLDP || packet.IsMulticast(eth.Src) {
+		return
+	}
+	var ip, assigned net.IP
+	var client net.HardwareAddr
+	switch eth.EtherType {
+	case packet.EtherTypeARP:
+		var arp packet.ARP
+		if err := arp.UnmarshalBinary(eth.Payload); err != nil {
+			return
+		}
+		ip = arp.SenderIP
+	case packet.EtherTypeIPv4:
+		var ipv4 packet.IPv4
+		if err := ipv4.UnmarshalBinary(eth.Payload); err != nil {
+			return
+		}
+		ip = ipv4.Src
+		assigned, client = dhcpAssignment(&ipv4)
+	}
+	// unconfigured hosts send from the unspecified address
+	if ip != nil && ip.IsUnspecified() {
+		ip = nil
+	}
+
+	var events []event
+	t.mu.Lock()
+	events = t.learn(events, eth.Src, ip, eth.VLANID, ep)
+	if assigned != nil {
+		// the server tells the address of its client, which may be elsewhere
+		if h, ok := t.hosts[keyOf(client)]; ok {
+			t.claim(assigned, h)
+		}
+	}
+	t.mu.Unlock()
+	t.notify(events)
+}
+
+// learn updates a host, it must be called with the lock held
+func (t *Tracker) learn(events []event, mac net.HardwareAddr, ip net.IP, vlan uint16, ep topology.Endpoint) []event {
+	h, ok := t.hosts[keyOf(mac)]
+	if !ok {
+		h = &Host{
+			MAC:      append(net.HardwareAddr(nil), mac...),
+			VLAN:     vlan,
+			Location: ep,
+		}
+		t.hosts[keyOf(mac)] = h
+	}
+	h.LastSeen = time.Now()
+	if ip != nil {
+		t.claim(ip, h)
+	}
+	switch {
+	case !ok:
+		events = append(events, event{kind: hostAdded, host: h.copy()})
+	case h.Location != ep || h.VLAN != vlan:
+		from := h.Location
+		h.Location, h.VLAN = ep, vlan
+		events = append(events, event{kind: hostMoved, host: h.copy(), from: from})
+	}
+	return events
+}
+
+// claim gives an IP address to a host, taking it from any other host
+func (t *Tracker) claim(ip net.IP, h *Host) {
+	if h.hasIP(ip) {
+		return
+	}
+	for _, other := range t.hosts {
+		other.removeIP(ip)
+	}
+	h.IPs = append(h.IPs, append(net.IP(nil), ip.To4()...))
+}
+
+// dhcpAssignment returns the address acknowledged by a DHCP server
+func dhcpAssignment(ip *packet.IPv4) (net.IP, net.HardwareAddr) {
+	if ip.Protocol != packet.IPProtocolUDP || ip.IsFragment() {
+		return nil, nil
+	}
+	var udp packet.UDP
+	if err := udp.UnmarshalBinary(ip.Payload); err != nil || udp.SrcPort != packet.DHCPServerPort {
+		return nil, nil
+	}
+	var dhcp packet.DHCP
+	if err := dhcp.UnmarshalBinary(udp.Payload); err != nil {
+		return nil, nil
+	}
+	if dhcp.Op != packet.DHCPBootReply || dhcp.MessageType() != packet.DHCPAck || dhcp.YourIP.IsUnspecified() {
+		return nil, nil
+	}
+	return dhcp.YourIP, dhcp.ClientMAC
+}
+
+func (t *Tracker) notify(events []event) {
+	if len(events) == 0 {
+		return
+	}
+	t.mu.Lock()
+	handlers := append([]interface{}(nil), t.handlers...)
+	t.mu.Unlock()
+	for _, ev := range events {
+		for _, handler := range handlers {
+			switch ev.kind {
+			case hostAdded:
+				if h, ok := handler.(HostAddedHandler); ok {
+					h.HostAdded(ev.host)
+				}
+			case hostMoved:
+				if h, ok := handler.(HostMovedHandler); ok {
+					h.HostMoved(ev.host, ev.from)
+				}
+			case hostRemoved:
+				if h, ok := handler.(HostRemovedHandler); ok {
+					h.HostRemoved(ev.host)
+				}
+			}
+		}
+	}
+}
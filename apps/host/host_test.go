@@ -0,0 +1,163 @@
+package host
+
+import (
+	"github.com/ksang/goflow/apps/topology"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/controller/controllertest"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/packet"
+	"net"
+	"testing"
+	"time"
+)
+
+var (
+	hostMAC   = net.HardwareAddr{0x0a, 0, 0, 0, 0, 1}
+	serverMAC = net.HardwareAddr{0x0a, 0, 0, 0, 0, 0xfe}
+)
+
+// switchPorts is a topology where port 3 of every switch is a link
+type switchPorts struct{}
+
+func (switchPorts) IsSwitchPort(ep topology.Endpoint) bool {
+	return ep.Port == 3
+}
+
+type events chan string
+
+func (e events) HostAdded(h Host) {
+	e <- "added " + h.MAC.String() + " " + h.Location.String()
+}
+
+func (e events) HostMoved(h Host, from topology.Endpoint) {
+	e <- "moved " + h.MAC.String() + " " + from.String() + " " + h.Location.String()
+}
+
+func (e events) HostRemoved(h Host) {
+	e <- "removed " + h.MAC.String()
+}
+
+func expect(t *testing.T, ev events, expected string) {
+	select {
+	case e := <-ev:
+		if e != expected {
+			t.Errorf("expected %q, got %q", expected, e)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected %q", expected)
+	}
+}
+
+func sendFrame(t *testing.T, s *controllertest.Switch, inPort uint16, eth *packet.Ethernet) {
+	data, err := eth.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := v10.NewPacketIn(0)
+	msg.SetBufferID(v10.OFP_NO_BUFFER)
+	msg.SetInPort(inPort)
+	msg.SetData(data)
+	if err := s.Send(msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func arpFrame(t *testing.T, mac net.HardwareAddr, ip net.IP) *packet.Ethernet {
+	arp := &packet.ARP{
+		Operation: packet.ARPRequest,
+		SenderMAC: mac,
+		SenderIP:  ip,
+		TargetMAC: make(net.HardwareAddr, 6),
+		TargetIP:  net.IP{10, 0, 0, 254},
+	}
+	payload, err := arp.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return packet.NewEthernet(mac, packet.Broadcast, packet.EtherTypeARP, payload)
+}
+
+func dhcpAckFrame(t *testing.T, client net.HardwareAddr, assigned net.IP) *packet.Ethernet {
+	dhcp := &packet.DHCP{
+		Op:        packet.DHCPBootReply,
+		YourIP:    assigned,
+		ClientMAC: client,
+		Options:   []packet.DHCPOption{{Code: packet.DHCPOptionMessageType, Data: []byte{packet.DHCPAck}}},
+	}
+	payload, err := dhcp.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	udp := &packet.UDP{SrcPort: packet.DHCPServerPort, DstPort: packet.DHCPClientPort, Payload: payload}
+	if payload, err = udp.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+	ip := &packet.IPv4{TTL: 64, Protocol: packet.IPProtocolUDP, Src: net.IP{10, 0, 0, 254}, Dst: net.IPv4bcast, Payload: payload}
+	if payload, err = ip.MarshalBinary(); err != nil {
+		t.Fatal(err)
+	}
+	return packet.NewEthernet(serverMAC, packet.Broadcast, packet.EtherTypeIPv4, payload)
+}
+
+func TestTracker(t *testing.T) {
+	tracker := New(switchPorts{})
+	ev := make(events, 10)
+	tracker.Register(ev)
+	c := controller.New()
+	c.Register(tracker)
+	s, err := controllertest.Connect(c, 1, controllertest.Port(1), controllertest.Port(2), controllertest.Port(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// a DHCP client is learned without address until the server acks
+	sendFrame(t, s, 1, arpFrame(t, hostMAC, net.IPv4zero))
+	expect(t, ev, "added 0a:00:00:00:00:01 0000000000000001:1")
+	sendFrame(t, s, 2, dhcpAckFrame(t, hostMAC, net.IP{10, 0, 0, 1}))
+	expect(t, ev, "added 0a:00:00:00:00:fe 0000000000000001:2")
+	if h, ok := tracker.HostByIP(net.IP{10, 0, 0, 1}); !ok || h.MAC.String() != hostMAC.String() {
+		t.Errorf("unexpected host by IP %v %v", h, ok)
+	}
+
+	// frames received on links are ignored
+	sendFrame(t, s, 3, arpFrame(t, hostMAC, net.IP{10, 0, 0, 1}))
+	sendFrame(t, s, 2, arpFrame(t, hostMAC, net.IP{10, 0, 0, 1}))
+	expect(t, ev, "moved 0a:00:00:00:00:01 0000000000000001:1 0000000000000001:2")
+	h, _ := tracker.Host(hostMAC)
+	if len(h.IPs) != 1 || h.VLAN != packet.NoVLAN {
+		t.Errorf("unexpected host %+v", h)
+	}
+
+	p := controllertest.Port(2)
+	p.SetConfig(openflow.PortDown)
+	status := v10.NewPortStatus(0)
+	status.SetReason(openflow.PortModified)
+	status.SetPort(p)
+	s.Send(status)
+	expect(t, ev, "removed 0a:00:00:00:00:01")
+	expect(t, ev, "removed 0a:00:00:00:00:fe")
+	if hosts := tracker.Hosts(); len(hosts) != 0 {
+		t.Errorf("unexpected hosts %v", hosts)
+	}
+}
+
+func TestTrackerLinkAdded(t *testing.T) {
+	tracker := New(nil)
+	ev := make(events, 10)
+	tracker.Register(ev)
+	ep := topology.Endpoint{DPID: 1, Port: 4}
+	tracker.mu.Lock()
+	tracker.learn(nil, hostMAC, net.IP{10, 0, 0, 1}, packet.NoVLAN, ep)
+	tracker.mu.Unlock()
+	tracker.LinkAdded(topology.Link{Src: topology.Endpoint{DPID: 2, Port: 1}, Dst: ep})
+	expect(t, ev, "removed 0a:00:00:00:00:01")
+
+	tracker.mu.Lock()
+	tracker.learn(nil, hostMAC, nil, packet.NoVLAN, ep)
+	tracker.hosts[keyOf(hostMAC)].LastSeen = time.Now().Add(-time.Hour)
+	tracker.mu.Unlock()
+	tracker.expire(1, time.Now().Add(-time.Minute))
+	expect(t, ev, "removed 0a:00:00:00:00:01")
+}
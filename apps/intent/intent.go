@@ -0,0 +1,424 @@
+/*
+Package intent installs proactive paths between pairs of hosts.
+
+An intent connects two hosts in both directions along the shortest path of
+the discovered topology. Its flows are installed through a flow mirror
+with a cookie of their own, and moved to another path when a link of the
+current one goes down.
+*/
+package intent
+
+import (
+	"errors"
+	"github.com/ksang/goflow/apps/host"
+	"github.com/ksang/goflow/apps/mirror"
+	"github.com/ksang/goflow/apps/topology"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/packet"
+	"log"
+	"net"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrUnknownHost   = errors.New("unknown host")
+	ErrUnknownIntent = errors.New("unknown intent")
+	ErrNoCookie      = errors.New("no cookie left in the mirror cookie space")
+	// ErrDuplicateIntent is returned when the hosts of an intent are
+	// already connected by another one, whose flows it would overwrite
+	ErrDuplicateIntent = errors.New("hosts already connected by an intent")
+)
+
+// Intent asks for connectivity between two hosts
+type Intent struct {
+	Src net.HardwareAddr
+	Dst net.HardwareAddr
+	// VLAN tags the traffic between the first and last switch when non
+	// zero, the hosts VLAN is restored before delivery
+	VLAN uint16
+	// TransitMAC replaces the destination address between the first and
+	// last switch when set, the host address is restored before delivery
+	TransitMAC net.HardwareAddr
+	// Priority of the flows, OFP_DEFAULT_PRIORITY when zero
+	Priority uint16
+}
+
+// Hosts locates hosts, host.Tracker implements it
+type Hosts interface {
+	Host(mac net.HardwareAddr) (host.Host, bool)
+}
+
+// Topology computes paths, topology.Discovery implements it
+type Topology interface {
+	ShortestPath(src, dst uint64) ([]topology.Link, error)
+}
+
+// flow is an installed flow, identified by datapath, match and priority
+type flow struct {
+	dpid uint64
+	mod  openflow.FlowMod
+}
+
+func (f *flow) key() string {
+	m, _ := v10.CanonicalMatch(f.mod.Match())
+	return v10.DPIDString(f.dpid) + string(m)
+}
+
+func (f *flow) encoded() string {
+	data, _ := f.mod.MarshalBinary()
+	return string(data)
+}
+
+type installed struct {
+	intent Intent
+	cookie uint64
+	path   []topology.Link
+	flows  []flow
+}
+
+// Manager is the intent application. Register it on the controller after
+// the topology discovery, so that links are gone when it reroutes, and on
+// the discovery and host tracker to follow link and host changes.
+type Manager struct {
+	mirror *mirror.Mirror
+	hosts  Hosts
+	topo   Topology
+
+	mu      sync.Mutex
+	intents map[uint64]*installed
+	nextID  uint64
+}
+
+// New returns a manager installing flows through m, intent cookies are
+// taken from the cookie space of m
+func New(m *mirror.Mirror, hosts Hosts, topo Topology) *Manager {
+	return &Manager{
+		mirror:  m,
+		hosts:   hosts,
+		topo:    topo,
+		intents: make(map[uint64]*installed),
+	}
+}
+
+// Add installs an intent and returns its cookie, which identifies it
+func (m *Manager) Add(in Intent) (uint64, error) {
+	if in.Priority == 0 {
+		in.Priority = v10.OFP_DEFAULT_PRIORITY
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, i := range m.intents {
+		if i.involves(in.Src) && i.involves(in.Dst) {
+			return 0, ErrDuplicateIntent
+		}
+	}
+	m.nextID++
+	if m.nextID&m.mirror.CookieMask != 0 {
+		return 0, ErrNoCookie
+	}
+	i := &installed{
+		intent: in,
+		cookie: m.mirror.Cookie&m.mirror.CookieMask | m.nextID,
+	}
+	if err := m.route(i); err != nil {
+		return 0, err
+	}
+	m.intents[i.cookie] = i
+	return i.cookie, nil
+}
+
+// Remove uninstalls an intent
+func (m *Manager) Remove(cookie uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i, ok := m.intents[cookie]
+	if !ok {
+		return ErrUnknownIntent
+	}
+	if err := m.apply(i.flows, nil); err != nil {
+		return err
+	}
+	delete(m.intents, cookie)
+	return nil
+}
+
+// Path returns the current path of an intent, it is nil while the hosts
+// are not connected
+func (m *Manager) Path(cookie uint64) ([]topology.Link, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i, ok := m.intents[cookie]
+	if !ok {
+		return nil, ErrUnknownIntent
+	}
+	return append([]topology.Link(nil), i.path...), nil
+}
+
+// Intents returns the cookies of the intents in order
+func (m *Manager) Intents() []uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return sortedCookies(m.intents)
+}
+
+// route computes the path of an intent and installs its flows, the
+// previous flows are removed once the new ones are in place. It must be
+// called with the lock held.
+func (m *Manager) route(i *installed) error {
+	src, ok := m.hosts.Host(i.intent.Src)
+	if !ok {
+		return ErrUnknownHost
+	}
+	dst, ok := m.hosts.Host(i.intent.Dst)
+	if !ok {
+		return ErrUnknownHost
+	}
+	path, err := m.topo.ShortestPath(src.Location.DPID, dst.Location.DPID)
+	if err != nil {
+		return err
+	}
+	flows := append(i.flowsAlong(src, dst, path), i.flowsAlong(dst, src, reverse(path))...)
+	if err := m.apply(i.flows, flows); err != nil {
+		return err
+	}
+	i.path, i.flows = path, flows
+	return nil
+}
+
+// unroute removes the flows of an intent which lost its path, they stay
+// tracked if they could not be removed
+func (m *Manager) unroute(i *installed) {
+	if err := m.apply(i.flows, nil); err != nil {
+		log.Printf("intent %x: %v", i.cookie, err)
+		return
+	}
+	i.path, i.flows = nil, nil
+}
+
+// deletion returns the flow mod removing f
+func (f *flow) deletion() openflow.FlowMod {
+	del := v10.NewFlowMod(0)
+	del.SetMatch(f.mod.Match())
+	del.SetPriority(f.mod.Priority())
+	del.SetCommand(openflow.DeleteStrict)
+	del.SetOutPort(uint16(openflow.None))
+	return del
+}
+
+// apply adds the new flows and deletes the old ones which were not
+// replaced, unchanged flows are left alone. When a flow mod fails the
+// changes made so far are undone, so that old stays installed.
+func (m *Manager) apply(old, flows []flow) error {
+	installed := make(map[string]flow)
+	for _, f := range old {
+		installed[f.key()] = f
+	}
+	// undo holds the flow mods reverting the changes, latest last
+	var undo []flow
+	revert := func(err error) error {
+		for k := len(undo) - 1; k >= 0; k-- {
+			if err := m.mirror.Apply(undo[k].dpid, undo[k].mod); err != nil {
+				log.Printf("intent: datapath %s: %v", v10.DPIDString(undo[k].dpid), err)
+			}
+		}
+		return err
+	}
+	kept := make(map[string]bool)
+	for _, f := range flows {
+		kept[f.key()] = true
+		prev, replaced := installed[f.key()]
+		if replaced && prev.encoded() == f.encoded() {
+			continue
+		}
+		if err := m.mirror.Apply(f.dpid, f.mod); err != nil {
+			return revert(err)
+		}
+		if replaced {
+			undo = append(undo, prev)
+		} else {
+			undo = append(undo, flow{dpid: f.dpid, mod: f.deletion()})
+		}
+	}
+	for _, f := range old {
+		if kept[f.key()] {
+			continue
+		}
+		if err := m.mirror.Apply(f.dpid, f.deletion()); err != nil {
+			return revert(err)
+		}
+		undo = append(undo, f)
+	}
+	return nil
+}
+
+func reverse(path []topology.Link) []topology.Link {
+	r := make([]topology.Link, len(path))
+	for i, l := range path {
+		r[len(path)-1-i] = topology.Link{Src: l.Dst, Dst: l.Src}
+	}
+	return r
+}
+
+func vlanOf(h host.Host) uint16 {
+	if h.VLAN == packet.NoVLAN {
+		return v10.OFP_VLAN_NONE
+	}
+	return h.VLAN
+}
+
+// flowsAlong returns the flows forwarding from src to dst along path
+func (i *installed) flowsAlong(src, dst host.Host, path []topology.Link) []flow {
+	in := i.intent
+	transitVLAN, transitDst := vlanOf(src), dst.MAC
+	if in.VLAN != 0 {
+		transitVLAN = in.VLAN
+	}
+	if in.TransitMAC != nil {
+		transitDst = in.TransitMAC
+	}
+	flows := make([]flow, 0, len(path)+1)
+	for hop := 0; hop <= len(path); hop++ {
+		ingress, egress := hop == 0, hop == len(path)
+		dpid, inPort, outPort := src.Location.DPID, src.Location.Port, dst.Location.Port
+		if !ingress {
+			dpid, inPort = path[hop-1].Dst.DPID, path[hop-1].Dst.Port
+		}
+		if !egress {
+			outPort = path[hop].Src.Port
+		}
+
+		match := v10.NewMatch()
+		match.SetInPort(inPort)
+		match.SetDLSrc(src.MAC)
+		if ingress {
+			match.SetDLDst(dst.MAC)
+			match.SetDLVlan(vlanOf(src))
+		} else {
+			match.SetDLDst(transitDst)
+			match.SetDLVlan(transitVLAN)
+		}
+		fm := v10.NewFlowMod(0)
+		fm.SetMatch(match)
+		fm.SetCommand(openflow.Add)
+		fm.SetCookie(i.cookie)
+		fm.SetPriority(in.Priority)
+		fm.SetBufferID(v10.OFP_NO_BUFFER)
+		fm.SetOutPort(uint16(openflow.None))
+		// rewrites happen between the first and the last switch only
+		if ingress && !egress {
+			if in.VLAN != 0 {
+				act := v10.NewActionSetVLANVID()
+				act.SetVLANVID(in.VLAN)
+				fm.AddAction(act)
+			}
+			if in.TransitMAC != nil {
+				act := v10.NewActionSetDLDst()
+				act.SetDLDst(in.TransitMAC)
+				fm.AddAction(act)
+			}
+		}
+		if egress && !ingress {
+			if in.VLAN != 0 && dst.VLAN == packet.NoVLAN {
+				fm.AddAction(v10.NewActionStripVLAN())
+			} else if in.VLAN != 0 {
+				act := v10.NewActionSetVLANVID()
+				act.SetVLANVID(dst.VLAN)
+				fm.AddAction(act)
+			}
+			if in.TransitMAC != nil {
+				act := v10.NewActionSetDLDst()
+				act.SetDLDst(dst.MAC)
+				fm.AddAction(act)
+			}
+		}
+		out := v10.NewActionOutput()
+		if outPort == inPort {
+			out.SetPort(uint16(openflow.InPort))
+		} else {
+			out.SetPort(outPort)
+		}
+		fm.AddAction(out)
+		flows = append(flows, flow{dpid: dpid, mod: fm})
+	}
+	return flows
+}
+
+// reroute recomputes the intents matching affected
+func (m *Manager) reroute(affected func(i *installed) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range sortedCookies(m.intents) {
+		i := m.intents[c]
+		if !affected(i) {
+			continue
+		}
+		if err := m.route(i); err != nil {
+			log.Printf("intent %x: %v", i.cookie, err)
+			m.unroute(i)
+		}
+	}
+}
+
+func sortedCookies(intents map[uint64]*installed) []uint64 {
+	cookies := make([]uint64, 0, len(intents))
+	for c := range intents {
+		cookies = append(cookies, c)
+	}
+	sort.Slice(cookies, func(i, j int) bool { return cookies[i] < cookies[j] })
+	return cookies
+}
+
+func (i *installed) uses(ep topology.Endpoint) bool {
+	for _, l := range i.path {
+		if l.Src == ep || l.Dst == ep {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *installed) involves(mac net.HardwareAddr) bool {
+	return i.intent.Src.String() == mac.String() || i.intent.Dst.String() == mac.String()
+}
+
+// PortStatus reroutes the intents whose path goes through a port that
+// went down
+func (m *Manager) PortStatus(dp *controller.Datapath, msg openflow.PortStatus) {
+	p := msg.Port()
+	if msg.Reason() != openflow.PortDeleted && p.State()&openflow.LinkDown == 0 && p.Config()&openflow.PortDown == 0 {
+		return
+	}
+	ep := topology.Endpoint{DPID: dp.ID(), Port: uint16(p.PortID())}
+	m.reroute(func(i *installed) bool { return i.uses(ep) })
+}
+
+// LinkRemoved reroutes the intents using a link which aged out
+func (m *Manager) LinkRemoved(l topology.Link) {
+	m.reroute(func(i *installed) bool { return i.uses(l.Src) })
+}
+
+// LinkAdded routes the intents left without a path
+func (m *Manager) LinkAdded(l topology.Link) {
+	m.reroute(func(i *installed) bool { return i.flows == nil })
+}
+
+func (m *Manager) HostAdded(h host.Host) {
+	m.reroute(func(i *installed) bool { return i.flows == nil && i.involves(h.MAC) })
+}
+
+func (m *Manager) HostMoved(h host.Host, from topology.Endpoint) {
+	m.reroute(func(i *installed) bool { return i.involves(h.MAC) })
+}
+
+func (m *Manager) HostRemoved(h host.Host) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range sortedCookies(m.intents) {
+		if i := m.intents[c]; i.involves(h.MAC) {
+			m.unroute(i)
+		}
+	}
+}
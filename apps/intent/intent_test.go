@@ -0,0 +1,169 @@
+package intent
+
+import (
+	"github.com/ksang/goflow/apps/host"
+	"github.com/ksang/goflow/apps/mirror"
+	"github.com/ksang/goflow/apps/topology"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/packet"
+	"net"
+	"testing"
+)
+
+var (
+	macA = net.HardwareAddr{0x0a, 0, 0, 0, 0, 1}
+	macB = net.HardwareAddr{0x0a, 0, 0, 0, 0, 2}
+)
+
+type hosts map[string]host.Host
+
+func (h hosts) Host(mac net.HardwareAddr) (host.Host, bool) {
+	found, ok := h[mac.String()]
+	return found, ok
+}
+
+type links []topology.Link
+
+func (l links) ShortestPath(src, dst uint64) ([]topology.Link, error) {
+	return topology.ShortestPath(l, src, dst)
+}
+
+func (l *links) remove(dpid uint64) {
+	var kept links
+	for _, link := range *l {
+		if link.Src.DPID != dpid && link.Dst.DPID != dpid {
+			kept = append(kept, link)
+		}
+	}
+	*l = kept
+}
+
+// square returns 1 - 2 - 4 and 1 - 3 - 4, port n of a switch leads to
+// switch n
+func square() *links {
+	var l links
+	for _, pair := range [][2]uint64{{1, 2}, {2, 4}, {1, 3}, {3, 4}} {
+		a, b := pair[0], pair[1]
+		l = append(l,
+			topology.Link{Src: topology.Endpoint{DPID: a, Port: uint16(b)}, Dst: topology.Endpoint{DPID: b, Port: uint16(a)}},
+			topology.Link{Src: topology.Endpoint{DPID: b, Port: uint16(a)}, Dst: topology.Endpoint{DPID: a, Port: uint16(b)}})
+	}
+	return &l
+}
+
+func testHosts() hosts {
+	return hosts{
+		macA.String(): {MAC: macA, VLAN: packet.NoVLAN, Location: topology.Endpoint{DPID: 1, Port: 10}},
+		macB.String(): {MAC: macB, VLAN: packet.NoVLAN, Location: topology.Endpoint{DPID: 4, Port: 10}},
+	}
+}
+
+// outputs returns the output port of each flow of a datapath by in_port
+func outputs(t *testing.T, m *mirror.Mirror, dpid uint64) map[uint16]uint16 {
+	out := make(map[uint16]uint16)
+	for _, f := range m.Flows(dpid) {
+		_, in := f.Match.InPort()
+		act := f.Actions[len(f.Actions)-1].(v10.ActionOutput)
+		out[in] = act.Port()
+	}
+	return out
+}
+
+func TestIntentReroute(t *testing.T) {
+	mir := mirror.New(0x0100000000000000, 0xff00000000000000)
+	topo := square()
+	m := New(mir, testHosts(), topo)
+	cookie, err := m.Add(Intent{Src: macA, Dst: macB})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cookie != 0x0100000000000001 {
+		t.Errorf("unexpected cookie %x", cookie)
+	}
+	path, _ := m.Path(cookie)
+	if len(path) != 2 || path[0].Dst.DPID != 2 {
+		t.Fatalf("unexpected path %v", path)
+	}
+	// both directions on every hop
+	for dpid, expected := range map[uint64]map[uint16]uint16{
+		1: {10: 2, 2: 10},
+		2: {1: 4, 4: 1},
+		4: {2: 10, 10: 2},
+	} {
+		got := outputs(t, mir, dpid)
+		if len(got) != len(expected) {
+			t.Errorf("datapath %d: unexpected flows %v", dpid, got)
+		}
+		for in, out := range expected {
+			if got[in] != out {
+				t.Errorf("datapath %d: in_port %d output %d, expected %d", dpid, in, got[in], out)
+			}
+		}
+	}
+
+	// switch 2 goes away, the path moves to switch 3
+	topo.remove(2)
+	m.LinkRemoved(topology.Link{Src: topology.Endpoint{DPID: 1, Port: 2}, Dst: topology.Endpoint{DPID: 2, Port: 1}})
+	path, _ = m.Path(cookie)
+	if len(path) != 2 || path[0].Dst.DPID != 3 {
+		t.Fatalf("unexpected path %v", path)
+	}
+	if flows := mir.Flows(2); len(flows) != 0 {
+		t.Errorf("flows left on the old path %v", flows)
+	}
+	if got := outputs(t, mir, 1); got[10] != 3 || got[3] != 10 || len(got) != 2 {
+		t.Errorf("unexpected flows on switch 1 %v", got)
+	}
+
+	if err := m.Remove(cookie); err != nil {
+		t.Fatal(err)
+	}
+	for _, dpid := range []uint64{1, 3, 4} {
+		if flows := mir.Flows(dpid); len(flows) != 0 {
+			t.Errorf("datapath %d: flows left %v", dpid, flows)
+		}
+	}
+}
+
+func TestIntentRewrite(t *testing.T) {
+	mir := mirror.New(0x0100000000000000, 0xff00000000000000)
+	transit := net.HardwareAddr{0x02, 0, 0, 0, 0, 0x99}
+	m := New(mir, testHosts(), square())
+	if _, err := m.Add(Intent{Src: macA, Dst: macB, VLAN: 100, TransitMAC: transit}); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range mir.Flows(1) {
+		if _, in := f.Match.InPort(); in != 10 {
+			continue
+		}
+		if len(f.Actions) != 3 || f.Actions[0].(v10.ActionSetVLANVID).VLANVID() != 100 ||
+			f.Actions[1].(v10.ActionSetDLDst).DLDst().String() != transit.String() {
+			t.Errorf("unexpected ingress actions %v", f.Actions)
+		}
+	}
+	for _, f := range mir.Flows(2) {
+		_, vlan := f.Match.DLVlan()
+		_, dst := f.Match.DLDst()
+		if _, in := f.Match.InPort(); in == 1 && (vlan != 100 || dst.String() != transit.String()) {
+			t.Errorf("unexpected transit match vlan %d dst %v", vlan, dst)
+		}
+	}
+	for _, f := range mir.Flows(4) {
+		if _, in := f.Match.InPort(); in != 2 {
+			continue
+		}
+		if len(f.Actions) != 3 || f.Actions[0].Type() != v10.OFPAT_STRIP_VLAN ||
+			f.Actions[1].(v10.ActionSetDLDst).DLDst().String() != macB.String() {
+			t.Errorf("unexpected egress actions %v", f.Actions)
+		}
+	}
+	if _, err := m.Add(Intent{Src: macB, Dst: macA}); err != ErrDuplicateIntent {
+		t.Errorf("expected duplicate intent, got %v", err)
+	}
+	if _, err := m.Add(Intent{Src: macA, Dst: net.HardwareAddr{0x0a, 0, 0, 0, 0, 3}}); err != ErrUnknownHost {
+		t.Errorf("expected unknown host, got %v", err)
+	}
+	if len(m.Intents()) != 1 {
+		t.Errorf("unexpected intents %v", m.Intents())
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Package learning implements an L2 learning switch controller application.
+
+Source addresses of packets sent to the controller are learned per
+datapath. Packets to known unicast destinations get an exact match flow
+towards the learned port, others are flooded. Addresses are forgotten when
+their port is deleted or goes down.
+*/
+package learning
+
+import (
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/packet"
+	"log"
+	"net"
+	"sync"
+)
+
+type macKey [6]byte
+
+func keyOf(mac net.HardwareAddr) macKey {
+	var k macKey
+	copy(k[:], mac)
+	return k
+}
+
+// Switch is the learning switch application, register it on a controller
+type Switch struct {
+	// IdleTimeout and HardTimeout of the installed flows in seconds
+	IdleTimeout uint16
+	HardTimeout uint16
+	Priority    uint16
+
+	mu     sync.Mutex
+	tables map[uint64]map[macKey]uint16
+}
+
+// New returns a learning switch installing flows idle for a minute
+func New() *Switch {
+	return &Switch{
+		IdleTimeout: 60,
+		Priority:    v10.OFP_DEFAULT_PRIORITY,
+		tables:      make(map[uint64]map[macKey]uint16),
+	}
+}
+
+// Lookup returns the port a MAC address was learned on
+func (s *Switch) Lookup(dpid uint64, mac net.HardwareAddr) (uint16, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	port, ok := s.tables[dpid][keyOf(mac)]
+	return port, ok
+}
+
+func (s *Switch) SwitchConnected(dp *controller.Datapath) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables[dp.ID()] = make(map[macKey]uint16)
+}
+
+func (s *Switch) SwitchDisconnected(dp *controller.Datapath) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tables, dp.ID())
+}
+
+// learn records the source port and returns the destination port
+func (s *Switch) learn(dpid uint64, src, dst net.HardwareAddr, inPort uint16) (uint16, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	table, ok := s.tables[dpid]
+	if !ok {
+		return 0, false
+	}
+	if !packet.IsMulticast(src) {
+		table[keyOf(src)] = inPort
+	}
+	if packet.IsMulticast(dst) {
+		return 0, false
+	}
+	port, ok := table[keyOf(dst)]
+	return port, ok
+}
+
+func (s *Switch) PacketIn(dp *controller.Datapath, msg openflow.PacketIn) {
+	var eth packet.Ethernet
+	if err := eth.UnmarshalBinary(msg.Data()); err != nil {
+		return
+	}
+	// link discovery frames are not forwarded
+	if eth.EtherType == packet.EtherTypeLLDP {
+		return
+	}
+	inPort := msg.InPort()
+	outPort, known := s.learn(dp.ID(), eth.Src, eth.Dst, inPort)
+	if known && outPort == inPort {
+		// the destination is behind the same port, drop
+		return
+	}
+	if !known {
+		s.packetOut(dp, msg, uint16(openflow.Flood))
+		return
+	}
+
+	match, err := v10.MatchFromPacket(inPort, msg.Data())
+	if err != nil {
+		return
+	}
+	fm := v10.NewFlowMod(dp.NextXID())
+	fm.SetMatch(match)
+	fm.SetCommand(openflow.Add)
+	fm.SetIdleTimeout(s.IdleTimeout)
+	fm.SetHardTimeout(s.HardTimeout)
+	fm.SetPriority(s.Priority)
+	fm.SetOutPort(uint16(openflow.None))
+	// the switch forwards the buffered packet through the new flow
+	fm.SetBufferID(msg.BufferID())
+	fm.SetAction(output(outPort))
+	if err := dp.Send(fm); err != nil {
+		log.Printf("learning: datapath %s: %v", dp, err)
+		return
+	}
+	if msg.BufferID() == v10.OFP_NO_BUFFER {
+		s.packetOut(dp, msg, outPort)
+	}
+}
+
+func output(port uint16) openflow.Action {
+	act := v10.NewActionOutput()
+	act.SetPort(port)
+	return act
+}
+
+func (s *Switch) packetOut(dp *controller.Datapath, msg openflow.PacketIn, port uint16) {
+	po := v10.NewPacketOut(dp.NextXID())
+	po.SetBufferID(msg.BufferID())
+	po.SetInPort(msg.InPort())
+	po.AddAction(output(port))
+	if msg.BufferID() == v10.OFP_NO_BUFFER {
+		po.SetData(msg.Data())
+	}
+	if err := dp.Send(po); err != nil {
+		log.Printf("learning: datapath %s: %v", dp, err)
+	}
+}
+
+func (s *Switch) PortStatus(dp *controller.Datapath, msg openflow.PortStatus) {
+	port := msg.Port()
+	down := msg.Reason() == openflow.PortDeleted ||
+		port.State()&openflow.LinkDown != 0 || port.Config()&openflow.PortDown != 0
+	if !down {
+		return
+	}
+	s.forget(dp.ID(), uint16(port.PortID()))
+	// remove the flows towards the port
+	fm := v10.NewFlowMod(dp.NextXID())
+	fm.SetCommand(openflow.Delete)
+	fm.SetBufferID(v10.OFP_NO_BUFFER)
+	fm.SetOutPort(uint16(port.PortID()))
+	if err := dp.Send(fm); err != nil {
+		log.Printf("learning: datapath %s: %v", dp, err)
+	}
+}
+
+// forget removes the addresses learned on a port
+func (s *Switch) forget(dpid uint64, port uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for mac, p := range s.tables[dpid] {
+		if p == port {
+			delete(s.tables[dpid], mac)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package learning
+
+import (
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/controller/controllertest"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/packet"
+	"net"
+	"testing"
+	"time"
+)
+
+var (
+	hostA = net.HardwareAddr{0x0a, 0, 0, 0, 0, 1}
+	hostB = net.HardwareAddr{0x0a, 0, 0, 0, 0, 2}
+)
+
+func packetIn(t *testing.T, s *controllertest.Switch, inPort uint16, src, dst net.HardwareAddr) {
+	ip := &packet.IPv4{
+		TTL:      64,
+		Protocol: packet.IPProtocolICMP,
+		Src:      net.IP{10, 0, 0, src[5]},
+		Dst:      net.IP{10, 0, 0, dst[5]},
+		Payload:  []byte{8, 0, 0, 0},
+	}
+	payload, err := ip.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := packet.NewEthernet(src, dst, packet.EtherTypeIPv4, payload).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := v10.NewPacketIn(0)
+	msg.SetBufferID(v10.OFP_NO_BUFFER)
+	msg.SetInPort(inPort)
+	msg.SetData(data)
+	if err := s.Send(msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func outPort(t *testing.T, acts []openflow.Action) uint16 {
+	if len(acts) != 1 {
+		t.Fatalf("got %d actions", len(acts))
+	}
+	out, ok := acts[0].(v10.ActionOutput)
+	if !ok {
+		t.Fatalf("unexpected action %#v", acts[0])
+	}
+	return out.Port()
+}
+
+func receive(t *testing.T, s *controllertest.Switch) openflow.MessageDecoder {
+	msg, err := s.Receive(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestLearningSwitch(t *testing.T) {
+	app := New()
+	c := controller.New()
+	c.Register(app)
+	s, err := controllertest.Connect(c, 1, controllertest.Port(1), controllertest.Port(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// unknown destination is flooded
+	packetIn(t, s, 1, hostA, hostB)
+	po, ok := receive(t, s).(openflow.PacketOut)
+	if !ok {
+		t.Fatal("expected packet out")
+	}
+	if port := outPort(t, po.Action()); port != uint16(openflow.Flood) {
+		t.Errorf("flooded to port %x", port)
+	}
+	if len(po.Data()) == 0 {
+		t.Error("unbuffered packet out without data")
+	}
+
+	// reply installs a flow towards the learned port
+	packetIn(t, s, 2, hostB, hostA)
+	fm, ok := receive(t, s).(openflow.FlowMod)
+	if !ok {
+		t.Fatal("expected flow mod")
+	}
+	if port := outPort(t, fm.Actions()); port != 1 {
+		t.Errorf("flow output to port %d", port)
+	}
+	if wild, in := fm.Match().InPort(); wild || in != 2 || fm.IdleTimeout() != 60 {
+		t.Errorf("unexpected flow in_port %d idle %d", in, fm.IdleTimeout())
+	}
+	if po, ok := receive(t, s).(openflow.PacketOut); !ok || outPort(t, po.Action()) != 1 {
+		t.Error("expected packet out to port 1")
+	}
+	if port, ok := app.Lookup(1, hostB); !ok || port != 2 {
+		t.Errorf("host B learned on %d %v", port, ok)
+	}
+
+	// link down flushes the port
+	p := controllertest.Port(1)
+	p.SetState(openflow.LinkDown)
+	status := v10.NewPortStatus(0)
+	status.SetReason(openflow.PortModified)
+	status.SetPort(p)
+	s.Send(status)
+	fm, ok = receive(t, s).(openflow.FlowMod)
+	if !ok {
+		t.Fatal("expected flow mod")
+	}
+	if fm.Command() != openflow.Delete || fm.OutPort() != 1 {
+		t.Errorf("unexpected flow mod command %d out_port %d", fm.Command(), fm.OutPort())
+	}
+	if _, ok := app.Lookup(1, hostA); ok {
+		t.Error("host A still learned")
+	}
+
+	packetIn(t, s, 2, hostB, hostA)
+	if _, ok := receive(t, s).(openflow.PacketOut); !ok {
+		t.Fatal("expected flood after flush")
+	}
+}
@@ -0,0 +1,395 @@
+/*
+Package mirror keeps a controller side shadow of the flows each datapath
+should have and reconciles switches with it.
+
+Flows are owned through their cookie: only switch flows whose cookie falls
+in the mirror cookie space are modified or deleted by a reconciliation,
+other applications may use the remaining cookies freely.
+*/
+package mirror
+
+import (
+	"bytes"
+	"errors"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrForeignCookie = errors.New("cookie outside of the mirror cookie space")
+	ErrNotConnected  = errors.New("datapath not connected")
+)
+
+// Flow is a flow entry of the shadow table
+type Flow struct {
+	Match       openflow.Match
+	Priority    uint16
+	Cookie      uint64
+	IdleTimeout uint16
+	HardTimeout uint16
+	Flags       openflow.FlowFlag
+	Actions     []openflow.Action
+}
+
+// key identifies a flow entry as the switch does, by match and priority
+func (f *Flow) key() (string, error) {
+	m, err := v10.CanonicalMatch(f.Match)
+	if err != nil {
+		return "", err
+	}
+	return string(m) + string([]byte{byte(f.Priority >> 8), byte(f.Priority)}), nil
+}
+
+// outputs tells whether the flow matches the out port of a flow mod, as
+// flowtable.Entry.Outputs does on the switch
+func (f *Flow) outputs(port uint16) bool {
+	if port == uint16(openflow.None) {
+		return true
+	}
+	for _, a := range f.Actions {
+		switch act := a.(type) {
+		case v10.ActionOutput:
+			if a.Type() == v10.OFPAT_OUTPUT && act.Port() == port {
+				return true
+			}
+		case v10.ActionEnqueue:
+			if act.Port() == port {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameActions(a, b []openflow.Action) bool {
+	ea, err := v10.EncodeActions(a)
+	if err != nil {
+		return false
+	}
+	eb, err := v10.EncodeActions(b)
+	return err == nil && bytes.Equal(ea, eb)
+}
+
+// Result counts the flow mods sent by a reconciliation
+type Result struct {
+	Added    int
+	Modified int
+	Deleted  int
+}
+
+type table map[string]*Flow
+
+// sorted returns the keys of the table in a stable order
+func (t table) sorted() []string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Mirror is the flow mirror application, register it on a controller.
+// Switches are reconciled when they connect and every Interval.
+type Mirror struct {
+	// Cookie and CookieMask define the cookies owned by the mirror
+	Cookie     uint64
+	CookieMask uint64
+	// Interval between periodic reconciliations, disabled when zero
+	Interval time.Duration
+
+	mu        sync.Mutex
+	tables    map[uint64]table
+	datapaths map[uint64]*controller.Datapath
+	stops     map[uint64]chan struct{}
+}
+
+// New returns a mirror owning the cookies c for which c&mask is cookie&mask
+func New(cookie, mask uint64) *Mirror {
+	return &Mirror{
+		Cookie:     cookie,
+		CookieMask: mask,
+		tables:     make(map[uint64]table),
+		datapaths:  make(map[uint64]*controller.Datapath),
+		stops:      make(map[uint64]chan struct{}),
+	}
+}
+
+// Owns tells whether a cookie belongs to the mirror
+func (m *Mirror) Owns(cookie uint64) bool {
+	return cookie&m.CookieMask == m.Cookie&m.CookieMask
+}
+
+// Flows returns the shadow table of a datapath
+func (m *Mirror) Flows(dpid uint64) []Flow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.tables[dpid]
+	flows := make([]Flow, 0, len(t))
+	for _, k := range t.sorted() {
+		flows = append(flows, *t[k])
+	}
+	return flows
+}
+
+// Apply updates the shadow table of a datapath with the semantics of the
+// flow mod command and sends the resulting changes if the datapath is
+// connected. Non strict modifications and deletions are sent as strict
+// flow mods of the affected shadow entries, leaving foreign flows alone.
+// Flows with timeouts are sent with OFPFF_SEND_FLOW_REM to notice their
+// expiry.
+func (m *Mirror) Apply(dpid uint64, fm openflow.FlowMod) error {
+	flow := &Flow{
+		Match:       fm.Match(),
+		Priority:    fm.Priority(),
+		Cookie:      fm.Cookie(),
+		IdleTimeout: fm.IdleTimeout(),
+		HardTimeout: fm.HardTimeout(),
+		Flags:       fm.Flags(),
+		Actions:     fm.Actions(),
+	}
+	if flow.IdleTimeout != 0 || flow.HardTimeout != 0 {
+		flow.Flags |= openflow.SendFlowRem
+	}
+	key, err := flow.key()
+	if err != nil {
+		return err
+	}
+	add := func(t table) ([]openflow.FlowMod, error) {
+		if !m.Owns(flow.Cookie) {
+			return nil, ErrForeignCookie
+		}
+		t[key] = flow
+		return []openflow.FlowMod{flowMod(openflow.Add, flow)}, nil
+	}
+
+	m.mu.Lock()
+	t, ok := m.tables[dpid]
+	if !ok {
+		t = make(table)
+		m.tables[dpid] = t
+	}
+	var mods []openflow.FlowMod
+	switch fm.Command() {
+	case openflow.Add:
+		mods, err = add(t)
+	case openflow.Modify, openflow.ModifyStrict:
+		for _, k := range t.sorted() {
+			f := t[k]
+			if k == key || fm.Command() == openflow.Modify && v10.MatchCovers(flow.Match, f.Match) {
+				f.Actions = flow.Actions
+				mods = append(mods, flowMod(openflow.ModifyStrict, f))
+			}
+		}
+		// modifying nothing adds the flow
+		if len(mods) == 0 {
+			mods, err = add(t)
+		}
+	case openflow.Delete, openflow.DeleteStrict:
+		for _, k := range t.sorted() {
+			f := t[k]
+			if (k == key || fm.Command() == openflow.Delete && v10.MatchCovers(flow.Match, f.Match)) && f.outputs(fm.OutPort()) {
+				delete(t, k)
+				mods = append(mods, flowMod(openflow.DeleteStrict, f))
+			}
+		}
+	default:
+		err = openflow.ErrInvalidValueProvided
+	}
+	dp := m.datapaths[dpid]
+	m.mu.Unlock()
+	if err != nil || dp == nil {
+		return err
+	}
+	for _, mod := range mods {
+		if err := send(dp, mod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flowMod(command openflow.FlowCommand, f *Flow) openflow.FlowMod {
+	fm := v10.NewFlowMod(0)
+	fm.SetMatch(f.Match)
+	fm.SetCommand(command)
+	fm.SetPriority(f.Priority)
+	fm.SetBufferID(v10.OFP_NO_BUFFER)
+	fm.SetOutPort(uint16(openflow.None))
+	if command == openflow.Add {
+		fm.SetCookie(f.Cookie)
+		fm.SetIdleTimeout(f.IdleTimeout)
+		fm.SetHardTimeout(f.HardTimeout)
+		fm.SetFlags(f.Flags)
+	}
+	if command != openflow.DeleteStrict {
+		for _, a := range f.Actions {
+			fm.AddAction(a)
+		}
+	}
+	return fm
+}
+
+func send(dp *controller.Datapath, fm openflow.FlowMod) error {
+	fm.SetTransactionID(dp.NextXID())
+	return dp.Send(fm)
+}
+
+// switchFlows dumps the flows of a datapath
+func switchFlows(dp *controller.Datapath) ([]v10.FlowStats, error) {
+	req := v10.NewStatsReuqestFlow(dp.NextXID())
+	req.SetTableID(0xff)
+	req.SetOutPort(uint16(openflow.None))
+	replies, err := dp.Request(req)
+	if err != nil {
+		return nil, err
+	}
+	var flows []v10.FlowStats
+	for _, r := range replies {
+		if reply, ok := r.(v10.StatsReplyFlow); ok {
+			flows = append(flows, reply.Flows()...)
+		}
+	}
+	return flows, nil
+}
+
+// Reconcile dumps the flows of a datapath and sends the flow mods needed
+// to converge to the shadow table, it returns once the switch processed
+// them
+func (m *Mirror) Reconcile(dpid uint64) (*Result, error) {
+	m.mu.Lock()
+	dp := m.datapaths[dpid]
+	m.mu.Unlock()
+	if dp == nil {
+		return nil, ErrNotConnected
+	}
+	stats, err := switchFlows(dp)
+	if err != nil {
+		return nil, err
+	}
+	current := make(table)
+	for _, s := range stats {
+		if !m.Owns(s.Cookie()) {
+			continue
+		}
+		f := &Flow{
+			Match:       s.Match(),
+			Priority:    s.Priority(),
+			Cookie:      s.Cookie(),
+			IdleTimeout: s.IdleTimeout(),
+			HardTimeout: s.HardTimeout(),
+			Actions:     s.Actions(),
+		}
+		key, err := f.key()
+		if err != nil {
+			return nil, err
+		}
+		current[key] = f
+	}
+
+	result := &Result{}
+	var mods []openflow.FlowMod
+	m.mu.Lock()
+	desired := m.tables[dpid]
+	for _, k := range desired.sorted() {
+		want := desired[k]
+		have, ok := current[k]
+		switch {
+		case !ok || have.Cookie != want.Cookie || have.IdleTimeout != want.IdleTimeout || have.HardTimeout != want.HardTimeout:
+			// adding over an identical entry replaces it
+			mods = append(mods, flowMod(openflow.Add, want))
+			result.Added++
+		case !sameActions(have.Actions, want.Actions):
+			mods = append(mods, flowMod(openflow.ModifyStrict, want))
+			result.Modified++
+		}
+	}
+	for _, k := range current.sorted() {
+		if _, ok := desired[k]; !ok {
+			mods = append(mods, flowMod(openflow.DeleteStrict, current[k]))
+			result.Deleted++
+		}
+	}
+	m.mu.Unlock()
+
+	for _, mod := range mods {
+		if err := send(dp, mod); err != nil {
+			return result, err
+		}
+	}
+	if len(mods) > 0 {
+		if err := dp.Barrier(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (m *Mirror) reconcile(dp *controller.Datapath) {
+	result, err := m.Reconcile(dp.ID())
+	if err != nil {
+		log.Printf("mirror: datapath %s: %v", dp, err)
+		return
+	}
+	if *result != (Result{}) {
+		log.Printf("mirror: datapath %s: reconciled %d added, %d modified, %d deleted",
+			dp, result.Added, result.Modified, result.Deleted)
+	}
+}
+
+func (m *Mirror) SwitchConnected(dp *controller.Datapath) {
+	stop := make(chan struct{})
+	m.mu.Lock()
+	m.datapaths[dp.ID()] = dp
+	m.stops[dp.ID()] = stop
+	m.mu.Unlock()
+	go func() {
+		m.reconcile(dp)
+		if m.Interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(m.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.reconcile(dp)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (m *Mirror) SwitchDisconnected(dp *controller.Datapath) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.datapaths[dp.ID()] == dp {
+		close(m.stops[dp.ID()])
+		delete(m.stops, dp.ID())
+		delete(m.datapaths, dp.ID())
+	}
+}
+
+// FlowRemoved drops expired or evicted flows from the shadow table
+func (m *Mirror) FlowRemoved(dp *controller.Datapath, msg openflow.FlowRemoved) {
+	if !m.Owns(msg.Cookie()) {
+		return
+	}
+	f := &Flow{Match: msg.Match(), Priority: msg.Priority()}
+	key, err := f.key()
+	if err != nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.tables[dp.ID()]
+	if shadow, ok := t[key]; ok && shadow.Cookie == msg.Cookie() {
+		delete(t, key)
+	}
+}
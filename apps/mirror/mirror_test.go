@@ -0,0 +1,194 @@
+package mirror
+
+import (
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/controller/controllertest"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"net"
+	"testing"
+	"time"
+)
+
+const (
+	owned   = 0x0100000000000000
+	mask    = 0xff00000000000000
+	foreign = 0x0200000000000001
+)
+
+func output(port uint16) openflow.Action {
+	out := v10.NewActionOutput()
+	out.SetPort(port)
+	return out
+}
+
+func flowTo(ip byte, port uint16) openflow.FlowMod {
+	match := v10.NewMatch()
+	match.SetDLType(0x0800)
+	match.SetNWDst(net.IP{10, 0, 0, ip})
+	fm := v10.NewFlowMod(0)
+	fm.SetMatch(match)
+	fm.SetCookie(owned | uint64(ip))
+	fm.SetPriority(100)
+	fm.SetAction(output(port))
+	return fm
+}
+
+func stats(fm openflow.FlowMod) v10.FlowStats {
+	s := v10.NewFlowStats()
+	s.SetMatch(fm.Match())
+	s.SetPriority(fm.Priority())
+	s.SetCookie(fm.Cookie())
+	for _, a := range fm.Actions() {
+		s.AddAction(a)
+	}
+	return s
+}
+
+func receiveFlowMod(t *testing.T, s *controllertest.Switch) openflow.FlowMod {
+	msg, err := s.Receive(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fm, ok := msg.(openflow.FlowMod)
+	if !ok {
+		t.Fatalf("expected flow mod, got %T", msg)
+	}
+	return fm
+}
+
+func TestReconcile(t *testing.T) {
+	m := New(owned, mask)
+	// desired before the switch connects
+	if err := m.Apply(1, flowTo(1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Apply(1, flowTo(2, 2)); err != nil {
+		t.Fatal(err)
+	}
+	bad := flowTo(3, 3)
+	bad.SetCookie(foreign)
+	if err := m.Apply(1, bad); err != ErrForeignCookie {
+		t.Errorf("expected foreign cookie error, got %v", err)
+	}
+
+	c := controller.New()
+	c.Register(m)
+	s, err := controllertest.Connect(c, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	msg, err := s.Receive(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, ok := msg.(v10.StatsRequestFlow)
+	if !ok || req.TableID() != 0xff || !v10.MatchesAll(req.Match()) {
+		t.Fatalf("expected flow dump, got %v", msg)
+	}
+	// the switch has flow 1 with other actions, a stale flow 4 and a
+	// foreign flow 5
+	stale := flowTo(4, 4)
+	other := flowTo(5, 5)
+	other.SetCookie(foreign)
+	reply := v10.NewStatsReplyFlow(req.TransactionID())
+	reply.AddFlow(stats(flowTo(1, 9)))
+	reply.AddFlow(stats(stale))
+	reply.AddFlow(stats(other))
+	s.Send(reply)
+
+	commands := map[openflow.FlowCommand]uint64{}
+	for i := 0; i < 3; i++ {
+		fm := receiveFlowMod(t, s)
+		commands[fm.Command()] = fm.Cookie()
+		switch fm.Command() {
+		case openflow.ModifyStrict:
+			if out := fm.Action().(v10.ActionOutput); out.Port() != 1 {
+				t.Errorf("modified to port %d", out.Port())
+			}
+		case openflow.DeleteStrict:
+			if fm.Match().NWDst().String() != "10.0.0.4" {
+				t.Errorf("deleted %v", fm.Match().NWDst())
+			}
+		}
+	}
+	if len(commands) != 3 || commands[openflow.Add] != owned|2 {
+		t.Errorf("unexpected commands %v", commands)
+	}
+
+	// expired flows leave the shadow table
+	removed := v10.NewFlowRemoved(0)
+	removed.SetMatch(flowTo(1, 1).Match())
+	removed.SetPriority(100)
+	removed.SetCookie(owned | 1)
+	s.Send(removed)
+	for deadline := time.Now().Add(time.Second); len(m.Flows(1)) != 1; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("flow removed ignored")
+		}
+	}
+
+	// a non strict delete only touches the mirrored flows
+	del := v10.NewFlowMod(0)
+	del.SetCommand(openflow.Delete)
+	del.SetOutPort(uint16(openflow.None))
+	if err := m.Apply(1, del); err != nil {
+		t.Fatal(err)
+	}
+	if fm := receiveFlowMod(t, s); fm.Command() != openflow.DeleteStrict || fm.Match().NWDst().String() != "10.0.0.2" {
+		t.Errorf("unexpected delete %v %v", fm.Command(), fm.Match().NWDst())
+	}
+	if flows := m.Flows(1); len(flows) != 0 {
+		t.Errorf("unexpected flows %v", flows)
+	}
+}
+
+func TestApplyModify(t *testing.T) {
+	m := New(owned, mask)
+	m.Apply(1, flowTo(1, 1))
+	m.Apply(1, flowTo(2, 2))
+	// modify all IP flows
+	match := v10.NewMatch()
+	match.SetDLType(0x0800)
+	mod := v10.NewFlowMod(0)
+	mod.SetMatch(match)
+	mod.SetCommand(openflow.Modify)
+	mod.SetAction(output(7))
+	if err := m.Apply(1, mod); err != nil {
+		t.Fatal(err)
+	}
+	flows := m.Flows(1)
+	if len(flows) != 2 {
+		t.Fatalf("unexpected flows %v", flows)
+	}
+	for _, f := range flows {
+		if f.Actions[0].(v10.ActionOutput).Port() != 7 {
+			t.Errorf("flow not modified %v", f)
+		}
+	}
+}
+
+func TestApplyDeleteOutPort(t *testing.T) {
+	m := New(owned, mask)
+	m.Apply(1, flowTo(1, 1))
+	queued := flowTo(2, 1)
+	enqueue := v10.NewActionEnqueue()
+	enqueue.SetPort(3)
+	queued.SetAction(enqueue)
+	m.Apply(1, queued)
+	// enqueue actions output to their port as well
+	match := v10.NewMatch()
+	match.SetDLType(0x0800)
+	del := v10.NewFlowMod(0)
+	del.SetMatch(match)
+	del.SetCommand(openflow.Delete)
+	del.SetOutPort(3)
+	if err := m.Apply(1, del); err != nil {
+		t.Fatal(err)
+	}
+	if flows := m.Flows(1); len(flows) != 1 || flows[0].Cookie != owned|1 {
+		t.Errorf("unexpected flows %v", flows)
+	}
+}
@@ -0,0 +1,406 @@
+/*
+Package rest exposes the controller over HTTP with JSON bodies.
+
+Messages are read and written in the JSON form of the openflow/v10
+package. The API is:
+
+	GET    /datapaths                      connected datapaths
+	GET    /datapaths/{dpid}               a datapath with features and ports
+	GET    /datapaths/{dpid}/flows         flow stats reply of all flows
+	POST   /datapaths/{dpid}/flows         send a flow_mod
+	DELETE /datapaths/{dpid}/flows         delete flows, optional flow_mod body
+	POST   /datapaths/{dpid}/packet_out    send a packet_out
+	GET    /datapaths/{dpid}/stats/{type}  stats replies of every entry
+	POST   /datapaths/{dpid}/stats         stats replies of a stats_request
+	GET    /events                         server-sent events
+
+Flow mods and packet outs are followed by a barrier, the request returns
+once the switch processed them. When the switch answers them with an
+error the request fails with 502 Bad Gateway and the error.
+*/
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownDatapath = errors.New("unknown datapath")
+	ErrUnexpectedType  = errors.New("unexpected message type")
+)
+
+// maxBody limits the size of request bodies
+const maxBody = 1 << 20
+
+// Event is an event of the controller as streamed to clients
+type Event struct {
+	Event   string                  `json:"event"`
+	DPID    string                  `json:"dpid"`
+	Message openflow.MessageDecoder `json:"message,omitempty"`
+}
+
+// Server is the REST API, it is a controller application notified of
+// the events it streams
+type Server struct {
+	// EventBuffer is the number of events queued per client, events are
+	// dropped for clients too slow to keep up
+	EventBuffer int
+
+	controller *controller.Controller
+	mu         sync.Mutex
+	clients    map[chan *Event]struct{}
+}
+
+// New returns the API of c and registers it on c
+func New(c *controller.Controller) *Server {
+	s := &Server{
+		EventBuffer: 256,
+		controller:  c,
+		clients:     make(map[chan *Event]struct{}),
+	}
+	c.Register(s)
+	return s
+}
+
+type jsonError struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if _, ok := err.(*controller.RequestError); ok {
+		status = http.StatusBadGateway
+	}
+	switch err {
+	case ErrUnknownDatapath:
+		status = http.StatusNotFound
+	case controller.ErrTimeout:
+		status = http.StatusGatewayTimeout
+	case controller.ErrClosed:
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, jsonError{err.Error()})
+}
+
+func badRequest(w http.ResponseWriter, err error) {
+	writeJSON(w, http.StatusBadRequest, jsonError{err.Error()})
+}
+
+// readMessage decodes the message of a request body, nil if it is empty
+func readMessage(r *http.Request) (openflow.MessageDecoder, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBody))
+	if err != nil {
+		return nil, err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, nil
+	}
+	return v10.UnmarshalJSONMessage(data)
+}
+
+// ServeHTTP routes API requests
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	route := func(method string, n int) bool {
+		return len(parts) == n && r.Method == method
+	}
+	switch {
+	case route("GET", 1) && parts[0] == "datapaths":
+		s.listDatapaths(w)
+		return
+	case route("GET", 1) && parts[0] == "events":
+		s.streamEvents(w, r)
+		return
+	case len(parts) < 2 || parts[0] != "datapaths":
+		writeJSON(w, http.StatusNotFound, jsonError{"not found"})
+		return
+	}
+
+	dpid, err := v10.ParseDPID(parts[1])
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+	dp, ok := s.controller.Datapath(dpid)
+	if !ok {
+		writeError(w, ErrUnknownDatapath)
+		return
+	}
+	switch {
+	case route("GET", 2):
+		writeJSON(w, http.StatusOK, datapathToJSON(dp))
+	case route("GET", 3) && parts[2] == "flows":
+		s.listFlows(w, dp)
+	case route("POST", 3) && parts[2] == "flows":
+		s.modifyFlows(w, r, dp, false)
+	case route("DELETE", 3) && parts[2] == "flows":
+		s.modifyFlows(w, r, dp, true)
+	case route("POST", 3) && parts[2] == "packet_out":
+		s.packetOut(w, r, dp)
+	case route("GET", 4) && parts[2] == "stats":
+		t, err := openflow.ParseStatsType(parts[3])
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+		req, err := statsRequest(t)
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+		s.stats(w, dp, req)
+	case route("POST", 3) && parts[2] == "stats":
+		msg, err := readMessage(r)
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+		req, ok := msg.(openflow.StatsRequest)
+		if !ok || msg.MsgType() != v10.OFPT_STATS_REQUEST {
+			badRequest(w, ErrUnexpectedType)
+			return
+		}
+		s.stats(w, dp, req)
+	default:
+		writeJSON(w, http.StatusNotFound, jsonError{"not found"})
+	}
+}
+
+type jsonDatapath struct {
+	DPID     string                `json:"dpid"`
+	Address  string                `json:"address"`
+	Features openflow.FeatureReply `json:"features"`
+	Ports    []openflow.Port       `json:"ports"`
+}
+
+func datapathToJSON(dp *controller.Datapath) jsonDatapath {
+	v := jsonDatapath{
+		DPID:     v10.DPIDString(dp.ID()),
+		Features: dp.Features(),
+		Ports:    dp.Ports(),
+	}
+	if addr := dp.RemoteAddr(); addr != nil {
+		v.Address = addr.String()
+	}
+	return v
+}
+
+func (s *Server) listDatapaths(w http.ResponseWriter) {
+	dps := s.controller.Datapaths()
+	v := make([]jsonDatapath, 0, len(dps))
+	for _, dp := range dps {
+		v = append(v, datapathToJSON(dp))
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// listFlows merges the flow stats replies of all flows into one reply
+func (s *Server) listFlows(w http.ResponseWriter, dp *controller.Datapath) {
+	req, _ := statsRequest(openflow.STATS_Flow)
+	replies, err := dp.Request(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	all := v10.NewStatsReplyFlow(req.TransactionID())
+	for _, msg := range replies {
+		if reply, ok := msg.(v10.StatsReplyFlow); ok {
+			for _, f := range reply.Flows() {
+				all.AddFlow(f)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, all)
+}
+
+// modifyFlows sends the flow mod of the body, a deletion without body
+// deletes every flow
+func (s *Server) modifyFlows(w http.ResponseWriter, r *http.Request, dp *controller.Datapath, del bool) {
+	msg, err := readMessage(r)
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+	if msg == nil && del {
+		fm := v10.NewFlowMod(0)
+		fm.SetOutPort(uint16(openflow.None))
+		msg = fm
+	}
+	fm, ok := msg.(openflow.FlowMod)
+	if !ok {
+		badRequest(w, ErrUnexpectedType)
+		return
+	}
+	if del && fm.Command() != openflow.DeleteStrict {
+		fm.SetCommand(openflow.Delete)
+	}
+	if !del && (fm.Command() == openflow.Delete || fm.Command() == openflow.DeleteStrict) {
+		badRequest(w, openflow.ErrInvalidValueProvided)
+		return
+	}
+	s.send(w, dp, fm)
+}
+
+func (s *Server) packetOut(w http.ResponseWriter, r *http.Request, dp *controller.Datapath) {
+	msg, err := readMessage(r)
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+	po, ok := msg.(openflow.PacketOut)
+	if !ok {
+		badRequest(w, ErrUnexpectedType)
+		return
+	}
+	s.send(w, dp, po)
+}
+
+// send sends a message and waits until the switch processed it
+func (s *Server) send(w http.ResponseWriter, dp *controller.Datapath, msg openflow.MessageDecoder) {
+	msg.SetTransactionID(dp.NextXID())
+	if err := dp.Check(msg); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// statsRequest returns a request for every entry of a stats type
+func statsRequest(t openflow.StatsType) (openflow.StatsRequest, error) {
+	switch t {
+	case openflow.STATS_Description:
+		return v10.NewStatsRequestDescription(0), nil
+	case openflow.STATS_Flow, openflow.STATS_Aggregate:
+		req := v10.NewStatsReuqestFlow(0)
+		if t == openflow.STATS_Aggregate {
+			req = v10.NewStatsReuqestAggregate(0)
+		}
+		req.SetTableID(0xff)
+		req.SetOutPort(uint16(openflow.None))
+		return req, nil
+	case openflow.STATS_Table:
+		return v10.NewStatsReuqestTable(0), nil
+	case openflow.STATS_Port:
+		req := v10.NewStatsReuqestPort(0)
+		req.SetPortNumber(uint16(openflow.None))
+		return req, nil
+	case openflow.STATS_Queue:
+		req := v10.NewStatsReuqestQueue(0)
+		req.SetPortNumber(uint16(openflow.All))
+		req.SetQueueID(v10.OFPQ_ALL)
+		return req, nil
+	}
+	return nil, openflow.ErrInvalidValueProvided
+}
+
+func (s *Server) stats(w http.ResponseWriter, dp *controller.Datapath, req openflow.StatsRequest) {
+	req.SetTransactionID(0)
+	replies, err := dp.Request(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, replies)
+}
+
+// streamEvents sends events to a client until it goes away
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, jsonError{"streaming unsupported"})
+		return
+	}
+	events := make(chan *Event, s.EventBuffer)
+	s.mu.Lock()
+	s.clients[events] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, events)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	gone := r.Context().Done()
+	for {
+		select {
+		case ev := <-events:
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Event, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-gone:
+			return
+		}
+	}
+}
+
+// publish queues an event for every client
+func (s *Server) publish(name string, dp *controller.Datapath, msg openflow.MessageDecoder) {
+	ev := &Event{Event: name, DPID: v10.DPIDString(dp.ID()), Message: msg}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		select {
+		case c <- ev:
+		default:
+		}
+	}
+}
+
+func (s *Server) SwitchConnected(dp *controller.Datapath) {
+	s.publish("switch_connected", dp, dp.Features())
+}
+
+func (s *Server) SwitchDisconnected(dp *controller.Datapath) {
+	s.publish("switch_disconnected", dp, nil)
+}
+
+func (s *Server) PacketIn(dp *controller.Datapath, msg openflow.PacketIn) {
+	s.publish("packet_in", dp, msg)
+}
+
+func (s *Server) PortStatus(dp *controller.Datapath, msg openflow.PortStatus) {
+	s.publish("port_status", dp, msg)
+}
+
+func (s *Server) FlowRemoved(dp *controller.Datapath, msg openflow.FlowRemoved) {
+	s.publish("flow_removed", dp, msg)
+}
+
+func (s *Server) Error(dp *controller.Datapath, msg openflow.Error) {
+	s.publish("error", dp, msg)
+}
+
+// ListenAndServe serves the API on a TCP address
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return http.Serve(ln, s)
+}
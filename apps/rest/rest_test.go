@@ -0,0 +1,175 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/controller/controllertest"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/softswitch"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setup(t *testing.T) (*httptest.Server, *controllertest.Switch) {
+	c := controller.New()
+	srv := httptest.NewServer(New(c))
+	s, err := controllertest.Connect(c, 1, controllertest.Port(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, s
+}
+
+func do(t *testing.T, method, url string, body []byte) (int, string) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestAPI(t *testing.T) {
+	srv, s := setup(t)
+	defer srv.Close()
+	defer s.Close()
+
+	status, body := do(t, "GET", srv.URL+"/datapaths", nil)
+	if status != http.StatusOK || !strings.Contains(body, `"dpid":"0000000000000001"`) || !strings.Contains(body, `"name":"eth1"`) {
+		t.Errorf("unexpected datapaths %d %s", status, body)
+	}
+	if status, _ := do(t, "GET", srv.URL+"/datapaths/2", nil); status != http.StatusNotFound {
+		t.Errorf("unknown datapath answered %d", status)
+	}
+
+	fm := v10.NewFlowMod(0)
+	out := v10.NewActionOutput()
+	out.SetPort(1)
+	fm.SetAction(out)
+	data, _ := json.Marshal(fm)
+	if status, body := do(t, "POST", srv.URL+"/datapaths/1/flows", data); status != http.StatusNoContent {
+		t.Errorf("flow mod answered %d %s", status, body)
+	}
+	msg, err := s.Receive(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent, ok := msg.(openflow.FlowMod); !ok || sent.Command() != openflow.Add {
+		t.Errorf("unexpected message %v", msg)
+	}
+	if status, _ := do(t, "POST", srv.URL+"/datapaths/1/flows", []byte(`{"type":"hello"}`)); status != http.StatusBadRequest {
+		t.Errorf("hello accepted as flow mod with %d", status)
+	}
+
+	done := make(chan string)
+	go func() {
+		_, body := do(t, "GET", srv.URL+"/datapaths/1/stats/port", nil)
+		done <- body
+	}()
+	msg, err = s.Receive(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := v10.NewStatsReplyPort(msg.TransactionID())
+	p := v10.NewPortStats()
+	p.SetPortNumber(1)
+	p.SetRxBytes(42)
+	reply.AddPort(p)
+	s.Send(reply)
+	if body := <-done; !strings.Contains(body, `"rx_bytes":42`) {
+		t.Errorf("unexpected stats %s", body)
+	}
+}
+
+func TestSwitchError(t *testing.T) {
+	c := controller.New()
+	srv := httptest.NewServer(New(c))
+	defer srv.Close()
+	sw := softswitch.New(1)
+	defer sw.Close()
+	a, b := net.Pipe()
+	go c.ServeConn(a)
+	go sw.ServeConn(b)
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if _, ok := c.Datapath(1); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("switch not connected")
+		}
+	}
+
+	// the switch rejects output to the table in flow mods
+	fm := v10.NewFlowMod(0)
+	out := v10.NewActionOutput()
+	out.SetPort(uint16(openflow.Table))
+	fm.SetAction(out)
+	data, _ := json.Marshal(fm)
+	if status, body := do(t, "POST", srv.URL+"/datapaths/1/flows", data); status != http.StatusBadGateway || !strings.Contains(body, "bad_action") {
+		t.Errorf("rejected flow mod answered %d %s", status, body)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	srv, s := setup(t)
+	defer srv.Close()
+	defer s.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("unexpected content type %s", ct)
+	}
+	pi := v10.NewPacketIn(0)
+	pi.SetInPort(1)
+	pi.SetData([]byte{1, 2, 3})
+	s.Send(pi)
+
+	lines := make(chan string)
+	go func() {
+		r := bufio.NewScanner(resp.Body)
+		for r.Scan() {
+			lines <- r.Text()
+		}
+		close(lines)
+	}()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case line := <-lines:
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			ev := struct {
+				Event   string
+				DPID    string
+				Message map[string]interface{}
+			}{}
+			if err := json.Unmarshal([]byte(line[6:]), &ev); err != nil {
+				t.Fatal(err)
+			}
+			if ev.Event != "packet_in" || ev.DPID != "0000000000000001" || ev.Message["type"] != "packet_in" {
+				t.Errorf("unexpected event %s", line)
+			}
+			return
+		case <-timeout:
+			t.Fatal("no event")
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package stats
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/ksang/goflow/openflow/v10"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// ContentType is the content type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metric describes a counter, exposed as a total and a rate per second
+type metric struct {
+	name string
+	help string
+}
+
+// in the order of Port.counters
+var portMetrics = []metric{
+	{"port_rx_packets", "Packets received by the port"},
+	{"port_tx_packets", "Packets transmitted by the port"},
+	{"port_rx_bytes", "Bytes received by the port"},
+	{"port_tx_bytes", "Bytes transmitted by the port"},
+	{"port_rx_dropped", "Packets dropped on receive by the port"},
+	{"port_tx_dropped", "Packets dropped on transmit by the port"},
+	{"port_rx_errors", "Receive errors of the port"},
+	{"port_tx_errors", "Transmit errors of the port"},
+}
+
+// in the order of Table.counters
+var tableMetrics = []metric{
+	{"table_lookups", "Packets looked up in the table"},
+	{"table_matches", "Packets that hit the table"},
+}
+
+// in the order of Queue.counters
+var queueMetrics = []metric{
+	{"queue_tx_bytes", "Bytes transmitted by the queue"},
+	{"queue_tx_packets", "Packets transmitted by the queue"},
+	{"queue_tx_errors", "Packets dropped by the queue"},
+}
+
+// series is a labelled value of a metric
+type series struct {
+	labels string
+	c      *Counter
+}
+
+type exposition struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *exposition) printf(format string, a ...interface{}) {
+	if e.err == nil {
+		_, e.err = fmt.Fprintf(e.w, format, a...)
+	}
+}
+
+func (e *exposition) gauges(name, help string, values map[string]float64) {
+	if len(values) == 0 {
+		return
+	}
+	e.printf("# HELP goflow_%s %s.\n# TYPE goflow_%s gauge\n", name, help, name)
+	labels := make([]string, 0, len(values))
+	for l := range values {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	for _, l := range labels {
+		e.printf("goflow_%s{%s} %s\n", name, l, strconv.FormatFloat(values[l], 'g', -1, 64))
+	}
+}
+
+// counters writes the total and the rate of a metric for each series
+// where the switch supports it
+func (e *exposition) counters(m metric, all []series) {
+	sort.Slice(all, func(i, j int) bool { return all[i].labels < all[j].labels })
+	rates := make(map[string]float64)
+	for _, s := range all {
+		if s.c.Supported() {
+			rates[s.labels] = s.c.Rate
+		}
+	}
+	if len(rates) == 0 {
+		return
+	}
+	e.printf("# HELP goflow_%s_total %s.\n# TYPE goflow_%s_total counter\n", m.name, m.help, m.name)
+	for _, s := range all {
+		if s.c.Supported() {
+			e.printf("goflow_%s_total{%s} %d\n", m.name, s.labels, s.c.Value)
+		}
+	}
+	e.gauges(m.name+"_per_second", m.help+" per second over the last poll", rates)
+}
+
+// WriteMetrics writes the latest samples in the Prometheus text format
+func (c *Collector) WriteMetrics(w io.Writer) error {
+	var samples []*Sample
+	for _, dpid := range c.Datapaths() {
+		if s, ok := c.Latest(dpid); ok {
+			samples = append(samples, s)
+		}
+	}
+	e := &exposition{w: bufio.NewWriter(w)}
+
+	for i, m := range portMetrics {
+		var all []series
+		for _, s := range samples {
+			for no, p := range s.Ports {
+				all = append(all, series{fmt.Sprintf(`dpid="%s",port="%d"`, v10.DPIDString(s.DPID), no), p.counters()[i]})
+			}
+		}
+		e.counters(m, all)
+	}
+
+	active := make(map[string]float64)
+	flows := make(map[string]float64)
+	packets := make(map[string]float64)
+	bytes := make(map[string]float64)
+	for _, s := range samples {
+		for id, t := range s.Tables {
+			l := fmt.Sprintf(`dpid="%s",table="%d"`, v10.DPIDString(s.DPID), id)
+			active[l] = float64(t.Active)
+			flows[l] = float64(t.Flows)
+			packets[l] = float64(t.Packets)
+			bytes[l] = float64(t.Bytes)
+		}
+	}
+	e.gauges("table_active_entries", "Active entries reported by the table", active)
+	e.gauges("table_flows", "Flows listed in the table", flows)
+	e.gauges("table_flow_packets", "Packets matched by the flows currently in the table", packets)
+	e.gauges("table_flow_bytes", "Bytes matched by the flows currently in the table", bytes)
+	for i, m := range tableMetrics {
+		var all []series
+		for _, s := range samples {
+			for id, t := range s.Tables {
+				all = append(all, series{fmt.Sprintf(`dpid="%s",table="%d"`, v10.DPIDString(s.DPID), id), t.counters()[i]})
+			}
+		}
+		e.counters(m, all)
+	}
+
+	for i, m := range queueMetrics {
+		var all []series
+		for _, s := range samples {
+			for id, q := range s.Queues {
+				all = append(all, series{fmt.Sprintf(`dpid="%s",port="%d",queue="%d"`, v10.DPIDString(s.DPID), id.Port, id.Queue), q.counters()[i]})
+			}
+		}
+		e.counters(m, all)
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// ServeHTTP serves the metrics to a Prometheus scraper
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	if err := c.WriteMetrics(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
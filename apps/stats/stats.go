@@ -0,0 +1,425 @@
+/*
+Package stats periodically collects port, table, flow and queue statistics
+of the connected datapaths.
+
+Each poll produces a Sample holding the switch counters together with
+their delta and rate since the previous poll. The latest samples are
+served in the Prometheus text exposition format, and a short history of
+samples may be kept per datapath.
+*/
+package stats
+
+import (
+	"errors"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrNotConnected = errors.New("datapath not connected")
+
+// unsupported is the value of counters a switch does not maintain
+const unsupported = ^uint64(0)
+
+// Counter is a switch counter and its change since the previous sample
+type Counter struct {
+	Value uint64
+	Delta uint64
+	// Rate is the delta per second
+	Rate float64
+}
+
+// Supported tells whether the switch maintains the counter
+func (c Counter) Supported() bool {
+	return c.Value != unsupported
+}
+
+// update sets the counter from a new value, a value lower than the
+// previous one means the counter was reset and counts as the delta
+func (c *Counter) update(value uint64, prev *Counter, elapsed time.Duration) {
+	c.Value = value
+	if prev == nil || value == unsupported || !prev.Supported() {
+		return
+	}
+	if value >= prev.Value {
+		c.Delta = value - prev.Value
+	} else {
+		c.Delta = value
+	}
+	if elapsed > 0 {
+		c.Rate = float64(c.Delta) / elapsed.Seconds()
+	}
+}
+
+// updateAll updates counters from values, prev holds the counters of the
+// previous sample in the same order and is nil if there is none
+func updateAll(counters []*Counter, values []uint64, prev []*Counter, elapsed time.Duration) {
+	for i, c := range counters {
+		var before *Counter
+		if prev != nil {
+			before = prev[i]
+		}
+		c.update(values[i], before, elapsed)
+	}
+}
+
+// Port holds the counters of a port
+type Port struct {
+	RxPackets Counter
+	TxPackets Counter
+	RxBytes   Counter
+	TxBytes   Counter
+	RxDropped Counter
+	TxDropped Counter
+	RxErrors  Counter
+	TxErrors  Counter
+}
+
+func (p *Port) counters() []*Counter {
+	if p == nil {
+		return nil
+	}
+	return []*Counter{
+		&p.RxPackets, &p.TxPackets, &p.RxBytes, &p.TxBytes,
+		&p.RxDropped, &p.TxDropped, &p.RxErrors, &p.TxErrors,
+	}
+}
+
+// Table holds the counters of a flow table. Packets and bytes are summed
+// over the flows in the table at the time of the poll, they go down when
+// flows expire or are deleted and are not counters.
+type Table struct {
+	Name    string
+	Active  uint32
+	Flows   int
+	Lookups Counter
+	Matches Counter
+	Packets uint64
+	Bytes   uint64
+}
+
+func (t *Table) counters() []*Counter {
+	if t == nil {
+		return nil
+	}
+	return []*Counter{&t.Lookups, &t.Matches}
+}
+
+// QueueID identifies a queue of a datapath
+type QueueID struct {
+	Port  uint16
+	Queue uint32
+}
+
+// Queue holds the counters of a queue
+type Queue struct {
+	TxBytes   Counter
+	TxPackets Counter
+	TxErrors  Counter
+}
+
+func (q *Queue) counters() []*Counter {
+	if q == nil {
+		return nil
+	}
+	return []*Counter{&q.TxBytes, &q.TxPackets, &q.TxErrors}
+}
+
+// Sample is the result of a poll of a datapath, it must not be modified
+type Sample struct {
+	DPID   uint64
+	Time   time.Time
+	Ports  map[uint16]*Port
+	Tables map[uint8]*Table
+	Queues map[QueueID]*Queue
+}
+
+// poll holds the replies of a poll
+type poll struct {
+	time   time.Time
+	ports  []v10.PortStats
+	tables []v10.TableStats
+	flows  []v10.FlowStats
+	queues []v10.QueueStats
+}
+
+// sample computes the counters of a poll against the previous sample of
+// the datapath, which may be nil
+func (r *poll) sample(dpid uint64, prev *Sample) *Sample {
+	s := &Sample{
+		DPID:   dpid,
+		Time:   r.time,
+		Ports:  make(map[uint16]*Port),
+		Tables: make(map[uint8]*Table),
+		Queues: make(map[QueueID]*Queue),
+	}
+	if prev == nil {
+		prev = &Sample{}
+	}
+	elapsed := s.Time.Sub(prev.Time)
+
+	for _, ps := range r.ports {
+		p := &Port{}
+		updateAll(p.counters(), []uint64{
+			ps.RxPackets(), ps.TxPackets(), ps.RxBytes(), ps.TxBytes(),
+			ps.RxDropped(), ps.TxDropped(), ps.RxErrors(), ps.TxErrors(),
+		}, prev.Ports[ps.PortNumber()].counters(), elapsed)
+		s.Ports[ps.PortNumber()] = p
+	}
+
+	packets := make(map[uint8]uint64)
+	bytes := make(map[uint8]uint64)
+	flows := make(map[uint8]int)
+	for _, f := range r.flows {
+		packets[f.TableID()] += f.PacketCount()
+		bytes[f.TableID()] += f.ByteCount()
+		flows[f.TableID()]++
+	}
+	for _, ts := range r.tables {
+		id := ts.TableID()
+		t := &Table{
+			Name:    ts.Name(),
+			Active:  ts.ActiveCount(),
+			Flows:   flows[id],
+			Packets: packets[id],
+			Bytes:   bytes[id],
+		}
+		updateAll(t.counters(), []uint64{
+			ts.LookupCount(), ts.MatchedCount(),
+		}, prev.Tables[id].counters(), elapsed)
+		s.Tables[id] = t
+	}
+
+	for _, qs := range r.queues {
+		id := QueueID{Port: qs.PortNumber(), Queue: qs.QueueID()}
+		q := &Queue{}
+		updateAll(q.counters(), []uint64{
+			qs.TxBytes(), qs.TxPackets(), qs.TxErrors(),
+		}, prev.Queues[id].counters(), elapsed)
+		s.Queues[id] = q
+	}
+	return s
+}
+
+// history is a ring buffer of the samples of a datapath
+type history struct {
+	samples []*Sample
+	next    int
+	full    bool
+}
+
+func newHistory(size int) *history {
+	if size < 1 {
+		size = 1
+	}
+	return &history{samples: make([]*Sample, size)}
+}
+
+func (h *history) add(s *Sample) {
+	h.samples[h.next] = s
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+func (h *history) latest() *Sample {
+	if h.next == 0 && !h.full {
+		return nil
+	}
+	return h.samples[(h.next+len(h.samples)-1)%len(h.samples)]
+}
+
+// all returns the samples oldest first
+func (h *history) all() []*Sample {
+	if !h.full {
+		return append([]*Sample(nil), h.samples[:h.next]...)
+	}
+	return append(append([]*Sample(nil), h.samples[h.next:]...), h.samples[:h.next]...)
+}
+
+// Collector is the stats application, register it on a controller
+type Collector struct {
+	// Interval between polls of a datapath
+	Interval time.Duration
+	// HistorySize is the number of samples kept per datapath, the latest
+	// sample is always kept
+	HistorySize int
+
+	mu        sync.Mutex
+	datapaths map[uint64]*controller.Datapath
+	stops     map[uint64]chan struct{}
+	histories map[uint64]*history
+}
+
+// New returns a collector polling every 10 seconds
+func New() *Collector {
+	return &Collector{
+		Interval:  10 * time.Second,
+		datapaths: make(map[uint64]*controller.Datapath),
+		stops:     make(map[uint64]chan struct{}),
+		histories: make(map[uint64]*history),
+	}
+}
+
+// Datapaths returns the ids of the datapaths with samples in order
+func (c *Collector) Datapaths() []uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]uint64, 0, len(c.histories))
+	for id, h := range c.histories {
+		if h.latest() != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Latest returns the latest sample of a datapath
+func (c *Collector) Latest(dpid uint64) (*Sample, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.histories[dpid]
+	if !ok || h.latest() == nil {
+		return nil, false
+	}
+	return h.latest(), true
+}
+
+// History returns the samples kept for a datapath, oldest first
+func (c *Collector) History(dpid uint64) []*Sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.histories[dpid]
+	if !ok {
+		return nil
+	}
+	return h.all()
+}
+
+// request sends a stats request, a switch refusing it gives no replies
+func request(dp *controller.Datapath, req openflow.MessageDecoder) ([]openflow.MessageDecoder, error) {
+	replies, err := dp.Request(req)
+	if _, refused := err.(*controller.RequestError); refused {
+		return nil, nil
+	}
+	return replies, err
+}
+
+// collect requests the stats of a datapath
+func collect(dp *controller.Datapath) (*poll, error) {
+	r := &poll{time: time.Now()}
+
+	portReq := v10.NewStatsReuqestPort(0)
+	portReq.SetPortNumber(uint16(openflow.None))
+	replies, err := request(dp, portReq)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range replies {
+		if reply, ok := msg.(v10.StatsReplyPort); ok {
+			r.ports = append(r.ports, reply.Ports()...)
+		}
+	}
+
+	replies, err = request(dp, v10.NewStatsReuqestTable(0))
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range replies {
+		if reply, ok := msg.(v10.StatsReplyTable); ok {
+			r.tables = append(r.tables, reply.Tables()...)
+		}
+	}
+
+	flowReq := v10.NewStatsReuqestFlow(0)
+	flowReq.SetTableID(0xff)
+	flowReq.SetOutPort(uint16(openflow.None))
+	replies, err = request(dp, flowReq)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range replies {
+		if reply, ok := msg.(v10.StatsReplyFlow); ok {
+			r.flows = append(r.flows, reply.Flows()...)
+		}
+	}
+
+	queueReq := v10.NewStatsReuqestQueue(0)
+	queueReq.SetPortNumber(uint16(openflow.All))
+	queueReq.SetQueueID(v10.OFPQ_ALL)
+	replies, err = request(dp, queueReq)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range replies {
+		if reply, ok := msg.(v10.StatsReplyQueue); ok {
+			r.queues = append(r.queues, reply.Queues()...)
+		}
+	}
+	return r, nil
+}
+
+// Poll polls a datapath now and returns the new sample
+func (c *Collector) Poll(dpid uint64) (*Sample, error) {
+	c.mu.Lock()
+	dp := c.datapaths[dpid]
+	c.mu.Unlock()
+	if dp == nil {
+		return nil, ErrNotConnected
+	}
+	r, err := collect(dp)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.histories[dpid]
+	if !ok || c.datapaths[dpid] != dp {
+		return nil, ErrNotConnected
+	}
+	s := r.sample(dpid, h.latest())
+	h.add(s)
+	return s, nil
+}
+
+func (c *Collector) SwitchConnected(dp *controller.Datapath) {
+	stop := make(chan struct{})
+	c.mu.Lock()
+	c.datapaths[dp.ID()] = dp
+	c.stops[dp.ID()] = stop
+	c.histories[dp.ID()] = newHistory(c.HistorySize)
+	c.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(c.Interval)
+		defer ticker.Stop()
+		for {
+			if _, err := c.Poll(dp.ID()); err != nil && err != ErrNotConnected {
+				log.Printf("stats: datapath %s: %v", dp, err)
+			}
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// SwitchDisconnected drops the samples of the datapath, its metrics are
+// no longer served
+func (c *Collector) SwitchDisconnected(dp *controller.Datapath) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.datapaths[dp.ID()] == dp {
+		close(c.stops[dp.ID()])
+		delete(c.stops, dp.ID())
+		delete(c.datapaths, dp.ID())
+		delete(c.histories, dp.ID())
+	}
+}
@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"bytes"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/controller/controllertest"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"strings"
+	"testing"
+	"time"
+)
+
+// answer serves one poll, port 1 has received rxBytes and queue stats are
+// not supported
+func answer(t *testing.T, s *controllertest.Switch, rxBytes uint64) {
+	for i := 0; i < 4; i++ {
+		msg, err := s.Receive(time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		xid := msg.TransactionID()
+		// queue requests implement the port request interface too
+		switch req := msg.(type) {
+		case v10.StatsRequestQueue:
+			e := v10.NewError(xid)
+			e.SetType(v10.OFPET_BAD_REQUEST)
+			e.SetCode(v10.OFPBRC_BAD_STAT)
+			s.Send(e)
+		case v10.StatsRequestPort:
+			if req.PortNumber() != uint16(openflow.None) {
+				t.Errorf("port stats of port %d requested", req.PortNumber())
+			}
+			reply := v10.NewStatsReplyPort(xid)
+			p := v10.NewPortStats()
+			p.SetPortNumber(1)
+			p.SetRxBytes(rxBytes)
+			p.SetRxErrors(^uint64(0))
+			reply.AddPort(p)
+			s.Send(reply)
+		case v10.StatsRequestFlow:
+			reply := v10.NewStatsReplyFlow(xid)
+			f := v10.NewFlowStats()
+			f.SetPacketCount(rxBytes / 100)
+			reply.AddFlow(f)
+			s.Send(reply)
+		case openflow.StatsRequest:
+			reply := v10.NewStatsReplyTable(xid)
+			table := v10.NewTableStats()
+			table.SetName("classifier")
+			table.SetActiveCount(1)
+			reply.AddTable(table)
+			s.Send(reply)
+		default:
+			t.Fatalf("unexpected message %T", msg)
+		}
+	}
+}
+
+func TestCollector(t *testing.T) {
+	c := New()
+	c.Interval = time.Hour
+	c.HistorySize = 2
+	ctrl := controller.New()
+	ctrl.Register(c)
+	s, err := controllertest.Connect(ctrl, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// first poll on connect
+	answer(t, s, 1000)
+	for deadline := time.Now().Add(time.Second); len(c.History(1)) != 1; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no sample")
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	done := make(chan *Sample)
+	go func() {
+		sample, err := c.Poll(1)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- sample
+	}()
+	answer(t, s, 3000)
+	sample := <-done
+	if sample == nil {
+		t.FailNow()
+	}
+
+	rx := sample.Ports[1].RxBytes
+	if rx.Value != 3000 || rx.Delta != 2000 || rx.Rate <= 0 {
+		t.Errorf("unexpected rx bytes %+v", rx)
+	}
+	if sample.Ports[1].RxErrors.Supported() {
+		t.Errorf("unsupported counter reported %+v", sample.Ports[1].RxErrors)
+	}
+	if table := sample.Tables[0]; table.Name != "classifier" || table.Flows != 1 || table.Packets != 30 {
+		t.Errorf("unexpected table %+v", table)
+	}
+	if len(sample.Queues) != 0 {
+		t.Errorf("unexpected queues %v", sample.Queues)
+	}
+	if h := c.History(1); len(h) != 2 || h[1] != sample {
+		t.Errorf("unexpected history %v", h)
+	}
+
+	var out bytes.Buffer
+	if err := c.WriteMetrics(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# TYPE goflow_port_rx_bytes_total counter",
+		`goflow_port_rx_bytes_total{dpid="0000000000000001",port="1"} 3000`,
+		"# TYPE goflow_port_rx_bytes_per_second gauge",
+		`goflow_table_active_entries{dpid="0000000000000001",table="0"} 1`,
+		"# TYPE goflow_table_flow_packets gauge",
+		`goflow_table_flow_packets{dpid="0000000000000001",table="0"} 30`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("%q not found in:\n%s", line, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "rx_errors") || strings.Contains(out.String(), "queue") {
+		t.Errorf("unexpected metrics:\n%s", out.String())
+	}
+}
+
+func TestHistory(t *testing.T) {
+	h := newHistory(3)
+	if h.latest() != nil || len(h.all()) != 0 {
+		t.Error("unexpected samples in an empty history")
+	}
+	samples := make([]*Sample, 5)
+	for i := range samples {
+		samples[i] = &Sample{DPID: uint64(i)}
+		h.add(samples[i])
+	}
+	all := h.all()
+	if len(all) != 3 || all[0] != samples[2] || all[2] != samples[4] || h.latest() != samples[4] {
+		t.Errorf("unexpected history %v", all)
+	}
+}
@@ -0,0 +1,57 @@
+package topology
+
+import (
+	"sort"
+)
+
+func sortLinks(links []Link) {
+	sort.Slice(links, func(i, j int) bool {
+		a, b := links[i], links[j]
+		if a.Src != b.Src {
+			return a.Src.DPID < b.Src.DPID || a.Src.DPID == b.Src.DPID && a.Src.Port < b.Src.Port
+		}
+		return a.Dst.DPID < b.Dst.DPID || a.Dst.DPID == b.Dst.DPID && a.Dst.Port < b.Dst.Port
+	})
+}
+
+// ShortestPath returns the links of a path with the fewest hops from src
+// to dst over links. Ties are broken by the lowest datapath and port so
+// that the same graph always yields the same path.
+func ShortestPath(links []Link, src, dst uint64) ([]Link, error) {
+	if src == dst {
+		return []Link{}, nil
+	}
+	sorted := append([]Link(nil), links...)
+	sortLinks(sorted)
+	adjacent := make(map[uint64][]Link)
+	for _, l := range sorted {
+		adjacent[l.Src.DPID] = append(adjacent[l.Src.DPID], l)
+	}
+	// breadth first search, via keeps the link each datapath was reached by
+	via := map[uint64]Link{}
+	visited := map[uint64]bool{src: true}
+	queue := []uint64{src}
+	for len(queue) > 0 && !visited[dst] {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, l := range adjacent[cur] {
+			if visited[l.Dst.DPID] {
+				continue
+			}
+			visited[l.Dst.DPID] = true
+			via[l.Dst.DPID] = l
+			queue = append(queue, l.Dst.DPID)
+		}
+	}
+	if !visited[dst] {
+		return nil, ErrNoPath
+	}
+	var path []Link
+	for cur := dst; cur != src; cur = via[cur].Src.DPID {
+		path = append(path, via[cur])
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, nil
+}
@@ -0,0 +1,358 @@
+/*
+Package topology discovers the links between datapaths with LLDP.
+
+Probes are sent out of every port of connected switches and caught back as
+packet ins on the neighbour switch, the resulting link graph can be queried
+for shortest paths.
+*/
+package topology
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/packet"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrNoPath = errors.New("no path between datapaths")
+
+// Endpoint is a port of a datapath
+type Endpoint struct {
+	DPID uint64
+	Port uint16
+}
+
+func (e Endpoint) String() string {
+	return fmt.Sprintf("%s:%d", v10.DPIDString(e.DPID), e.Port)
+}
+
+// Link is a unidirectional link, packets sent out of Src are received on Dst
+type Link struct {
+	Src Endpoint
+	Dst Endpoint
+}
+
+func (l Link) String() string {
+	return l.Src.String() + "->" + l.Dst.String()
+}
+
+// LinkAddedHandler is notified when a link is discovered
+type LinkAddedHandler interface {
+	LinkAdded(l Link)
+}
+
+// LinkRemovedHandler is notified when a link goes down or ages out
+type LinkRemovedHandler interface {
+	LinkRemoved(l Link)
+}
+
+type probed struct {
+	link Link
+	seen time.Time
+}
+
+type datapath struct {
+	dp   *controller.Datapath
+	stop chan struct{}
+}
+
+// Discovery is the topology discovery application, register it on a
+// controller. Handlers registered on it are called from the datapath
+// event goroutines, possibly concurrently.
+type Discovery struct {
+	// Interval between two probes of a port
+	Interval time.Duration
+	// Timeout after which a link which was not probed again is removed,
+	// three intervals when zero
+	Timeout time.Duration
+
+	mu        sync.Mutex
+	datapaths map[uint64]*datapath
+	// links are indexed by source endpoint, a port has a single peer
+	links    map[Endpoint]*probed
+	handlers []interface{}
+}
+
+// New returns a discovery probing every five seconds
+func New() *Discovery {
+	return &Discovery{
+		Interval:  5 * time.Second,
+		datapaths: make(map[uint64]*datapath),
+		links:     make(map[Endpoint]*probed),
+	}
+}
+
+// Register adds a link event handler
+func (d *Discovery) Register(handler interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers = append(d.handlers, handler)
+}
+
+func (d *Discovery) timeout() time.Duration {
+	if d.Timeout > 0 {
+		return d.Timeout
+	}
+	return 3 * d.Interval
+}
+
+func (d *Discovery) SwitchConnected(dp *controller.Datapath) {
+	state := &datapath{dp: dp, stop: make(chan struct{})}
+	d.mu.Lock()
+	d.datapaths[dp.ID()] = state
+	d.mu.Unlock()
+	if err := d.installTrap(dp); err != nil {
+		log.Printf("topology: datapath %s: %v", dp, err)
+	}
+	go d.probeLoop(state)
+}
+
+func (d *Discovery) SwitchDisconnected(dp *controller.Datapath) {
+	d.mu.Lock()
+	if state, ok := d.datapaths[dp.ID()]; ok && state.dp == dp {
+		close(state.stop)
+		delete(d.datapaths, dp.ID())
+	}
+	d.mu.Unlock()
+	d.removeLinks(func(l Link) bool {
+		return l.Src.DPID == dp.ID() || l.Dst.DPID == dp.ID()
+	})
+}
+
+// installTrap sends LLDP frames to the controller ahead of any other flow
+func (d *Discovery) installTrap(dp *controller.Datapath) error {
+	match := v10.NewMatch()
+	match.SetDLType(packet.EtherTypeLLDP)
+	out := v10.NewActionOutput()
+	out.SetPort(uint16(openflow.Controller))
+	out.SetMaxLen(0xffff)
+	fm := v10.NewFlowMod(dp.NextXID())
+	fm.SetMatch(match)
+	fm.SetCommand(openflow.Add)
+	fm.SetPriority(0xffff)
+	fm.SetBufferID(v10.OFP_NO_BUFFER)
+	fm.SetOutPort(uint16(openflow.None))
+	fm.SetAction(out)
+	return dp.Send(fm)
+}
+
+func (d *Discovery) probeLoop(state *datapath) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		for _, p := range state.dp.Ports() {
+			d.probe(state.dp, p)
+		}
+		select {
+		case <-ticker.C:
+			d.expire()
+		case <-state.stop:
+			return
+		}
+	}
+}
+
+func portUp(p openflow.Port) bool {
+	return p.PortID() <= openflow.Max && p.State()&openflow.LinkDown == 0 && p.Config()&openflow.PortDown == 0
+}
+
+// probe sends an LLDP frame out of a port
+func (d *Discovery) probe(dp *controller.Datapath, p openflow.Port) {
+	if !portUp(p) {
+		return
+	}
+	portID := make([]byte, 2)
+	binary.BigEndian.PutUint16(portID, uint16(p.PortID()))
+	lldp := &packet.LLDP{
+		ChassisIDSubtype: packet.LLDPChassisIDLocal,
+		ChassisID:        []byte(v10.DPIDString(dp.ID())),
+		PortIDSubtype:    packet.LLDPPortIDLocal,
+		PortID:           portID,
+		TTL:              uint16(d.timeout() / time.Second),
+	}
+	payload, err := lldp.MarshalBinary()
+	if err != nil {
+		return
+	}
+	data, err := packet.NewEthernet(p.HWAddr(), packet.LLDPMulticast, packet.EtherTypeLLDP, payload).MarshalBinary()
+	if err != nil {
+		return
+	}
+	out := v10.NewActionOutput()
+	out.SetPort(uint16(p.PortID()))
+	po := v10.NewPacketOut(dp.NextXID())
+	po.SetBufferID(v10.OFP_NO_BUFFER)
+	po.SetInPort(uint16(openflow.None))
+	po.AddAction(out)
+	po.SetData(data)
+	if err := dp.Send(po); err != nil {
+		log.Printf("topology: datapath %s: %v", dp, err)
+	}
+}
+
+func (d *Discovery) PacketIn(dp *controller.Datapath, msg openflow.PacketIn) {
+	var eth packet.Ethernet
+	if err := eth.UnmarshalBinary(msg.Data()); err != nil || eth.EtherType != packet.EtherTypeLLDP {
+		return
+	}
+	var lldp packet.LLDP
+	if err := lldp.UnmarshalBinary(eth.Payload); err != nil {
+		return
+	}
+	if lldp.ChassisIDSubtype != packet.LLDPChassisIDLocal || lldp.PortIDSubtype != packet.LLDPPortIDLocal || len(lldp.PortID) != 2 {
+		return
+	}
+	src, err := v10.ParseDPID(string(lldp.ChassisID))
+	if err != nil {
+		return
+	}
+	link := Link{
+		Src: Endpoint{DPID: src, Port: binary.BigEndian.Uint16(lldp.PortID)},
+		Dst: Endpoint{DPID: dp.ID(), Port: msg.InPort()},
+	}
+
+	var added, removed []Link
+	d.mu.Lock()
+	// probes of other controllers or unknown switches are ignored
+	if _, ok := d.datapaths[src]; !ok {
+		d.mu.Unlock()
+		return
+	}
+	if old, ok := d.links[link.Src]; ok && old.link == link {
+		old.seen = time.Now()
+	} else {
+		if ok {
+			removed = append(removed, old.link)
+		}
+		d.links[link.Src] = &probed{link: link, seen: time.Now()}
+		added = append(added, link)
+	}
+	d.mu.Unlock()
+	d.notify(added, removed)
+}
+
+func (d *Discovery) PortStatus(dp *controller.Datapath, msg openflow.PortStatus) {
+	p := msg.Port()
+	if msg.Reason() != openflow.PortDeleted && portUp(p) {
+		// discover the new neighbour without waiting for the next round
+		d.probe(dp, p)
+		return
+	}
+	ep := Endpoint{DPID: dp.ID(), Port: uint16(p.PortID())}
+	d.removeLinks(func(l Link) bool {
+		return l.Src == ep || l.Dst == ep
+	})
+}
+
+// expire removes the links which were not probed again in time
+func (d *Discovery) expire() {
+	deadline := time.Now().Add(-d.timeout())
+	d.mu.Lock()
+	var removed []Link
+	for src, p := range d.links {
+		if p.seen.Before(deadline) {
+			removed = append(removed, p.link)
+			delete(d.links, src)
+		}
+	}
+	d.mu.Unlock()
+	d.notify(nil, removed)
+}
+
+func (d *Discovery) removeLinks(match func(Link) bool) {
+	d.mu.Lock()
+	var removed []Link
+	for src, p := range d.links {
+		if match(p.link) {
+			removed = append(removed, p.link)
+			delete(d.links, src)
+		}
+	}
+	d.mu.Unlock()
+	d.notify(nil, removed)
+}
+
+func (d *Discovery) notify(added, removed []Link) {
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	d.mu.Lock()
+	handlers := append([]interface{}(nil), d.handlers...)
+	d.mu.Unlock()
+	sortLinks(removed)
+	sortLinks(added)
+	for _, h := range handlers {
+		if h, ok := h.(LinkRemovedHandler); ok {
+			for _, l := range removed {
+				h.LinkRemoved(l)
+			}
+		}
+		if h, ok := h.(LinkAddedHandler); ok {
+			for _, l := range added {
+				h.LinkAdded(l)
+			}
+		}
+	}
+}
+
+// Switches returns the ids of the connected datapaths
+func (d *Discovery) Switches() []uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ids := make([]uint64, 0, len(d.datapaths))
+	for id := range d.datapaths {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Links returns the discovered links
+func (d *Discovery) Links() []Link {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	links := make([]Link, 0, len(d.links))
+	for _, p := range d.links {
+		links = append(links, p.link)
+	}
+	sortLinks(links)
+	return links
+}
+
+// Neighbor returns the endpoint on the other side of a port
+func (d *Discovery) Neighbor(ep Endpoint) (Endpoint, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if p, ok := d.links[ep]; ok {
+		return p.link.Dst, true
+	}
+	return Endpoint{}, false
+}
+
+// IsSwitchPort tells whether a port connects to another datapath
+func (d *Discovery) IsSwitchPort(ep Endpoint) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.links[ep]; ok {
+		return true
+	}
+	for _, p := range d.links {
+		if p.link.Dst == ep {
+			return true
+		}
+	}
+	return false
+}
+
+// ShortestPath returns the links of a path with the fewest hops from src
+// to dst, it is empty when src is dst
+func (d *Discovery) ShortestPath(src, dst uint64) ([]Link, error) {
+	return ShortestPath(d.Links(), src, dst)
+}
@@ -0,0 +1,143 @@
+package topology
+
+import (
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/controller/controllertest"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func link(src uint64, srcPort uint16, dst uint64, dstPort uint16) Link {
+	return Link{Src: Endpoint{src, srcPort}, Dst: Endpoint{dst, dstPort}}
+}
+
+func TestShortestPath(t *testing.T) {
+	// 1 - 2 - 3 - 4 and a longer way round 1 - 5 - 6 - 4
+	var links []Link
+	for _, l := range []Link{
+		link(1, 1, 2, 1), link(2, 2, 3, 1), link(3, 2, 4, 1),
+		link(1, 2, 5, 1), link(5, 2, 6, 1), link(6, 2, 4, 2),
+	} {
+		links = append(links, l, Link{Src: l.Dst, Dst: l.Src})
+	}
+	path, err := ShortestPath(links, 1, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Link{link(1, 1, 2, 1), link(2, 2, 3, 1), link(3, 2, 4, 1)}
+	if !reflect.DeepEqual(path, expected) {
+		t.Errorf("unexpected path %v", path)
+	}
+	// without 2 - 3 the other way is taken
+	path, err = ShortestPath(append(links[:2:2], links[4:]...), 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []Link{link(4, 2, 6, 2), link(6, 1, 5, 2), link(5, 1, 1, 2)}
+	if !reflect.DeepEqual(path, expected) {
+		t.Errorf("unexpected path %v", path)
+	}
+	if path, err := ShortestPath(links, 3, 3); err != nil || len(path) != 0 {
+		t.Errorf("unexpected path to itself %v %v", path, err)
+	}
+	if _, err := ShortestPath(links, 1, 7); err != ErrNoPath {
+		t.Errorf("expected no path, got %v", err)
+	}
+}
+
+type events chan string
+
+func (e events) LinkAdded(l Link)   { e <- "added " + l.String() }
+func (e events) LinkRemoved(l Link) { e <- "removed " + l.String() }
+
+// forward delivers the probe sent by from out of port as a packet in of to
+func forward(t *testing.T, from, to *controllertest.Switch, port, inPort uint16) {
+	for {
+		msg, err := from.Receive(time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		po, ok := msg.(openflow.PacketOut)
+		if !ok || po.Action()[0].(v10.ActionOutput).Port() != port {
+			continue
+		}
+		pi := v10.NewPacketIn(0)
+		pi.SetBufferID(v10.OFP_NO_BUFFER)
+		pi.SetInPort(inPort)
+		pi.SetData(po.Data())
+		to.Send(pi)
+		return
+	}
+}
+
+func expect(t *testing.T, ev events, expected string) {
+	select {
+	case e := <-ev:
+		if e != expected {
+			t.Errorf("expected %q, got %q", expected, e)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected %q", expected)
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	d := New()
+	d.Interval = time.Hour
+	ev := make(events, 10)
+	d.Register(ev)
+	c := controller.New()
+	c.Register(d)
+	s1, err := controllertest.Connect(c, 1, controllertest.Port(1), controllertest.Port(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s1.Close()
+	s2, err := controllertest.Connect(c, 2, controllertest.Port(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s2.Close()
+
+	forward(t, s1, s2, 2, 3)
+	expect(t, ev, "added 0000000000000001:2->0000000000000002:3")
+	forward(t, s2, s1, 3, 2)
+	expect(t, ev, "added 0000000000000002:3->0000000000000001:2")
+
+	if n, ok := d.Neighbor(Endpoint{1, 2}); !ok || n != (Endpoint{2, 3}) {
+		t.Errorf("unexpected neighbor %v", n)
+	}
+	if !d.IsSwitchPort(Endpoint{2, 3}) || d.IsSwitchPort(Endpoint{1, 1}) {
+		t.Error("unexpected switch ports")
+	}
+	path, err := d.ShortestPath(2, 1)
+	if err != nil || len(path) != 1 || path[0] != link(2, 3, 1, 2) {
+		t.Errorf("unexpected path %v %v", path, err)
+	}
+
+	// link down on either side removes both directions
+	p := controllertest.Port(3)
+	p.SetState(openflow.LinkDown)
+	status := v10.NewPortStatus(0)
+	status.SetReason(openflow.PortModified)
+	status.SetPort(p)
+	s2.Send(status)
+	expect(t, ev, "removed 0000000000000001:2->0000000000000002:3")
+	expect(t, ev, "removed 0000000000000002:3->0000000000000001:2")
+	if links := d.Links(); len(links) != 0 {
+		t.Errorf("unexpected links %v", links)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	d := New()
+	d.links[Endpoint{1, 1}] = &probed{link: link(1, 1, 2, 1), seen: time.Now().Add(-time.Minute)}
+	d.links[Endpoint{2, 1}] = &probed{link: link(2, 1, 1, 1), seen: time.Now()}
+	d.expire()
+	if links := d.Links(); len(links) != 1 || links[0] != link(2, 1, 1, 1) {
+		t.Errorf("unexpected links %v", links)
+	}
+}
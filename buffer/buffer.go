@@ -0,0 +1,171 @@
+/*
+Package buffer implements the packet buffers of an openflow 1.0 switch.
+
+A Pool stores the packets a switch sends to the controller in packet ins
+and hands out their buffer ids. Packet outs and flow mods referencing a
+buffer id retrieve the packet once; buffers the controller does not claim
+expire. Buffer ids embed a generation number, so that a stale id of a
+reused buffer is told apart from the packet now stored in it.
+*/
+package buffer
+
+import (
+	"errors"
+	"github.com/ksang/goflow/openflow/v10"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknown = errors.New("buffer: unknown buffer")
+	ErrEmpty   = errors.New("buffer: buffer already used")
+)
+
+// Code returns the OFPBRC code of an error returned by Retrieve
+func Code(err error) uint16 {
+	if err == ErrEmpty {
+		return v10.OFPBRC_BUFFER_EMPTY
+	}
+	return v10.OFPBRC_BUFFER_UNKNOWN
+}
+
+const (
+	// DefaultSize is the number of buffers of a pool created with a zero
+	// size
+	DefaultSize = 256
+	// DefaultTimeout is how long a packet stays buffered
+	DefaultTimeout = 5 * time.Second
+)
+
+// Packet is a buffered packet with the port it was received on
+type Packet struct {
+	InPort uint16
+	Data   []byte
+}
+
+type slot struct {
+	generation uint32
+	packet     Packet
+	stored     time.Time
+	// full tells whether the packet was not retrieved yet
+	full bool
+	// used tells whether the packet was retrieved
+	used bool
+}
+
+// Pool is a fixed number of packet buffers, it is safe for concurrent use
+type Pool struct {
+	// Timeout is how long a packet stays buffered
+	Timeout time.Duration
+	// Now returns the current time, it defaults to time.Now
+	Now func() time.Time
+
+	mu    sync.Mutex
+	slots []slot
+	next  int
+}
+
+// NewPool returns a pool of size buffers
+func NewPool(size int) *Pool {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Pool{
+		Timeout: DefaultTimeout,
+		Now:     time.Now,
+		slots:   make([]slot, size),
+	}
+}
+
+// Size returns the number of buffers, as announced in features replies
+func (p *Pool) Size() int {
+	return len(p.slots)
+}
+
+// generations returns the number of generations of a slot, so that no
+// buffer id is OFP_NO_BUFFER
+func (p *Pool) generations() uint32 {
+	return v10.OFP_NO_BUFFER / uint32(len(p.slots))
+}
+
+func (p *Pool) id(i int) uint32 {
+	return p.slots[i].generation*uint32(len(p.slots)) + uint32(i)
+}
+
+func (p *Pool) expired(s *slot, now time.Time) bool {
+	return s.full && now.Sub(s.stored) >= p.Timeout
+}
+
+// Store buffers a packet received on inPort and returns its buffer id,
+// it returns OFP_NO_BUFFER and false when every buffer holds a packet
+func (p *Pool) Store(inPort uint16, data []byte) (uint32, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.Now()
+	for n := 0; n < len(p.slots); n++ {
+		i := (p.next + n) % len(p.slots)
+		s := &p.slots[i]
+		if s.full && !p.expired(s, now) {
+			continue
+		}
+		s.generation = (s.generation + 1) % p.generations()
+		s.packet = Packet{InPort: inPort, Data: append([]byte(nil), data...)}
+		s.stored = now
+		s.full = true
+		s.used = false
+		p.next = (i + 1) % len(p.slots)
+		return p.id(i), true
+	}
+	return v10.OFP_NO_BUFFER, false
+}
+
+// Retrieve returns the packet of a buffer id and releases the buffer. It
+// fails with ErrEmpty when the packet was already retrieved and with
+// ErrUnknown when the id was never handed out, expired or was reused.
+func (p *Pool) Retrieve(id uint32) (Packet, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	i := int(id % uint32(len(p.slots)))
+	s := &p.slots[i]
+	if id == v10.OFP_NO_BUFFER || p.id(i) != id {
+		return Packet{}, ErrUnknown
+	}
+	if s.used {
+		return Packet{}, ErrEmpty
+	}
+	if !s.full || p.expired(s, p.Now()) {
+		return Packet{}, ErrUnknown
+	}
+	packet := s.packet
+	s.packet = Packet{}
+	s.full = false
+	s.used = true
+	return packet, nil
+}
+
+// Expire releases the packets buffered longer than the timeout
+func (p *Pool) Expire() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.Now()
+	for i := range p.slots {
+		if s := &p.slots[i]; p.expired(s, now) {
+			s.packet = Packet{}
+			s.full = false
+		}
+	}
+}
+
+// Len returns the number of buffered packets
+func (p *Pool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.Now()
+	n := 0
+	for i := range p.slots {
+		if s := &p.slots[i]; s.full && !p.expired(s, now) {
+			n++
+		}
+	}
+	return n
+}
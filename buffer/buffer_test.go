@@ -0,0 +1,57 @@
+package buffer
+
+import (
+	"bytes"
+	"github.com/ksang/goflow/openflow/v10"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	now := time.Unix(1000, 0)
+	p := NewPool(2)
+	p.Now = func() time.Time { return now }
+
+	data := []byte("frame")
+	a, ok := p.Store(1, data)
+	if !ok {
+		t.Fatal("buffer not stored")
+	}
+	data[0] = 'x'
+	b, _ := p.Store(2, []byte("other"))
+	if _, ok := p.Store(3, nil); ok || p.Len() != 2 {
+		t.Error("full pool stored a packet")
+	}
+
+	pkt, err := p.Retrieve(a)
+	if err != nil || pkt.InPort != 1 || !bytes.Equal(pkt.Data, []byte("frame")) {
+		t.Fatalf("unexpected packet %v %v", pkt, err)
+	}
+	if _, err := p.Retrieve(a); err != ErrEmpty || Code(err) != v10.OFPBRC_BUFFER_EMPTY {
+		t.Errorf("reused buffer answered %v", err)
+	}
+	if _, err := p.Retrieve(a + 8); err != ErrUnknown {
+		t.Errorf("unknown buffer answered %v", err)
+	}
+	if _, err := p.Retrieve(v10.OFP_NO_BUFFER); err != ErrUnknown {
+		t.Errorf("no buffer answered %v", err)
+	}
+
+	// the slot of a is reused with another generation
+	c, _ := p.Store(4, nil)
+	if c == a {
+		t.Fatal("buffer id reused")
+	}
+	if _, err := p.Retrieve(a); err != ErrUnknown {
+		t.Errorf("stale buffer answered %v", err)
+	}
+
+	now = now.Add(DefaultTimeout)
+	if _, err := p.Retrieve(b); err != ErrUnknown || Code(err) != v10.OFPBRC_BUFFER_UNKNOWN {
+		t.Errorf("expired buffer answered %v", err)
+	}
+	p.Expire()
+	if p.Len() != 0 {
+		t.Errorf("%d packets left after expiry", p.Len())
+	}
+}
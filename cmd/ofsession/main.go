@@ -0,0 +1,118 @@
+/*
+Command ofsession records controller-switch sessions and replays them.
+
+	ofsession record -listen :6653 -controller 10.0.0.1:6653 -o session.json
+	ofsession replay -role switch -target 10.0.0.1:6653 -speed 2 session.json
+
+Replay also accepts pcap and pcapng captures. Listen and target addresses
+are connection URIs such as tcp:10.0.0.1:6653, udp:10.0.0.1 or
+unix:/var/run/openvswitch/br0.mgmt, plain addresses are TCP.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ksang/goflow/dissector"
+	"github.com/ksang/goflow/pcap"
+	"github.com/ksang/goflow/session"
+	"github.com/ksang/goflow/transport"
+	"log"
+	"net"
+	"os"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: ofsession record|replay [flags]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "record":
+		record(os.Args[2:])
+	case "replay":
+		replay(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func record(args []string) {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	listen := fs.String("listen", ":6653", "address switches connect to")
+	controller := fs.String("controller", "127.0.0.1:6633", "controller address")
+	output := fs.String("o", "session.json", "recording file")
+	fs.Parse(args)
+
+	f, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	ln, err := transport.ListenURI(*listen, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Recording sessions from %s to %s in %s", *listen, *controller, *output)
+	log.Print(session.NewRecorder(*controller, session.NewWriter(f)).Serve(ln))
+}
+
+func replay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	role := fs.String("role", "switch", "side to play, switch or controller")
+	target := fs.String("target", "127.0.0.1:6633", "controller address when playing the switch, listen address otherwise")
+	speed := fs.Float64("speed", 1, "time scaling, 0 sends without delay")
+	conn := fs.Int("conn", 0, "connection of the recording to replay")
+	keepXID := fs.Bool("keep-xid", false, "send recorded xids unchanged")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	records, err := session.Load(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	r := session.NewReplayer(session.Filter(records, *conn), pcap.FromSwitch)
+	r.Speed = *speed
+	r.RewriteXID = !*keepXID
+
+	var c net.Conn
+	switch *role {
+	case "switch":
+		c, err = transport.DialURI(*target, nil)
+	case "controller":
+		r.Role = pcap.FromController
+		var ln net.Listener
+		if ln, err = transport.ListenURI(*target, nil); err == nil {
+			c, err = ln.Accept()
+			ln.Close()
+		}
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+	report, err := r.Replay(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%d sent, %d received", report.Sent, report.Received)
+	for _, m := range report.Missing {
+		log.Print("Missing:\n", dissector.Dissect(m.Data))
+	}
+	for _, data := range report.Unexpected {
+		log.Print("Unexpected:\n", dissector.Dissect(data))
+	}
+}
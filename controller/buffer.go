@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+)
+
+// Complete tells whether a packet in carries the whole packet, so that it
+// can be sent back in a packet out without its buffer
+func Complete(msg openflow.PacketIn) bool {
+	return len(msg.Data()) == int(msg.TotalLength())
+}
+
+// BufferLost tells whether err answers a message referencing a buffer the
+// switch no longer holds, because it expired or was already used
+func BufferLost(err error) bool {
+	e, ok := err.(*RequestError)
+	return ok && e.Msg.Type() == v10.OFPET_BAD_REQUEST &&
+		(e.Msg.Code() == v10.OFPBRC_BUFFER_UNKNOWN || e.Msg.Code() == v10.OFPBRC_BUFFER_EMPTY)
+}
+
+// PacketOut sends the packet of a packet in out with actions. Buffered
+// packets are released from their buffer, if the switch lost the buffer
+// the packet is sent again with the data of msg when it is complete.
+// Errors of the switch are returned as *RequestError.
+func (dp *Datapath) PacketOut(msg openflow.PacketIn, actions ...openflow.Action) error {
+	po := v10.NewPacketOut(dp.NextXID())
+	po.SetBufferID(msg.BufferID())
+	po.SetInPort(msg.InPort())
+	for _, a := range actions {
+		po.AddAction(a)
+	}
+	if msg.BufferID() == v10.OFP_NO_BUFFER {
+		po.SetData(msg.Data())
+		return dp.Check(po)
+	}
+	err := dp.Check(po)
+	if !BufferLost(err) || !Complete(msg) {
+		return err
+	}
+	po.SetTransactionID(dp.NextXID())
+	po.SetBufferID(v10.OFP_NO_BUFFER)
+	po.SetData(msg.Data())
+	return dp.Check(po)
+}
+
+// Check sends a message the switch does not answer, such as a flow mod
+// or a packet out, and waits until the switch processed it. Errors of the
+// switch are returned as *RequestError. A zero xid is replaced by a fresh
+// one.
+func (dp *Datapath) Check(msg openflow.MessageDecoder) error {
+	if msg.TransactionID() == 0 {
+		msg.SetTransactionID(dp.NextXID())
+	}
+	xid := msg.TransactionID()
+	req := &request{
+		done: make(chan error, 1),
+	}
+	dp.mu.Lock()
+	if dp.closed {
+		dp.mu.Unlock()
+		return ErrClosed
+	}
+	dp.pending[xid] = req
+	dp.mu.Unlock()
+	defer func() {
+		dp.mu.Lock()
+		delete(dp.pending, xid)
+		dp.mu.Unlock()
+	}()
+
+	if err := dp.Send(msg); err != nil {
+		return err
+	}
+	// messages are processed in order, an error answers before the
+	// barrier reply
+	if err := dp.Barrier(); err != nil {
+		return err
+	}
+	select {
+	case err := <-req.done:
+		return err
+	default:
+		return nil
+	}
+}
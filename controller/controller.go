@@ -0,0 +1,278 @@
+/*
+Package controller implements an openflow controller framework.
+
+The Controller accepts switch connections, performs the handshake and turns
+each switch into a Datapath keyed by the DPID of its features reply. Typed
+events are then fanned out to registered applications, which implement any
+of the handler interfaces of this package. Events of a datapath are
+delivered in order, one at a time, to every application in registration
+order; different datapaths are handled concurrently.
+
+Switches are read without waiting for the applications, so that replies
+and echoes are never held back: a handler may send requests and wait for
+their replies. The next event of the datapath waits for the handler to
+return though, so long running work, such as periodic polling, belongs
+in a goroutine started by the handler. Packet ins go through per datapath and
+per port rate limits to a bounded queue, the other events are delivered
+before them, and drops are counted in Datapath.PacketInStats. The miss
+send length of switches can be lowered while their queue is loaded.
+
+The limits are checked on a v10.PacketInView of the encoded packet in, so
+that dropped packet ins are never decoded. Queued ones are decoded with
+v10.Parse rather than v10.ParsePooled: handlers own the messages they are
+given and may keep them after returning, as the rest application does
+when it publishes them, so the controller cannot release them.
+
+Switches may open auxiliary connections next to the main one, as in
+OpenFlow 1.3, with a non zero auxiliary id in the features reply. Their
+messages, typically packet ins, are handled as those of the main
+connection and applications only see one Datapath. Messages to the switch
+go on the main connection, so that barriers order them.
+
+Auxiliary connections are a goflow extension of OpenFlow 1.0: the id is
+carried in a padding byte of the 1.0 features reply, which only the goflow
+agent sets. They are accepted when Controller.Auxiliary is set, otherwise
+the byte is ignored and every connection is a main connection, as plain
+1.0 switches may leave garbage in it.
+*/
+package controller
+
+import (
+	"crypto/tls"
+	"errors"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	ErrClosed    = errors.New("datapath closed")
+	ErrTimeout   = errors.New("request timed out")
+	ErrHandshake = errors.New("handshake failed")
+	// ErrNoMainConnection is returned for auxiliary connections of a
+	// datapath whose main connection is not established
+	ErrNoMainConnection = errors.New("auxiliary connection without main connection")
+)
+
+// Controller accepts switch connections and dispatches their events
+type Controller struct {
+	// EchoInterval is the keepalive period, a switch silent for three
+	// periods is disconnected
+	EchoInterval time.Duration
+	// RequestTimeout bounds the wait for replies of Datapath.Request
+	RequestTimeout time.Duration
+	// HandshakeTimeout bounds the hello and features exchange
+	HandshakeTimeout time.Duration
+	// PacketInQueue bounds the packet ins of a datapath waiting for the
+	// applications, packet ins finding it full are dropped
+	PacketInQueue int
+	// PacketInRate and PacketInBurst limit the packet ins of a datapath
+	// per second, PortPacketInRate and PortPacketInBurst those of each
+	// ingress port. Zero rates do not limit.
+	PacketInRate      float64
+	PacketInBurst     int
+	PortPacketInRate  float64
+	PortPacketInBurst int
+	// LoadMissSendLen, when not zero, is set as miss send length of
+	// switches while their packet in queue is over half full, so that
+	// packet ins carry less data
+	LoadMissSendLen uint16
+	// VerifyDPID, when set, checks the datapath id a switch claims on its
+	// connection, such as with the certificate of a TLS connection. The
+	// switch is disconnected when it fails.
+	VerifyDPID func(conn net.Conn, dpid uint64) error
+	// Auxiliary accepts auxiliary connections of goflow agents, the
+	// auxiliary id of features replies is ignored when not set
+	Auxiliary bool
+
+	mu        sync.Mutex
+	apps      []interface{}
+	datapaths map[uint64]*Datapath
+	wg        sync.WaitGroup
+}
+
+// New creates a controller with default timeouts
+func New() *Controller {
+	return &Controller{
+		EchoInterval:     15 * time.Second,
+		RequestTimeout:   10 * time.Second,
+		HandshakeTimeout: 10 * time.Second,
+		PacketInQueue:    1024,
+		datapaths:        make(map[uint64]*Datapath),
+	}
+}
+
+// Register adds an application, it receives the events of the handler
+// interfaces it implements. Applications should be registered before
+// switches connect.
+func (c *Controller) Register(app interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apps = append(c.apps, app)
+}
+
+func (c *Controller) applications() []interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.apps
+}
+
+// Datapath returns a connected datapath
+func (c *Controller) Datapath(dpid uint64) (*Datapath, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dp, ok := c.datapaths[dpid]
+	return dp, ok
+}
+
+// Datapaths returns every connected datapath
+func (c *Controller) Datapaths() []*Datapath {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dps := make([]*Datapath, 0, len(c.datapaths))
+	for _, dp := range c.datapaths {
+		dps = append(dps, dp)
+	}
+	return dps
+}
+
+// ListenAndServe listens on the TCP address and serves switches
+func (c *Controller) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return c.Serve(ln)
+}
+
+// ListenAndServeTLS listens on the TCP address for TLS connections and
+// serves switches
+func (c *Controller) ListenAndServeTLS(addr string, config *tls.Config) error {
+	ln, err := tls.Listen("tcp", addr, config)
+	if err != nil {
+		return err
+	}
+	return c.Serve(ln)
+}
+
+// Serve accepts switch connections on ln until it is closed, then waits
+// for the connected datapaths to go away
+func (c *Controller) Serve(ln net.Listener) error {
+	defer c.wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			if err := c.ServeConn(conn); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+}
+
+// ServeConn handles a single switch connection until it closes
+func (c *Controller) ServeConn(conn net.Conn) error {
+	dp := newDatapath(c, conn)
+	features, err := dp.handshake()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if c.VerifyDPID != nil {
+		if err := c.VerifyDPID(conn, features.DPID()); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	if id := features.AuxiliaryID(); id != 0 && c.Auxiliary {
+		main, ok := c.Datapath(features.DPID())
+		if !ok {
+			conn.Close()
+			return ErrNoMainConnection
+		}
+		return main.attach(id, conn)
+	}
+	dp.features = features
+	for _, p := range features.Ports() {
+		dp.ports[p.PortID()] = p
+	}
+
+	c.mu.Lock()
+	old, reconnected := c.datapaths[features.DPID()]
+	c.datapaths[features.DPID()] = dp
+	c.mu.Unlock()
+	if reconnected {
+		// the switch reconnected, the old connection is stale
+		old.Close()
+		<-old.done
+	}
+
+	go dp.dispatch()
+	dp.queue.push(event{kind: switchConnected})
+	dp.serve()
+
+	c.mu.Lock()
+	if c.datapaths[features.DPID()] == dp {
+		delete(c.datapaths, features.DPID())
+	}
+	c.mu.Unlock()
+	<-dp.done
+	return nil
+}
+
+// handshake exchanges hellos and features, echo requests are answered
+func (dp *Datapath) handshake() (openflow.FeatureReply, error) {
+	deadline := time.Now().Add(dp.controller.HandshakeTimeout)
+	dp.conn.SetDeadline(deadline)
+	defer dp.conn.SetDeadline(time.Time{})
+
+	if err := dp.Send(v10.NewHello(dp.NextXID())); err != nil {
+		return nil, err
+	}
+	helloReceived := false
+	featuresXID := dp.NextXID()
+	for {
+		data, err := openflow.ReadMessage(dp.conn)
+		if err != nil {
+			return nil, err
+		}
+		if !helloReceived {
+			if data[1] != v10.OFPT_HELLO {
+				continue
+			}
+			if data[0] < openflow.OF10_VERSION {
+				return nil, ErrHandshake
+			}
+			// the lowest common version is 1.0
+			helloReceived = true
+			if err := dp.Send(v10.NewFeatureRequest(featuresXID)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if data[0] != openflow.OF10_VERSION {
+			return nil, openflow.ErrUnsupportedVersion
+		}
+		switch data[1] {
+		case v10.OFPT_ECHO_REQUEST:
+			if err := dp.echoReply(dp.conn, data); err != nil {
+				return nil, err
+			}
+		case v10.OFPT_ERROR:
+			return nil, ErrHandshake
+		case v10.OFPT_FEATURES_REPLY:
+			msg, err := v10.Parse(data)
+			if err != nil {
+				return nil, err
+			}
+			return msg.(openflow.FeatureReply), nil
+		}
+	}
+}
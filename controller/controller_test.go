@@ -0,0 +1,398 @@
+package controller
+
+import (
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	events chan string
+}
+
+func (r *recorder) SwitchConnected(dp *Datapath) {
+	r.events <- "connected " + dp.String()
+}
+
+func (r *recorder) SwitchDisconnected(dp *Datapath) {
+	r.events <- "disconnected " + dp.String()
+}
+
+func (r *recorder) PacketIn(dp *Datapath, msg openflow.PacketIn) {
+	// reply through the datapath while handling the event
+	if _, err := dp.Request(v10.NewStatsRequestDescription(0)); err != nil {
+		r.events <- err.Error()
+		return
+	}
+	r.events <- "packet_in"
+}
+
+func (r *recorder) PortStatus(dp *Datapath, msg openflow.PortStatus) {
+	if _, ok := dp.Port(msg.Port().PortID()); ok {
+		r.events <- "port_status"
+	}
+}
+
+// unordered returns a function expecting events in any order
+func (r *recorder) unordered(t *testing.T) func(...string) {
+	return func(want ...string) {
+		missing := map[string]int{}
+		for _, w := range want {
+			missing[w]++
+		}
+		for range want {
+			select {
+			case got := <-r.events:
+				if missing[got] == 0 {
+					t.Errorf("unexpected event %q, expected %v", got, want)
+				}
+				missing[got]--
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for %v", want)
+			}
+		}
+	}
+}
+
+func (r *recorder) expecter(t *testing.T) func(string) {
+	return func(want string) {
+		select {
+		case got := <-r.events:
+			if got != want {
+				t.Errorf("got event %q, expected %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+}
+
+// fakeSwitch completes the handshake and answers description requests
+func fakeSwitch(t *testing.T, conn net.Conn, dpid uint64, aux uint8) {
+	openflow.WriteMessage(conn, v10.NewHello(1))
+	for {
+		data, err := openflow.ReadMessage(conn)
+		if err != nil {
+			return
+		}
+		msg, err := v10.Parse(data)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		switch data[1] {
+		case v10.OFPT_FEATURES_REQUEST:
+			reply := v10.NewFeatureReply(msg.TransactionID())
+			reply.SetDPID(dpid)
+			reply.SetAuxiliaryID(aux)
+			openflow.WriteMessage(conn, reply)
+			openflow.WriteMessage(conn, v10.NewPacketIn(0))
+			p, _ := v10.NewPort(3, []byte{0, 0, 0, 0, 0, 3}, "eth3")
+			status := v10.NewPortStatus(0)
+			status.SetReason(openflow.PortAdded)
+			status.SetPort(p)
+			openflow.WriteMessage(conn, status)
+		case v10.OFPT_STATS_REQUEST:
+			openflow.WriteMessage(conn, v10.NewStatsReplyDescription(msg.TransactionID()))
+		}
+	}
+}
+
+func TestController(t *testing.T) {
+	c := New()
+	r := &recorder{events: make(chan string, 10)}
+	c.Register(r)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go c.Serve(ln)
+	defer ln.Close()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// without Controller.Auxiliary the padding byte carrying the
+	// auxiliary id is ignored
+	go fakeSwitch(t, conn, 0x2a, 1)
+
+	expect := r.expecter(t)
+	unordered := r.unordered(t)
+	expect("connected 000000000000002a")
+	// port status events go before packet ins
+	unordered("packet_in", "port_status")
+	if _, ok := c.Datapath(0x2a); !ok {
+		t.Error("datapath not found")
+	}
+	conn.Close()
+	expect("disconnected 000000000000002a")
+}
+
+func TestAuxiliary(t *testing.T) {
+	c := New()
+	c.Auxiliary = true
+	r := &recorder{events: make(chan string, 10)}
+	c.Register(r)
+	expect := r.expecter(t)
+	unordered := r.unordered(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an auxiliary connection needs the main connection
+	lone, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go fakeSwitch(t, lone, 0x2b, 1)
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ServeConn(conn); err != ErrNoMainConnection {
+		t.Errorf("lone auxiliary connection answered %v", err)
+	}
+	lone.Close()
+
+	go c.Serve(ln)
+	defer ln.Close()
+	main, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go fakeSwitch(t, main, 0x2b, 0)
+	expect("connected 000000000000002b")
+	// port status events go before packet ins
+	unordered("packet_in", "port_status")
+
+	aux, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := make(chan struct{})
+	go func() {
+		fakeSwitch(t, aux, 0x2b, 1)
+		close(closed)
+	}()
+	// the packet in of the auxiliary connection is requested through the
+	// main connection
+	// port status events go before packet ins
+	unordered("packet_in", "port_status")
+	dp, _ := c.Datapath(0x2b)
+	if ids := dp.Auxiliaries(); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("auxiliary ids %v", ids)
+	}
+
+	main.Close()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Error("auxiliary connection not closed with the main connection")
+	}
+	expect("disconnected 000000000000002b")
+}
+
+// floodSwitch completes the handshake, sends packet ins of ports and a
+// port status, then answers requests and reports the miss send lengths
+// it is configured with
+func floodSwitch(t *testing.T, conn net.Conn, ports []uint16, configs chan uint16) {
+	openflow.WriteMessage(conn, v10.NewHello(1))
+	for {
+		data, err := openflow.ReadMessage(conn)
+		if err != nil {
+			return
+		}
+		msg, err := v10.Parse(data)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		switch data[1] {
+		case v10.OFPT_FEATURES_REQUEST:
+			reply := v10.NewFeatureReply(msg.TransactionID())
+			reply.SetDPID(0x2c)
+			openflow.WriteMessage(conn, reply)
+			for _, port := range ports {
+				pi := v10.NewPacketIn(0)
+				pi.SetInPort(port)
+				openflow.WriteMessage(conn, pi)
+			}
+			p, _ := v10.NewPort(3, []byte{0, 0, 0, 0, 0, 3}, "eth3")
+			status := v10.NewPortStatus(0)
+			status.SetPort(p)
+			openflow.WriteMessage(conn, status)
+		case v10.OFPT_BARRIER_REQUEST:
+			openflow.WriteMessage(conn, v10.NewBarrierReply(msg.TransactionID()))
+		case v10.OFPT_GET_CONFIG_REQUEST:
+			reply := v10.NewGetConfigReply(msg.TransactionID())
+			reply.SetMissSendLength(128)
+			openflow.WriteMessage(conn, reply)
+		case v10.OFPT_SET_CONFIG:
+			configs <- msg.(openflow.SetConfig).MissSendLength()
+		}
+	}
+}
+
+// blocker holds the first packet in until released, then sends a
+// barrier from each
+type blocker struct {
+	release chan struct{}
+	events  chan string
+	once    sync.Once
+}
+
+func (b *blocker) PacketIn(dp *Datapath, msg openflow.PacketIn) {
+	b.once.Do(func() {
+		<-b.release
+	})
+	if err := dp.Barrier(); err != nil {
+		b.events <- err.Error()
+		return
+	}
+	b.events <- "packet_in"
+}
+
+func (b *blocker) PortStatus(dp *Datapath, msg openflow.PortStatus) {
+	b.events <- "port_status"
+}
+
+// connPair returns both ends of a TCP connection
+func connPair(t *testing.T) (net.Conn, net.Conn) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	peer, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, peer
+}
+
+func waitStats(t *testing.T, c *Controller, ok func(PacketInStats) bool) PacketInStats {
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if dp, found := c.Datapath(0x2c); found {
+			if stats := dp.PacketInStats(); ok(stats) || time.Now().After(deadline) {
+				return stats
+			}
+		} else if time.Now().After(deadline) {
+			t.Fatal("datapath not connected")
+		}
+	}
+}
+
+func TestPacketInLimits(t *testing.T) {
+	c := New()
+	c.PacketInRate = 0.001
+	c.PacketInBurst = 10
+	c.PortPacketInRate = 0.001
+	c.PortPacketInBurst = 4
+	b := &blocker{release: make(chan struct{}), events: make(chan string, 64)}
+	close(b.release)
+	c.Register(b)
+	conn, peer := connPair(t)
+	defer conn.Close()
+	var ports []uint16
+	for _, port := range []uint16{1, 2, 3} {
+		for i := 0; i < 8; i++ {
+			ports = append(ports, port)
+		}
+	}
+	go floodSwitch(t, peer, ports, make(chan uint16, 4))
+	go c.ServeConn(conn)
+
+	// each port passes 4 packet ins and the datapath 10 of them
+	stats := waitStats(t, c, func(s PacketInStats) bool {
+		return s.Received == 24 && s.Queued == 0
+	})
+	if stats.Received != 24 || stats.Limited != 14 || stats.Overflowed != 0 {
+		t.Errorf("stats %+v", stats)
+	}
+	packetIns := 0
+	for i := 0; i < 11; i++ {
+		select {
+		case ev := <-b.events:
+			if ev == "packet_in" {
+				packetIns++
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("events missing")
+		}
+	}
+	if packetIns != 10 {
+		t.Errorf("%d packet ins delivered", packetIns)
+	}
+}
+
+func TestPacketInDecoding(t *testing.T) {
+	q := newQueue(1, nil)
+	decoded := 0
+	decode := func() (openflow.MessageDecoder, error) {
+		decoded++
+		return v10.NewPacketIn(0), nil
+	}
+	// only the queued packet in is decoded
+	q.pushPacketIn(false, decode)
+	q.pushPacketIn(true, decode)
+	q.pushPacketIn(true, decode)
+	if decoded != 1 {
+		t.Errorf("%d packet ins decoded", decoded)
+	}
+	if err := newQueue(1, nil).pushPacketIn(true, func() (openflow.MessageDecoder, error) {
+		return nil, openflow.ErrInvalidPacketLength
+	}); err != openflow.ErrInvalidPacketLength {
+		t.Errorf("decoding failed with %v", err)
+	}
+}
+
+func TestPacketInBackpressure(t *testing.T) {
+	c := New()
+	c.PacketInQueue = 8
+	c.LoadMissSendLen = 64
+	b := &blocker{release: make(chan struct{}), events: make(chan string, 64)}
+	c.Register(b)
+	conn, peer := connPair(t)
+	defer conn.Close()
+	configs := make(chan uint16, 4)
+	go floodSwitch(t, peer, make([]uint16, 50), configs)
+	go c.ServeConn(conn)
+
+	// the switch is read and configured while the applications are busy
+	expectConfig := func(want uint16) {
+		select {
+		case got := <-configs:
+			if got != want {
+				t.Errorf("miss send length %d, expected %d", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("miss send length %d not set", want)
+		}
+	}
+	expectConfig(64)
+	stats := waitStats(t, c, func(s PacketInStats) bool {
+		return s.Received == 50
+	})
+	// a packet in is held by the application, at most 8 wait
+	if stats.Received != 50 || stats.Queued != 8 && stats.Queued != 7 || stats.Overflowed+uint64(stats.Queued) != 49 {
+		t.Errorf("stats %+v", stats)
+	}
+
+	// the port status goes before the queued packet ins
+	close(b.release)
+	expect := (&recorder{events: b.events}).unordered(t)
+	expect("packet_in", "port_status")
+	for i := 0; i < stats.Queued; i++ {
+		expect("packet_in")
+	}
+	expectConfig(128)
+}
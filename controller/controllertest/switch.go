@@ -0,0 +1,111 @@
+/*
+Package controllertest provides a scripted switch to test controller
+applications without a real datapath.
+*/
+package controllertest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"net"
+	"time"
+)
+
+var ErrTimeout = errors.New("no message received")
+
+// Switch is a fake switch connected to a controller, it completes the
+// handshake and answers echo and barrier requests by itself
+type Switch struct {
+	conn  net.Conn
+	dpid  uint64
+	ports []openflow.Port
+	msgs  chan openflow.MessageDecoder
+}
+
+// Port returns a port numbered no with a derived hardware address
+func Port(no uint16) openflow.Port {
+	p, _ := v10.NewPort(openflow.PortID(no), net.HardwareAddr{0x02, 0, 0, 0, byte(no >> 8), byte(no)}, fmt.Sprintf("eth%d", no))
+	return p
+}
+
+// Connect connects a switch to c and waits until its datapath is up
+func Connect(c *controller.Controller, dpid uint64, ports ...openflow.Port) (*Switch, error) {
+	a, b := net.Pipe()
+	s := &Switch{
+		conn:  b,
+		dpid:  dpid,
+		ports: ports,
+		msgs:  make(chan openflow.MessageDecoder, 1024),
+	}
+	go c.ServeConn(a)
+	go s.read()
+	if err := s.Send(v10.NewHello(0)); err != nil {
+		return nil, err
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if _, ok := c.Datapath(dpid); ok {
+			return s, nil
+		}
+		time.Sleep(time.Millisecond)
+	}
+	s.Close()
+	return nil, ErrTimeout
+}
+
+func (s *Switch) read() {
+	defer close(s.msgs)
+	for {
+		data, err := openflow.ReadMessage(s.conn)
+		if err != nil {
+			return
+		}
+		msg, err := v10.Parse(data)
+		if err != nil {
+			continue
+		}
+		xid := msg.TransactionID()
+		switch data[1] {
+		case v10.OFPT_HELLO:
+		case v10.OFPT_FEATURES_REQUEST:
+			reply := v10.NewFeatureReply(xid)
+			reply.SetDPID(s.dpid)
+			reply.SetNumTables(1)
+			for _, p := range s.ports {
+				reply.AddPort(p)
+			}
+			s.Send(reply)
+		case v10.OFPT_ECHO_REQUEST:
+			s.Send(v10.NewEchoReply(xid))
+		case v10.OFPT_BARRIER_REQUEST:
+			s.Send(v10.NewBarrierReply(xid))
+		default:
+			s.msgs <- msg
+		}
+	}
+}
+
+// Send sends a message to the controller
+func (s *Switch) Send(msg openflow.MessageDecoder) error {
+	return openflow.WriteMessage(s.conn, msg)
+}
+
+// Receive returns the next message sent by the controller
+func (s *Switch) Receive(timeout time.Duration) (openflow.MessageDecoder, error) {
+	select {
+	case msg, ok := <-s.msgs:
+		if !ok {
+			return nil, controller.ErrClosed
+		}
+		return msg, nil
+	case <-time.After(timeout):
+		return nil, ErrTimeout
+	}
+}
+
+// Close disconnects the switch
+func (s *Switch) Close() error {
+	return s.conn.Close()
+}
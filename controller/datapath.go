@@ -0,0 +1,370 @@
+package controller
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"log"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RequestError is returned by Datapath.Request when the switch answers
+// with an error message
+type RequestError struct {
+	Msg openflow.Error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("openflow error %s, code %d", v10.ErrorTypeName(e.Msg.Type()), e.Msg.Code())
+}
+
+type request struct {
+	replies []openflow.MessageDecoder
+	done    chan error
+}
+
+// Datapath is a connected switch, messages of its auxiliary connections
+// are handled as if they came on the main connection
+type Datapath struct {
+	controller *Controller
+	conn       net.Conn
+	features   openflow.FeatureReply
+	xid        uint32
+	queue      *queue
+	limiter    *limiter
+	// done is closed once the applications saw the disconnection
+	done chan struct{}
+
+	// tuning serializes the miss send length changes, missSendLen is the
+	// length restored when lowered is cleared
+	tuning      sync.Mutex
+	lowered     bool
+	missSendLen uint16
+
+	// writer batches the messages of the main connection, wmu serializes
+	// the writes of auxiliary connections
+	writer    *openflow.Writer
+	wmu       sync.Mutex
+	mu        sync.Mutex
+	ports     map[openflow.PortID]openflow.Port
+	pending   map[uint32]*request
+	auxiliary map[uint8]net.Conn
+	auxWG     sync.WaitGroup
+	closed    bool
+}
+
+func newDatapath(c *Controller, conn net.Conn) *Datapath {
+	dp := &Datapath{
+		controller: c,
+		conn:       conn,
+		writer:     openflow.NewWriter(conn),
+		limiter:    newLimiter(c),
+		done:       make(chan struct{}),
+		ports:      make(map[openflow.PortID]openflow.Port),
+		pending:    make(map[uint32]*request),
+		auxiliary:  make(map[uint8]net.Conn),
+	}
+	var load func()
+	if c.LoadMissSendLen != 0 {
+		load = dp.tuneMissSendLen
+	}
+	dp.queue = newQueue(c.PacketInQueue, load)
+	return dp
+}
+
+// ID returns the datapath id
+func (dp *Datapath) ID() uint64 {
+	return dp.features.DPID()
+}
+
+func (dp *Datapath) String() string {
+	return v10.DPIDString(dp.ID())
+}
+
+// Features returns the features reply received at connection
+func (dp *Datapath) Features() openflow.FeatureReply {
+	return dp.features
+}
+
+// RemoteAddr returns the address of the switch
+func (dp *Datapath) RemoteAddr() net.Addr {
+	return dp.conn.RemoteAddr()
+}
+
+// Ports returns the current ports, kept up to date with port status
+func (dp *Datapath) Ports() []openflow.Port {
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+	ports := make([]openflow.Port, 0, len(dp.ports))
+	for _, p := range dp.ports {
+		ports = append(ports, p)
+	}
+	return ports
+}
+
+// Port returns a port by number
+func (dp *Datapath) Port(id openflow.PortID) (openflow.Port, bool) {
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+	p, ok := dp.ports[id]
+	return p, ok
+}
+
+// Auxiliaries returns the ids of the connected auxiliary connections
+func (dp *Datapath) Auxiliaries() []uint8 {
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+	ids := make([]uint8, 0, len(dp.auxiliary))
+	for id := range dp.auxiliary {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// NextXID returns a transaction id unused on this datapath
+func (dp *Datapath) NextXID() uint32 {
+	return atomic.AddUint32(&dp.xid, 1)
+}
+
+// Send writes a message to the switch on the main connection, messages
+// sent concurrently are written together
+func (dp *Datapath) Send(msg openflow.MessageDecoder) error {
+	return dp.writer.WriteMessages(msg)
+}
+
+// SendMessages writes several messages to the switch with a single write
+// on the main connection, such as the flow mods of a table update
+func (dp *Datapath) SendMessages(msgs ...openflow.MessageDecoder) error {
+	return dp.writer.WriteMessages(msgs...)
+}
+
+func (dp *Datapath) write(conn net.Conn, msg openflow.MessageDecoder) error {
+	if conn == dp.conn {
+		return dp.writer.WriteMessages(msg)
+	}
+	dp.wmu.Lock()
+	defer dp.wmu.Unlock()
+	return openflow.WriteMessage(conn, msg)
+}
+
+// Request sends a request and waits for its replies, stats replies
+// split in several messages are all returned. A zero xid is replaced
+// by a fresh one.
+func (dp *Datapath) Request(msg openflow.MessageDecoder) ([]openflow.MessageDecoder, error) {
+	if msg.TransactionID() == 0 {
+		msg.SetTransactionID(dp.NextXID())
+	}
+	xid := msg.TransactionID()
+	req := &request{
+		done: make(chan error, 1),
+	}
+	dp.mu.Lock()
+	if dp.closed {
+		dp.mu.Unlock()
+		return nil, ErrClosed
+	}
+	dp.pending[xid] = req
+	dp.mu.Unlock()
+	forget := func() {
+		dp.mu.Lock()
+		delete(dp.pending, xid)
+		dp.mu.Unlock()
+	}
+
+	if err := dp.Send(msg); err != nil {
+		forget()
+		return nil, err
+	}
+	select {
+	case err := <-req.done:
+		return req.replies, err
+	case <-time.After(dp.controller.RequestTimeout):
+		forget()
+		return nil, ErrTimeout
+	}
+}
+
+// Barrier waits until the switch processed every previous message
+func (dp *Datapath) Barrier() error {
+	_, err := dp.Request(v10.NewBarrierRequest(0))
+	return err
+}
+
+// Close disconnects the switch, with its auxiliary connections
+func (dp *Datapath) Close() error {
+	return dp.conn.Close()
+}
+
+func (dp *Datapath) echoReply(conn net.Conn, request []byte) error {
+	reply := v10.NewEchoReply(binary.BigEndian.Uint32(request[4:8]))
+	if len(request) > openflow.OF_HEADER_SIZE {
+		reply.SetData(request[openflow.OF_HEADER_SIZE:])
+	}
+	return dp.write(conn, reply)
+}
+
+// keepalive sends echo requests on conn until stop closes
+func (dp *Datapath) keepalive(conn net.Conn, stop chan struct{}) {
+	ticker := time.NewTicker(dp.controller.EchoInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := dp.write(conn, v10.NewEchoRequest(dp.NextXID())); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// serve reads the main connection until it fails, the auxiliary
+// connections are closed with it
+func (dp *Datapath) serve() {
+	defer func() {
+		dp.mu.Lock()
+		dp.closed = true
+		for xid, req := range dp.pending {
+			req.done <- ErrClosed
+			delete(dp.pending, xid)
+		}
+		for _, conn := range dp.auxiliary {
+			conn.Close()
+		}
+		dp.mu.Unlock()
+		dp.auxWG.Wait()
+		dp.queue.push(event{kind: switchDisconnected})
+	}()
+	dp.read(dp.conn)
+}
+
+// attach serves an auxiliary connection until it fails, a previous
+// connection with the same id is replaced
+func (dp *Datapath) attach(id uint8, conn net.Conn) error {
+	dp.mu.Lock()
+	if dp.closed {
+		dp.mu.Unlock()
+		conn.Close()
+		return ErrClosed
+	}
+	if old, ok := dp.auxiliary[id]; ok {
+		old.Close()
+	}
+	dp.auxiliary[id] = conn
+	dp.auxWG.Add(1)
+	dp.mu.Unlock()
+	defer dp.auxWG.Done()
+
+	dp.read(conn)
+	dp.mu.Lock()
+	if dp.auxiliary[id] == conn {
+		delete(dp.auxiliary, id)
+	}
+	dp.mu.Unlock()
+	return nil
+}
+
+// read handles the messages of a connection of the datapath until it
+// fails
+func (dp *Datapath) read(conn net.Conn) {
+	stop := make(chan struct{})
+	go dp.keepalive(conn, stop)
+	defer func() {
+		close(stop)
+		conn.Close()
+	}()
+	for {
+		conn.SetReadDeadline(time.Now().Add(3 * dp.controller.EchoInterval))
+		data, err := openflow.ReadMessage(conn)
+		if err != nil {
+			return
+		}
+		switch data[1] {
+		case v10.OFPT_ECHO_REQUEST:
+			if err := dp.echoReply(conn, data); err != nil {
+				return
+			}
+			continue
+		case v10.OFPT_PACKET_IN:
+			if err := dp.packetIn(data); err != nil {
+				log.Printf("datapath %s: %v", dp, err)
+			}
+			continue
+		}
+		msg, err := v10.Parse(data)
+		if err != nil {
+			log.Printf("datapath %s: %v", dp, err)
+			continue
+		}
+		if v10.IsReply(data[1]) && dp.reply(msg) {
+			continue
+		}
+		dp.handle(msg)
+	}
+}
+
+// reply hands a message to the request waiting for it
+func (dp *Datapath) reply(msg openflow.MessageDecoder) bool {
+	dp.mu.Lock()
+	defer dp.mu.Unlock()
+	req, ok := dp.pending[msg.TransactionID()]
+	if !ok {
+		return false
+	}
+	switch m := msg.(type) {
+	case openflow.Error:
+		delete(dp.pending, msg.TransactionID())
+		req.done <- &RequestError{Msg: m}
+		return true
+	case openflow.StatsReply:
+		req.replies = append(req.replies, msg)
+		if m.Flags()&v10.OFPSF_REPLY_MORE != 0 {
+			return true
+		}
+	default:
+		req.replies = append(req.replies, msg)
+	}
+	delete(dp.pending, msg.TransactionID())
+	req.done <- nil
+	return true
+}
+
+// packetIn queues a packet in, the rate limits read its ingress port from
+// a view of data so that dropped packet ins are not decoded
+func (dp *Datapath) packetIn(data []byte) error {
+	v, err := v10.NewPacketInView(data)
+	if err != nil {
+		return err
+	}
+	allowed := dp.limiter.allow(v.InPort(), time.Now())
+	return dp.queue.pushPacketIn(allowed, func() (openflow.MessageDecoder, error) {
+		return v10.Parse(data)
+	})
+}
+
+// handle queues the event of an asynchronous message other than a packet
+// in
+func (dp *Datapath) handle(msg openflow.MessageDecoder) {
+	switch m := msg.(type) {
+	case openflow.PortStatus:
+		dp.mu.Lock()
+		if m.Reason() == openflow.PortDeleted {
+			delete(dp.ports, m.Port().PortID())
+		} else {
+			dp.ports[m.Port().PortID()] = m.Port()
+		}
+		dp.mu.Unlock()
+		dp.queue.push(event{kind: portStatus, msg: m})
+	case openflow.FlowRemoved:
+		dp.queue.push(event{kind: flowRemoved, msg: m})
+	case openflow.Error:
+		dp.queue.push(event{kind: errorMessage, msg: m})
+	}
+}
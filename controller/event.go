@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"github.com/ksang/goflow/openflow"
+)
+
+// SwitchConnectedHandler is notified once a switch completed the handshake
+type SwitchConnectedHandler interface {
+	SwitchConnected(dp *Datapath)
+}
+
+// SwitchDisconnectedHandler is notified when a switch connection closes,
+// it is the last event of the datapath
+type SwitchDisconnectedHandler interface {
+	SwitchDisconnected(dp *Datapath)
+}
+
+type PacketInHandler interface {
+	PacketIn(dp *Datapath, msg openflow.PacketIn)
+}
+
+// PortStatusHandler is notified of port changes, Datapath.Ports is
+// already updated when it is called
+type PortStatusHandler interface {
+	PortStatus(dp *Datapath, msg openflow.PortStatus)
+}
+
+type FlowRemovedHandler interface {
+	FlowRemoved(dp *Datapath, msg openflow.FlowRemoved)
+}
+
+// ErrorHandler is notified of errors which do not answer a pending
+// Datapath.Request
+type ErrorHandler interface {
+	Error(dp *Datapath, msg openflow.Error)
+}
+
+type eventKind int
+
+const (
+	switchConnected eventKind = iota
+	switchDisconnected
+	packetIn
+	portStatus
+	flowRemoved
+	errorMessage
+)
+
+type event struct {
+	kind eventKind
+	msg  openflow.MessageDecoder
+}
+
+// dispatch delivers the events of a datapath in order, packet ins come
+// after the other events
+func (dp *Datapath) dispatch() {
+	defer close(dp.done)
+	for {
+		ev, ok := dp.queue.pop()
+		if !ok {
+			return
+		}
+		for _, app := range dp.controller.applications() {
+			deliver(dp, app, ev)
+		}
+	}
+}
+
+func deliver(dp *Datapath, app interface{}, ev event) {
+	switch ev.kind {
+	case switchConnected:
+		if h, ok := app.(SwitchConnectedHandler); ok {
+			h.SwitchConnected(dp)
+		}
+	case switchDisconnected:
+		if h, ok := app.(SwitchDisconnectedHandler); ok {
+			h.SwitchDisconnected(dp)
+		}
+	case packetIn:
+		if h, ok := app.(PacketInHandler); ok {
+			h.PacketIn(dp, ev.msg.(openflow.PacketIn))
+		}
+	case portStatus:
+		if h, ok := app.(PortStatusHandler); ok {
+			h.PortStatus(dp, ev.msg.(openflow.PortStatus))
+		}
+	case flowRemoved:
+		if h, ok := app.(FlowRemovedHandler); ok {
+			h.FlowRemoved(dp, ev.msg.(openflow.FlowRemoved))
+		}
+	case errorMessage:
+		if h, ok := app.(ErrorHandler); ok {
+			h.Error(dp, ev.msg.(openflow.Error))
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package controller
+
+import (
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"sync"
+	"time"
+)
+
+// PacketInStats counts the packet ins of a datapath
+type PacketInStats struct {
+	Received uint64
+	// Limited packet ins went over a rate limit, Overflowed found the
+	// queue full, Discarded were queued when the switch disconnected
+	Limited    uint64
+	Overflowed uint64
+	Discarded  uint64
+	// Queued packet ins wait for the applications
+	Queued int
+}
+
+// bucket is a token bucket, it holds up to burst tokens and gains rate
+// tokens per second
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+func (b *bucket) take(now time.Time) bool {
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// limiter applies the packet in rate limits of the controller to a
+// datapath
+type limiter struct {
+	mu       sync.Mutex
+	datapath *bucket
+	ports    map[uint16]*bucket
+	rate     float64
+	burst    int
+}
+
+func newLimiter(c *Controller) *limiter {
+	l := &limiter{
+		ports: make(map[uint16]*bucket),
+		rate:  c.PortPacketInRate,
+		burst: c.PortPacketInBurst,
+	}
+	if c.PacketInRate > 0 {
+		l.datapath = newBucket(c.PacketInRate, c.PacketInBurst)
+	}
+	return l
+}
+
+// allow tells whether a packet in of port is within the limits, a packet
+// in over the port limit does not take a token of the datapath
+func (l *limiter) allow(port uint16, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate > 0 {
+		b, ok := l.ports[port]
+		if !ok {
+			b = newBucket(l.rate, l.burst)
+			l.ports[port] = b
+		}
+		if !b.take(now) {
+			return false
+		}
+	}
+	return l.datapath == nil || l.datapath.take(now)
+}
+
+// queue holds the events of a datapath waiting for the applications.
+// Other events go before packet ins, which are dropped when the queue is
+// full, so that reading the switch never waits for the applications.
+type queue struct {
+	mu        sync.Mutex
+	control   []event
+	packetIns []event
+	max       int
+	stats     PacketInStats
+	// loaded is set over half of max and cleared under a quarter
+	loaded bool
+	closed bool
+	ready  chan struct{}
+	// load is called in a goroutine when loaded changes
+	load func()
+}
+
+func newQueue(max int, load func()) *queue {
+	if max < 1 {
+		max = 1
+	}
+	return &queue{
+		max:   max,
+		ready: make(chan struct{}, 1),
+		load:  load,
+	}
+}
+
+func (q *queue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// push queues an event other than a packet in
+func (q *queue) push(ev event) {
+	q.mu.Lock()
+	q.control = append(q.control, ev)
+	q.mu.Unlock()
+	q.signal()
+}
+
+// pushPacketIn queues a packet in within the rate limits unless the queue
+// is full, decode is only called for the packet ins queued
+func (q *queue) pushPacketIn(allowed bool, decode func() (openflow.MessageDecoder, error)) error {
+	q.mu.Lock()
+	if !allowed {
+		q.stats.Received++
+		q.stats.Limited++
+		q.mu.Unlock()
+		return nil
+	}
+	if len(q.packetIns) >= q.max {
+		q.stats.Received++
+		q.stats.Overflowed++
+		q.mu.Unlock()
+		return nil
+	}
+	msg, err := decode()
+	if err != nil {
+		q.mu.Unlock()
+		return err
+	}
+	q.stats.Received++
+	q.packetIns = append(q.packetIns, event{kind: packetIn, msg: msg})
+	changed := !q.loaded && len(q.packetIns) > q.max/2
+	if changed {
+		q.loaded = true
+	}
+	q.mu.Unlock()
+	q.signal()
+	if changed && q.load != nil {
+		go q.load()
+	}
+	return nil
+}
+
+// isLoaded tells whether the packet ins are piling up
+func (q *queue) isLoaded() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.loaded
+}
+
+// pop waits for the next event, it returns false after the disconnection
+// was popped, the packet ins still queued are then discarded
+func (q *queue) pop() (event, bool) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return event{}, false
+		}
+		var ev event
+		var ok, changed bool
+		if len(q.control) > 0 {
+			ev, ok = q.control[0], true
+			q.control[0] = event{}
+			q.control = q.control[1:]
+			if ev.kind == switchDisconnected {
+				q.closed = true
+				q.stats.Discarded += uint64(len(q.packetIns))
+				q.packetIns = nil
+			}
+		} else if len(q.packetIns) > 0 {
+			ev, ok = q.packetIns[0], true
+			q.packetIns[0] = event{}
+			q.packetIns = q.packetIns[1:]
+			changed = q.loaded && len(q.packetIns) < q.max/4
+			if changed {
+				q.loaded = false
+			}
+		}
+		q.mu.Unlock()
+		if changed && q.load != nil {
+			go q.load()
+		}
+		if ok {
+			return ev, true
+		}
+		<-q.ready
+	}
+}
+
+// PacketInStats returns the packet in counters of the datapath
+func (dp *Datapath) PacketInStats() PacketInStats {
+	dp.queue.mu.Lock()
+	defer dp.queue.mu.Unlock()
+	stats := dp.queue.stats
+	stats.Queued = len(dp.queue.packetIns)
+	return stats
+}
+
+// tuneMissSendLen lowers the miss send length of the switch to the load
+// value of the controller while the packet in queue is loaded, and then
+// restores it, the configuration flags are kept
+func (dp *Datapath) tuneMissSendLen() {
+	dp.tuning.Lock()
+	defer dp.tuning.Unlock()
+	loaded := dp.queue.isLoaded()
+	if loaded == dp.lowered {
+		return
+	}
+	replies, err := dp.Request(v10.NewGetConfigRequest(0))
+	if err != nil || len(replies) == 0 {
+		return
+	}
+	current, ok := replies[0].(openflow.GetConfigReply)
+	if !ok {
+		return
+	}
+	config := v10.NewSetConfig(dp.NextXID())
+	config.SetFlags(current.Flags())
+	if loaded {
+		dp.missSendLen = current.MissSendLength()
+		config.SetMissSendLength(dp.controller.LoadMissSendLen)
+	} else {
+		config.SetMissSendLength(dp.missSendLen)
+	}
+	if dp.Send(config) == nil {
+		dp.lowered = loaded
+	}
+}
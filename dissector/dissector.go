@@ -0,0 +1,665 @@
+/*
+Package dissector renders openflow messages as a tree of fields, in the way
+of a protocol analyzer. Every field shows its byte offsets in the message,
+the decoded value and its meaning, e.g.
+
+	[8:12] wildcards: 0x003820ff (in_port|dl_vlan|...)
+
+Unknown versions, unknown types and truncated messages are shown with
+as many fields as can be decoded, followed by the raw bytes.
+*/
+package dissector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"io"
+	"net"
+	"strings"
+)
+
+// Field is a node of the dissected tree
+type Field struct {
+	Name     string
+	Offset   int
+	Length   int
+	Value    string
+	Meaning  string
+	Children []*Field
+}
+
+func (f *Field) add(name string, offset, length int, value, meaning string) *Field {
+	c := &Field{
+		Name:    name,
+		Offset:  offset,
+		Length:  length,
+		Value:   value,
+		Meaning: meaning,
+	}
+	f.Children = append(f.Children, c)
+	return c
+}
+
+// String renders the tree, one field per line
+func (f *Field) String() string {
+	var buf bytes.Buffer
+	f.WriteTo(&buf)
+	return buf.String()
+}
+
+// WriteTo writes the rendered tree to w
+func (f *Field) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	f.write(&buf, 0)
+	return buf.WriteTo(w)
+}
+
+func (f *Field) write(buf *bytes.Buffer, depth int) {
+	fmt.Fprintf(buf, "%s[%d:%d] %s", strings.Repeat("  ", depth), f.Offset, f.Offset+f.Length, f.Name)
+	if f.Value != "" {
+		fmt.Fprintf(buf, ": %s", f.Value)
+	}
+	if f.Meaning != "" {
+		fmt.Fprintf(buf, " (%s)", f.Meaning)
+	}
+	buf.WriteByte('\n')
+	for _, c := range f.Children {
+		c.write(buf, depth+1)
+	}
+}
+
+func be(data []byte, off, size int) uint64 {
+	var v uint64
+	for _, b := range data[off : off+size] {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+func (f *Field) num(data []byte, name string, off, size int, meaning string) *Field {
+	return f.add(name, off, size, fmt.Sprint(be(data, off, size)), meaning)
+}
+
+func (f *Field) hex(data []byte, name string, off, size int, meaning string) *Field {
+	// empty flag sets render as "0", which says nothing more than the value
+	if meaning == "0" {
+		meaning = ""
+	}
+	return f.add(name, off, size, fmt.Sprintf("0x%0*x", size*2, be(data, off, size)), meaning)
+}
+
+func (f *Field) mac(data []byte, name string, off int, meaning string) *Field {
+	return f.add(name, off, 6, net.HardwareAddr(data[off:off+6]).String(), meaning)
+}
+
+func (f *Field) ip(data []byte, name string, off int, meaning string) *Field {
+	return f.add(name, off, 4, net.IP(data[off:off+4]).String(), meaning)
+}
+
+func (f *Field) str(data []byte, name string, off, size int) *Field {
+	s := strings.TrimRight(string(data[off:off+size]), "\x00")
+	return f.add(name, off, size, fmt.Sprintf("%q", s), "")
+}
+
+func (f *Field) port(data []byte, name string, off int) *Field {
+	p := openflow.PortID(be(data, off, 2))
+	meaning := ""
+	if p.IsReserved() {
+		meaning = p.String()
+	}
+	return f.add(name, off, 2, fmt.Sprint(uint16(p)), meaning)
+}
+
+// raw shows bytes as hex rows of 16 bytes
+func (f *Field) raw(data []byte, name string, off, end int) *Field {
+	if end <= off {
+		return nil
+	}
+	r := f.add(name, off, end-off, fmt.Sprintf("%d bytes", end-off), "")
+	for pos := off; pos < end; pos += 16 {
+		row := pos + 16
+		if row > end {
+			row = end
+		}
+		r.add("", pos, row-pos, hex.EncodeToString(data[pos:row]), "")
+	}
+	return r
+}
+
+// minBodyLength is the fixed part of each message type after the header
+var minBodyLength = map[uint8]int{
+	v10.OFPT_ERROR:                    4,
+	v10.OFPT_VENDOR:                   4,
+	v10.OFPT_FEATURES_REPLY:           24,
+	v10.OFPT_GET_CONFIG_REPLY:         4,
+	v10.OFPT_SET_CONFIG:               4,
+	v10.OFPT_PACKET_IN:                10,
+	v10.OFPT_FLOW_REMOVED:             80,
+	v10.OFPT_PORT_STATUS:              56,
+	v10.OFPT_PACKET_OUT:               8,
+	v10.OFPT_FLOW_MOD:                 64,
+	v10.OFPT_PORT_MOD:                 24,
+	v10.OFPT_STATS_REQUEST:            4,
+	v10.OFPT_STATS_REPLY:              4,
+	v10.OFPT_QUEUE_GET_CONFIG_REQUEST: 4,
+	v10.OFPT_QUEUE_GET_CONFIG_REPLY:   8,
+}
+
+// Dissect decodes a single message, data may be truncated or malformed
+func Dissect(data []byte) *Field {
+	root := &Field{
+		Name:   "openflow",
+		Length: len(data),
+	}
+	if len(data) < openflow.OF_HEADER_SIZE {
+		root.Meaning = "truncated header"
+		root.raw(data, "raw", 0, len(data))
+		return root
+	}
+	version, msgType := data[0], data[1]
+	length := int(binary.BigEndian.Uint16(data[2:4]))
+	xid := binary.BigEndian.Uint32(data[4:8])
+
+	h := root.add("header", 0, openflow.OF_HEADER_SIZE, "", "")
+	h.hex(data, "version", 0, 1, versionName(version))
+	h.num(data, "type", 1, 1, typeName(version, msgType))
+	lengthMeaning := ""
+	end := length
+	if length > len(data) {
+		lengthMeaning = fmt.Sprintf("truncated, %d bytes captured", len(data))
+		end = len(data)
+	} else if length < openflow.OF_HEADER_SIZE {
+		lengthMeaning = "invalid"
+		end = len(data)
+	}
+	h.num(data, "length", 2, 2, lengthMeaning)
+	h.num(data, "xid", 4, 4, "")
+	root.Length = end
+	root.Value = typeName(version, msgType)
+	root.Meaning = fmt.Sprintf("version %s, xid %d", versionName(version), xid)
+
+	body := data[:end]
+	if version != openflow.OF10_VERSION {
+		root.raw(body, "body", openflow.OF_HEADER_SIZE, end)
+		return root
+	}
+	if min, ok := minBodyLength[msgType]; ok && end-openflow.OF_HEADER_SIZE < min {
+		root.add("malformed", openflow.OF_HEADER_SIZE, 0, "", fmt.Sprintf("body shorter than %d bytes", min))
+		root.raw(body, "body", openflow.OF_HEADER_SIZE, end)
+		return root
+	}
+	dissectBody(root, body, msgType)
+	if length <= len(data) {
+		if _, err := v10.Parse(body); err != nil {
+			root.add("malformed", 0, end, "", err.Error())
+		}
+	}
+	return root
+}
+
+// DissectAll decodes every message in a buffer holding consecutive messages
+func DissectAll(data []byte) []*Field {
+	fields := []*Field{}
+	for len(data) > 0 {
+		n := len(data)
+		if n >= openflow.OF_HEADER_SIZE {
+			if length := int(binary.BigEndian.Uint16(data[2:4])); length >= openflow.OF_HEADER_SIZE && length < n {
+				n = length
+			}
+		}
+		fields = append(fields, Dissect(data[:n]))
+		data = data[n:]
+	}
+	return fields
+}
+
+func versionName(v uint8) string {
+	switch v {
+	case openflow.OF10_VERSION:
+		return "1.0"
+	case openflow.OF11_VERSION:
+		return "1.1"
+	case openflow.OF12_VERSION:
+		return "1.2"
+	case openflow.OF13_VERSION:
+		return "1.3"
+	case openflow.OF14_VERSION:
+		return "1.4"
+	}
+	return "unknown"
+}
+
+func typeName(version, t uint8) string {
+	if version != openflow.OF10_VERSION {
+		return fmt.Sprintf("unknown(%d)", t)
+	}
+	return v10.MessageTypeName(t)
+}
+
+func dissectBody(root *Field, data []byte, msgType uint8) {
+	const b = openflow.OF_HEADER_SIZE
+	end := len(data)
+	switch msgType {
+	case v10.OFPT_HELLO, v10.OFPT_ECHO_REQUEST, v10.OFPT_ECHO_REPLY:
+		root.raw(data, "data", b, end)
+	case v10.OFPT_ERROR:
+		typ := uint16(be(data, b, 2))
+		root.num(data, "error_type", b, 2, v10.ErrorTypeName(typ))
+		root.num(data, "code", b+2, 2, "")
+		if end-b-4 >= openflow.OF_HEADER_SIZE {
+			// error data holds at least the header of the failed request
+			req := Dissect(data[b+4:])
+			shift(req, b+4)
+			req.Name = "request"
+			root.Children = append(root.Children, req)
+		} else {
+			root.raw(data, "data", b+4, end)
+		}
+	case v10.OFPT_VENDOR:
+		root.hex(data, "vendor", b, 4, "")
+		root.raw(data, "data", b+4, end)
+	case v10.OFPT_FEATURES_REPLY:
+		root.hex(data, "datapath_id", b, 8, "")
+		root.num(data, "n_buffers", b+8, 4, "")
+		root.num(data, "n_tables", b+12, 1, "")
+		if data[b+13] != 0 {
+			root.num(data, "auxiliary_id", b+13, 1, "")
+		}
+		root.hex(data, "capabilities", b+16, 4, openflow.FeatureCapability(be(data, b+16, 4)).String())
+		root.hex(data, "actions", b+20, 4, openflow.FeatureAction(be(data, b+20, 4)).String())
+		for i, pos := 0, b+24; pos < end; i, pos = i+1, pos+48 {
+			if pos+48 > end {
+				root.raw(data, "truncated", pos, end)
+				break
+			}
+			dissectPort(root, data, fmt.Sprintf("port[%d]", i), pos)
+		}
+	case v10.OFPT_GET_CONFIG_REPLY, v10.OFPT_SET_CONFIG:
+		root.hex(data, "flags", b, 2, v10.ConfigFlagsName(uint16(be(data, b, 2))))
+		root.num(data, "miss_send_len", b+2, 2, "")
+	case v10.OFPT_PACKET_IN:
+		dissectBufferID(root, data, b)
+		root.num(data, "total_len", b+4, 2, "")
+		root.port(data, "in_port", b+6)
+		root.num(data, "reason", b+8, 1, v10.PacketInReasonName(data[b+8]))
+		root.raw(data, "data", b+10, end)
+	case v10.OFPT_FLOW_REMOVED:
+		dissectMatch(root, data, b)
+		root.hex(data, "cookie", b+40, 8, "")
+		root.num(data, "priority", b+48, 2, "")
+		root.num(data, "reason", b+50, 1, v10.FlowRemovedReasonName(data[b+50]))
+		root.num(data, "duration_sec", b+52, 4, "")
+		root.num(data, "duration_nsec", b+56, 4, "")
+		root.num(data, "idle_timeout", b+60, 2, "")
+		root.num(data, "packet_count", b+64, 8, "")
+		root.num(data, "byte_count", b+72, 8, "")
+	case v10.OFPT_PORT_STATUS:
+		root.num(data, "reason", b, 1, openflow.PortReason(data[b]).String())
+		dissectPort(root, data, "port", b+8)
+	case v10.OFPT_PACKET_OUT:
+		dissectBufferID(root, data, b)
+		root.port(data, "in_port", b+4)
+		actionsLen := int(be(data, b+6, 2))
+		actionsEnd := b + 8 + actionsLen
+		meaning := ""
+		if actionsEnd > end {
+			meaning = "exceeds message length"
+			actionsEnd = end
+		}
+		root.num(data, "actions_len", b+6, 2, meaning)
+		dissectActions(root, data, b+8, actionsEnd)
+		root.raw(data, "data", actionsEnd, end)
+	case v10.OFPT_FLOW_MOD:
+		dissectMatch(root, data, b)
+		root.hex(data, "cookie", b+40, 8, "")
+		root.num(data, "command", b+48, 2, openflow.FlowCommand(be(data, b+48, 2)).String())
+		root.num(data, "idle_timeout", b+50, 2, "")
+		root.num(data, "hard_timeout", b+52, 2, "")
+		root.num(data, "priority", b+54, 2, "")
+		dissectBufferID(root, data, b+56)
+		root.port(data, "out_port", b+60)
+		root.hex(data, "flags", b+62, 2, openflow.FlowFlag(be(data, b+62, 2)).String())
+		dissectActions(root, data, b+64, end)
+	case v10.OFPT_PORT_MOD:
+		root.port(data, "port_no", b)
+		root.mac(data, "hw_addr", b+2, "")
+		root.hex(data, "config", b+8, 4, openflow.PortConfig(be(data, b+8, 4)).String())
+		root.hex(data, "mask", b+12, 4, openflow.PortConfig(be(data, b+12, 4)).String())
+		root.hex(data, "advertise", b+16, 4, openflow.PortFeature(be(data, b+16, 4)).String())
+	case v10.OFPT_STATS_REQUEST, v10.OFPT_STATS_REPLY:
+		dissectStats(root, data, msgType == v10.OFPT_STATS_REPLY)
+	case v10.OFPT_QUEUE_GET_CONFIG_REQUEST:
+		root.port(data, "port", b)
+	case v10.OFPT_QUEUE_GET_CONFIG_REPLY:
+		root.port(data, "port", b)
+		for i, pos := 0, b+8; pos < end; i++ {
+			if pos+8 > end {
+				root.raw(data, "truncated", pos, end)
+				break
+			}
+			qlen := int(be(data, pos+4, 2))
+			if qlen < 8 || pos+qlen > end {
+				root.raw(data, "malformed", pos, end)
+				break
+			}
+			q := root.add(fmt.Sprintf("queue[%d]", i), pos, qlen, "", "")
+			q.num(data, "queue_id", pos, 4, "")
+			q.num(data, "len", pos+4, 2, "")
+			dissectQueueProperties(q, data, pos+8, pos+qlen)
+			pos += qlen
+		}
+	case v10.OFPT_FEATURES_REQUEST, v10.OFPT_GET_CONFIG_REQUEST,
+		v10.OFPT_BARRIER_REQUEST, v10.OFPT_BARRIER_REPLY:
+	default:
+		root.raw(data, "body", b, end)
+	}
+}
+
+// shift moves offsets of a nested tree by off
+func shift(f *Field, off int) {
+	f.Offset += off
+	for _, c := range f.Children {
+		shift(c, off)
+	}
+}
+
+func dissectBufferID(f *Field, data []byte, off int) {
+	meaning := ""
+	if be(data, off, 4) == v10.OFP_NO_BUFFER {
+		meaning = "no buffer"
+	}
+	f.hex(data, "buffer_id", off, 4, meaning)
+}
+
+func dissectPort(parent *Field, data []byte, name string, off int) {
+	p := parent.add(name, off, 48, "", "")
+	p.port(data, "port_no", off)
+	p.mac(data, "hw_addr", off+2, "")
+	p.str(data, "name", off+8, 16)
+	p.hex(data, "config", off+24, 4, openflow.PortConfig(be(data, off+24, 4)).String())
+	state := openflow.PortState(be(data, off+28, 4))
+	stateMeaning := state.String()
+	if state&openflow.STPMask == openflow.STPListen {
+		stateMeaning += "|stp_listen"
+	}
+	p.hex(data, "state", off+28, 4, strings.TrimPrefix(stateMeaning, "0|"))
+	p.hex(data, "curr", off+32, 4, openflow.PortFeature(be(data, off+32, 4)).String())
+	p.hex(data, "advertised", off+36, 4, openflow.PortFeature(be(data, off+36, 4)).String())
+	p.hex(data, "supported", off+40, 4, openflow.PortFeature(be(data, off+40, 4)).String())
+	p.hex(data, "peer", off+44, 4, openflow.PortFeature(be(data, off+44, 4)).String())
+}
+
+var etherTypeNames = map[uint64]string{
+	0x0800: "ipv4",
+	0x0806: "arp",
+	0x8100: "vlan",
+	0x86dd: "ipv6",
+	0x88cc: "lldp",
+}
+
+var ipProtoNames = map[uint8]string{
+	1:  "icmp",
+	6:  "tcp",
+	17: "udp",
+}
+
+func dissectMatch(parent *Field, data []byte, off int) {
+	m := parent.add("match", off, 40, "", "")
+	w := uint32(be(data, off, 4))
+	m.hex(data, "wildcards", off, 4, v10.WildcardNames(w))
+	wildcarded := func(bit uint32, meaning string) string {
+		if w&bit != 0 {
+			return "wildcarded"
+		}
+		return meaning
+	}
+	inPort := openflow.PortID(be(data, off+4, 2))
+	inPortMeaning := ""
+	if inPort.IsReserved() {
+		inPortMeaning = inPort.String()
+	}
+	m.num(data, "in_port", off+4, 2, wildcarded(v10.OFPFW_IN_PORT, inPortMeaning))
+	m.mac(data, "dl_src", off+6, wildcarded(v10.OFPFW_DL_SRC, ""))
+	m.mac(data, "dl_dst", off+12, wildcarded(v10.OFPFW_DL_DST, ""))
+	m.num(data, "dl_vlan", off+18, 2, wildcarded(v10.OFPFW_DL_VLAN, ""))
+	m.num(data, "dl_vlan_pcp", off+20, 1, wildcarded(v10.OFPFW_DL_VLAN_PCP, ""))
+	m.hex(data, "dl_type", off+22, 2, wildcarded(v10.OFPFW_DL_TYPE, etherTypeNames[be(data, off+22, 2)]))
+	m.num(data, "nw_tos", off+24, 1, wildcarded(v10.OFPFW_NW_TOS, ""))
+	m.num(data, "nw_proto", off+25, 1, wildcarded(v10.OFPFW_NW_PROTO, ipProtoNames[data[off+25]]))
+	m.ip(data, "nw_src", off+28, prefixMeaning((w>>8)&0x3f))
+	m.ip(data, "nw_dst", off+32, prefixMeaning((w>>14)&0x3f))
+	m.num(data, "tp_src", off+36, 2, wildcarded(v10.OFPFW_TP_SRC, ""))
+	m.num(data, "tp_dst", off+38, 2, wildcarded(v10.OFPFW_TP_DST, ""))
+}
+
+func prefixMeaning(wildcardedBits uint32) string {
+	if wildcardedBits >= 32 {
+		return "wildcarded"
+	}
+	return fmt.Sprintf("/%d", 32-wildcardedBits)
+}
+
+func dissectActions(parent *Field, data []byte, off, end int) {
+	for i, pos := 0, off; pos < end; i++ {
+		if pos+4 > end {
+			parent.raw(data, "truncated", pos, end)
+			return
+		}
+		typ := uint16(be(data, pos, 2))
+		length := int(be(data, pos+2, 2))
+		if length < 4 || pos+length > end {
+			parent.raw(data, "malformed", pos, end)
+			return
+		}
+		a := parent.add(fmt.Sprintf("action[%d]", i), pos, length, v10.ActionTypeName(typ), "")
+		a.num(data, "type", pos, 2, v10.ActionTypeName(typ))
+		a.num(data, "len", pos+2, 2, "")
+		dissectActionBody(a, data, typ, pos, pos+length)
+		pos += length
+	}
+}
+
+// actionBodyLength is the length of known action types
+var actionBodyLength = map[uint16]int{
+	v10.OFPAT_OUTPUT:       8,
+	v10.OFPAT_SET_VLAN_VID: 8,
+	v10.OFPAT_SET_VLAN_PCP: 8,
+	v10.OFPAT_SET_DL_SRC:   16,
+	v10.OFPAT_SET_DL_DST:   16,
+	v10.OFPAT_SET_NW_SRC:   8,
+	v10.OFPAT_SET_NW_DST:   8,
+	v10.OFPAT_SET_NW_TOS:   8,
+	v10.OFPAT_SET_TP_SRC:   8,
+	v10.OFPAT_SET_TP_DST:   8,
+	v10.OFPAT_ENQUEUE:      16,
+	v10.OFPAT_VENDOR:       8,
+}
+
+func dissectActionBody(a *Field, data []byte, typ uint16, off, end int) {
+	if min, ok := actionBodyLength[typ]; !ok || end-off < min {
+		a.raw(data, "body", off+4, end)
+		return
+	}
+	switch typ {
+	case v10.OFPAT_OUTPUT:
+		a.port(data, "port", off+4)
+		a.num(data, "max_len", off+6, 2, "")
+	case v10.OFPAT_SET_VLAN_VID:
+		a.num(data, "vlan_vid", off+4, 2, "")
+	case v10.OFPAT_SET_VLAN_PCP:
+		a.num(data, "vlan_pcp", off+4, 1, "")
+	case v10.OFPAT_SET_DL_SRC, v10.OFPAT_SET_DL_DST:
+		a.mac(data, "dl_addr", off+4, "")
+	case v10.OFPAT_SET_NW_SRC, v10.OFPAT_SET_NW_DST:
+		a.ip(data, "nw_addr", off+4, "")
+	case v10.OFPAT_SET_NW_TOS:
+		a.num(data, "nw_tos", off+4, 1, "")
+	case v10.OFPAT_SET_TP_SRC, v10.OFPAT_SET_TP_DST:
+		a.num(data, "tp_port", off+4, 2, "")
+	case v10.OFPAT_ENQUEUE:
+		a.port(data, "port", off+4)
+		a.num(data, "queue_id", off+12, 4, "")
+	case v10.OFPAT_VENDOR:
+		a.hex(data, "vendor", off+4, 4, "")
+		a.raw(data, "body", off+8, end)
+	}
+}
+
+func dissectQueueProperties(q *Field, data []byte, off, end int) {
+	for pos := off; pos < end; {
+		if pos+8 > end {
+			q.raw(data, "truncated", pos, end)
+			return
+		}
+		prop := uint16(be(data, pos, 2))
+		plen := int(be(data, pos+2, 2))
+		if plen < 8 || pos+plen > end {
+			q.raw(data, "malformed", pos, end)
+			return
+		}
+		meaning := ""
+		if prop == 1 {
+			meaning = "min_rate"
+		}
+		p := q.add("property", pos, plen, "", "")
+		p.num(data, "property", pos, 2, meaning)
+		p.num(data, "len", pos+2, 2, "")
+		if prop == 1 && plen >= 16 {
+			p.num(data, "rate", pos+8, 2, "1/10 of a percent")
+		} else {
+			p.raw(data, "body", pos+8, pos+plen)
+		}
+		pos += plen
+	}
+}
+
+func dissectStats(root *Field, data []byte, reply bool) {
+	const b = openflow.OF_HEADER_SIZE
+	end := len(data)
+	typ := openflow.StatsType(be(data, b, 2))
+	root.num(data, "stats_type", b, 2, typ.String())
+	flagsMeaning := ""
+	if reply && be(data, b+2, 2)&1 != 0 {
+		flagsMeaning = "more"
+	}
+	root.hex(data, "flags", b+2, 2, flagsMeaning)
+	body := b + 4
+	switch {
+	case !reply && (typ == openflow.STATS_Flow || typ == openflow.STATS_Aggregate) && end-body >= 44:
+		dissectMatch(root, data, body)
+		root.num(data, "table_id", body+40, 1, tableMeaning(data[body+40]))
+		root.port(data, "out_port", body+42)
+	case !reply && typ == openflow.STATS_Port && end-body >= 8:
+		root.port(data, "port_no", body)
+	case !reply && typ == openflow.STATS_Queue && end-body >= 8:
+		root.port(data, "port_no", body)
+		root.num(data, "queue_id", body+4, 4, queueMeaning(uint32(be(data, body+4, 4))))
+	case typ == openflow.STATS_Vendor && end-body >= 4:
+		root.hex(data, "vendor", body, 4, "")
+		root.raw(data, "body", body+4, end)
+	case reply && typ == openflow.STATS_Description && end-body >= 1056:
+		root.str(data, "mfr_desc", body, 256)
+		root.str(data, "hw_desc", body+256, 256)
+		root.str(data, "sw_desc", body+512, 256)
+		root.str(data, "serial_num", body+768, 32)
+		root.str(data, "dp_desc", body+800, 256)
+	case reply && typ == openflow.STATS_Flow:
+		dissectFlowStats(root, data, body, end)
+	case reply && typ == openflow.STATS_Aggregate && end-body >= 24:
+		root.num(data, "packet_count", body, 8, "")
+		root.num(data, "byte_count", body+8, 8, "")
+		root.num(data, "flow_count", body+16, 4, "")
+	case reply && typ == openflow.STATS_Table:
+		dissectEntries(root, data, body, end, "table", 64, func(f *Field, pos int) {
+			f.num(data, "table_id", pos, 1, "")
+			f.str(data, "name", pos+4, 32)
+			f.hex(data, "wildcards", pos+36, 4, "")
+			f.num(data, "max_entries", pos+40, 4, "")
+			f.num(data, "active_count", pos+44, 4, "")
+			f.num(data, "lookup_count", pos+48, 8, "")
+			f.num(data, "matched_count", pos+56, 8, "")
+		})
+	case reply && typ == openflow.STATS_Port:
+		dissectEntries(root, data, body, end, "port", 104, func(f *Field, pos int) {
+			f.port(data, "port_no", pos)
+			for i, name := range portCounterNames {
+				f.num(data, name, pos+8+8*i, 8, "")
+			}
+		})
+	case reply && typ == openflow.STATS_Queue:
+		dissectEntries(root, data, body, end, "queue", 32, func(f *Field, pos int) {
+			f.port(data, "port_no", pos)
+			f.num(data, "queue_id", pos+4, 4, "")
+			f.num(data, "tx_bytes", pos+8, 8, "")
+			f.num(data, "tx_packets", pos+16, 8, "")
+			f.num(data, "tx_errors", pos+24, 8, "")
+		})
+	default:
+		root.raw(data, "body", body, end)
+	}
+}
+
+func dissectFlowStats(parent *Field, data []byte, off, end int) {
+	for i, pos := 0, off; pos < end; i++ {
+		if pos+88 > end {
+			parent.raw(data, "truncated", pos, end)
+			return
+		}
+		length := int(be(data, pos, 2))
+		if length < 88 || pos+length > end {
+			parent.raw(data, "malformed", pos, end)
+			return
+		}
+		f := parent.add(fmt.Sprintf("flow[%d]", i), pos, length, "", "")
+		f.num(data, "length", pos, 2, "")
+		f.num(data, "table_id", pos+2, 1, "")
+		dissectMatch(f, data, pos+4)
+		f.num(data, "duration_sec", pos+44, 4, "")
+		f.num(data, "duration_nsec", pos+48, 4, "")
+		f.num(data, "priority", pos+52, 2, "")
+		f.num(data, "idle_timeout", pos+54, 2, "")
+		f.num(data, "hard_timeout", pos+56, 2, "")
+		f.hex(data, "cookie", pos+64, 8, "")
+		f.num(data, "packet_count", pos+72, 8, "")
+		f.num(data, "byte_count", pos+80, 8, "")
+		dissectActions(f, data, pos+88, pos+length)
+		pos += length
+	}
+}
+
+var portCounterNames = []string{
+	"rx_packets", "tx_packets", "rx_bytes", "tx_bytes", "rx_dropped", "tx_dropped",
+	"rx_errors", "tx_errors", "rx_frame_err", "rx_over_err", "rx_crc_err", "collisions",
+}
+
+// dissectEntries dissects a body of fixed size stats entries
+func dissectEntries(parent *Field, data []byte, off, end int, name string, size int, entry func(f *Field, pos int)) {
+	for i, pos := 0, off; pos < end; i, pos = i+1, pos+size {
+		if pos+size > end {
+			parent.raw(data, "truncated", pos, end)
+			return
+		}
+		entry(parent.add(fmt.Sprintf("%s[%d]", name, i), pos, size, "", ""), pos)
+	}
+}
+
+func tableMeaning(t uint8) string {
+	switch t {
+	case 0xff:
+		return "all"
+	case 0xfe:
+		return "emergency"
+	}
+	return ""
+}
+
+func queueMeaning(q uint32) string {
+	if q == 0xffffffff {
+		return "all"
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package dissector
+
+import (
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"strings"
+	"testing"
+)
+
+func TestDissectFlowMod(t *testing.T) {
+	fm := v10.NewFlowMod(uint32(23334))
+	act := v10.NewActionOutput()
+	act.SetPort(uint16(2))
+	fm.SetAction(act)
+	fm.SetFlags(openflow.SendFlowRem)
+	data, err := fm.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := Dissect(data).String()
+	for _, s := range []string{
+		"[0:80] openflow: flow_mod (version 1.0, xid 23334)",
+		"[8:12] wildcards: 0x003820ff (",
+		"[70:72] flags: 0x0001 (send_flow_rem)",
+		"[72:80] action[0]: output",
+		"[76:78] port: 2",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("%q not found in:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "malformed") {
+		t.Errorf("unexpected malformed:\n%s", out)
+	}
+}
+
+func TestDissectTruncated(t *testing.T) {
+	fm := v10.NewFlowMod(uint32(1))
+	data, _ := fm.MarshalBinary()
+	out := Dissect(data[:30]).String()
+	if !strings.Contains(out, "truncated, 30 bytes captured") || !strings.Contains(out, "malformed") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	out = Dissect([]byte{0x04, 0x00, 0x00, 0x0a, 0, 0, 0, 1, 0xab, 0xcd}).String()
+	if !strings.Contains(out, "version 1.3") || !strings.Contains(out, "abcd") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestDissectPortStats(t *testing.T) {
+	reply := v10.NewStatsReplyPort(uint32(5))
+	p := v10.NewPortStats()
+	p.SetPortNumber(uint16(3))
+	p.SetTxBytes(uint64(1500))
+	reply.AddPort(p)
+	data, err := reply.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := Dissect(data).String()
+	for _, s := range []string{
+		"[12:116] port[0]",
+		"[12:14] port_no: 3",
+		"[44:52] tx_bytes: 1500",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("%q not found in:\n%s", s, out)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+/*
+Package flowtable implements an openflow 1.0 flow table.
+
+A Table runs flow mods with the add, modify and delete semantics of the
+specification, including OFPFF_CHECK_OVERLAP and OFPFF_EMERG, looks up
+packets with the wildcard and priority rules of openflow 1.0, counts the
+packets and bytes of every flow and expires flows on idle and hard
+timeouts. Removals of flows added with OFPFF_SEND_FLOW_REM are returned as
+FlowRemoved messages for the caller to send. Tables are safe for
+concurrent use, so that switch emulators and controllers keeping a shadow
+of the flows of a switch can share the same implementation.
+*/
+package flowtable
+
+import (
+	"errors"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"sync"
+	"time"
+)
+
+var (
+	ErrOverlap         = errors.New("flowtable: flow overlaps an existing flow")
+	ErrTableFull       = errors.New("flowtable: table full")
+	ErrBadEmergTimeout = errors.New("flowtable: emergency flow with timeout")
+	ErrBadCommand      = errors.New("flowtable: bad command")
+)
+
+// Code returns the OFPFMFC code of an error returned by Apply
+func Code(err error) uint16 {
+	switch err {
+	case ErrOverlap:
+		return v10.OFPFMFC_OVERLAP
+	case ErrTableFull:
+		return v10.OFPFMFC_ALL_TABLES_FULL
+	case ErrBadEmergTimeout:
+		return v10.OFPFMFC_BAD_EMERG_TIMEOUT
+	}
+	return v10.OFPFMFC_BAD_COMMAND
+}
+
+// Entry is a flow of the table
+type Entry struct {
+	Match       openflow.Match
+	Priority    uint16
+	Cookie      uint64
+	IdleTimeout uint16
+	HardTimeout uint16
+	Flags       openflow.FlowFlag
+	Actions     []openflow.Action
+
+	Created time.Time
+	Used    time.Time
+	Packets uint64
+	Bytes   uint64
+
+	canonical string
+	exact     bool
+}
+
+func newEntry(fm openflow.FlowMod, canonical []byte, now time.Time) *Entry {
+	return &Entry{
+		Match:       fm.Match(),
+		Priority:    fm.Priority(),
+		Cookie:      fm.Cookie(),
+		IdleTimeout: fm.IdleTimeout(),
+		HardTimeout: fm.HardTimeout(),
+		Flags:       fm.Flags(),
+		Actions:     fm.Actions(),
+		Created:     now,
+		Used:        now,
+		canonical:   string(canonical),
+		exact:       isExact(fm.Match()),
+	}
+}
+
+// isExact tells whether m wildcards no field, exact flows take
+// precedence over all wildcarded flows
+func isExact(m openflow.Match) bool {
+	return m.Wildcards()&v10.OFPFW_ALL == 0
+}
+
+// Outputs tells whether the entry has an output or enqueue action to
+// port, every entry outputs to OFPP_NONE
+func (e *Entry) Outputs(port uint16) bool {
+	if port == uint16(openflow.None) {
+		return true
+	}
+	for _, a := range e.Actions {
+		switch act := a.(type) {
+		case v10.ActionOutput:
+			if a.Type() == v10.OFPAT_OUTPUT && act.Port() == port {
+				return true
+			}
+		case v10.ActionEnqueue:
+			if act.Port() == port {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expired returns the reason the entry expired at now, or false
+func (e *Entry) expired(now time.Time) (uint8, bool) {
+	if e.HardTimeout != 0 && now.Sub(e.Created) >= time.Duration(e.HardTimeout)*time.Second {
+		return v10.OFPRR_HARD_TIMEOUT, true
+	}
+	if e.IdleTimeout != 0 && now.Sub(e.Used) >= time.Duration(e.IdleTimeout)*time.Second {
+		return v10.OFPRR_IDLE_TIMEOUT, true
+	}
+	return 0, false
+}
+
+// Removed returns the flow removed message of the entry
+func (e *Entry) Removed(reason uint8, now time.Time) openflow.FlowRemoved {
+	fr := v10.NewFlowRemoved(0)
+	fr.SetMatch(e.Match)
+	fr.SetCookie(e.Cookie)
+	fr.SetPriority(e.Priority)
+	fr.SetReason(reason)
+	d := now.Sub(e.Created)
+	fr.SetDurationSec(uint32(d / time.Second))
+	fr.SetDurationNanoSec(uint32(d % time.Second))
+	fr.SetIdleTimeout(e.IdleTimeout)
+	fr.SetPacketCount(e.Packets)
+	fr.SetByteCount(e.Bytes)
+	return fr
+}
+
+// Stats returns the flow stats entry of the entry
+func (e *Entry) Stats(now time.Time) v10.FlowStats {
+	s := v10.NewFlowStats()
+	s.SetMatch(e.Match)
+	d := now.Sub(e.Created)
+	s.SetDurationSec(uint32(d / time.Second))
+	s.SetDurationNanoSec(uint32(d % time.Second))
+	s.SetPriority(e.Priority)
+	s.SetIdleTimeout(e.IdleTimeout)
+	s.SetHardTimeout(e.HardTimeout)
+	s.SetCookie(e.Cookie)
+	s.SetPacketCount(e.Packets)
+	s.SetByteCount(e.Bytes)
+	for _, a := range e.Actions {
+		s.AddAction(a)
+	}
+	return s
+}
+
+// Table is a single openflow 1.0 flow table
+type Table struct {
+	// Now returns the current time, it defaults to time.Now
+	Now func() time.Time
+
+	mu        sync.Mutex
+	maxFlows  int
+	flows     []*Entry
+	emerg     []*Entry
+	emergency bool
+	lookups   uint64
+	matched   uint64
+}
+
+// New returns an empty table holding at most maxFlows normal flows and
+// as many emergency flows
+func New(maxFlows int) *Table {
+	return &Table{
+		Now:      time.Now,
+		maxFlows: maxFlows,
+	}
+}
+
+// list returns the flows flow mods with flags act on
+func (t *Table) list(flags openflow.FlowFlag) *[]*Entry {
+	if flags&openflow.Emerg != 0 {
+		return &t.emerg
+	}
+	return &t.flows
+}
+
+// selects tells whether a flow mod or stats request selects e
+func selects(e *Entry, m openflow.Match, canonical string, priority uint16, strict bool) bool {
+	if strict {
+		return e.canonical == canonical && e.Priority == priority
+	}
+	return v10.MatchCovers(m, e.Match)
+}
+
+// Apply runs a flow mod, it returns the flow removed messages of the
+// deleted flows added with OFPFF_SEND_FLOW_REM
+func (t *Table) Apply(fm openflow.FlowMod) ([]openflow.FlowRemoved, error) {
+	canonical, err := v10.CanonicalMatch(fm.Match())
+	if err != nil {
+		return nil, ErrBadCommand
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.Now()
+	list := t.list(fm.Flags())
+	switch fm.Command() {
+	case openflow.Add:
+		return nil, t.add(list, newEntry(fm, canonical, now))
+	case openflow.Modify, openflow.ModifyStrict:
+		strict := fm.Command() == openflow.ModifyStrict
+		modified := false
+		for _, e := range *list {
+			if selects(e, fm.Match(), string(canonical), fm.Priority(), strict) {
+				e.Actions = fm.Actions()
+				modified = true
+			}
+		}
+		if !modified {
+			// modifications of no flow add it
+			return nil, t.add(list, newEntry(fm, canonical, now))
+		}
+		return nil, nil
+	case openflow.Delete, openflow.DeleteStrict:
+		strict := fm.Command() == openflow.DeleteStrict
+		var removed []openflow.FlowRemoved
+		kept := (*list)[:0]
+		for _, e := range *list {
+			if selects(e, fm.Match(), string(canonical), fm.Priority(), strict) && e.Outputs(fm.OutPort()) {
+				if e.Flags&openflow.SendFlowRem != 0 {
+					removed = append(removed, e.Removed(v10.OFPRR_DELETE, now))
+				}
+				continue
+			}
+			kept = append(kept, e)
+		}
+		release(*list, len(kept))
+		*list = kept
+		return removed, nil
+	}
+	return nil, ErrBadCommand
+}
+
+// add inserts e in list, replacing a flow with the same match and
+// priority along with its counters
+func (t *Table) add(list *[]*Entry, e *Entry) error {
+	if e.Flags&openflow.Emerg != 0 && (e.IdleTimeout != 0 || e.HardTimeout != 0) {
+		return ErrBadEmergTimeout
+	}
+	if e.Flags&openflow.CheckOverlap != 0 {
+		for _, old := range *list {
+			if old.Priority == e.Priority && old.canonical != e.canonical && v10.MatchOverlaps(old.Match, e.Match) {
+				return ErrOverlap
+			}
+		}
+	}
+	for i, old := range *list {
+		if old.canonical == e.canonical && old.Priority == e.Priority {
+			(*list)[i] = e
+			return nil
+		}
+	}
+	if len(*list) >= t.maxFlows {
+		return ErrTableFull
+	}
+	*list = append(*list, e)
+	return nil
+}
+
+// release drops the references beyond n of a list filtered in place
+func release(list []*Entry, n int) {
+	for i := n; i < len(list); i++ {
+		list[i] = nil
+	}
+}
+
+// Lookup returns the actions of the flow a packet of length bytes
+// matches, exact flows first then the highest priority, and counts the
+// packet. Emergency flows are used instead of normal flows in emergency
+// mode.
+func (t *Table) Lookup(pkt openflow.Match, length int) ([]openflow.Action, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lookups++
+	list := t.flows
+	if t.emergency {
+		list = t.emerg
+	}
+	var best *Entry
+	for _, e := range list {
+		if !v10.MatchCovers(e.Match, pkt) {
+			continue
+		}
+		if best == nil || e.exact && !best.exact || e.exact == best.exact && e.Priority > best.Priority {
+			best = e
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	t.matched++
+	best.Packets++
+	best.Bytes += uint64(length)
+	best.Used = t.Now()
+	return best.Actions, true
+}
+
+// Expire removes the flows whose timeout elapsed, it returns the flow
+// removed messages of those added with OFPFF_SEND_FLOW_REM
+func (t *Table) Expire() []openflow.FlowRemoved {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.Now()
+	var removed []openflow.FlowRemoved
+	kept := t.flows[:0]
+	for _, e := range t.flows {
+		if reason, ok := e.expired(now); ok {
+			if e.Flags&openflow.SendFlowRem != 0 {
+				removed = append(removed, e.Removed(reason, now))
+			}
+			continue
+		}
+		kept = append(kept, e)
+	}
+	release(t.flows, len(kept))
+	t.flows = kept
+	return removed
+}
+
+// SetEmergency enters or leaves emergency mode, entering it deletes
+// every normal flow as when the switch loses its controller
+func (t *Table) SetEmergency(on bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if on && !t.emergency {
+		release(t.flows, 0)
+		t.flows = nil
+	}
+	t.emergency = on
+}
+
+// Emergency tells whether the table is in emergency mode
+func (t *Table) Emergency() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.emergency
+}
+
+// Query returns copies of the normal and emergency flows match covers
+// with an output to outPort, as selected by flow and aggregate stats
+// requests
+func (t *Table) Query(match openflow.Match, outPort uint16) []Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var v []Entry
+	for _, list := range [][]*Entry{t.flows, t.emerg} {
+		for _, e := range list {
+			if v10.MatchCovers(match, e.Match) && e.Outputs(outPort) {
+				v = append(v, *e)
+			}
+		}
+	}
+	return v
+}
+
+// Stats returns the table stats entry of the table
+func (t *Table) Stats(id uint8, name string) v10.TableStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := v10.NewTableStats()
+	s.SetTableID(id)
+	s.SetName(name)
+	s.SetWildcards(v10.OFPFW_ALL)
+	s.SetMaxEntries(uint32(t.maxFlows))
+	s.SetActiveCount(uint32(len(t.flows) + len(t.emerg)))
+	s.SetLookupCount(t.lookups)
+	s.SetMatchedCount(t.matched)
+	return s
+}
+
+// Len returns the number of normal flows of the table
+func (t *Table) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.flows)
+}
@@ -0,0 +1,218 @@
+package flowtable
+
+import (
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/packet"
+	"net"
+	"testing"
+	"time"
+)
+
+// clock is a manually advanced time source
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTable(maxFlows int) (*Table, *clock) {
+	c := &clock{now: time.Unix(1000, 0)}
+	t := New(maxFlows)
+	t.Now = c.Now
+	return t, c
+}
+
+func flowMod(cmd openflow.FlowCommand, m openflow.Match, priority uint16, port uint16) openflow.FlowMod {
+	fm := v10.NewFlowMod(0)
+	fm.SetCommand(cmd)
+	fm.SetMatch(m)
+	fm.SetPriority(priority)
+	fm.SetOutPort(uint16(openflow.None))
+	out := v10.NewActionOutput()
+	out.SetPort(port)
+	fm.AddAction(out)
+	return fm
+}
+
+func subnet(ip net.IP, bits int) openflow.Match {
+	m := v10.NewMatch()
+	m.SetDLType(0x0800)
+	m.SetNWDst(ip)
+	m.SetWildcardNWDst(bits)
+	return m
+}
+
+// exact returns the exact match of an IPv4 packet to dst
+func exact(t *testing.T, dst net.IP) openflow.Match {
+	ip, _ := (&packet.IPv4{TTL: 64, Protocol: 253, Src: net.IP{10, 9, 9, 9}, Dst: dst}).MarshalBinary()
+	hw := net.HardwareAddr{0x0a, 0, 0, 0, 0, 1}
+	frame, _ := packet.NewEthernet(hw, hw, packet.EtherTypeIPv4, ip).MarshalBinary()
+	m, err := v10.MatchFromPacket(1, frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func output(actions []openflow.Action) uint16 {
+	if len(actions) != 1 {
+		return 0
+	}
+	return actions[0].(v10.ActionOutput).Port()
+}
+
+func TestLookup(t *testing.T) {
+	table, _ := newTable(16)
+	for _, fm := range []openflow.FlowMod{
+		flowMod(openflow.Add, subnet(net.IP{10, 0, 0, 0}, 8), 10, 1),
+		flowMod(openflow.Add, subnet(net.IP{10, 1, 0, 0}, 16), 20, 2),
+		flowMod(openflow.Add, v10.NewMatch(), 0, 3),
+	} {
+		if _, err := table.Apply(fm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for dst, port := range map[string]uint16{"10.1.2.3": 2, "10.2.0.1": 1, "11.0.0.1": 3} {
+		actions, ok := table.Lookup(exact(t, net.ParseIP(dst).To4()), 100)
+		if !ok || output(actions) != port {
+			t.Errorf("%s matched port %d, expected %d", dst, output(actions), port)
+		}
+	}
+	host := exact(t, net.IP{10, 1, 2, 3})
+	table.Apply(flowMod(openflow.Add, host, 0, 4))
+	if actions, _ := table.Lookup(host, 100); output(actions) != 4 {
+		t.Errorf("exact flow not preferred")
+	}
+	stats := table.Stats(0, "classifier")
+	if stats.ActiveCount() != 4 || stats.LookupCount() != 4 || stats.MatchedCount() != 4 {
+		t.Errorf("unexpected table stats %d %d %d", stats.ActiveCount(), stats.LookupCount(), stats.MatchedCount())
+	}
+	flows := table.Query(subnet(net.IP{10, 1, 0, 0}, 16), uint16(openflow.None))
+	if len(flows) != 2 {
+		t.Fatalf("query returned %d flows", len(flows))
+	}
+	for _, f := range flows {
+		if f.Packets != 1 || f.Bytes != 100 {
+			t.Errorf("unexpected counters %d %d", f.Packets, f.Bytes)
+		}
+	}
+}
+
+func TestModifyDelete(t *testing.T) {
+	table, _ := newTable(16)
+	m := subnet(net.IP{10, 0, 0, 0}, 8)
+	add := flowMod(openflow.Add, m, 10, 1)
+	add.SetFlags(openflow.SendFlowRem)
+	table.Apply(add)
+	table.Apply(flowMod(openflow.Add, m, 20, 1))
+
+	table.Apply(flowMod(openflow.ModifyStrict, m, 20, 2))
+	flows := table.Query(v10.NewMatch(), 2)
+	if len(flows) != 1 || flows[0].Priority != 20 {
+		t.Errorf("strict modify changed %v", flows)
+	}
+	table.Apply(flowMod(openflow.Modify, v10.NewMatch(), 0, 3))
+	if flows := table.Query(v10.NewMatch(), 3); len(flows) != 2 {
+		t.Errorf("modify changed %d flows", len(flows))
+	}
+	table.Apply(flowMod(openflow.Modify, subnet(net.IP{11, 0, 0, 0}, 8), 5, 4))
+	if table.Len() != 3 {
+		t.Errorf("modify of no flow did not add it")
+	}
+
+	removed, err := table.Apply(flowMod(openflow.DeleteStrict, m, 30, 0))
+	if err != nil || len(removed) != 0 || table.Len() != 3 {
+		t.Errorf("strict delete of no flow removed %v", removed)
+	}
+	removed, _ = table.Apply(flowMod(openflow.Delete, m, 0, 0))
+	if len(removed) != 1 || removed[0].Reason() != v10.OFPRR_DELETE || removed[0].Priority() != 10 || table.Len() != 1 {
+		t.Errorf("unexpected removals %v", removed)
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	table, c := newTable(16)
+	idle := flowMod(openflow.Add, subnet(net.IP{10, 0, 0, 0}, 8), 0, 1)
+	idle.SetIdleTimeout(2)
+	idle.SetFlags(openflow.SendFlowRem)
+	hard := flowMod(openflow.Add, subnet(net.IP{11, 0, 0, 0}, 8), 0, 1)
+	hard.SetHardTimeout(3)
+	hard.SetFlags(openflow.SendFlowRem)
+	quiet := flowMod(openflow.Add, subnet(net.IP{12, 0, 0, 0}, 8), 0, 1)
+	quiet.SetHardTimeout(1)
+	for _, fm := range []openflow.FlowMod{idle, hard, quiet} {
+		table.Apply(fm)
+	}
+
+	c.now = c.now.Add(1500 * time.Millisecond)
+	table.Lookup(exact(t, net.IP{10, 0, 0, 1}), 60)
+	if removed := table.Expire(); len(removed) != 0 || table.Len() != 2 {
+		t.Errorf("unexpected expiry %v", removed)
+	}
+	c.now = c.now.Add(1500 * time.Millisecond)
+	removed := table.Expire()
+	if len(removed) != 1 || removed[0].Reason() != v10.OFPRR_HARD_TIMEOUT || removed[0].DurationSec() != 3 {
+		t.Fatalf("unexpected hard timeout %v", removed)
+	}
+	c.now = c.now.Add(time.Second)
+	removed = table.Expire()
+	if len(removed) != 1 || removed[0].Reason() != v10.OFPRR_IDLE_TIMEOUT || removed[0].PacketCount() != 1 || removed[0].IdleTimeout() != 2 {
+		t.Fatalf("unexpected idle timeout %v", removed)
+	}
+	if table.Len() != 0 {
+		t.Errorf("%d flows left", table.Len())
+	}
+}
+
+func TestErrors(t *testing.T) {
+	table, _ := newTable(2)
+	table.Apply(flowMod(openflow.Add, subnet(net.IP{10, 0, 0, 0}, 8), 10, 1))
+
+	overlap := flowMod(openflow.Add, subnet(net.IP{10, 1, 0, 0}, 16), 10, 2)
+	overlap.SetFlags(openflow.CheckOverlap)
+	if _, err := table.Apply(overlap); err != ErrOverlap || Code(err) != v10.OFPFMFC_OVERLAP {
+		t.Errorf("overlapping flow answered %v", err)
+	}
+	overlap.SetPriority(11)
+	if _, err := table.Apply(overlap); err != nil {
+		t.Errorf("flow of another priority answered %v", err)
+	}
+	if _, err := table.Apply(flowMod(openflow.Add, v10.NewMatch(), 0, 3)); err != ErrTableFull {
+		t.Errorf("full table answered %v", err)
+	}
+	emerg := flowMod(openflow.Add, v10.NewMatch(), 0, 3)
+	emerg.SetFlags(openflow.Emerg)
+	emerg.SetIdleTimeout(5)
+	if _, err := table.Apply(emerg); err != ErrBadEmergTimeout || Code(err) != v10.OFPFMFC_BAD_EMERG_TIMEOUT {
+		t.Errorf("emergency timeout answered %v", err)
+	}
+	bad := flowMod(openflow.FlowCommand(9), v10.NewMatch(), 0, 3)
+	if _, err := table.Apply(bad); err != ErrBadCommand {
+		t.Errorf("unknown command answered %v", err)
+	}
+}
+
+func TestEmergency(t *testing.T) {
+	table, _ := newTable(16)
+	table.Apply(flowMod(openflow.Add, v10.NewMatch(), 0, 1))
+	emerg := flowMod(openflow.Add, v10.NewMatch(), 0, 2)
+	emerg.SetFlags(openflow.Emerg)
+	if _, err := table.Apply(emerg); err != nil {
+		t.Fatal(err)
+	}
+	pkt := exact(t, net.IP{10, 0, 0, 1})
+	if actions, _ := table.Lookup(pkt, 60); output(actions) != 1 {
+		t.Errorf("emergency flow used in normal mode")
+	}
+	table.SetEmergency(true)
+	if actions, _ := table.Lookup(pkt, 60); output(actions) != 2 || table.Len() != 0 {
+		t.Errorf("normal flows used in emergency mode")
+	}
+	table.SetEmergency(false)
+	if _, ok := table.Lookup(pkt, 60); ok {
+		t.Errorf("emergency flow used after emergency mode")
+	}
+}
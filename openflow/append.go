@@ -0,0 +1,42 @@
+package openflow
+
+import (
+	"encoding"
+)
+
+// BinaryAppender is implemented by messages and structures which encode
+// themselves at the end of a buffer, saving the allocations of
+// MarshalBinary when the buffer is reused
+type BinaryAppender interface {
+	AppendBinary(dst []byte) ([]byte, error)
+}
+
+// AppendBinary appends the encoding of v to dst, with its AppendBinary
+// method when it has one
+func AppendBinary(dst []byte, v encoding.BinaryMarshaler) ([]byte, error) {
+	if a, ok := v.(BinaryAppender); ok {
+		return a.AppendBinary(dst)
+	}
+	data, err := v.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(dst, data...), nil
+}
+
+// Grow appends n zero bytes to dst, it returns dst and the appended bytes
+// for the caller to fill
+func Grow(dst []byte, n int) ([]byte, []byte) {
+	l := len(dst)
+	if cap(dst)-l < n {
+		grown := make([]byte, l, 2*cap(dst)+n)
+		copy(grown, dst)
+		dst = grown
+	}
+	dst = dst[:l+n]
+	v := dst[l:]
+	for i := range v {
+		v[i] = 0
+	}
+	return dst, v
+}
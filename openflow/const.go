@@ -16,16 +16,15 @@ const (
 // Port id reserved values, for other normal ports should specify their own id value
 type PortID uint16
 const (
-	Max 	PortID = 0xFF00
-	inPort 	PortID = 0xFFF8 + iota
-	Table
-	Normal
-	Flood
-	All
-	Controller
-	Local
-	// workaround complier complaining overflow
-	None  	PortID = 0xFFFF
+	Max        PortID = 0xFF00
+	InPort     PortID = 0xFFF8
+	Table      PortID = 0xFFF9
+	Normal     PortID = 0xFFFA
+	Flood      PortID = 0xFFFB
+	All        PortID = 0xFFFC
+	Controller PortID = 0xFFFD
+	Local      PortID = 0xFFFE
+	None       PortID = 0xFFFF
 )
 
 // Port reason codes
@@ -39,12 +38,12 @@ const (
 // Port state
 type PortState uint32
 const (
-	STPListen PortState = 0x00
-	LinkDown  PortState = 0x01 << iota
-	STPLearn
-	STPForward
-	STPBlock
-	STPMask
+	LinkDown   PortState = 0x01
+	STPListen  PortState = 0x00 << 8
+	STPLearn   PortState = 0x01 << 8
+	STPForward PortState = 0x02 << 8
+	STPBlock   PortState = 0x03 << 8
+	STPMask    PortState = 0x03 << 8
 )
 
 // Port config
@@ -87,7 +86,7 @@ const (
 
 type FlowFlag uint16
 const (
-	SendFlowRem FlowFlag = iota + 0x01
+	SendFlowRem FlowFlag = 0x01 << iota
 	CheckOverlap
 	Emerg
 )
@@ -131,5 +130,5 @@ const (
 	STATS_Table
 	STATS_Port
 	STATS_Queue
-	STATS_Vendor
+	STATS_Vendor StatsType = 0xFFFF
 )
\ No newline at end of file
@@ -158,6 +158,8 @@ type FeatureReply interface {
 	SetNumBuffers(uint32)
 	NumTables() uint8
 	SetNumTables(uint8)
+	AuxiliaryID() uint8
+	SetAuxiliaryID(uint8)
 	Capabilities() FeatureCapability
 	SetCapabilities(FeatureCapability)
 	Actions() FeatureAction
@@ -201,6 +203,7 @@ type PacketIn interface {
 	BufferID() uint32
 	SetBufferID(uint32)
 	TotalLength() uint16
+	SetTotalLength(uint16)
 	InPort() uint16
 	SetInPort(uint16)
 	TableID() uint8
@@ -285,8 +288,11 @@ type FlowMod interface {
 	SetOutPort(uint16)
 	Flags() FlowFlag
 	SetFlags(FlowFlag)
+	// Action returns the first action, SetAction replaces all actions
 	Action() Action
 	SetAction(Action)
+	Actions() []Action
+	AddAction(Action)
 	encoding.BinaryMarshaler
 	encoding.BinaryUnmarshaler
 }
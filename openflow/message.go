@@ -63,20 +63,37 @@ func (m *Message) SetPayload(payload []byte) {
 
 // convert Message to binary data
 func (m *Message) MarshalBinary() ([]byte, error) {
-	var length uint16 = OF_HEADER_SIZE
-	if m.payload != nil {
-		length += uint16(len(m.payload))
-	}
+	return m.AppendBinary(make([]byte, 0, OF_HEADER_SIZE+len(m.payload)))
+}
 
-	v := make([]byte, length)
-	v[0] = m.header.version
-	v[1] = m.header.msgType
-	binary.BigEndian.PutUint16(v[2:4], length)
-	binary.BigEndian.PutUint32(v[4:8], m.header.xid)
-	if length > OF_HEADER_SIZE {
-		copy(v[8:], m.payload)
-	}
-	return v, nil
+// AppendBinary appends the header and the payload of the message to dst
+func (m *Message) AppendBinary(dst []byte) ([]byte, error) {
+	dst = m.AppendHeader(dst, len(m.payload))
+	return append(dst, m.payload...), nil
+}
+
+// AppendHeader appends the header of the message with a body of n bytes
+// to dst, the body is appended by the caller
+func (m *Message) AppendHeader(dst []byte, n int) []byte {
+	m.header.length = uint16(OF_HEADER_SIZE + n)
+	return append(dst,
+		m.header.version,
+		m.header.msgType,
+		byte(m.header.length>>8),
+		byte(m.header.length),
+		byte(m.header.xid>>24),
+		byte(m.header.xid>>16),
+		byte(m.header.xid>>8),
+		byte(m.header.xid),
+	)
+}
+
+// FixLength sets the length of the message encoded in data, the header
+// followed by the whole body, for bodies whose length is only known once
+// they are appended
+func (m *Message) FixLength(data []byte) {
+	m.header.length = uint16(len(data))
+	binary.BigEndian.PutUint16(data[2:4], m.header.length)
 }
 
 // convert binary data to message
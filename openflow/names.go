@@ -0,0 +1,322 @@
+package openflow
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// flagName maps a single bit (or a masked value) to its symbolic name
+type flagName struct {
+	value uint32
+	name  string
+}
+
+// flagsToNames returns symbolic names of all bits set in v,
+// bits without a name are rendered in hex
+func flagsToNames(v uint32, table []flagName) []string {
+	names := []string{}
+	for _, f := range table {
+		if f.value != 0 && v&f.value == f.value {
+			names = append(names, f.name)
+			v &^= f.value
+		}
+	}
+	if v != 0 {
+		names = append(names, fmt.Sprintf("0x%x", v))
+	}
+	return names
+}
+
+// namesToFlags is the reverse of flagsToNames, hex values are accepted
+func namesToFlags(names []string, table []flagName) (uint32, error) {
+	var v uint32
+	for _, n := range names {
+		found := false
+		for _, f := range table {
+			if f.name == n {
+				v |= f.value
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		raw, err := strconv.ParseUint(n, 0, 32)
+		if err != nil {
+			return 0, ErrInvalidValueProvided
+		}
+		v |= uint32(raw)
+	}
+	return v, nil
+}
+
+func joinNames(names []string) string {
+	if len(names) == 0 {
+		return "0"
+	}
+	return strings.Join(names, "|")
+}
+
+// enumToName returns the name of an enumerated value, or its decimal value
+func enumToName(v uint32, table []flagName) string {
+	for _, f := range table {
+		if f.value == v {
+			return f.name
+		}
+	}
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+func nameToEnum(name string, table []flagName) (uint32, error) {
+	for _, f := range table {
+		if f.name == name {
+			return f.value, nil
+		}
+	}
+	raw, err := strconv.ParseUint(name, 0, 32)
+	if err != nil {
+		return 0, ErrInvalidValueProvided
+	}
+	return uint32(raw), nil
+}
+
+var portIDNames = []flagName{
+	{uint32(InPort), "in_port"},
+	{uint32(Table), "table"},
+	{uint32(Normal), "normal"},
+	{uint32(Flood), "flood"},
+	{uint32(All), "all"},
+	{uint32(Controller), "controller"},
+	{uint32(Local), "local"},
+	{uint32(None), "none"},
+}
+
+// String returns the name of a reserved port, or the port number
+func (p PortID) String() string {
+	return enumToName(uint32(p), portIDNames)
+}
+
+// IsReserved reports whether p is one of the reserved port numbers
+func (p PortID) IsReserved() bool {
+	return p >= InPort
+}
+
+// ParsePortID parses a port number or a reserved port name
+func ParsePortID(s string) (PortID, error) {
+	v, err := nameToEnum(s, portIDNames)
+	if err != nil || v > 0xFFFF {
+		return 0, ErrInvalidValueProvided
+	}
+	return PortID(v), nil
+}
+
+var portReasonNames = []flagName{
+	{uint32(PortAdded), "add"},
+	{uint32(PortDeleted), "delete"},
+	{uint32(PortModified), "modify"},
+}
+
+func (r PortReason) String() string {
+	return enumToName(uint32(r), portReasonNames)
+}
+
+func ParsePortReason(s string) (PortReason, error) {
+	v, err := nameToEnum(s, portReasonNames)
+	if err != nil || v > 0xFF {
+		return 0, ErrInvalidValueProvided
+	}
+	return PortReason(v), nil
+}
+
+var portStateNames = []flagName{
+	{uint32(LinkDown), "link_down"},
+	{uint32(STPBlock), "stp_block"},
+	{uint32(STPForward), "stp_forward"},
+	{uint32(STPLearn), "stp_learn"},
+}
+
+// Names returns the symbolic names of the state, STP listen (zero) is implied
+func (s PortState) Names() []string {
+	return flagsToNames(uint32(s), portStateNames)
+}
+
+func (s PortState) String() string {
+	return joinNames(s.Names())
+}
+
+func ParsePortState(names []string) (PortState, error) {
+	filtered := make([]string, 0, len(names))
+	for _, n := range names {
+		if n != "stp_listen" {
+			filtered = append(filtered, n)
+		}
+	}
+	v, err := namesToFlags(filtered, portStateNames)
+	return PortState(v), err
+}
+
+var portConfigNames = []flagName{
+	{uint32(PortDown), "port_down"},
+	{uint32(NoSTP), "no_stp"},
+	{uint32(NoRecv), "no_recv"},
+	{uint32(NoRecvSTP), "no_recv_stp"},
+	{uint32(NoFlood), "no_flood"},
+	{uint32(NoFwd), "no_fwd"},
+	{uint32(NoPacketIn), "no_packet_in"},
+}
+
+func (c PortConfig) Names() []string {
+	return flagsToNames(uint32(c), portConfigNames)
+}
+
+func (c PortConfig) String() string {
+	return joinNames(c.Names())
+}
+
+func ParsePortConfig(names []string) (PortConfig, error) {
+	v, err := namesToFlags(names, portConfigNames)
+	return PortConfig(v), err
+}
+
+var portFeatureNames = []flagName{
+	{uint32(HD_10MB), "10mb_hd"},
+	{uint32(FD_10MB), "10mb_fd"},
+	{uint32(HD_100MB), "100mb_hd"},
+	{uint32(FD_100MB), "100mb_fd"},
+	{uint32(HD_1GB), "1gb_hd"},
+	{uint32(FD_1GB), "1gb_fd"},
+	{uint32(FD_10GB), "10gb_fd"},
+	{uint32(Copper), "copper"},
+	{uint32(Fiber), "fiber"},
+	{uint32(AutoNeg), "autoneg"},
+	{uint32(Pause), "pause"},
+	{uint32(PauseAsym), "pause_asym"},
+}
+
+func (f PortFeature) Names() []string {
+	return flagsToNames(uint32(f), portFeatureNames)
+}
+
+func (f PortFeature) String() string {
+	return joinNames(f.Names())
+}
+
+func ParsePortFeature(names []string) (PortFeature, error) {
+	v, err := namesToFlags(names, portFeatureNames)
+	return PortFeature(v), err
+}
+
+var flowCommandNames = []flagName{
+	{uint32(Add), "add"},
+	{uint32(Modify), "modify"},
+	{uint32(ModifyStrict), "modify_strict"},
+	{uint32(Delete), "delete"},
+	{uint32(DeleteStrict), "delete_strict"},
+}
+
+func (c FlowCommand) String() string {
+	return enumToName(uint32(c), flowCommandNames)
+}
+
+func ParseFlowCommand(s string) (FlowCommand, error) {
+	v, err := nameToEnum(s, flowCommandNames)
+	if err != nil || v > 0xFFFF {
+		return 0, ErrInvalidValueProvided
+	}
+	return FlowCommand(v), nil
+}
+
+var flowFlagNames = []flagName{
+	{uint32(SendFlowRem), "send_flow_rem"},
+	{uint32(CheckOverlap), "check_overlap"},
+	{uint32(Emerg), "emerg"},
+}
+
+func (f FlowFlag) Names() []string {
+	return flagsToNames(uint32(f), flowFlagNames)
+}
+
+func (f FlowFlag) String() string {
+	return joinNames(f.Names())
+}
+
+func ParseFlowFlag(names []string) (FlowFlag, error) {
+	v, err := namesToFlags(names, flowFlagNames)
+	return FlowFlag(v), err
+}
+
+var featureCapabilityNames = []flagName{
+	{uint32(FLOW_STATS), "flow_stats"},
+	{uint32(TABLE_STATS), "table_stats"},
+	{uint32(PORT_STATS), "port_stats"},
+	{uint32(STP), "stp"},
+	{uint32(RESERVED), "reserved"},
+	{uint32(IP_REASM), "ip_reasm"},
+	{uint32(QUEUE_STATS), "queue_stats"},
+	{uint32(ARP_MATCH_IP), "arp_match_ip"},
+}
+
+func (c FeatureCapability) Names() []string {
+	return flagsToNames(uint32(c), featureCapabilityNames)
+}
+
+func (c FeatureCapability) String() string {
+	return joinNames(c.Names())
+}
+
+func ParseFeatureCapability(names []string) (FeatureCapability, error) {
+	v, err := namesToFlags(names, featureCapabilityNames)
+	return FeatureCapability(v), err
+}
+
+var featureActionNames = []flagName{
+	{uint32(OUTPUT), "output"},
+	{uint32(SET_VLAN_VID), "set_vlan_vid"},
+	{uint32(SET_VLAN_PCP), "set_vlan_pcp"},
+	{uint32(STRIP_VLAN), "strip_vlan"},
+	{uint32(SET_DL_SRC), "set_dl_src"},
+	{uint32(SET_DL_DST), "set_dl_dst"},
+	{uint32(SET_NW_SRC), "set_nw_src"},
+	{uint32(SET_NW_DST), "set_nw_dst"},
+	{uint32(SET_NW_TOS), "set_nw_tos"},
+	{uint32(SET_TP_SRC), "set_tp_src"},
+	{uint32(SET_TP_DST), "set_tp_dst"},
+	{uint32(ENQUEUE), "enqueue"},
+}
+
+func (a FeatureAction) Names() []string {
+	return flagsToNames(uint32(a), featureActionNames)
+}
+
+func (a FeatureAction) String() string {
+	return joinNames(a.Names())
+}
+
+func ParseFeatureAction(names []string) (FeatureAction, error) {
+	v, err := namesToFlags(names, featureActionNames)
+	return FeatureAction(v), err
+}
+
+var statsTypeNames = []flagName{
+	{uint32(STATS_Description), "desc"},
+	{uint32(STATS_Flow), "flow"},
+	{uint32(STATS_Aggregate), "aggregate"},
+	{uint32(STATS_Table), "table"},
+	{uint32(STATS_Port), "port"},
+	{uint32(STATS_Queue), "queue"},
+	{uint32(STATS_Vendor), "vendor"},
+}
+
+func (t StatsType) String() string {
+	return enumToName(uint32(t), statsTypeNames)
+}
+
+func ParseStatsType(s string) (StatsType, error) {
+	v, err := nameToEnum(s, statsTypeNames)
+	if err != nil || v > 0xFFFF {
+		return 0, ErrInvalidValueProvided
+	}
+	return StatsType(v), nil
+}
@@ -0,0 +1,156 @@
+package openflow
+
+import (
+	"encoding"
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// ReadMessage reads a single message from a byte stream such as a TCP
+// connection, the returned slice holds the header followed by the payload
+func ReadMessage(r io.Reader) ([]byte, error) {
+	header := make([]byte, OF_HEADER_SIZE)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint16(header[2:4])
+	if length < OF_HEADER_SIZE {
+		return nil, ErrInvalidPacketLength
+	}
+	data := make([]byte, length)
+	copy(data, header)
+	if _, err := io.ReadFull(r, data[OF_HEADER_SIZE:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// Reader reads messages from a byte stream into a buffer it reuses, so
+// that reading does not allocate once the buffer holds the longest message
+type Reader struct {
+	r   io.Reader
+	buf []byte
+}
+
+// NewReader returns a Reader reading from r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r, buf: make([]byte, 0, 1024)}
+}
+
+// ReadMessage reads a single message as ReadMessage does, the returned
+// slice is only valid until the next call
+func (r *Reader) ReadMessage() ([]byte, error) {
+	header := r.buf[:OF_HEADER_SIZE]
+	if _, err := io.ReadFull(r.r, header); err != nil {
+		return nil, err
+	}
+	length := int(binary.BigEndian.Uint16(header[2:4]))
+	if length < OF_HEADER_SIZE {
+		return nil, ErrInvalidPacketLength
+	}
+	if cap(r.buf) < length {
+		r.buf = make([]byte, 0, length)
+		copy(r.buf[:OF_HEADER_SIZE], header)
+	}
+	data := r.buf[:length]
+	if _, err := io.ReadFull(r.r, data[OF_HEADER_SIZE:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// WriteMessage encodes msg and writes it to w with a single call
+func WriteMessage(w io.Writer, msg MessageDecoder) error {
+	m, ok := msg.(encoding.BinaryMarshaler)
+	if !ok {
+		return ErrUnsupportedMessage
+	}
+	data, err := AppendBinary(nil, m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Writer writes messages to a byte stream for several goroutines. The
+// messages sent while a write is in progress are written together by the
+// next write, so that a burst of messages takes a few system calls. Write
+// errors are kept and returned by the later calls.
+type Writer struct {
+	w    io.Writer
+	mu   sync.Mutex
+	cond *sync.Cond
+	// pending holds the encoded messages of batch queued, written is the
+	// last batch written, spare is the buffer of the previous write
+	pending []byte
+	spare   []byte
+	queued  uint64
+	written uint64
+	writing bool
+	err     error
+}
+
+// NewWriter returns a Writer writing to w
+func NewWriter(w io.Writer) *Writer {
+	wr := &Writer{w: w, queued: 1}
+	wr.cond = sync.NewCond(&wr.mu)
+	return wr
+}
+
+// WriteMessages encodes msgs and writes them in order, it returns once
+// they are written. No message is written when one fails to encode.
+func (w *Writer) WriteMessages(msgs ...MessageDecoder) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	n := len(w.pending)
+	for _, msg := range msgs {
+		m, ok := msg.(encoding.BinaryMarshaler)
+		if !ok {
+			w.pending = w.pending[:n]
+			return ErrUnsupportedMessage
+		}
+		data, err := AppendBinary(w.pending, m)
+		if err != nil {
+			w.pending = w.pending[:n]
+			return err
+		}
+		w.pending = data
+	}
+	if len(w.pending) == n {
+		return nil
+	}
+	batch := w.queued
+	for w.writing && w.written < batch {
+		w.cond.Wait()
+	}
+	if w.written >= batch || w.err != nil {
+		return w.err
+	}
+	// the batch is still pending and nobody writes, write it
+	data := w.pending
+	w.pending, w.spare = w.spare[:0], nil
+	w.queued++
+	w.writing = true
+	w.mu.Unlock()
+	_, err := w.w.Write(data)
+	w.mu.Lock()
+	w.spare = data[:0]
+	w.written = batch
+	w.writing = false
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.cond.Broadcast()
+	return w.err
+}
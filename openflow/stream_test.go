@@ -0,0 +1,90 @@
+package openflow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter records the writes, each taking a while
+type slowWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+}
+
+func (w *slowWriter) Write(b []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes++
+	return w.buf.Write(b)
+}
+
+func TestWriter(t *testing.T) {
+	sw := &slowWriter{}
+	w := NewWriter(sw)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				a := NewMessage(OF10_VERSION, 2, uint32(g<<16|2*i))
+				b := NewMessage(OF10_VERSION, 2, uint32(g<<16|2*i+1))
+				b.SetPayload([]byte{byte(g)})
+				if err := w.WriteMessages(&a, &b); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	next := make(map[uint32]uint32)
+	count := 0
+	for sw.buf.Len() > 0 {
+		data, err := ReadMessage(&sw.buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		xid := binary.BigEndian.Uint32(data[4:8])
+		if g := xid >> 16; xid&0xffff != next[g] {
+			t.Fatalf("goroutine %d wrote %d after %d", g, xid&0xffff, next[g])
+		}
+		next[xid>>16]++
+		count++
+	}
+	if count != 800 {
+		t.Errorf("%d messages written", count)
+	}
+	if sw.writes >= 400 {
+		t.Errorf("%d writes for 400 batches", sw.writes)
+	}
+}
+
+func TestReader(t *testing.T) {
+	var buf bytes.Buffer
+	short := NewMessage(OF10_VERSION, 2, 1)
+	long := NewMessage(OF10_VERSION, 2, 2)
+	long.SetPayload(make([]byte, 2000))
+	for _, m := range []*Message{&short, &long, &short} {
+		if err := WriteMessage(&buf, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewReader(&buf)
+	for _, length := range []int{8, 2008, 8} {
+		data, err := r.ReadMessage()
+		if err != nil || len(data) != length || binary.BigEndian.Uint16(data[2:4]) != uint16(length) {
+			t.Fatalf("read %d bytes, %v", len(data), err)
+		}
+	}
+	if _, err := r.ReadMessage(); err != io.EOF {
+		t.Errorf("read past the end with %v", err)
+	}
+}
@@ -121,6 +121,11 @@ func (a *actionHeader) SetPayload(payload []byte) error {
 }
 
 func (a *actionHeader) MarshalBinary() ([]byte, error) {
+	return a.AppendBinary(nil)
+}
+
+// AppendBinary appends the action to dst
+func (a *actionHeader) AppendBinary(dst []byte) ([]byte, error) {
 	if a.length == uint16(0) {
 		a.length = uint16(4)
 	}
@@ -128,12 +133,31 @@ func (a *actionHeader) MarshalBinary() ([]byte, error) {
 	if a.length != uint16(4+len(a.payload)) {
 		return nil, openflow.ErrInvalidDataLength
 	}
-	v := make([]byte, int(a.length))
-	binary.BigEndian.PutUint16(v[0:2], a.actionType)
-	binary.BigEndian.PutUint16(v[2:4], a.length)
-	if len(a.payload) > 0 {
-		copy(v[4:], a.payload)
+	dst, v := a.appendAction(dst, len(a.payload))
+	copy(v, a.payload)
+	return dst, nil
+}
+
+// appendAction appends the header of an action with a body of n bytes to
+// dst, it returns dst and the zeroed body to fill
+func (a *actionHeader) appendAction(dst []byte, n int) ([]byte, []byte) {
+	a.length = uint16(4 + n)
+	dst = append(dst,
+		byte(a.actionType>>8),
+		byte(a.actionType),
+		byte(a.length>>8),
+		byte(a.length),
+	)
+	return openflow.Grow(dst, n)
+}
+
+// marshalAction encodes an action, its body is kept as the payload
+func (a *actionHeader) marshalAction(action openflow.BinaryAppender) ([]byte, error) {
+	v, err := action.AppendBinary(nil)
+	if err != nil {
+		return nil, err
 	}
+	a.payload = v[4:]
 	return v, nil
 }
 
@@ -167,8 +191,10 @@ func (ao *actionOutput) Port() uint16 {
 	return ao.port
 }
 
+// SetPort accepts physical ports and reserved ports but none
 func (ao *actionOutput) SetPort(port uint16) error {
-	if port > 0xffef {
+	p := openflow.PortID(port)
+	if (p > openflow.Max && p < openflow.InPort) || p == openflow.None {
 		return openflow.ErrInvalidValueProvided
 	}
 	ao.port = port
@@ -184,13 +210,14 @@ func (ao *actionOutput) SetMaxLen(maxLen uint16) {
 }
 
 func (ao *actionOutput) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 4)
+	return ao.marshalAction(ao)
+}
+
+func (ao *actionOutput) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := ao.appendAction(dst, 4)
 	binary.BigEndian.PutUint16(v[0:2], ao.port)
 	binary.BigEndian.PutUint16(v[2:4], ao.maxLen)
-	if err := ao.SetPayload(v); err != nil {
-		return nil, err
-	}
-	return ao.actionHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (ao *actionOutput) UnmarshalBinary(data []byte) error {
@@ -230,13 +257,14 @@ func (as *actionSetVLANVID) SetVLANVID(vid uint16) {
 }
 
 func (as *actionSetVLANVID) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 4)
+	return as.marshalAction(as)
+}
+
+func (as *actionSetVLANVID) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := as.appendAction(dst, 4)
 	binary.BigEndian.PutUint16(v[0:2], as.vlanVID)
 	// v[2:4] is padding
-	if err := as.SetPayload(v); err != nil {
-		return nil, err
-	}
-	return as.actionHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (as *actionSetVLANVID) UnmarshalBinary(data []byte) error {
@@ -276,13 +304,14 @@ func (as *actionSetVLANPCP) SetVLANPCP(pcp uint8) {
 }
 
 func (as *actionSetVLANPCP) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 4)
+	return as.marshalAction(as)
+}
+
+func (as *actionSetVLANPCP) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := as.appendAction(dst, 4)
 	v[0] = as.vlanPCP
 	// v[1:4] is padding
-	if err := as.SetPayload(v); err != nil {
-		return nil, err
-	}
-	return as.actionHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (as *actionSetVLANPCP) UnmarshalBinary(data []byte) error {
@@ -313,7 +342,13 @@ type actionStripVLAN struct {
 }
 
 func (as *actionStripVLAN) MarshalBinary() ([]byte, error) {
-	return as.actionHeader.MarshalBinary()
+	return as.marshalAction(as)
+}
+
+func (as *actionStripVLAN) AppendBinary(dst []byte) ([]byte, error) {
+	// v[0:4] is padding
+	dst, _ = as.appendAction(dst, 4)
+	return dst, nil
 }
 
 func (as *actionStripVLAN) UnmarshalBinary(data []byte) error {
@@ -321,10 +356,11 @@ func (as *actionStripVLAN) UnmarshalBinary(data []byte) error {
 }
 
 func NewActionStripVLAN() ActionStripVLAN {
-	return &actionSetVLANVID{
+	return &actionStripVLAN{
 		actionHeader: actionHeader{
 			actionType: OFPAT_STRIP_VLAN,
-			length:     4,
+			length:     8,
+			payload:    make([]byte, 4),
 		},
 	}
 }
@@ -352,13 +388,14 @@ func (as *actionSetDL) SetDLDst(mac net.HardwareAddr) {
 }
 
 func (as *actionSetDL) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 12)
+	return as.marshalAction(as)
+}
+
+func (as *actionSetDL) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := as.appendAction(dst, 12)
 	copy(v[0:6], as.mac)
 	// v[6:12] is padding
-	if err := as.SetPayload(v); err != nil {
-		return nil, err
-	}
-	return as.actionHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (as *actionSetDL) UnmarshalBinary(data []byte) error {
@@ -415,12 +452,13 @@ func (as *actionSetNW) SetNWDst(ip net.IP) {
 }
 
 func (as *actionSetNW) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 4)
+	return as.marshalAction(as)
+}
+
+func (as *actionSetNW) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := as.appendAction(dst, 4)
 	copy(v[0:4], as.ip)
-	if err := as.SetPayload(v); err != nil {
-		return nil, err
-	}
-	return as.actionHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (as *actionSetNW) UnmarshalBinary(data []byte) error {
@@ -468,13 +506,14 @@ func (as *actionSetNWTos) SetNWTos(tos uint8) {
 }
 
 func (as *actionSetNWTos) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 4)
+	return as.marshalAction(as)
+}
+
+func (as *actionSetNWTos) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := as.appendAction(dst, 4)
 	v[0] = as.nwTos
 	// v[1:4] is padding
-	if err := as.SetPayload(v); err != nil {
-		return nil, err
-	}
-	return as.actionHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (as *actionSetNWTos) UnmarshalBinary(data []byte) error {
@@ -510,21 +549,19 @@ func (as *actionSetTP) Port() uint16 {
 }
 
 func (as *actionSetTP) SetPort(port uint16) error {
-	if port > 0xffef {
-		return openflow.ErrInvalidValueProvided
-	}
 	as.port = port
 	return nil
 }
 
 func (as *actionSetTP) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 4)
+	return as.marshalAction(as)
+}
+
+func (as *actionSetTP) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := as.appendAction(dst, 4)
 	binary.BigEndian.PutUint16(v[0:2], as.port)
 	// v[2:4] is pad
-	if err := as.SetPayload(v); err != nil {
-		return nil, err
-	}
-	return as.actionHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (as *actionSetTP) UnmarshalBinary(data []byte) error {
@@ -569,8 +606,9 @@ func (ae *actionEnqueue) Port() uint16 {
 	return ae.port
 }
 
+// SetPort accepts physical ports and in_port
 func (ae *actionEnqueue) SetPort(port uint16) error {
-	if port > 0xffef {
+	if p := openflow.PortID(port); p > openflow.Max && p != openflow.InPort {
 		return openflow.ErrInvalidValueProvided
 	}
 	ae.port = port
@@ -586,14 +624,15 @@ func (ae *actionEnqueue) SetQueueID(qid uint32) {
 }
 
 func (ae *actionEnqueue) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 12)
+	return ae.marshalAction(ae)
+}
+
+func (ae *actionEnqueue) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := ae.appendAction(dst, 12)
 	binary.BigEndian.PutUint16(v[0:2], ae.port)
 	// v[2:8] is pad
 	binary.BigEndian.PutUint32(v[8:12], ae.queueID)
-	if err := ae.SetPayload(v); err != nil {
-		return nil, err
-	}
-	return ae.actionHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (ae *actionEnqueue) UnmarshalBinary(data []byte) error {
@@ -634,12 +673,13 @@ func (av *actionVendor) SetVendor(vendor uint32) {
 }
 
 func (av *actionVendor) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 4)
+	return av.marshalAction(av)
+}
+
+func (av *actionVendor) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := av.appendAction(dst, 4)
 	binary.BigEndian.PutUint32(v[0:4], av.vendor)
-	if err := av.SetPayload(v); err != nil {
-		return nil, err
-	}
-	return av.actionHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (av *actionVendor) UnmarshalBinary(data []byte) error {
@@ -662,3 +702,76 @@ func NewActionVendor() ActionVendor {
 		},
 	}
 }
+
+// NewAction returns an empty action of the given type,
+// unknown types are represented by a bare action header
+func NewAction(typ uint16) openflow.Action {
+	switch typ {
+	case OFPAT_OUTPUT:
+		return NewActionOutput()
+	case OFPAT_SET_VLAN_VID:
+		return NewActionSetVLANVID()
+	case OFPAT_SET_VLAN_PCP:
+		return NewActionSetVLANPCP()
+	case OFPAT_STRIP_VLAN:
+		return NewActionStripVLAN()
+	case OFPAT_SET_DL_SRC:
+		return NewActionSetDLSrc()
+	case OFPAT_SET_DL_DST:
+		return NewActionSetDLDst()
+	case OFPAT_SET_NW_SRC:
+		return NewActionSetNWSrc()
+	case OFPAT_SET_NW_DST:
+		return NewActionSetNWDst()
+	case OFPAT_SET_NW_TOS:
+		return NewActionSetNWTos()
+	case OFPAT_SET_TP_SRC:
+		return NewActionSetTPSrc()
+	case OFPAT_SET_TP_DST:
+		return NewActionSetTPDst()
+	case OFPAT_ENQUEUE:
+		return NewActionEnqueue()
+	case OFPAT_VENDOR:
+		return NewActionVendor()
+	}
+	return &actionHeader{
+		actionType: typ,
+	}
+}
+
+// DecodeActions decodes a list of actions,
+// each action is unmarshalled into the structure of its type
+func DecodeActions(data []byte) ([]openflow.Action, error) {
+	return decodeActions([]openflow.Action{}, data)
+}
+
+// decodeActions decodes a list of actions in the slice of reuse, an action
+// of reuse is decoded again when its type is the type at its position
+func decodeActions(reuse []openflow.Action, data []byte) ([]openflow.Action, error) {
+	if reuse == nil {
+		reuse = []openflow.Action{}
+	}
+	actions := reuse[:0]
+	for pos := 0; pos < len(data); {
+		if len(data)-pos < 4 {
+			return nil, openflow.ErrInvalidPacketLength
+		}
+		typ := binary.BigEndian.Uint16(data[pos : pos+2])
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 4 || pos+length > len(data) {
+			return nil, openflow.ErrInvalidDataLength
+		}
+		var act openflow.Action
+		if i := len(actions); i < len(reuse) && reuse[i].Type() == typ {
+			act = reuse[i]
+		} else {
+			act = NewAction(typ)
+		}
+		if err := act.UnmarshalBinary(data[pos : pos+length]); err != nil {
+			return nil, err
+		}
+		actions = append(actions, act)
+		pos += length
+	}
+	return actions, nil
+}
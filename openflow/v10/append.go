@@ -0,0 +1,25 @@
+package v10
+
+import (
+	"encoding"
+	"github.com/ksang/goflow/openflow"
+)
+
+// appendMessage appends the header of m with a body of n bytes to dst, it
+// returns dst and the zeroed body to fill
+func appendMessage(m *openflow.Message, dst []byte, n int) ([]byte, []byte) {
+	return openflow.Grow(m.AppendHeader(dst, n), n)
+}
+
+// put encodes b in place of v, an encoding shorter than v leaves the rest
+// zeroed
+func put(v []byte, b encoding.BinaryMarshaler) error {
+	data, err := openflow.AppendBinary(v[:0:len(v)], b)
+	if err != nil {
+		return err
+	}
+	if len(data) > len(v) {
+		return openflow.ErrInvalidDataLength
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package v10
+
+import (
+	"bytes"
+	"encoding"
+	"github.com/ksang/goflow/openflow"
+	"net"
+	"testing"
+)
+
+func TestAppendBinary(t *testing.T) {
+	p, _ := NewPort(openflow.PortID(3), []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}, "eth3")
+	feature := NewFeatureReply(uint32(1))
+	feature.SetDPID(uint64(0x1b207))
+	feature.AddPort(p)
+	feature.AddPort(p)
+	status := NewPortStatus(uint32(2))
+	status.SetPort(p)
+
+	packetIn := NewPacketIn(uint32(3))
+	packetIn.SetData([]byte{0xde, 0xad, 0xbe, 0xef})
+	packetOut := NewPacketOut(uint32(4))
+	vid := NewActionSetVLANVID()
+	vid.SetVLANVID(uint16(10))
+	packetOut.AddAction(vid)
+	packetOut.AddAction(NewActionStripVLAN())
+	packetOut.SetData([]byte{0xde, 0xad})
+
+	removed := NewFlowRemoved(uint32(5))
+	removed.SetCookie(uint64(7))
+	errMsg := NewError(uint32(6))
+	errMsg.SetData([]byte{0x01, 0x02})
+	vendor := NewVendor(uint32(7))
+	vendor.SetData([]byte{0x03})
+	echo := NewEchoRequest(uint32(8))
+	echo.SetData([]byte("ping"))
+	queue := NewQueue()
+	queue.SetRate(uint16(500))
+	queueReply := NewQueueGetConfigReply(uint32(9))
+	queueReply.AddQueue(queue)
+	portRequest := NewStatsReuqestPort(uint32(10))
+	portRequest.SetPortNumber(uint16(2))
+	aggregate := NewStatsReuqestAggregate(uint32(11))
+	aggregate.SetMatch(testFlowMod().Match())
+	flow := NewFlowStats()
+	flow.SetMatch(testFlowMod().Match())
+	flow.AddAction(testFlowMod().Action())
+	enqueue := NewActionEnqueue()
+	enqueue.SetPort(uint16(1))
+	flow.AddAction(enqueue)
+	flowReply := NewStatsReplyFlow(uint32(12))
+	flowReply.AddFlow(flow)
+	flowReply.AddFlow(NewFlowStats())
+	tableReply := NewStatsReplyTable(uint32(13))
+	tableReply.AddTable(NewTableStats())
+	portReply := NewStatsReplyPort(uint32(14))
+	portReply.AddPort(NewPortStats())
+	queueStats := NewStatsReplyQueue(uint32(15))
+	queueStats.AddQueue(NewQueueStats())
+	nw := NewActionSetNWSrc()
+	nw.SetNWSrc(net.IP{10, 0, 0, 1})
+	fm := testFlowMod()
+	fm.AddAction(nw)
+
+	for _, c := range []struct {
+		msg    openflow.MessageDecoder
+		length int
+	}{
+		{fm, 88},
+		{feature, 128},
+		{status, 64},
+		{packetIn, 22},
+		{packetOut, 34},
+		{removed, 88},
+		{errMsg, 14},
+		{vendor, 13},
+		{echo, 12},
+		{NewSetConfig(uint32(16)), 12},
+		{NewBarrierRequest(uint32(17)), 8},
+		{NewPortMod(uint32(18)), 32},
+		{NewQueueGetConfigRequest(uint32(19)), 12},
+		{queueReply, 40},
+		{NewStatsRequestDescription(uint32(20)), 12},
+		{NewStatsReuqestFlow(uint32(21)), 56},
+		{aggregate, 56},
+		{portRequest, 20},
+		{NewStatsReuqestQueue(uint32(22)), 20},
+		{NewStatsReuqestVendor(uint32(23)), 16},
+		{NewStatsReplyDescription(uint32(24)), 1068},
+		{flowReply, 212},
+		{NewStatsReplyAggregate(uint32(25)), 36},
+		{tableReply, 76},
+		{portReply, 116},
+		{queueStats, 44},
+	} {
+		prefix := []byte{0xff, 0xff}
+		got, err := c.msg.(openflow.BinaryAppender).AppendBinary(prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := c.msg.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(want) != c.length || !bytes.Equal(got[:2], prefix) || !bytes.Equal(got[2:], want) {
+			t.Errorf("%T appended as %x, marshalled as %x", c.msg, got, want)
+			continue
+		}
+		decoded, err := Parse(want)
+		if err != nil {
+			t.Fatalf("%T: %v", c.msg, err)
+		}
+		again, err := decoded.(openflow.BinaryAppender).AppendBinary(nil)
+		if err != nil || !bytes.Equal(again, want) {
+			t.Errorf("%T decoded as %x, %v", c.msg, again, err)
+		}
+	}
+}
+
+func TestAppendReuse(t *testing.T) {
+	fm := testFlowMod()
+	buf, err := fm.(openflow.BinaryAppender).AppendBinary(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = fm.(openflow.BinaryAppender).AppendBinary(buf[:0])
+	})
+	if allocs != 0 {
+		t.Errorf("appending a flow mod to a reused buffer allocates %v times", allocs)
+	}
+}
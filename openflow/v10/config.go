@@ -32,12 +32,14 @@ type setConfig struct {
 }
 
 func (s *setConfig) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 4)
+	return s.AppendBinary(nil)
+}
+
+func (s *setConfig) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := appendMessage(&s.Message, dst, 4)
 	binary.BigEndian.PutUint16(v[0:2], s.flags)
 	binary.BigEndian.PutUint16(v[2:4], s.missSendLength)
-	s.SetPayload(v)
-
-	return s.Message.MarshalBinary()
+	return dst, nil
 }
 
 func (s *setConfig) UnmarshalBinary(data []byte) error {
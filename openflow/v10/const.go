@@ -70,6 +70,14 @@ const (
 	OFPFW_TP_DST      = 1 << 7  /* TCP/UDP destination port. */
 	OFPFW_DL_VLAN_PCP = 1 << 20 /* VLAN priority. */
 	OFPFW_NW_TOS      = 1 << 21 /* IP ToS (DSCP field, 6 bits). */
+
+	OFPFW_NW_SRC_SHIFT = 8
+	OFPFW_NW_SRC_MASK  = 0x3f << OFPFW_NW_SRC_SHIFT
+	OFPFW_NW_SRC_ALL   = 32 << OFPFW_NW_SRC_SHIFT
+	OFPFW_NW_DST_SHIFT = 14
+	OFPFW_NW_DST_MASK  = 0x3f << OFPFW_NW_DST_SHIFT
+	OFPFW_NW_DST_ALL   = 32 << OFPFW_NW_DST_SHIFT
+	OFPFW_ALL          = (1 << 22) - 1 /* Wildcard all fields. */
 )
 
 const (
@@ -99,6 +107,14 @@ const (
 
 const (
 	OFP_NO_BUFFER = 0xffffffff
+	// dl_vlan of frames without 802.1q header
+	OFP_VLAN_NONE = 0xffff
+	// dl_type of 802.3 frames without SNAP
+	OFP_DL_TYPE_NOT_ETH_TYPE = 0x05ff
+	OFP_DEFAULT_PRIORITY     = 0x8000
+	OFP_FLOW_PERMANENT       = 0
+	// queue_id of queue stats requests for all queues
+	OFPQ_ALL = 0xffffffff
 )
 
 const (
@@ -149,9 +165,91 @@ const (
 	OFPC_FRAG_MASK
 )
 
+// Stats reply flags
+const (
+	OFPSF_REPLY_MORE = 1 << 0 /* More replies to follow. */
+)
+
 const (
 	OFPPR_ADD    = 0
 	OFPPR_DELETE = 1
 	OFPPR_MODIFY = 2
 )
 
+// Packet in reasons
+const (
+	OFPR_NO_MATCH = 0 /* No matching flow. */
+	OFPR_ACTION   = 1 /* Action explicitly output to controller. */
+)
+
+// Flow removed reasons
+const (
+	OFPRR_IDLE_TIMEOUT = 0 /* Flow idle time exceeded idle_timeout. */
+	OFPRR_HARD_TIMEOUT = 1 /* Time exceeded hard_timeout. */
+	OFPRR_DELETE       = 2 /* Evicted by a DELETE flow mod. */
+)
+
+// Error types
+const (
+	OFPET_HELLO_FAILED    = iota /* Hello protocol failed. */
+	OFPET_BAD_REQUEST            /* Request was not understood. */
+	OFPET_BAD_ACTION             /* Error in action description. */
+	OFPET_FLOW_MOD_FAILED        /* Problem modifying flow entry. */
+	OFPET_PORT_MOD_FAILED        /* Port mod request failed. */
+	OFPET_QUEUE_OP_FAILED        /* Queue operation failed. */
+)
+
+// Hello failed codes
+const (
+	OFPHFC_INCOMPATIBLE = iota /* No compatible version. */
+	OFPHFC_EPERM               /* Permissions error. */
+)
+
+// Bad request codes
+const (
+	OFPBRC_BAD_VERSION    = iota /* ofp_header.version not supported. */
+	OFPBRC_BAD_TYPE              /* ofp_header.type not supported. */
+	OFPBRC_BAD_STAT              /* ofp_stats_request.type not supported. */
+	OFPBRC_BAD_VENDOR            /* Vendor not supported. */
+	OFPBRC_BAD_SUBTYPE           /* Vendor subtype not supported. */
+	OFPBRC_EPERM                 /* Permissions error. */
+	OFPBRC_BAD_LEN               /* Wrong request length for type. */
+	OFPBRC_BUFFER_EMPTY          /* Specified buffer has already been used. */
+	OFPBRC_BUFFER_UNKNOWN        /* Specified buffer does not exist. */
+)
+
+// Bad action codes
+const (
+	OFPBAC_BAD_TYPE        = iota /* Unknown action type. */
+	OFPBAC_BAD_LEN                /* Length problem in actions. */
+	OFPBAC_BAD_VENDOR             /* Unknown vendor id specified. */
+	OFPBAC_BAD_VENDOR_TYPE        /* Unknown action type for vendor id. */
+	OFPBAC_BAD_OUT_PORT           /* Problem validating output action. */
+	OFPBAC_BAD_ARGUMENT           /* Bad action argument. */
+	OFPBAC_EPERM                  /* Permissions error. */
+	OFPBAC_TOO_MANY               /* Can't handle this many actions. */
+	OFPBAC_BAD_QUEUE              /* Problem validating output queue. */
+)
+
+// Flow mod failed codes
+const (
+	OFPFMFC_ALL_TABLES_FULL   = iota /* Flow not added because of full tables. */
+	OFPFMFC_OVERLAP                  /* Attempted to add overlapping flow with CHECK_OVERLAP flag set. */
+	OFPFMFC_EPERM                    /* Permissions error. */
+	OFPFMFC_BAD_EMERG_TIMEOUT        /* Flow not added because of non-zero idle/hard timeout. */
+	OFPFMFC_BAD_COMMAND              /* Unknown command. */
+	OFPFMFC_UNSUPPORTED              /* Unsupported action list. */
+)
+
+// Port mod failed codes
+const (
+	OFPPMFC_BAD_PORT    = iota /* Specified port does not exist. */
+	OFPPMFC_BAD_HW_ADDR        /* Specified hardware address is wrong. */
+)
+
+// Queue op failed codes
+const (
+	OFPQOFC_BAD_PORT  = iota /* Invalid port (or port does not exist). */
+	OFPQOFC_BAD_QUEUE        /* Queue does not exist. */
+	OFPQOFC_EPERM            /* Permissions error. */
+)
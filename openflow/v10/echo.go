@@ -28,11 +28,15 @@ func (e *echo) Error() error {
 }
 
 func (e *echo) MarshalBinary() ([]byte, error) {
+	return e.AppendBinary(nil)
+}
+
+func (e *echo) AppendBinary(dst []byte) ([]byte, error) {
 	if e.err != nil {
 		return nil, e.err
 	}
-	e.SetPayload(e.data)
-	return e.Message.MarshalBinary()
+	dst = e.AppendHeader(dst, len(e.data))
+	return append(dst, e.data...), nil
 }
 
 func (e *echo) UnmarshalBinary(data []byte) error {
@@ -51,14 +51,15 @@ func (e *errorMessage) SetData(data []byte) error {
 }
 
 func (e *errorMessage) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 4+len(e.data))
+	return e.AppendBinary(nil)
+}
+
+func (e *errorMessage) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := appendMessage(&e.Message, dst, 4+len(e.data))
 	binary.BigEndian.PutUint16(v[0:2], e.typ)
 	binary.BigEndian.PutUint16(v[2:4], e.code)
-	if len(e.data) > 0 {
-		copy(v[4:], e.data)
-	}
-	e.SetPayload(v)
-	return e.Message.MarshalBinary()
+	copy(v[4:], e.data)
+	return dst, nil
 }
 
 func (e *errorMessage) UnmarshalBinary(data []byte) error {
@@ -72,6 +73,7 @@ func (e *errorMessage) UnmarshalBinary(data []byte) error {
 	}
 	e.typ = binary.BigEndian.Uint16(payload[0:2])
 	e.code = binary.BigEndian.Uint16(payload[2:4])
+	e.data = nil
 	if len(payload) > 4 {
 		e.data = payload[4:]
 	}
@@ -28,6 +28,7 @@ type featureReply struct {
 	dpid         uint64
 	numBuffers   uint32
 	numTables    uint8
+	auxiliaryID  uint8
 	capabilities openflow.FeatureCapability
 	actions      openflow.FeatureAction
 	ports        []openflow.Port
@@ -57,6 +58,17 @@ func (f *featureReply) SetNumTables(nt uint8) {
 	f.numTables = nt
 }
 
+// AuxiliaryID is the auxiliary connection id of OpenFlow 1.3, carried at
+// the same offset in the first padding byte as a goflow extension. It is
+// zero on the main connection, plain 1.0 switches may set anything.
+func (f *featureReply) AuxiliaryID() uint8 {
+	return f.auxiliaryID
+}
+
+func (f *featureReply) SetAuxiliaryID(id uint8) {
+	f.auxiliaryID = id
+}
+
 func (f *featureReply) Capabilities() openflow.FeatureCapability {
 	return f.capabilities
 }
@@ -82,28 +94,28 @@ func (f *featureReply) AddPort(p openflow.Port) {
 }
 
 func (f *featureReply) MarshalBinary() ([]byte, error) {
+	return f.AppendBinary(nil)
+}
+
+func (f *featureReply) AppendBinary(dst []byte) ([]byte, error) {
 	// lengh = feature reply fields + len(port)*portSize
-	packetOutLen := 24 + len(f.ports)*48
-	v := make([]byte, packetOutLen)
+	dst, v := appendMessage(&f.Message, dst, 24+len(f.ports)*48)
 	binary.BigEndian.PutUint64(v[0:8], f.dpid)
 	binary.BigEndian.PutUint32(v[8:12], f.numBuffers)
 	v[12] = f.numTables
-	// v[13:16] is padding
+	v[13] = f.auxiliaryID
+	// v[14:16] is padding
 	binary.BigEndian.PutUint32(v[16:20], uint32(f.capabilities))
 	binary.BigEndian.PutUint32(v[20:24], uint32(f.actions))
 	// Marshal ports
 	pos := 24
 	for _, p := range f.ports {
-		portPayload, err := p.MarshalBinary()
-		if err != nil {
+		if err := put(v[pos:pos+48], p); err != nil {
 			return nil, err
 		}
-		copy(v[pos:pos+48], portPayload)
 		pos += 48
 	}
-	// Call Message's SetPayload method
-	f.SetPayload(v)
-	return f.Message.MarshalBinary()
+	return dst, nil
 }
 
 func (f *featureReply) UnmarshalBinary(data []byte) error {
@@ -117,14 +129,24 @@ func (f *featureReply) UnmarshalBinary(data []byte) error {
 	f.dpid = binary.BigEndian.Uint64(payload[0:8])
 	f.numBuffers = binary.BigEndian.Uint32(payload[8:12])
 	f.numTables = payload[12]
-	// payload[13:16] is padding
+	f.auxiliaryID = payload[13]
+	// payload[14:16] is padding
 	f.capabilities = openflow.FeatureCapability(binary.BigEndian.Uint32(payload[16:20]))
 	f.actions = openflow.FeatureAction(binary.BigEndian.Uint32(payload[20:24]))
-	for pos := 24; pos < len(payload); pos += 48 {
-		port := NewEmptyPort()
+	// the ports of a reused reply are decoded again
+	ports := f.ports
+	f.ports = f.ports[:0]
+	for i, pos := 0, 24; pos < len(payload); i, pos = i+1, pos+48 {
+		var port openflow.Port
+		if i < len(ports) {
+			port = ports[i]
+		} else {
+			port = NewEmptyPort()
+		}
 		if err := port.UnmarshalBinary(payload[pos : pos+48]); err != nil {
 			return err
 		}
+		f.ports = append(f.ports, port)
 	}
 	return nil
 }
@@ -16,7 +16,7 @@ type flowMod struct {
 	bufferID    uint32
 	outPort     uint16
 	flags       openflow.FlowFlag
-	action      openflow.Action
+	actions     []openflow.Action
 }
 
 func (f *flowMod) Match() openflow.Match {
@@ -96,21 +96,34 @@ func (f *flowMod) SetFlags(ff openflow.FlowFlag) {
 }
 
 func (f *flowMod) Action() openflow.Action {
-	return f.action
+	if len(f.actions) == 0 {
+		return nil
+	}
+	return f.actions[0]
 }
 
 func (f *flowMod) SetAction(a openflow.Action) {
-	f.action = a
+	f.actions = []openflow.Action{a}
+}
+
+func (f *flowMod) Actions() []openflow.Action {
+	return f.actions
+}
+
+func (f *flowMod) AddAction(a openflow.Action) {
+	f.actions = append(f.actions, a)
 }
 
 func (f *flowMod) MarshalBinary() ([]byte, error) {
-	m, err := f.match.MarshalBinary()
-	a, err := f.action.MarshalBinary()
-	if err != nil {
+	return f.AppendBinary(nil)
+}
+
+func (f *flowMod) AppendBinary(dst []byte) ([]byte, error) {
+	start := len(dst)
+	dst, v := appendMessage(&f.Message, dst, 64)
+	if err := put(v[0:40], f.match); err != nil {
 		return nil, err
 	}
-	v := make([]byte, 64+int(f.action.Length()))
-	copy(v[0:40], m)
 	binary.BigEndian.PutUint64(v[40:48], f.cookie)
 	binary.BigEndian.PutUint16(v[48:50], uint16(f.command))
 	binary.BigEndian.PutUint16(v[50:52], f.idleTimeout)
@@ -119,9 +132,12 @@ func (f *flowMod) MarshalBinary() ([]byte, error) {
 	binary.BigEndian.PutUint32(v[56:60], f.bufferID)
 	binary.BigEndian.PutUint16(v[60:62], f.outPort)
 	binary.BigEndian.PutUint16(v[62:64], uint16(f.flags))
-	copy(v[64:], a)
-	f.SetPayload(v)
-	return f.Message.MarshalBinary()
+	dst, err := AppendActions(dst, f.actions)
+	if err != nil {
+		return nil, err
+	}
+	f.FixLength(dst[start:])
+	return dst, nil
 }
 
 func (f *flowMod) UnmarshalBinary(data []byte) error {
@@ -130,25 +146,29 @@ func (f *flowMod) UnmarshalBinary(data []byte) error {
 	}
 
 	payload := f.Payload()
-	if payload == nil || len(payload) < 68 {
+	// a flow mod without actions drops matching packets
+	if payload == nil || len(payload) < 64 {
 		return openflow.ErrInvalidPacketLength
 	}
-	f.match = NewMatch()
-	f.action = NewActionHeader()
+	if f.match == nil {
+		f.match = NewMatch()
+	}
 	if err := f.match.UnmarshalBinary(payload[0:40]); err != nil {
 		return err
 	}
 	f.cookie = binary.BigEndian.Uint64(payload[40:48])
 	f.command = openflow.FlowCommand(binary.BigEndian.Uint16(payload[48:50]))
 	f.idleTimeout = binary.BigEndian.Uint16(payload[50:52])
-	f.idleTimeout = binary.BigEndian.Uint16(payload[52:54])
+	f.hardTimeout = binary.BigEndian.Uint16(payload[52:54])
 	f.priority = binary.BigEndian.Uint16(payload[54:56])
 	f.bufferID = binary.BigEndian.Uint32(payload[56:60])
 	f.outPort = binary.BigEndian.Uint16(payload[60:62])
 	f.flags = openflow.FlowFlag(binary.BigEndian.Uint16(payload[62:64]))
-	if err := f.action.UnmarshalBinary(payload[64:]); err != nil {
+	actions, err := decodeActions(f.actions, payload[64:])
+	if err != nil {
 		return err
 	}
+	f.actions = actions
 	return nil
 }
 
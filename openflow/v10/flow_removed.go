@@ -94,12 +94,14 @@ func (f *flowRemoved) SetByteCount(bc uint64) {
 }
 
 func (f *flowRemoved) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 80)
-	m, err := f.match.MarshalBinary()
-	if err != nil {
+	return f.AppendBinary(nil)
+}
+
+func (f *flowRemoved) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := appendMessage(&f.Message, dst, 80)
+	if err := put(v[0:40], f.match); err != nil {
 		return nil, err
 	}
-	copy(v[0:40], m)
 	binary.BigEndian.PutUint64(v[40:48], f.cookie)
 	binary.BigEndian.PutUint16(v[48:50], f.priority)
 	v[50] = f.reason
@@ -110,8 +112,7 @@ func (f *flowRemoved) MarshalBinary() ([]byte, error) {
 	//v[62:64] is pad
 	binary.BigEndian.PutUint64(v[64:72], f.packetCount)
 	binary.BigEndian.PutUint64(v[72:80], f.byteCount)
-	f.SetPayload(v)
-	return f.Message.MarshalBinary()
+	return dst, nil
 }
 
 func (f *flowRemoved) UnmarshalBinary(data []byte) error {
@@ -123,7 +124,9 @@ func (f *flowRemoved) UnmarshalBinary(data []byte) error {
 	if payload == nil || len(payload) != 80 {
 		return openflow.ErrInvalidPacketLength
 	}
-	f.match = NewMatch()
+	if f.match == nil {
+		f.match = NewMatch()
+	}
 	if err := f.match.UnmarshalBinary(payload[0:40]); err != nil {
 		return err
 	}
@@ -0,0 +1,2358 @@
+package v10
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ksang/goflow/openflow"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// JSON and YAML representation of openflow 1.0 messages.
+//
+// Every message and sub-structure implements json.Marshaler, json.Unmarshaler
+// and the yaml Marshaler/Unmarshaler interfaces:
+//	MarshalYAML() (interface{}, error)
+//	UnmarshalYAML(unmarshal func(interface{}) error) error
+// so they can be used with encoding/json and gopkg.in/yaml directly.
+// Field names are stable, addresses are strings and flags are symbolic names.
+
+// hexBytes is a byte slice represented as a hex string
+type hexBytes []byte
+
+func (h hexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(h))
+}
+
+func (h *hexBytes) UnmarshalJSON(data []byte) error {
+	return h.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (h hexBytes) MarshalYAML() (interface{}, error) {
+	return hex.EncodeToString(h), nil
+}
+
+func (h *hexBytes) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	v, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*h = v
+	return nil
+}
+
+// portNo is a port number, reserved ports are represented by their names
+type portNo uint16
+
+func (p portNo) value() interface{} {
+	if openflow.PortID(p).IsReserved() {
+		return openflow.PortID(p).String()
+	}
+	return uint16(p)
+}
+
+func (p portNo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.value())
+}
+
+func (p *portNo) UnmarshalJSON(data []byte) error {
+	return p.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (p portNo) MarshalYAML() (interface{}, error) {
+	return p.value(), nil
+}
+
+func (p *portNo) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	s := fmt.Sprint(v)
+	if f, ok := v.(float64); ok {
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	pid, err := openflow.ParsePortID(s)
+	if err != nil {
+		return err
+	}
+	*p = portNo(pid)
+	return nil
+}
+
+func jsonUnmarshaler(data []byte) func(interface{}) error {
+	return func(v interface{}) error {
+		return json.Unmarshal(data, v)
+	}
+}
+
+func parseMAC(s string) (net.HardwareAddr, error) {
+	if s == "" {
+		return net.HardwareAddr{0, 0, 0, 0, 0, 0}, nil
+	}
+	mac, err := net.ParseMAC(s)
+	if err != nil || len(mac) != 6 {
+		return nil, openflow.ErrInvalidMACAddress
+	}
+	return mac, nil
+}
+
+func parseIPv4(s string) (net.IP, error) {
+	ip := net.ParseIP(s).To4()
+	if ip == nil {
+		return nil, openflow.ErrInvalidIPAddress
+	}
+	return ip, nil
+}
+
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return "0.0.0.0"
+	}
+	return ip.String()
+}
+
+type jsonHeader struct {
+	Version uint8  `json:"version" yaml:"version"`
+	Type    string `json:"type" yaml:"type"`
+	XID     uint32 `json:"xid" yaml:"xid"`
+}
+
+func headerToJSON(m openflow.HeaderDecoder) jsonHeader {
+	return jsonHeader{
+		Version: m.Version(),
+		Type:    MessageTypeName(m.MsgType()),
+		XID:     m.TransactionID(),
+	}
+}
+
+// apply checks the decoded header against the message and sets its xid
+func (h *jsonHeader) apply(m openflow.HeaderDecoder) error {
+	if h.Version != 0 && h.Version != m.Version() {
+		return openflow.ErrUnsupportedVersion
+	}
+	if h.Type != "" && h.Type != MessageTypeName(m.MsgType()) {
+		return openflow.ErrUnsupportedMessage
+	}
+	m.SetTransactionID(h.XID)
+	return nil
+}
+
+type jsonMatch struct {
+	InPort    *portNo `json:"in_port,omitempty" yaml:"in_port,omitempty"`
+	DLSrc     string  `json:"dl_src,omitempty" yaml:"dl_src,omitempty"`
+	DLDst     string  `json:"dl_dst,omitempty" yaml:"dl_dst,omitempty"`
+	DLVlan    *uint16 `json:"dl_vlan,omitempty" yaml:"dl_vlan,omitempty"`
+	DLVlanPCP *uint8  `json:"dl_vlan_pcp,omitempty" yaml:"dl_vlan_pcp,omitempty"`
+	DLType    *uint16 `json:"dl_type,omitempty" yaml:"dl_type,omitempty"`
+	NWTos     *uint8  `json:"nw_tos,omitempty" yaml:"nw_tos,omitempty"`
+	NWProto   *uint8  `json:"nw_proto,omitempty" yaml:"nw_proto,omitempty"`
+	NWSrc     string  `json:"nw_src,omitempty" yaml:"nw_src,omitempty"`
+	NWDst     string  `json:"nw_dst,omitempty" yaml:"nw_dst,omitempty"`
+	TPSrc     *uint16 `json:"tp_src,omitempty" yaml:"tp_src,omitempty"`
+	TPDst     *uint16 `json:"tp_dst,omitempty" yaml:"tp_dst,omitempty"`
+}
+
+// matchToJSON only includes fields that are not wildcarded,
+// a match with all fields wildcarded is an empty object
+func matchToJSON(m openflow.Match) jsonMatch {
+	v := jsonMatch{}
+	if m == nil {
+		return v
+	}
+	if w, p := m.InPort(); !w {
+		pn := portNo(p)
+		v.InPort = &pn
+	}
+	if w, mac := m.DLSrc(); !w {
+		v.DLSrc = mac.String()
+	}
+	if w, mac := m.DLDst(); !w {
+		v.DLDst = mac.String()
+	}
+	if w, vlan := m.DLVlan(); !w {
+		v.DLVlan = &vlan
+	}
+	if w, pcp := m.DLPCP(); !w {
+		v.DLVlanPCP = &pcp
+	}
+	if w, t := m.DLType(); !w {
+		v.DLType = &t
+	}
+	if w, tos := m.NWTos(); !w {
+		v.NWTos = &tos
+	}
+	if w, proto := m.NWProto(); !w {
+		v.NWProto = &proto
+	}
+	wildcards := m.Wildcards()
+	v.NWSrc = prefixString(m.NWSrc(), uint8((wildcards>>8)&0x3f))
+	v.NWDst = prefixString(m.NWDst(), uint8((wildcards>>14)&0x3f))
+	if w, p := m.TPSrc(); !w {
+		v.TPSrc = &p
+	}
+	if w, p := m.TPDst(); !w {
+		v.TPDst = &p
+	}
+	return v
+}
+
+// prefixString formats an address with the wildcarded bits count,
+// fully wildcarded addresses are omitted
+func prefixString(ip net.IP, wildcarded uint8) string {
+	if wildcarded >= 32 {
+		return ""
+	}
+	if wildcarded == 0 {
+		return ipString(ip)
+	}
+	return fmt.Sprintf("%s/%d", ipString(ip), 32-wildcarded)
+}
+
+func parsePrefix(s string) (net.IP, uint8, error) {
+	if s == "" {
+		return net.IPv4zero, 32, nil
+	}
+	if !strings.Contains(s, "/") {
+		ip, err := parseIPv4(s)
+		return ip, 0, err
+	}
+	ip, ipnet, err := net.ParseCIDR(s)
+	if err != nil || ip.To4() == nil {
+		return nil, 0, openflow.ErrInvalidIPAddress
+	}
+	ones, _ := ipnet.Mask.Size()
+	return ip.To4(), uint8(32 - ones), nil
+}
+
+func matchFromJSON(v *jsonMatch) (*match, error) {
+	m := NewMatch().(*match)
+	var err error
+	if v.InPort != nil {
+		m.SetInPort(uint16(*v.InPort))
+	}
+	if v.DLSrc != "" {
+		if m.dlSrc, err = parseMAC(v.DLSrc); err != nil {
+			return nil, err
+		}
+		m.wildcards.dlSrc = false
+	}
+	if v.DLDst != "" {
+		if m.dlDst, err = parseMAC(v.DLDst); err != nil {
+			return nil, err
+		}
+		m.wildcards.dlDst = false
+	}
+	if v.DLVlan != nil {
+		if err := m.SetDLVlan(*v.DLVlan); err != nil {
+			return nil, err
+		}
+	}
+	if v.DLVlanPCP != nil {
+		m.SetDLPCP(*v.DLVlanPCP)
+	}
+	// dl_type and nw_proto are set directly, any value is valid on the wire
+	if v.DLType != nil {
+		m.dlType = *v.DLType
+		m.wildcards.dlType = false
+	}
+	if v.NWTos != nil {
+		m.SetNWTos(*v.NWTos)
+	}
+	if v.NWProto != nil {
+		m.nwProto = *v.NWProto
+		m.wildcards.nwProto = false
+	}
+	if m.nwSrc, m.wildcards.nwSrc, err = parsePrefix(v.NWSrc); err != nil {
+		return nil, err
+	}
+	if m.nwDst, m.wildcards.nwDst, err = parsePrefix(v.NWDst); err != nil {
+		return nil, err
+	}
+	if v.TPSrc != nil {
+		m.SetTPSrc(*v.TPSrc)
+	}
+	if v.TPDst != nil {
+		m.SetTPDst(*v.TPDst)
+	}
+	return m, nil
+}
+
+func (m *match) MarshalJSON() ([]byte, error) {
+	return json.Marshal(matchToJSON(m))
+}
+
+func (m *match) UnmarshalJSON(data []byte) error {
+	return m.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (m *match) MarshalYAML() (interface{}, error) {
+	return matchToJSON(m), nil
+}
+
+func (m *match) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	v := jsonMatch{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	nm, err := matchFromJSON(&v)
+	if err != nil {
+		return err
+	}
+	*m = *nm
+	return nil
+}
+
+type jsonAction struct {
+	Type    string   `json:"type" yaml:"type"`
+	Port    *portNo  `json:"port,omitempty" yaml:"port,omitempty"`
+	MaxLen  *uint16  `json:"max_len,omitempty" yaml:"max_len,omitempty"`
+	VlanVID *uint16  `json:"vlan_vid,omitempty" yaml:"vlan_vid,omitempty"`
+	VlanPCP *uint8   `json:"vlan_pcp,omitempty" yaml:"vlan_pcp,omitempty"`
+	DLAddr  string   `json:"dl_addr,omitempty" yaml:"dl_addr,omitempty"`
+	NWAddr  string   `json:"nw_addr,omitempty" yaml:"nw_addr,omitempty"`
+	NWTos   *uint8   `json:"nw_tos,omitempty" yaml:"nw_tos,omitempty"`
+	TPPort  *uint16  `json:"tp_port,omitempty" yaml:"tp_port,omitempty"`
+	QueueID *uint32  `json:"queue_id,omitempty" yaml:"queue_id,omitempty"`
+	Vendor  *uint32  `json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	Body    hexBytes `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+func actionToJSON(a openflow.Action) jsonAction {
+	v := jsonAction{
+		Type: ActionTypeName(a.Type()),
+	}
+	switch act := a.(type) {
+	case *actionOutput:
+		port, maxLen := portNo(act.Port()), act.MaxLen()
+		v.Port, v.MaxLen = &port, &maxLen
+	case *actionSetVLANVID:
+		if act.Type() == OFPAT_SET_VLAN_VID {
+			vid := act.VLANVID()
+			v.VlanVID = &vid
+		}
+	case *actionSetVLANPCP:
+		pcp := act.VLANPCP()
+		v.VlanPCP = &pcp
+	case *actionStripVLAN:
+	case *actionSetDL:
+		v.DLAddr = act.mac.String()
+	case *actionSetNW:
+		v.NWAddr = ipString(act.ip)
+	case *actionSetNWTos:
+		tos := act.NWTos()
+		v.NWTos = &tos
+	case *actionSetTP:
+		port := act.Port()
+		v.TPPort = &port
+	case *actionEnqueue:
+		port, qid := portNo(act.Port()), act.QueueID()
+		v.Port, v.QueueID = &port, &qid
+	case *actionVendor:
+		vendor := act.Vendor()
+		v.Vendor = &vendor
+	default:
+		v.Body = a.Payload()
+	}
+	return v
+}
+
+func actionFromJSON(v *jsonAction) (openflow.Action, error) {
+	typ, ok := ParseActionType(v.Type)
+	if !ok {
+		return nil, openflow.ErrInvalidValueProvided
+	}
+	a := NewAction(typ)
+	switch act := a.(type) {
+	case *actionOutput:
+		if v.Port != nil {
+			act.port = uint16(*v.Port)
+		}
+		if v.MaxLen != nil {
+			act.SetMaxLen(*v.MaxLen)
+		}
+	case *actionSetVLANVID:
+		if v.VlanVID != nil {
+			act.SetVLANVID(*v.VlanVID)
+		}
+	case *actionSetVLANPCP:
+		if v.VlanPCP != nil {
+			act.SetVLANPCP(*v.VlanPCP)
+		}
+	case *actionSetDL:
+		mac, err := parseMAC(v.DLAddr)
+		if err != nil {
+			return nil, err
+		}
+		act.mac = mac
+	case *actionSetNW:
+		ip, err := parseIPv4(v.NWAddr)
+		if err != nil {
+			return nil, err
+		}
+		act.ip = ip
+	case *actionSetNWTos:
+		if v.NWTos != nil {
+			act.SetNWTos(*v.NWTos)
+		}
+	case *actionSetTP:
+		if v.TPPort != nil {
+			act.port = *v.TPPort
+		}
+	case *actionEnqueue:
+		if v.Port != nil {
+			act.port = uint16(*v.Port)
+		}
+		if v.QueueID != nil {
+			act.SetQueueID(*v.QueueID)
+		}
+	case *actionVendor:
+		if v.Vendor != nil {
+			act.SetVendor(*v.Vendor)
+		}
+	case *actionHeader:
+		if err := act.SetPayload(v.Body); err != nil && len(v.Body) > 0 {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func actionsToJSON(actions []openflow.Action) []jsonAction {
+	v := make([]jsonAction, 0, len(actions))
+	for _, a := range actions {
+		v = append(v, actionToJSON(a))
+	}
+	return v
+}
+
+func actionsFromJSON(v []jsonAction) ([]openflow.Action, error) {
+	actions := []openflow.Action{}
+	for i := range v {
+		a, err := actionFromJSON(&v[i])
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
+
+func (a *actionHeader) MarshalJSON() ([]byte, error) {
+	return json.Marshal(actionToJSON(a))
+}
+
+func (a *actionHeader) UnmarshalJSON(data []byte) error {
+	return a.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (a *actionHeader) MarshalYAML() (interface{}, error) {
+	return actionToJSON(a), nil
+}
+
+// UnmarshalYAML of a bare action header only restores type and body
+func (a *actionHeader) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	v := jsonAction{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	typ, ok := ParseActionType(v.Type)
+	if !ok {
+		return openflow.ErrInvalidValueProvided
+	}
+	a.actionType = typ
+	a.payload = v.Body
+	a.length = uint16(4 + len(v.Body))
+	return nil
+}
+
+// typed actions are encoded through actionToJSON/actionFromJSON,
+// decoding requires the action type to match the receiver
+func unmarshalActionInto(dst openflow.Action, unmarshal func(interface{}) error) (openflow.Action, error) {
+	v := jsonAction{}
+	if err := unmarshal(&v); err != nil {
+		return nil, err
+	}
+	a, err := actionFromJSON(&v)
+	if err != nil {
+		return nil, err
+	}
+	if a.Type() != dst.Type() {
+		return nil, openflow.ErrInvalidValueProvided
+	}
+	return a, nil
+}
+
+func (ao *actionOutput) MarshalJSON() ([]byte, error) {
+	return json.Marshal(actionToJSON(ao))
+}
+
+func (ao *actionOutput) UnmarshalJSON(data []byte) error {
+	return ao.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (ao *actionOutput) MarshalYAML() (interface{}, error) {
+	return actionToJSON(ao), nil
+}
+
+func (ao *actionOutput) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	a, err := unmarshalActionInto(ao, unmarshal)
+	if err != nil {
+		return err
+	}
+	*ao = *a.(*actionOutput)
+	return nil
+}
+
+func (as *actionSetVLANVID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(actionToJSON(as))
+}
+
+func (as *actionSetVLANVID) UnmarshalJSON(data []byte) error {
+	return as.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (as *actionSetVLANVID) MarshalYAML() (interface{}, error) {
+	return actionToJSON(as), nil
+}
+
+func (as *actionSetVLANVID) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	a, err := unmarshalActionInto(as, unmarshal)
+	if err != nil {
+		return err
+	}
+	*as = *a.(*actionSetVLANVID)
+	return nil
+}
+
+func (as *actionSetVLANPCP) MarshalJSON() ([]byte, error) {
+	return json.Marshal(actionToJSON(as))
+}
+
+func (as *actionSetVLANPCP) UnmarshalJSON(data []byte) error {
+	return as.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (as *actionSetVLANPCP) MarshalYAML() (interface{}, error) {
+	return actionToJSON(as), nil
+}
+
+func (as *actionSetVLANPCP) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	a, err := unmarshalActionInto(as, unmarshal)
+	if err != nil {
+		return err
+	}
+	*as = *a.(*actionSetVLANPCP)
+	return nil
+}
+
+func (as *actionSetDL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(actionToJSON(as))
+}
+
+func (as *actionSetDL) UnmarshalJSON(data []byte) error {
+	return as.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (as *actionSetDL) MarshalYAML() (interface{}, error) {
+	return actionToJSON(as), nil
+}
+
+func (as *actionSetDL) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	a, err := unmarshalActionInto(as, unmarshal)
+	if err != nil {
+		return err
+	}
+	*as = *a.(*actionSetDL)
+	return nil
+}
+
+func (as *actionSetNW) MarshalJSON() ([]byte, error) {
+	return json.Marshal(actionToJSON(as))
+}
+
+func (as *actionSetNW) UnmarshalJSON(data []byte) error {
+	return as.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (as *actionSetNW) MarshalYAML() (interface{}, error) {
+	return actionToJSON(as), nil
+}
+
+func (as *actionSetNW) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	a, err := unmarshalActionInto(as, unmarshal)
+	if err != nil {
+		return err
+	}
+	*as = *a.(*actionSetNW)
+	return nil
+}
+
+func (as *actionSetNWTos) MarshalJSON() ([]byte, error) {
+	return json.Marshal(actionToJSON(as))
+}
+
+func (as *actionSetNWTos) UnmarshalJSON(data []byte) error {
+	return as.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (as *actionSetNWTos) MarshalYAML() (interface{}, error) {
+	return actionToJSON(as), nil
+}
+
+func (as *actionSetNWTos) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	a, err := unmarshalActionInto(as, unmarshal)
+	if err != nil {
+		return err
+	}
+	*as = *a.(*actionSetNWTos)
+	return nil
+}
+
+func (as *actionSetTP) MarshalJSON() ([]byte, error) {
+	return json.Marshal(actionToJSON(as))
+}
+
+func (as *actionSetTP) UnmarshalJSON(data []byte) error {
+	return as.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (as *actionSetTP) MarshalYAML() (interface{}, error) {
+	return actionToJSON(as), nil
+}
+
+func (as *actionSetTP) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	a, err := unmarshalActionInto(as, unmarshal)
+	if err != nil {
+		return err
+	}
+	*as = *a.(*actionSetTP)
+	return nil
+}
+
+func (ae *actionEnqueue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(actionToJSON(ae))
+}
+
+func (ae *actionEnqueue) UnmarshalJSON(data []byte) error {
+	return ae.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (ae *actionEnqueue) MarshalYAML() (interface{}, error) {
+	return actionToJSON(ae), nil
+}
+
+func (ae *actionEnqueue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	a, err := unmarshalActionInto(ae, unmarshal)
+	if err != nil {
+		return err
+	}
+	*ae = *a.(*actionEnqueue)
+	return nil
+}
+
+func (av *actionVendor) MarshalJSON() ([]byte, error) {
+	return json.Marshal(actionToJSON(av))
+}
+
+func (av *actionVendor) UnmarshalJSON(data []byte) error {
+	return av.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (av *actionVendor) MarshalYAML() (interface{}, error) {
+	return actionToJSON(av), nil
+}
+
+func (av *actionVendor) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	a, err := unmarshalActionInto(av, unmarshal)
+	if err != nil {
+		return err
+	}
+	*av = *a.(*actionVendor)
+	return nil
+}
+
+type jsonPort struct {
+	PortNo     portNo   `json:"port_no" yaml:"port_no"`
+	HWAddr     string   `json:"hw_addr" yaml:"hw_addr"`
+	Name       string   `json:"name" yaml:"name"`
+	Config     []string `json:"config" yaml:"config"`
+	State      []string `json:"state" yaml:"state"`
+	Curr       []string `json:"curr" yaml:"curr"`
+	Advertised []string `json:"advertised" yaml:"advertised"`
+	Supported  []string `json:"supported" yaml:"supported"`
+	Peer       []string `json:"peer" yaml:"peer"`
+}
+
+func portToJSON(p openflow.Port) jsonPort {
+	return jsonPort{
+		PortNo:     portNo(p.PortID()),
+		HWAddr:     p.HWAddr().String(),
+		Name:       p.Name(),
+		Config:     p.Config().Names(),
+		State:      p.State().Names(),
+		Curr:       p.Curr().Names(),
+		Advertised: p.Advertised().Names(),
+		Supported:  p.Supported().Names(),
+		Peer:       p.Peer().Names(),
+	}
+}
+
+func portFromJSON(v *jsonPort) (*port, error) {
+	p := &port{
+		portID: openflow.PortID(v.PortNo),
+	}
+	mac, err := parseMAC(v.HWAddr)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.SetHWAddr(mac); err != nil {
+		return nil, err
+	}
+	if err := p.SetName(v.Name); err != nil {
+		return nil, err
+	}
+	if p.config, err = openflow.ParsePortConfig(v.Config); err != nil {
+		return nil, err
+	}
+	if p.state, err = openflow.ParsePortState(v.State); err != nil {
+		return nil, err
+	}
+	if p.curr, err = openflow.ParsePortFeature(v.Curr); err != nil {
+		return nil, err
+	}
+	if p.advertised, err = openflow.ParsePortFeature(v.Advertised); err != nil {
+		return nil, err
+	}
+	if p.supported, err = openflow.ParsePortFeature(v.Supported); err != nil {
+		return nil, err
+	}
+	if p.peer, err = openflow.ParsePortFeature(v.Peer); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *port) MarshalJSON() ([]byte, error) {
+	return json.Marshal(portToJSON(p))
+}
+
+func (p *port) UnmarshalJSON(data []byte) error {
+	return p.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (p *port) MarshalYAML() (interface{}, error) {
+	return portToJSON(p), nil
+}
+
+func (p *port) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	v := jsonPort{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	np, err := portFromJSON(&v)
+	if err != nil {
+		return err
+	}
+	*p = *np
+	return nil
+}
+
+type jsonQueue struct {
+	QueueID uint32 `json:"queue_id" yaml:"queue_id"`
+	MinRate uint16 `json:"min_rate" yaml:"min_rate"`
+}
+
+func queueToJSON(q openflow.Queue) jsonQueue {
+	return jsonQueue{
+		QueueID: q.QueueID(),
+		MinRate: q.Rate(),
+	}
+}
+
+func queueFromJSON(v *jsonQueue) *queue {
+	q := NewQueue().(*queue)
+	q.SetQueueID(v.QueueID)
+	q.SetRate(v.MinRate)
+	return q
+}
+
+func (q *queue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(queueToJSON(q))
+}
+
+func (q *queue) UnmarshalJSON(data []byte) error {
+	return q.UnmarshalYAML(jsonUnmarshaler(data))
+}
+
+func (q *queue) MarshalYAML() (interface{}, error) {
+	return queueToJSON(q), nil
+}
+
+func (q *queue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	v := jsonQueue{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	*q = *queueFromJSON(&v)
+	return nil
+}
+
+// jsonMessage is implemented by every message in this package
+type jsonMessage interface {
+	openflow.MessageDecoder
+	toJSON() interface{}
+	fromJSON(unmarshal func(interface{}) error) error
+}
+
+// UnmarshalMessage decodes a message of any type from JSON or YAML,
+// the concrete message is chosen by its "type" and "stats_type" fields.
+// For JSON use UnmarshalJSONMessage, for YAML pass the unmarshal function
+// given to an UnmarshalYAML method.
+func UnmarshalMessage(unmarshal func(interface{}) error) (openflow.MessageDecoder, error) {
+	v := struct {
+		Type      string `json:"type" yaml:"type"`
+		StatsType string `json:"stats_type" yaml:"stats_type"`
+	}{}
+	if err := unmarshal(&v); err != nil {
+		return nil, err
+	}
+	msgType, ok := ParseMessageType(v.Type)
+	if !ok {
+		return nil, openflow.ErrUnsupportedMessage
+	}
+	var statsType openflow.StatsType
+	if msgType == OFPT_STATS_REQUEST || msgType == OFPT_STATS_REPLY {
+		st, err := openflow.ParseStatsType(v.StatsType)
+		if err != nil {
+			return nil, err
+		}
+		statsType = st
+	}
+	msg, err := newMessage(msgType, statsType, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := msg.(jsonMessage).fromJSON(unmarshal); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// UnmarshalJSONMessage decodes a message of any type from JSON
+func UnmarshalJSONMessage(data []byte) (openflow.MessageDecoder, error) {
+	return UnmarshalMessage(jsonUnmarshaler(data))
+}
+
+// decodeInto decodes v and applies its header to msg
+func decodeInto(msg openflow.HeaderDecoder, unmarshal func(interface{}) error, v interface{}, h *jsonHeader) error {
+	if err := unmarshal(v); err != nil {
+		return err
+	}
+	return h.apply(msg)
+}
+
+// Header only messages: features request, get config request and barrier
+
+type jsonHeaderOnly struct {
+	jsonHeader `yaml:",inline"`
+}
+
+func (f *featureRequest) toJSON() interface{} {
+	return jsonHeaderOnly{headerToJSON(f)}
+}
+
+func (f *featureRequest) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonHeaderOnly{}
+	return decodeInto(f, unmarshal, &v, &v.jsonHeader)
+}
+
+func (f *featureRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.toJSON())
+}
+
+func (f *featureRequest) UnmarshalJSON(data []byte) error {
+	return f.fromJSON(jsonUnmarshaler(data))
+}
+
+func (f *featureRequest) MarshalYAML() (interface{}, error) {
+	return f.toJSON(), nil
+}
+
+func (f *featureRequest) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return f.fromJSON(unmarshal)
+}
+
+func (g *getConfigRequest) toJSON() interface{} {
+	return jsonHeaderOnly{headerToJSON(g)}
+}
+
+func (g *getConfigRequest) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonHeaderOnly{}
+	return decodeInto(g, unmarshal, &v, &v.jsonHeader)
+}
+
+func (g *getConfigRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(g.toJSON())
+}
+
+func (g *getConfigRequest) UnmarshalJSON(data []byte) error {
+	return g.fromJSON(jsonUnmarshaler(data))
+}
+
+func (g *getConfigRequest) MarshalYAML() (interface{}, error) {
+	return g.toJSON(), nil
+}
+
+func (g *getConfigRequest) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return g.fromJSON(unmarshal)
+}
+
+func (b *barrier) toJSON() interface{} {
+	return jsonHeaderOnly{headerToJSON(b)}
+}
+
+func (b *barrier) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonHeaderOnly{}
+	return decodeInto(b, unmarshal, &v, &v.jsonHeader)
+}
+
+func (b *barrier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.toJSON())
+}
+
+func (b *barrier) UnmarshalJSON(data []byte) error {
+	return b.fromJSON(jsonUnmarshaler(data))
+}
+
+func (b *barrier) MarshalYAML() (interface{}, error) {
+	return b.toJSON(), nil
+}
+
+func (b *barrier) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return b.fromJSON(unmarshal)
+}
+
+// Hello and echo
+
+type jsonEcho struct {
+	jsonHeader `yaml:",inline"`
+	Data       hexBytes `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+func (e *echo) toJSON() interface{} {
+	return jsonEcho{
+		jsonHeader: headerToJSON(e),
+		Data:       e.data,
+	}
+}
+
+func (e *echo) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonEcho{}
+	if err := decodeInto(e, unmarshal, &v, &v.jsonHeader); err != nil {
+		return err
+	}
+	e.data = v.Data
+	return nil
+}
+
+func (e *echo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.toJSON())
+}
+
+func (e *echo) UnmarshalJSON(data []byte) error {
+	return e.fromJSON(jsonUnmarshaler(data))
+}
+
+func (e *echo) MarshalYAML() (interface{}, error) {
+	return e.toJSON(), nil
+}
+
+func (e *echo) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return e.fromJSON(unmarshal)
+}
+
+// Error
+
+type jsonError struct {
+	jsonHeader `yaml:",inline"`
+	ErrorType  string   `json:"error_type" yaml:"error_type"`
+	Code       uint16   `json:"code" yaml:"code"`
+	Data       hexBytes `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+func (e *errorMessage) toJSON() interface{} {
+	return jsonError{
+		jsonHeader: headerToJSON(e),
+		ErrorType:  ErrorTypeName(e.typ),
+		Code:       e.code,
+		Data:       e.data,
+	}
+}
+
+func (e *errorMessage) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonError{}
+	if err := decodeInto(e, unmarshal, &v, &v.jsonHeader); err != nil {
+		return err
+	}
+	typ, err := parseEnum(v.ErrorType, errorTypeNames)
+	if err != nil {
+		return err
+	}
+	e.typ = uint16(typ)
+	e.code = v.Code
+	e.data = v.Data
+	return nil
+}
+
+func (e *errorMessage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.toJSON())
+}
+
+func (e *errorMessage) UnmarshalJSON(data []byte) error {
+	return e.fromJSON(jsonUnmarshaler(data))
+}
+
+func (e *errorMessage) MarshalYAML() (interface{}, error) {
+	return e.toJSON(), nil
+}
+
+func (e *errorMessage) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return e.fromJSON(unmarshal)
+}
+
+// Vendor
+
+type jsonVendor struct {
+	jsonHeader `yaml:",inline"`
+	VendorID   uint32   `json:"vendor_id" yaml:"vendor_id"`
+	Data       hexBytes `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+func (v *vendor) toJSON() interface{} {
+	return jsonVendor{
+		jsonHeader: headerToJSON(v),
+		VendorID:   v.vendorID,
+		Data:       v.data,
+	}
+}
+
+func (v *vendor) fromJSON(unmarshal func(interface{}) error) error {
+	jv := jsonVendor{}
+	if err := decodeInto(v, unmarshal, &jv, &jv.jsonHeader); err != nil {
+		return err
+	}
+	v.vendorID = jv.VendorID
+	v.data = jv.Data
+	return nil
+}
+
+func (v *vendor) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.toJSON())
+}
+
+func (v *vendor) UnmarshalJSON(data []byte) error {
+	return v.fromJSON(jsonUnmarshaler(data))
+}
+
+func (v *vendor) MarshalYAML() (interface{}, error) {
+	return v.toJSON(), nil
+}
+
+func (v *vendor) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return v.fromJSON(unmarshal)
+}
+
+// Features reply
+
+type jsonFeatureReply struct {
+	jsonHeader   `yaml:",inline"`
+	DatapathID   string     `json:"datapath_id" yaml:"datapath_id"`
+	NumBuffers   uint32     `json:"n_buffers" yaml:"n_buffers"`
+	NumTables    uint8      `json:"n_tables" yaml:"n_tables"`
+	AuxiliaryID  uint8      `json:"auxiliary_id,omitempty" yaml:"auxiliary_id,omitempty"`
+	Capabilities []string   `json:"capabilities" yaml:"capabilities"`
+	Actions      []string   `json:"actions" yaml:"actions"`
+	Ports        []jsonPort `json:"ports" yaml:"ports"`
+}
+
+// DPIDString formats a datapath id the way it is shown in JSON and YAML
+func DPIDString(dpid uint64) string {
+	return fmt.Sprintf("%016x", dpid)
+}
+
+// ParseDPID is the reverse of DPIDString
+func ParseDPID(s string) (uint64, error) {
+	s = strings.Replace(strings.TrimPrefix(s, "0x"), ":", "", -1)
+	dpid, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, openflow.ErrInvalidValueProvided
+	}
+	return dpid, nil
+}
+
+func (f *featureReply) toJSON() interface{} {
+	v := jsonFeatureReply{
+		jsonHeader:   headerToJSON(f),
+		DatapathID:   DPIDString(f.dpid),
+		NumBuffers:   f.numBuffers,
+		NumTables:    f.numTables,
+		AuxiliaryID:  f.auxiliaryID,
+		Capabilities: f.capabilities.Names(),
+		Actions:      f.actions.Names(),
+		Ports:        []jsonPort{},
+	}
+	for _, p := range f.ports {
+		v.Ports = append(v.Ports, portToJSON(p))
+	}
+	return v
+}
+
+func (f *featureReply) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonFeatureReply{}
+	if err := decodeInto(f, unmarshal, &v, &v.jsonHeader); err != nil {
+		return err
+	}
+	var err error
+	if f.dpid, err = ParseDPID(v.DatapathID); err != nil {
+		return err
+	}
+	f.numBuffers = v.NumBuffers
+	f.numTables = v.NumTables
+	f.auxiliaryID = v.AuxiliaryID
+	if f.capabilities, err = openflow.ParseFeatureCapability(v.Capabilities); err != nil {
+		return err
+	}
+	if f.actions, err = openflow.ParseFeatureAction(v.Actions); err != nil {
+		return err
+	}
+	f.ports = nil
+	for i := range v.Ports {
+		p, err := portFromJSON(&v.Ports[i])
+		if err != nil {
+			return err
+		}
+		f.ports = append(f.ports, p)
+	}
+	return nil
+}
+
+func (f *featureReply) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.toJSON())
+}
+
+func (f *featureReply) UnmarshalJSON(data []byte) error {
+	return f.fromJSON(jsonUnmarshaler(data))
+}
+
+func (f *featureReply) MarshalYAML() (interface{}, error) {
+	return f.toJSON(), nil
+}
+
+func (f *featureReply) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return f.fromJSON(unmarshal)
+}
+
+// Set config and get config reply
+
+type jsonConfig struct {
+	jsonHeader     `yaml:",inline"`
+	Flags          string `json:"flags" yaml:"flags"`
+	MissSendLength uint16 `json:"miss_send_len" yaml:"miss_send_len"`
+}
+
+func (s *setConfig) toJSON() interface{} {
+	return jsonConfig{
+		jsonHeader:     headerToJSON(s),
+		Flags:          ConfigFlagsName(s.flags),
+		MissSendLength: s.missSendLength,
+	}
+}
+
+func (s *setConfig) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonConfig{}
+	if err := decodeInto(s, unmarshal, &v, &v.jsonHeader); err != nil {
+		return err
+	}
+	flags, err := parseEnum(v.Flags, configFlagNames)
+	if err != nil {
+		return err
+	}
+	s.flags = uint16(flags)
+	s.missSendLength = v.MissSendLength
+	return nil
+}
+
+func (s *setConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func (s *setConfig) UnmarshalJSON(data []byte) error {
+	return s.fromJSON(jsonUnmarshaler(data))
+}
+
+func (s *setConfig) MarshalYAML() (interface{}, error) {
+	return s.toJSON(), nil
+}
+
+func (s *setConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return s.fromJSON(unmarshal)
+}
+
+// Packet in
+
+type jsonPacketIn struct {
+	jsonHeader  `yaml:",inline"`
+	BufferID    uint32   `json:"buffer_id" yaml:"buffer_id"`
+	TotalLength uint16   `json:"total_len" yaml:"total_len"`
+	InPort      portNo   `json:"in_port" yaml:"in_port"`
+	Reason      string   `json:"reason" yaml:"reason"`
+	Data        hexBytes `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+func (p *packetIn) toJSON() interface{} {
+	return jsonPacketIn{
+		jsonHeader:  headerToJSON(p),
+		BufferID:    p.bufferID,
+		TotalLength: p.totalLength,
+		InPort:      portNo(p.inPort),
+		Reason:      PacketInReasonName(p.reason),
+		Data:        p.data,
+	}
+}
+
+func (p *packetIn) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonPacketIn{}
+	if err := decodeInto(p, unmarshal, &v, &v.jsonHeader); err != nil {
+		return err
+	}
+	reason, err := parseEnum(v.Reason, packetInReasonNames)
+	if err != nil {
+		return err
+	}
+	p.bufferID = v.BufferID
+	p.inPort = uint16(v.InPort)
+	p.reason = uint8(reason)
+	p.SetData(v.Data)
+	if int(v.TotalLength) > len(v.Data) {
+		p.totalLength = v.TotalLength
+	}
+	return nil
+}
+
+func (p *packetIn) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.toJSON())
+}
+
+func (p *packetIn) UnmarshalJSON(data []byte) error {
+	return p.fromJSON(jsonUnmarshaler(data))
+}
+
+func (p *packetIn) MarshalYAML() (interface{}, error) {
+	return p.toJSON(), nil
+}
+
+func (p *packetIn) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return p.fromJSON(unmarshal)
+}
+
+// Flow removed
+
+type jsonFlowRemoved struct {
+	jsonHeader      `yaml:",inline"`
+	Match           jsonMatch `json:"match" yaml:"match"`
+	Cookie          uint64    `json:"cookie" yaml:"cookie"`
+	Priority        uint16    `json:"priority" yaml:"priority"`
+	Reason          string    `json:"reason" yaml:"reason"`
+	DurationSec     uint32    `json:"duration_sec" yaml:"duration_sec"`
+	DurationNanoSec uint32    `json:"duration_nsec" yaml:"duration_nsec"`
+	IdleTimeout     uint16    `json:"idle_timeout" yaml:"idle_timeout"`
+	PacketCount     uint64    `json:"packet_count" yaml:"packet_count"`
+	ByteCount       uint64    `json:"byte_count" yaml:"byte_count"`
+}
+
+func (f *flowRemoved) toJSON() interface{} {
+	return jsonFlowRemoved{
+		jsonHeader:      headerToJSON(f),
+		Match:           matchToJSON(f.match),
+		Cookie:          f.cookie,
+		Priority:        f.priority,
+		Reason:          FlowRemovedReasonName(f.reason),
+		DurationSec:     f.durationSec,
+		DurationNanoSec: f.durationNanoSec,
+		IdleTimeout:     f.idleTimeout,
+		PacketCount:     f.packetCount,
+		ByteCount:       f.byteCount,
+	}
+}
+
+func (f *flowRemoved) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonFlowRemoved{}
+	if err := decodeInto(f, unmarshal, &v, &v.jsonHeader); err != nil {
+		return err
+	}
+	m, err := matchFromJSON(&v.Match)
+	if err != nil {
+		return err
+	}
+	reason, err := parseEnum(v.Reason, flowRemovedReasonNames)
+	if err != nil {
+		return err
+	}
+	f.match = m
+	f.cookie = v.Cookie
+	f.priority = v.Priority
+	f.reason = uint8(reason)
+	f.durationSec = v.DurationSec
+	f.durationNanoSec = v.DurationNanoSec
+	f.idleTimeout = v.IdleTimeout
+	f.packetCount = v.PacketCount
+	f.byteCount = v.ByteCount
+	return nil
+}
+
+func (f *flowRemoved) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.toJSON())
+}
+
+func (f *flowRemoved) UnmarshalJSON(data []byte) error {
+	return f.fromJSON(jsonUnmarshaler(data))
+}
+
+func (f *flowRemoved) MarshalYAML() (interface{}, error) {
+	return f.toJSON(), nil
+}
+
+func (f *flowRemoved) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return f.fromJSON(unmarshal)
+}
+
+// Port status
+
+type jsonPortStatus struct {
+	jsonHeader `yaml:",inline"`
+	Reason     string   `json:"reason" yaml:"reason"`
+	Port       jsonPort `json:"port" yaml:"port"`
+}
+
+func (p *portStatus) toJSON() interface{} {
+	v := jsonPortStatus{
+		jsonHeader: headerToJSON(p),
+		Reason:     p.reason.String(),
+	}
+	if p.port != nil {
+		v.Port = portToJSON(p.port)
+	}
+	return v
+}
+
+func (p *portStatus) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonPortStatus{}
+	if err := decodeInto(p, unmarshal, &v, &v.jsonHeader); err != nil {
+		return err
+	}
+	reason, err := openflow.ParsePortReason(v.Reason)
+	if err != nil {
+		return err
+	}
+	np, err := portFromJSON(&v.Port)
+	if err != nil {
+		return err
+	}
+	p.reason = reason
+	p.port = np
+	return nil
+}
+
+func (p *portStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.toJSON())
+}
+
+func (p *portStatus) UnmarshalJSON(data []byte) error {
+	return p.fromJSON(jsonUnmarshaler(data))
+}
+
+func (p *portStatus) MarshalYAML() (interface{}, error) {
+	return p.toJSON(), nil
+}
+
+func (p *portStatus) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return p.fromJSON(unmarshal)
+}
+
+// Packet out
+
+type jsonPacketOut struct {
+	jsonHeader `yaml:",inline"`
+	BufferID   uint32       `json:"buffer_id" yaml:"buffer_id"`
+	InPort     portNo       `json:"in_port" yaml:"in_port"`
+	Actions    []jsonAction `json:"actions" yaml:"actions"`
+	Data       hexBytes     `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+func (p *packetOut) toJSON() interface{} {
+	return jsonPacketOut{
+		jsonHeader: headerToJSON(p),
+		BufferID:   p.bufferID,
+		InPort:     portNo(p.inPort),
+		Actions:    actionsToJSON(p.action),
+		Data:       p.data,
+	}
+}
+
+func (p *packetOut) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonPacketOut{}
+	if err := decodeInto(p, unmarshal, &v, &v.jsonHeader); err != nil {
+		return err
+	}
+	actions, err := actionsFromJSON(v.Actions)
+	if err != nil {
+		return err
+	}
+	p.bufferID = v.BufferID
+	p.inPort = uint16(v.InPort)
+	p.action = nil
+	p.actionsLength = 0
+	for _, a := range actions {
+		p.AddAction(a)
+	}
+	p.data = v.Data
+	return nil
+}
+
+func (p *packetOut) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.toJSON())
+}
+
+func (p *packetOut) UnmarshalJSON(data []byte) error {
+	return p.fromJSON(jsonUnmarshaler(data))
+}
+
+func (p *packetOut) MarshalYAML() (interface{}, error) {
+	return p.toJSON(), nil
+}
+
+func (p *packetOut) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return p.fromJSON(unmarshal)
+}
+
+// Flow mod
+
+type jsonFlowMod struct {
+	jsonHeader  `yaml:",inline"`
+	Match       jsonMatch    `json:"match" yaml:"match"`
+	Cookie      uint64       `json:"cookie" yaml:"cookie"`
+	Command     string       `json:"command" yaml:"command"`
+	IdleTimeout uint16       `json:"idle_timeout" yaml:"idle_timeout"`
+	HardTimeout uint16       `json:"hard_timeout" yaml:"hard_timeout"`
+	Priority    uint16       `json:"priority" yaml:"priority"`
+	BufferID    uint32       `json:"buffer_id" yaml:"buffer_id"`
+	OutPort     portNo       `json:"out_port" yaml:"out_port"`
+	Flags       []string     `json:"flags" yaml:"flags"`
+	Actions     []jsonAction `json:"actions" yaml:"actions"`
+}
+
+func (f *flowMod) toJSON() interface{} {
+	v := jsonFlowMod{
+		jsonHeader:  headerToJSON(f),
+		Match:       matchToJSON(f.match),
+		Cookie:      f.cookie,
+		Command:     f.command.String(),
+		IdleTimeout: f.idleTimeout,
+		HardTimeout: f.hardTimeout,
+		Priority:    f.priority,
+		BufferID:    f.bufferID,
+		OutPort:     portNo(f.outPort),
+		Flags:       f.flags.Names(),
+		Actions:     actionsToJSON(f.actions),
+	}
+	return v
+}
+
+func (f *flowMod) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonFlowMod{}
+	if err := decodeInto(f, unmarshal, &v, &v.jsonHeader); err != nil {
+		return err
+	}
+	m, err := matchFromJSON(&v.Match)
+	if err != nil {
+		return err
+	}
+	command, err := openflow.ParseFlowCommand(v.Command)
+	if err != nil {
+		return err
+	}
+	flags, err := openflow.ParseFlowFlag(v.Flags)
+	if err != nil {
+		return err
+	}
+	actions, err := actionsFromJSON(v.Actions)
+	if err != nil {
+		return err
+	}
+	f.match = m
+	f.cookie = v.Cookie
+	f.command = command
+	f.idleTimeout = v.IdleTimeout
+	f.hardTimeout = v.HardTimeout
+	f.priority = v.Priority
+	f.bufferID = v.BufferID
+	f.outPort = uint16(v.OutPort)
+	f.flags = flags
+	f.actions = actions
+	return nil
+}
+
+func (f *flowMod) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.toJSON())
+}
+
+func (f *flowMod) UnmarshalJSON(data []byte) error {
+	return f.fromJSON(jsonUnmarshaler(data))
+}
+
+func (f *flowMod) MarshalYAML() (interface{}, error) {
+	return f.toJSON(), nil
+}
+
+func (f *flowMod) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return f.fromJSON(unmarshal)
+}
+
+// Port mod
+
+type jsonPortMod struct {
+	jsonHeader `yaml:",inline"`
+	PortNo     portNo   `json:"port_no" yaml:"port_no"`
+	HWAddr     string   `json:"hw_addr" yaml:"hw_addr"`
+	Config     []string `json:"config" yaml:"config"`
+	Mask       []string `json:"mask" yaml:"mask"`
+	Advertise  []string `json:"advertise" yaml:"advertise"`
+}
+
+func (p *portMod) toJSON() interface{} {
+	return jsonPortMod{
+		jsonHeader: headerToJSON(p),
+		PortNo:     portNo(p.port),
+		HWAddr:     p.hwAddr.String(),
+		Config:     p.config.Names(),
+		Mask:       openflow.PortConfig(p.mask).Names(),
+		Advertise:  p.advertise.Names(),
+	}
+}
+
+func (p *portMod) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonPortMod{}
+	if err := decodeInto(p, unmarshal, &v, &v.jsonHeader); err != nil {
+		return err
+	}
+	mac, err := parseMAC(v.HWAddr)
+	if err != nil {
+		return err
+	}
+	config, err := openflow.ParsePortConfig(v.Config)
+	if err != nil {
+		return err
+	}
+	mask, err := openflow.ParsePortConfig(v.Mask)
+	if err != nil {
+		return err
+	}
+	advertise, err := openflow.ParsePortFeature(v.Advertise)
+	if err != nil {
+		return err
+	}
+	p.port = openflow.PortID(v.PortNo)
+	p.hwAddr = mac
+	p.config = config
+	if err := p.SetMask(uint32(mask)); err != nil {
+		return err
+	}
+	p.advertise = advertise
+	return nil
+}
+
+func (p *portMod) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.toJSON())
+}
+
+func (p *portMod) UnmarshalJSON(data []byte) error {
+	return p.fromJSON(jsonUnmarshaler(data))
+}
+
+func (p *portMod) MarshalYAML() (interface{}, error) {
+	return p.toJSON(), nil
+}
+
+func (p *portMod) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return p.fromJSON(unmarshal)
+}
+
+// Queue get config
+
+type jsonQueueGetConfig struct {
+	jsonHeader `yaml:",inline"`
+	Port       portNo      `json:"port" yaml:"port"`
+	Queues     []jsonQueue `json:"queues,omitempty" yaml:"queues,omitempty"`
+}
+
+func (q *queueGetConfigRequest) toJSON() interface{} {
+	return jsonQueueGetConfig{
+		jsonHeader: headerToJSON(q),
+		Port:       portNo(q.port),
+	}
+}
+
+func (q *queueGetConfigRequest) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonQueueGetConfig{}
+	if err := decodeInto(q, unmarshal, &v, &v.jsonHeader); err != nil {
+		return err
+	}
+	return q.SetPort(uint16(v.Port))
+}
+
+func (q *queueGetConfigRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.toJSON())
+}
+
+func (q *queueGetConfigRequest) UnmarshalJSON(data []byte) error {
+	return q.fromJSON(jsonUnmarshaler(data))
+}
+
+func (q *queueGetConfigRequest) MarshalYAML() (interface{}, error) {
+	return q.toJSON(), nil
+}
+
+func (q *queueGetConfigRequest) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return q.fromJSON(unmarshal)
+}
+
+func (q *queueGetConfigReply) toJSON() interface{} {
+	v := jsonQueueGetConfig{
+		jsonHeader: headerToJSON(q),
+		Port:       portNo(q.port),
+		Queues:     []jsonQueue{},
+	}
+	for _, nq := range q.queue {
+		v.Queues = append(v.Queues, queueToJSON(nq))
+	}
+	return v
+}
+
+func (q *queueGetConfigReply) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonQueueGetConfig{}
+	if err := decodeInto(q, unmarshal, &v, &v.jsonHeader); err != nil {
+		return err
+	}
+	q.port = uint16(v.Port)
+	q.queue = nil
+	for i := range v.Queues {
+		q.queue = append(q.queue, queueFromJSON(&v.Queues[i]))
+	}
+	return nil
+}
+
+func (q *queueGetConfigReply) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.toJSON())
+}
+
+func (q *queueGetConfigReply) UnmarshalJSON(data []byte) error {
+	return q.fromJSON(jsonUnmarshaler(data))
+}
+
+func (q *queueGetConfigReply) MarshalYAML() (interface{}, error) {
+	return q.toJSON(), nil
+}
+
+func (q *queueGetConfigReply) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return q.fromJSON(unmarshal)
+}
+
+// Stats request and reply
+
+type jsonStatsHeader struct {
+	jsonHeader `yaml:",inline"`
+	StatsType  string `json:"stats_type" yaml:"stats_type"`
+	Flags      uint16 `json:"flags" yaml:"flags"`
+}
+
+func statsHeaderToJSON(s *statsHeader) jsonStatsHeader {
+	return jsonStatsHeader{
+		jsonHeader: headerToJSON(s),
+		StatsType:  s.typ.String(),
+		Flags:      s.flags,
+	}
+}
+
+// apply checks the stats type and sets the header fields of s
+func (h *jsonStatsHeader) apply(s *statsHeader) error {
+	if err := h.jsonHeader.apply(s); err != nil {
+		return err
+	}
+	t, err := openflow.ParseStatsType(h.StatsType)
+	if err != nil {
+		return err
+	}
+	if t != s.typ {
+		return openflow.ErrUnsupportedMessage
+	}
+	s.flags = h.Flags
+	return nil
+}
+
+type jsonStatsGeneric struct {
+	jsonStatsHeader `yaml:",inline"`
+	Body            hexBytes `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+func (s *statsHeader) toJSON() interface{} {
+	return jsonStatsGeneric{
+		jsonStatsHeader: statsHeaderToJSON(s),
+		Body:            s.statsPayload,
+	}
+}
+
+func (s *statsHeader) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonStatsGeneric{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	// a bare stats header takes any stats type
+	t, err := openflow.ParseStatsType(v.StatsType)
+	if err != nil {
+		return err
+	}
+	s.typ = t
+	if err := v.apply(s); err != nil {
+		return err
+	}
+	s.statsPayload = v.Body
+	return nil
+}
+
+func (s *statsHeader) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func (s *statsHeader) UnmarshalJSON(data []byte) error {
+	return s.fromJSON(jsonUnmarshaler(data))
+}
+
+func (s *statsHeader) MarshalYAML() (interface{}, error) {
+	return s.toJSON(), nil
+}
+
+func (s *statsHeader) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return s.fromJSON(unmarshal)
+}
+
+type jsonStatsRequestFlow struct {
+	jsonStatsHeader `yaml:",inline"`
+	Match           jsonMatch `json:"match" yaml:"match"`
+	TableID         uint8     `json:"table_id" yaml:"table_id"`
+	OutPort         portNo    `json:"out_port" yaml:"out_port"`
+}
+
+func (s *statsRequestFlow) toJSON() interface{} {
+	return jsonStatsRequestFlow{
+		jsonStatsHeader: statsHeaderToJSON(s.statsHeader),
+		Match:           matchToJSON(s.match),
+		TableID:         s.tableID,
+		OutPort:         portNo(s.outPort),
+	}
+}
+
+func (s *statsRequestFlow) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonStatsRequestFlow{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	if err := v.apply(s.statsHeader); err != nil {
+		return err
+	}
+	m, err := matchFromJSON(&v.Match)
+	if err != nil {
+		return err
+	}
+	s.match = m
+	s.tableID = v.TableID
+	s.outPort = uint16(v.OutPort)
+	return nil
+}
+
+func (s *statsRequestFlow) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func (s *statsRequestFlow) UnmarshalJSON(data []byte) error {
+	return s.fromJSON(jsonUnmarshaler(data))
+}
+
+func (s *statsRequestFlow) MarshalYAML() (interface{}, error) {
+	return s.toJSON(), nil
+}
+
+func (s *statsRequestFlow) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return s.fromJSON(unmarshal)
+}
+
+type jsonStatsRequestPort struct {
+	jsonStatsHeader `yaml:",inline"`
+	PortNo          portNo  `json:"port_no" yaml:"port_no"`
+	QueueID         *uint32 `json:"queue_id,omitempty" yaml:"queue_id,omitempty"`
+}
+
+func (s *statsRequestPort) toJSON() interface{} {
+	return jsonStatsRequestPort{
+		jsonStatsHeader: statsHeaderToJSON(s.statsHeader),
+		PortNo:          portNo(s.portNumber),
+	}
+}
+
+func (s *statsRequestPort) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonStatsRequestPort{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	if err := v.apply(s.statsHeader); err != nil {
+		return err
+	}
+	s.portNumber = uint16(v.PortNo)
+	return nil
+}
+
+func (s *statsRequestPort) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func (s *statsRequestPort) UnmarshalJSON(data []byte) error {
+	return s.fromJSON(jsonUnmarshaler(data))
+}
+
+func (s *statsRequestPort) MarshalYAML() (interface{}, error) {
+	return s.toJSON(), nil
+}
+
+func (s *statsRequestPort) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return s.fromJSON(unmarshal)
+}
+
+func (s *statsRequestQueue) toJSON() interface{} {
+	qid := s.queueID
+	return jsonStatsRequestPort{
+		jsonStatsHeader: statsHeaderToJSON(s.statsHeader),
+		PortNo:          portNo(s.portNumber),
+		QueueID:         &qid,
+	}
+}
+
+func (s *statsRequestQueue) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonStatsRequestPort{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	if err := v.apply(s.statsHeader); err != nil {
+		return err
+	}
+	s.portNumber = uint16(v.PortNo)
+	s.queueID = 0
+	if v.QueueID != nil {
+		s.queueID = *v.QueueID
+	}
+	return nil
+}
+
+func (s *statsRequestQueue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func (s *statsRequestQueue) UnmarshalJSON(data []byte) error {
+	return s.fromJSON(jsonUnmarshaler(data))
+}
+
+func (s *statsRequestQueue) MarshalYAML() (interface{}, error) {
+	return s.toJSON(), nil
+}
+
+func (s *statsRequestQueue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return s.fromJSON(unmarshal)
+}
+
+type jsonStatsRequestVendor struct {
+	jsonStatsHeader `yaml:",inline"`
+	VendorID        uint32 `json:"vendor_id" yaml:"vendor_id"`
+}
+
+func (s *statsRequestVendor) toJSON() interface{} {
+	return jsonStatsRequestVendor{
+		jsonStatsHeader: statsHeaderToJSON(s.statsHeader),
+		VendorID:        s.vendorID,
+	}
+}
+
+func (s *statsRequestVendor) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonStatsRequestVendor{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	if err := v.apply(s.statsHeader); err != nil {
+		return err
+	}
+	s.vendorID = v.VendorID
+	return nil
+}
+
+func (s *statsRequestVendor) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func (s *statsRequestVendor) UnmarshalJSON(data []byte) error {
+	return s.fromJSON(jsonUnmarshaler(data))
+}
+
+func (s *statsRequestVendor) MarshalYAML() (interface{}, error) {
+	return s.toJSON(), nil
+}
+
+func (s *statsRequestVendor) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return s.fromJSON(unmarshal)
+}
+
+type jsonStatsReplyDescription struct {
+	jsonStatsHeader `yaml:",inline"`
+	MfrDesc         string `json:"mfr_desc" yaml:"mfr_desc"`
+	HwDesc          string `json:"hw_desc" yaml:"hw_desc"`
+	SwDesc          string `json:"sw_desc" yaml:"sw_desc"`
+	SerialNum       string `json:"serial_num" yaml:"serial_num"`
+	DpDesc          string `json:"dp_desc" yaml:"dp_desc"`
+}
+
+// cString returns the string stored in a NUL padded byte array
+func cString(b []byte) string {
+	return strings.TrimRight(string(b), "\x00")
+}
+
+func (s *statsReplyDescription) toJSON() interface{} {
+	return jsonStatsReplyDescription{
+		jsonStatsHeader: statsHeaderToJSON(s.statsHeader),
+		MfrDesc:         cString(s.mfrDesc[:]),
+		HwDesc:          cString(s.hwDesc[:]),
+		SwDesc:          cString(s.swDesc[:]),
+		SerialNum:       cString(s.serialNum[:]),
+		DpDesc:          cString(s.dpDesc[:]),
+	}
+}
+
+func (s *statsReplyDescription) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonStatsReplyDescription{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	if err := v.apply(s.statsHeader); err != nil {
+		return err
+	}
+	if len(v.MfrDesc) > 256 || len(v.HwDesc) > 256 || len(v.SwDesc) > 256 ||
+		len(v.SerialNum) > 32 || len(v.DpDesc) > 256 {
+		return openflow.ErrInvalidDataLength
+	}
+	*s.mfrDesc = [256]byte{}
+	*s.hwDesc = [256]byte{}
+	*s.swDesc = [256]byte{}
+	*s.serialNum = [32]byte{}
+	*s.dpDesc = [256]byte{}
+	copy(s.mfrDesc[:], v.MfrDesc)
+	copy(s.hwDesc[:], v.HwDesc)
+	copy(s.swDesc[:], v.SwDesc)
+	copy(s.serialNum[:], v.SerialNum)
+	copy(s.dpDesc[:], v.DpDesc)
+	return nil
+}
+
+func (s *statsReplyDescription) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func (s *statsReplyDescription) UnmarshalJSON(data []byte) error {
+	return s.fromJSON(jsonUnmarshaler(data))
+}
+
+func (s *statsReplyDescription) MarshalYAML() (interface{}, error) {
+	return s.toJSON(), nil
+}
+
+func (s *statsReplyDescription) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return s.fromJSON(unmarshal)
+}
+
+type jsonFlowStats struct {
+	TableID         uint8        `json:"table_id" yaml:"table_id"`
+	Match           jsonMatch    `json:"match" yaml:"match"`
+	DurationSec     uint32       `json:"duration_sec" yaml:"duration_sec"`
+	DurationNanoSec uint32       `json:"duration_nsec" yaml:"duration_nsec"`
+	Priority        uint16       `json:"priority" yaml:"priority"`
+	IdleTimeout     uint16       `json:"idle_timeout" yaml:"idle_timeout"`
+	HardTimeout     uint16       `json:"hard_timeout" yaml:"hard_timeout"`
+	Cookie          uint64       `json:"cookie" yaml:"cookie"`
+	PacketCount     uint64       `json:"packet_count" yaml:"packet_count"`
+	ByteCount       uint64       `json:"byte_count" yaml:"byte_count"`
+	Actions         []jsonAction `json:"actions" yaml:"actions"`
+}
+
+type jsonStatsReplyFlow struct {
+	jsonStatsHeader `yaml:",inline"`
+	Flows           []jsonFlowStats `json:"flows" yaml:"flows"`
+}
+
+func (s *statsReplyFlow) toJSON() interface{} {
+	v := jsonStatsReplyFlow{
+		jsonStatsHeader: statsHeaderToJSON(s.statsHeader),
+		Flows:           make([]jsonFlowStats, 0, len(s.flows)),
+	}
+	for _, f := range s.flows {
+		v.Flows = append(v.Flows, jsonFlowStats{
+			TableID:         f.TableID(),
+			Match:           matchToJSON(f.Match()),
+			DurationSec:     f.DurationSec(),
+			DurationNanoSec: f.DurationNanoSec(),
+			Priority:        f.Priority(),
+			IdleTimeout:     f.IdleTimeout(),
+			HardTimeout:     f.HardTimeout(),
+			Cookie:          f.Cookie(),
+			PacketCount:     f.PacketCount(),
+			ByteCount:       f.ByteCount(),
+			Actions:         actionsToJSON(f.Actions()),
+		})
+	}
+	return v
+}
+
+func (s *statsReplyFlow) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonStatsReplyFlow{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	if err := v.apply(s.statsHeader); err != nil {
+		return err
+	}
+	s.flows = nil
+	for i := range v.Flows {
+		jf := &v.Flows[i]
+		m, err := matchFromJSON(&jf.Match)
+		if err != nil {
+			return err
+		}
+		actions, err := actionsFromJSON(jf.Actions)
+		if err != nil {
+			return err
+		}
+		s.flows = append(s.flows, &flowStats{
+			tableID:         jf.TableID,
+			match:           m,
+			durationSec:     jf.DurationSec,
+			durationNanoSec: jf.DurationNanoSec,
+			priority:        jf.Priority,
+			idleTimeout:     jf.IdleTimeout,
+			hardTimeout:     jf.HardTimeout,
+			cookie:          jf.Cookie,
+			packetCount:     jf.PacketCount,
+			byteCount:       jf.ByteCount,
+			actions:         actions,
+		})
+	}
+	return nil
+}
+
+func (s *statsReplyFlow) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func (s *statsReplyFlow) UnmarshalJSON(data []byte) error {
+	return s.fromJSON(jsonUnmarshaler(data))
+}
+
+func (s *statsReplyFlow) MarshalYAML() (interface{}, error) {
+	return s.toJSON(), nil
+}
+
+func (s *statsReplyFlow) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return s.fromJSON(unmarshal)
+}
+
+type jsonStatsReplyAggregate struct {
+	jsonStatsHeader `yaml:",inline"`
+	PacketCount     uint64 `json:"packet_count" yaml:"packet_count"`
+	ByteCount       uint64 `json:"byte_count" yaml:"byte_count"`
+	FlowCount       uint32 `json:"flow_count" yaml:"flow_count"`
+}
+
+func (s *statsReplyAggregate) toJSON() interface{} {
+	return jsonStatsReplyAggregate{
+		jsonStatsHeader: statsHeaderToJSON(s.statsHeader),
+		PacketCount:     s.packetCount,
+		ByteCount:       s.byteCount,
+		FlowCount:       s.flowCount,
+	}
+}
+
+func (s *statsReplyAggregate) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonStatsReplyAggregate{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	if err := v.apply(s.statsHeader); err != nil {
+		return err
+	}
+	s.packetCount = v.PacketCount
+	s.byteCount = v.ByteCount
+	s.flowCount = v.FlowCount
+	return nil
+}
+
+func (s *statsReplyAggregate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func (s *statsReplyAggregate) UnmarshalJSON(data []byte) error {
+	return s.fromJSON(jsonUnmarshaler(data))
+}
+
+func (s *statsReplyAggregate) MarshalYAML() (interface{}, error) {
+	return s.toJSON(), nil
+}
+
+func (s *statsReplyAggregate) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return s.fromJSON(unmarshal)
+}
+
+type jsonTableStats struct {
+	TableID      uint8  `json:"table_id" yaml:"table_id"`
+	Name         string `json:"name" yaml:"name"`
+	Wildcards    uint32 `json:"wildcards" yaml:"wildcards"`
+	MaxEntries   uint32 `json:"max_entries" yaml:"max_entries"`
+	ActiveCount  uint32 `json:"active_count" yaml:"active_count"`
+	LookupCount  uint64 `json:"lookup_count" yaml:"lookup_count"`
+	MatchedCount uint64 `json:"matched_count" yaml:"matched_count"`
+}
+
+type jsonStatsReplyTable struct {
+	jsonStatsHeader `yaml:",inline"`
+	Tables          []jsonTableStats `json:"tables" yaml:"tables"`
+}
+
+func (s *statsReplyTable) toJSON() interface{} {
+	v := jsonStatsReplyTable{
+		jsonStatsHeader: statsHeaderToJSON(s.statsHeader),
+		Tables:          make([]jsonTableStats, 0, len(s.tables)),
+	}
+	for _, t := range s.tables {
+		v.Tables = append(v.Tables, jsonTableStats{
+			TableID:      t.TableID(),
+			Name:         t.Name(),
+			Wildcards:    t.Wildcards(),
+			MaxEntries:   t.MaxEntries(),
+			ActiveCount:  t.ActiveCount(),
+			LookupCount:  t.LookupCount(),
+			MatchedCount: t.MatchedCount(),
+		})
+	}
+	return v
+}
+
+func (s *statsReplyTable) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonStatsReplyTable{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	if err := v.apply(s.statsHeader); err != nil {
+		return err
+	}
+	s.tables = nil
+	for _, jt := range v.Tables {
+		t := &tableStats{
+			tableID:      jt.TableID,
+			wildcards:    jt.Wildcards,
+			maxEntries:   jt.MaxEntries,
+			activeCount:  jt.ActiveCount,
+			lookupCount:  jt.LookupCount,
+			matchedCount: jt.MatchedCount,
+		}
+		if err := t.SetName(jt.Name); err != nil {
+			return err
+		}
+		s.tables = append(s.tables, t)
+	}
+	return nil
+}
+
+func (s *statsReplyTable) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func (s *statsReplyTable) UnmarshalJSON(data []byte) error {
+	return s.fromJSON(jsonUnmarshaler(data))
+}
+
+func (s *statsReplyTable) MarshalYAML() (interface{}, error) {
+	return s.toJSON(), nil
+}
+
+func (s *statsReplyTable) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return s.fromJSON(unmarshal)
+}
+
+type jsonPortStats struct {
+	PortNo     portNo `json:"port_no" yaml:"port_no"`
+	RxPackets  uint64 `json:"rx_packets" yaml:"rx_packets"`
+	TxPackets  uint64 `json:"tx_packets" yaml:"tx_packets"`
+	RxBytes    uint64 `json:"rx_bytes" yaml:"rx_bytes"`
+	TxBytes    uint64 `json:"tx_bytes" yaml:"tx_bytes"`
+	RxDropped  uint64 `json:"rx_dropped" yaml:"rx_dropped"`
+	TxDropped  uint64 `json:"tx_dropped" yaml:"tx_dropped"`
+	RxErrors   uint64 `json:"rx_errors" yaml:"rx_errors"`
+	TxErrors   uint64 `json:"tx_errors" yaml:"tx_errors"`
+	RxFrameErr uint64 `json:"rx_frame_err" yaml:"rx_frame_err"`
+	RxOverErr  uint64 `json:"rx_over_err" yaml:"rx_over_err"`
+	RxCRCErr   uint64 `json:"rx_crc_err" yaml:"rx_crc_err"`
+	Collisions uint64 `json:"collisions" yaml:"collisions"`
+}
+
+type jsonStatsReplyPort struct {
+	jsonStatsHeader `yaml:",inline"`
+	Ports           []jsonPortStats `json:"ports" yaml:"ports"`
+}
+
+func (s *statsReplyPort) toJSON() interface{} {
+	v := jsonStatsReplyPort{
+		jsonStatsHeader: statsHeaderToJSON(s.statsHeader),
+		Ports:           make([]jsonPortStats, 0, len(s.ports)),
+	}
+	for _, p := range s.ports {
+		v.Ports = append(v.Ports, jsonPortStats{
+			PortNo:     portNo(p.PortNumber()),
+			RxPackets:  p.RxPackets(),
+			TxPackets:  p.TxPackets(),
+			RxBytes:    p.RxBytes(),
+			TxBytes:    p.TxBytes(),
+			RxDropped:  p.RxDropped(),
+			TxDropped:  p.TxDropped(),
+			RxErrors:   p.RxErrors(),
+			TxErrors:   p.TxErrors(),
+			RxFrameErr: p.RxFrameErr(),
+			RxOverErr:  p.RxOverErr(),
+			RxCRCErr:   p.RxCRCErr(),
+			Collisions: p.Collisions(),
+		})
+	}
+	return v
+}
+
+func (s *statsReplyPort) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonStatsReplyPort{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	if err := v.apply(s.statsHeader); err != nil {
+		return err
+	}
+	s.ports = nil
+	for _, jp := range v.Ports {
+		s.ports = append(s.ports, &portStats{
+			portNumber: uint16(jp.PortNo),
+			counters: [portCounters]uint64{
+				jp.RxPackets, jp.TxPackets, jp.RxBytes, jp.TxBytes,
+				jp.RxDropped, jp.TxDropped, jp.RxErrors, jp.TxErrors,
+				jp.RxFrameErr, jp.RxOverErr, jp.RxCRCErr, jp.Collisions,
+			},
+		})
+	}
+	return nil
+}
+
+func (s *statsReplyPort) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func (s *statsReplyPort) UnmarshalJSON(data []byte) error {
+	return s.fromJSON(jsonUnmarshaler(data))
+}
+
+func (s *statsReplyPort) MarshalYAML() (interface{}, error) {
+	return s.toJSON(), nil
+}
+
+func (s *statsReplyPort) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return s.fromJSON(unmarshal)
+}
+
+type jsonQueueStats struct {
+	PortNo    portNo `json:"port_no" yaml:"port_no"`
+	QueueID   uint32 `json:"queue_id" yaml:"queue_id"`
+	TxBytes   uint64 `json:"tx_bytes" yaml:"tx_bytes"`
+	TxPackets uint64 `json:"tx_packets" yaml:"tx_packets"`
+	TxErrors  uint64 `json:"tx_errors" yaml:"tx_errors"`
+}
+
+type jsonStatsReplyQueue struct {
+	jsonStatsHeader `yaml:",inline"`
+	Queues          []jsonQueueStats `json:"queues" yaml:"queues"`
+}
+
+func (s *statsReplyQueue) toJSON() interface{} {
+	v := jsonStatsReplyQueue{
+		jsonStatsHeader: statsHeaderToJSON(s.statsHeader),
+		Queues:          make([]jsonQueueStats, 0, len(s.queues)),
+	}
+	for _, q := range s.queues {
+		v.Queues = append(v.Queues, jsonQueueStats{
+			PortNo:    portNo(q.PortNumber()),
+			QueueID:   q.QueueID(),
+			TxBytes:   q.TxBytes(),
+			TxPackets: q.TxPackets(),
+			TxErrors:  q.TxErrors(),
+		})
+	}
+	return v
+}
+
+func (s *statsReplyQueue) fromJSON(unmarshal func(interface{}) error) error {
+	v := jsonStatsReplyQueue{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	if err := v.apply(s.statsHeader); err != nil {
+		return err
+	}
+	s.queues = nil
+	for _, jq := range v.Queues {
+		s.queues = append(s.queues, &queueStats{
+			portNumber: uint16(jq.PortNo),
+			queueID:    jq.QueueID,
+			txBytes:    jq.TxBytes,
+			txPackets:  jq.TxPackets,
+			txErrors:   jq.TxErrors,
+		})
+	}
+	return nil
+}
+
+func (s *statsReplyQueue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func (s *statsReplyQueue) UnmarshalJSON(data []byte) error {
+	return s.fromJSON(jsonUnmarshaler(data))
+}
+
+func (s *statsReplyQueue) MarshalYAML() (interface{}, error) {
+	return s.toJSON(), nil
+}
+
+func (s *statsReplyQueue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return s.fromJSON(unmarshal)
+}
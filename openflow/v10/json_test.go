@@ -0,0 +1,356 @@
+package v10
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"github.com/ksang/goflow/openflow"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testFlowMod() openflow.FlowMod {
+	fm := NewFlowMod(uint32(23334))
+	m := NewMatch()
+	m.SetInPort(uint16(1))
+	m.SetDLSrc([]byte{0x1, 0x1, 0x1, 0x1, 0x1, 0x1})
+	m.SetNWSrc(net.IPv4(10, 0, 0, 0))
+	m.SetWildcardNWSrc(24)
+	fm.SetMatch(m)
+	fm.SetCookie(uint64(111))
+	fm.SetIdleTimeout(uint16(30))
+	fm.SetFlags(openflow.SendFlowRem | openflow.CheckOverlap)
+	actOut := NewActionOutput()
+	actOut.SetPort(uint16(2))
+	actOut.SetMaxLen(uint16(65535))
+	fm.SetAction(actOut)
+	return fm
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	feature := NewFeatureReply(uint32(7))
+	feature.SetDPID(uint64(0x1b207))
+	feature.SetCapabilities(openflow.FLOW_STATS | openflow.ARP_MATCH_IP)
+	feature.SetActions(openflow.OUTPUT | openflow.ENQUEUE)
+	p, _ := NewPort(openflow.Local, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}, "br0")
+	p.SetState(openflow.LinkDown | openflow.STPForward)
+	feature.AddPort(p)
+
+	packetOut := NewPacketOut(uint32(8))
+	packetOut.SetBufferID(OFP_NO_BUFFER)
+	act := NewActionSetNWDst()
+	act.SetNWDst(net.IPv4(192, 168, 0, 1).To4())
+	packetOut.AddAction(act)
+	packetOut.SetData([]byte{0xde, 0xad})
+
+	srf := NewStatsReuqestFlow(uint32(9))
+	srf.SetOutPort(uint16(openflow.None))
+
+	flow := NewFlowStats()
+	flow.SetMatch(testFlowMod().Match())
+	flow.SetCookie(uint64(111))
+	flow.SetPacketCount(uint64(3))
+	flow.AddAction(testFlowMod().Action())
+	flowReply := NewStatsReplyFlow(uint32(11))
+	flowReply.SetFlags(OFPSF_REPLY_MORE)
+	flowReply.AddFlow(flow)
+	flowReply.AddFlow(NewFlowStats())
+
+	aggregate := NewStatsReplyAggregate(uint32(12))
+	aggregate.SetPacketCount(uint64(5))
+	aggregate.SetFlowCount(uint32(2))
+	table := NewTableStats()
+	table.SetName("classifier")
+	table.SetActiveCount(uint32(2))
+	tableReply := NewStatsReplyTable(uint32(13))
+	tableReply.AddTable(table)
+	port := NewPortStats()
+	port.SetPortNumber(uint16(openflow.Local))
+	port.SetRxBytes(uint64(1500))
+	port.SetCollisions(^uint64(0))
+	portReply := NewStatsReplyPort(uint32(14))
+	portReply.AddPort(port)
+	queue := NewQueueStats()
+	queue.SetPortNumber(uint16(1))
+	queue.SetQueueID(uint32(3))
+	queue.SetTxPackets(uint64(9))
+	queueReply := NewStatsReplyQueue(uint32(15))
+	queueReply.AddQueue(queue)
+
+	tests := []openflow.MessageDecoder{
+		testFlowMod(),
+		feature,
+		packetOut,
+		srf,
+		flowReply,
+		aggregate,
+		tableReply,
+		portReply,
+		queueReply,
+		NewEchoRequest(uint32(10)),
+	}
+	for _, msg := range tests {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := UnmarshalJSONMessage(data)
+		if err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		again, err := json.Marshal(decoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, again) {
+			t.Errorf("round trip mismatch:\n%s\n%s", data, again)
+		}
+		want, err := msg.(interface{ MarshalBinary() ([]byte, error) }).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := decoded.(interface{ MarshalBinary() ([]byte, error) }).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(want, got) {
+			t.Errorf("%s: binary mismatch\n%x\n%x", data, want, got)
+		}
+	}
+}
+
+func TestJSONFieldNames(t *testing.T) {
+	data, err := json.Marshal(testFlowMod())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`"type":"flow_mod"`,
+		`"dl_src":"01:01:01:01:01:01"`,
+		`"nw_src":"10.0.0.0/24"`,
+		`"command":"add"`,
+		`"flags":["send_flow_rem","check_overlap"]`,
+		`"out_port":0`,
+		`{"type":"output","port":2,"max_len":65535}`,
+	} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("%s not found in %s", s, data)
+		}
+	}
+}
+
+// yamlValue turns v into the generic value a yaml.v2 decoder reads from
+// the document v encodes to: structs are maps keyed by their yaml tags,
+// integers are ints, and MarshalYAML is used where implemented
+func yamlValue(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() || (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, nil
+	}
+	// embedded unexported structs are only inlined
+	if v.CanInterface() {
+		if m, ok := v.Interface().(interface{ MarshalYAML() (interface{}, error) }); ok {
+			mv, err := m.MarshalYAML()
+			if err != nil {
+				return nil, err
+			}
+			return yamlValue(reflect.ValueOf(mv))
+		}
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return yamlValue(v.Elem())
+	case reflect.Struct:
+		m := make(map[interface{}]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			name, inline, omitEmpty := yamlField(v.Type().Field(i))
+			if omitEmpty && v.Field(i).IsZero() {
+				continue
+			}
+			fv, err := yamlValue(v.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			if !inline {
+				m[name] = fv
+				continue
+			}
+			for k, e := range fv.(map[interface{}]interface{}) {
+				m[k] = e
+			}
+		}
+		return m, nil
+	case reflect.Slice:
+		l := make([]interface{}, v.Len())
+		for i := range l {
+			e, err := yamlValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			l[i] = e
+		}
+		return l, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(v.Uint()), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	}
+	return nil, fmt.Errorf("yaml: cannot encode %s", v.Type())
+}
+
+// yamlDecode sets out from a generic yaml value the way yaml.v2 does,
+// calling UnmarshalYAML where implemented
+func yamlDecode(node interface{}, out reflect.Value) error {
+	if out.CanAddr() && out.CanInterface() {
+		if u, ok := out.Addr().Interface().(interface {
+			UnmarshalYAML(func(interface{}) error) error
+		}); ok {
+			return u.UnmarshalYAML(yamlUnmarshaler(node))
+		}
+	}
+	mismatch := fmt.Errorf("yaml: cannot decode %#v into %s", node, out.Type())
+	switch out.Kind() {
+	case reflect.Ptr:
+		if node == nil {
+			out.Set(reflect.Zero(out.Type()))
+			return nil
+		}
+		out.Set(reflect.New(out.Type().Elem()))
+		return yamlDecode(node, out.Elem())
+	case reflect.Interface:
+		if node != nil {
+			out.Set(reflect.ValueOf(node))
+		}
+		return nil
+	case reflect.Struct:
+		m, ok := node.(map[interface{}]interface{})
+		if !ok {
+			return mismatch
+		}
+		for i := 0; i < out.NumField(); i++ {
+			name, inline, _ := yamlField(out.Type().Field(i))
+			if inline {
+				if err := yamlDecode(m, out.Field(i)); err != nil {
+					return err
+				}
+			} else if e, ok := m[name]; ok {
+				if err := yamlDecode(e, out.Field(i)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case reflect.Slice:
+		l, ok := node.([]interface{})
+		if !ok {
+			return mismatch
+		}
+		out.Set(reflect.MakeSlice(out.Type(), len(l), len(l)))
+		for i, e := range l {
+			if err := yamlDecode(e, out.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := node.(int)
+		if !ok || out.OverflowInt(int64(n)) {
+			return mismatch
+		}
+		out.SetInt(int64(n))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := node.(int)
+		if !ok || n < 0 || out.OverflowUint(uint64(n)) {
+			return mismatch
+		}
+		out.SetUint(uint64(n))
+		return nil
+	case reflect.String:
+		s, ok := node.(string)
+		if !ok {
+			return mismatch
+		}
+		out.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, ok := node.(bool)
+		if !ok {
+			return mismatch
+		}
+		out.SetBool(b)
+		return nil
+	}
+	return mismatch
+}
+
+func yamlUnmarshaler(node interface{}) func(interface{}) error {
+	return func(v interface{}) error {
+		return yamlDecode(node, reflect.ValueOf(v).Elem())
+	}
+}
+
+// yamlField reads the yaml tag of a struct field
+func yamlField(f reflect.StructField) (name string, inline, omitEmpty bool) {
+	opts := strings.Split(f.Tag.Get("yaml"), ",")
+	name = opts[0]
+	if name == "" {
+		name = strings.ToLower(f.Name)
+	}
+	for _, o := range opts[1:] {
+		inline = inline || o == "inline"
+		omitEmpty = omitEmpty || o == "omitempty"
+	}
+	return name, inline, omitEmpty
+}
+
+// TestYAMLRoundTrip goes through the yaml.v2 interfaces with a stand-in
+// for the decoder, the tree needs no yaml dependency
+func TestYAMLRoundTrip(t *testing.T) {
+	p, _ := NewPort(openflow.PortID(3), []byte{1, 2, 3, 4, 5, 6}, "eth3")
+	status := NewPortStatus(uint32(2))
+	status.SetReason(openflow.PortAdded)
+	status.SetPort(p)
+	packetIn := NewPacketIn(uint32(3))
+	packetIn.SetInPort(uint16(openflow.Local))
+	packetIn.SetData([]byte{0xde, 0xad})
+	request := NewStatsReuqestFlow(uint32(4))
+	request.SetMatch(testFlowMod().Match())
+	request.SetOutPort(uint16(openflow.None))
+
+	for _, msg := range []openflow.MessageDecoder{testFlowMod(), status, packetIn, request} {
+		node, err := yamlValue(reflect.ValueOf(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := UnmarshalMessage(yamlUnmarshaler(node))
+		if err != nil {
+			t.Fatalf("%T: %v", msg, err)
+		}
+		want, _ := msg.(encoding.BinaryMarshaler).MarshalBinary()
+		got, _ := decoded.(encoding.BinaryMarshaler).MarshalBinary()
+		if !bytes.Equal(got, want) {
+			t.Errorf("%T decoded from yaml as %x, want %x", msg, got, want)
+		}
+	}
+
+	// keys are the yaml tags, reserved ports are names
+	node, _ := yamlValue(reflect.ValueOf(testFlowMod()))
+	m := node.(map[interface{}]interface{})
+	if m["type"] != "flow_mod" || m["xid"] != 23334 || m["out_port"] != 0 {
+		t.Errorf("unexpected yaml value %v", m)
+	}
+	if _, ok := m["jsonheader"]; ok {
+		t.Error("header not inlined")
+	}
+	node, _ = yamlValue(reflect.ValueOf(packetIn))
+	if port := node.(map[interface{}]interface{})["in_port"]; port != openflow.Local.String() {
+		t.Errorf("local port encoded as %v", port)
+	}
+}
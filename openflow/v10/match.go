@@ -3,6 +3,7 @@ package v10
 import (
 	"encoding/binary"
 	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/packet"
 	"net"
 )
 
@@ -22,17 +23,14 @@ type wildcard struct {
 	nwDst     uint8
 	dlVlanPCP bool /* VLAN priority. */
 	nwTos     bool
-	all       bool
 }
 
 func (w *wildcard) MarshalBinary() ([]byte, error) {
-	data := make([]byte, 4)
-	// if wildcard all is set, return 0x003fffff directly
-	if w.all {
-		binary.BigEndian.PutUint32(data, 0x003fffff)
-		return data, nil
-	}
+	return w.AppendBinary(nil)
+}
 
+func (w *wildcard) AppendBinary(dst []byte) ([]byte, error) {
+	dst, data := openflow.Grow(dst, 4)
 	var v uint32 = 0
 
 	if w.inPort {
@@ -72,7 +70,7 @@ func (w *wildcard) MarshalBinary() ([]byte, error) {
 		v = v | OFPFW_NW_TOS
 	}
 	binary.BigEndian.PutUint32(data[0:4], v)
-	return data, nil
+	return dst, nil
 }
 
 func (w *wildcard) UnmarshalBinary(data []byte) error {
@@ -130,13 +128,28 @@ type match struct {
 	nwDst     net.IP
 	tpSrc     uint16
 	tpDst     uint16
+	// dl and nw hold the addresses of a decoded match, decoding again
+	// reuses them
+	dl [12]byte
+	nw [32]byte
 }
 
 // NewMatch returns a Match whose fields are all wildcarded
 func NewMatch() openflow.Match {
 	return &match{
 		wildcards: wildcard{
-			all: true,
+			inPort:    true,
+			dlVlan:    true,
+			dlSrc:     true,
+			dlDst:     true,
+			dlType:    true,
+			nwProto:   true,
+			tpSrc:     true,
+			tpDst:     true,
+			nwSrc:     32,
+			nwDst:     32,
+			dlVlanPCP: true,
+			nwTos:     true,
 		},
 		dlSrc: net.HardwareAddr([]byte{0, 0, 0, 0, 0, 0}),
 		dlDst: net.HardwareAddr([]byte{0, 0, 0, 0, 0, 0}),
@@ -197,7 +210,7 @@ func (m *match) DLVlan() (bool, uint16) {
 }
 
 func (m *match) SetDLVlan(vlan uint16) error {
-	if int(vlan) > 4096 {
+	if vlan > 4095 && vlan != OFP_VLAN_NONE {
 		return openflow.ErrInvalidVlanID
 	}
 	m.dlVlan = vlan
@@ -227,9 +240,6 @@ func (m *match) DLType() (bool, uint16) {
 }
 
 func (m *match) SetDLType(dlt uint16) error {
-	if dlt != 0x0800 {
-		return openflow.ErrUnsupportedEtherType
-	}
 	m.dlType = dlt
 	m.wildcards.dlType = false
 	return nil
@@ -256,10 +266,9 @@ func (m *match) NWProto() (bool, uint8) {
 	return m.wildcards.nwProto, m.nwProto
 }
 
+// SetNWProto sets the IP protocol, or the lower 8 bits of the opcode
+// of ARP packets
 func (m *match) SetNWProto(proto uint8) error {
-	if proto != 0x06 && proto != 0x11 {
-		return openflow.ErrUnsupportedIPProtocol
-	}
 	m.nwProto = proto
 	m.wildcards.nwProto = false
 	return nil
@@ -269,13 +278,45 @@ func (m *match) SetWildcardNWProto() {
 	m.wildcards.nwProto = true
 }
 
+// ipMask converts wildcarded bits count to an IPv4 mask,
+// values larger than 32 wildcard the whole address
+func ipMask(wildcarded uint8) net.IPMask {
+	if wildcarded > 32 {
+		wildcarded = 32
+	}
+	return net.CIDRMask(32-int(wildcarded), 32)
+}
+
+// ipv4 stores the 4 bytes address ip in the 16 bytes form, as net.IPv4
+// returns it, in v
+func ipv4(v []byte, ip []byte) net.IP {
+	copy(v, v4InV6Prefix)
+	copy(v[12:16], ip)
+	return net.IP(v)
+}
+
+var v4InV6Prefix = []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff}
+
+// putMaskedIP writes an IPv4 address with its wildcarded low bits cleared
+// in v, as NWSrc and NWDst return it without allocating
+func putMaskedIP(v []byte, ip net.IP, wildcarded uint8) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return
+	}
+	if wildcarded > 32 {
+		wildcarded = 32
+	}
+	binary.BigEndian.PutUint32(v, binary.BigEndian.Uint32(ip4)&(^uint32(0)<<wildcarded))
+}
+
 func (m *match) NWSrc() net.IP {
-	mask := net.CIDRMask(32-int(m.wildcards.nwSrc), 32)
-	return m.nwSrc.Mask(mask)
+	return m.nwSrc.Mask(ipMask(m.wildcards.nwSrc))
 }
 
 func (m *match) SetNWSrc(ip net.IP) {
 	m.nwSrc = ip
+	m.wildcards.nwSrc = 0
 }
 
 func (m *match) SetWildcardNWSrc(vlsm int) {
@@ -290,12 +331,12 @@ func (m *match) SetWildcardNWSrc(vlsm int) {
 }
 
 func (m *match) NWDst() net.IP {
-	mask := net.CIDRMask(32-int(m.wildcards.nwDst), 32)
-	return m.nwDst.Mask(mask)
+	return m.nwDst.Mask(ipMask(m.wildcards.nwDst))
 }
 
 func (m *match) SetNWDst(ip net.IP) {
 	m.nwDst = ip
+	m.wildcards.nwDst = 0
 }
 func (m *match) SetWildcardNWDst(vlsm int) {
 	if vlsm < 0 {
@@ -335,13 +376,14 @@ func (m *match) SetWildcardTPDst() {
 }
 
 func (m *match) MarshalBinary() ([]byte, error) {
-	wildcard, err := m.wildcards.MarshalBinary()
-	if err != nil {
+	return m.AppendBinary(nil)
+}
+
+func (m *match) AppendBinary(dst []byte) ([]byte, error) {
+	dst, data := openflow.Grow(dst, 40)
+	if err := put(data[0:4], &m.wildcards); err != nil {
 		return nil, err
 	}
-
-	data := make([]byte, 40)
-	copy(data[0:4], wildcard)
 	binary.BigEndian.PutUint16(data[4:6], m.inPort)
 	copy(data[6:12], m.dlSrc)
 	copy(data[12:18], m.dlDst)
@@ -352,12 +394,12 @@ func (m *match) MarshalBinary() ([]byte, error) {
 	data[24] = m.nwTos
 	data[25] = m.nwProto
 	// data[26:28] is padding
-	copy(data[28:32], m.NWSrc())
-	copy(data[32:36], m.NWDst())
+	putMaskedIP(data[28:32], m.nwSrc, m.wildcards.nwSrc)
+	putMaskedIP(data[32:36], m.nwDst, m.wildcards.nwDst)
 	binary.BigEndian.PutUint16(data[36:38], m.tpSrc)
 	binary.BigEndian.PutUint16(data[38:40], m.tpDst)
 
-	return data, nil
+	return dst, nil
 }
 
 func (m *match) UnmarshalBinary(data []byte) error {
@@ -370,10 +412,9 @@ func (m *match) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	m.inPort = binary.BigEndian.Uint16(data[4:6])
-	m.dlSrc = make(net.HardwareAddr, 6)
-	copy(m.dlSrc, data[6:12])
-	m.dlDst = make(net.HardwareAddr, 6)
-	copy(m.dlDst, data[12:18])
+	copy(m.dl[:], data[6:18])
+	m.dlSrc = m.dl[0:6]
+	m.dlDst = m.dl[6:12]
 	m.dlVlan = binary.BigEndian.Uint16(data[18:20])
 	m.dlPCP = data[20]
 	// data[21] = padding
@@ -381,10 +422,214 @@ func (m *match) UnmarshalBinary(data []byte) error {
 	m.nwTos = data[24]
 	m.nwProto = data[25]
 	// data[26:28] = padding
-	m.nwSrc = net.IPv4(data[28], data[29], data[30], data[31])
-	m.nwDst = net.IPv4(data[32], data[33], data[34], data[35])
+	m.nwSrc = ipv4(m.nw[0:16], data[28:32])
+	m.nwDst = ipv4(m.nw[16:32], data[32:36])
 	m.tpSrc = binary.BigEndian.Uint16(data[36:38])
 	m.tpDst = binary.BigEndian.Uint16(data[38:40])
 
 	return nil
 }
+
+// MatchesAll tells whether every field of m is wildcarded
+func MatchesAll(m openflow.Match) bool {
+	w := m.Wildcards()
+	all := uint32(OFPFW_ALL &^ (OFPFW_NW_SRC_MASK | OFPFW_NW_DST_MASK))
+	return w&all == all &&
+		w&OFPFW_NW_SRC_MASK >= OFPFW_NW_SRC_ALL &&
+		w&OFPFW_NW_DST_MASK >= OFPFW_NW_DST_ALL
+}
+
+// MatchFromPacket returns the exact match of a frame received on inPort,
+// fields absent from the frame are zero as specified by openflow 1.0
+func MatchFromPacket(inPort uint16, data []byte) (openflow.Match, error) {
+	var eth packet.Ethernet
+	if err := eth.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	m := NewMatch()
+	m.SetInPort(inPort)
+	// don't keep references to the frame
+	m.SetDLSrc(append(net.HardwareAddr{}, eth.Src...))
+	m.SetDLDst(append(net.HardwareAddr{}, eth.Dst...))
+	m.SetDLVlan(eth.VLANID)
+	m.SetDLPCP(eth.VLANPriority)
+	m.SetDLType(eth.EtherType)
+	m.SetNWTos(0)
+	m.SetNWProto(0)
+	m.SetNWSrc(net.IPv4zero.To4())
+	m.SetNWDst(net.IPv4zero.To4())
+	m.SetTPSrc(0)
+	m.SetTPDst(0)
+
+	switch eth.EtherType {
+	case packet.EtherTypeARP:
+		var arp packet.ARP
+		if arp.UnmarshalBinary(eth.Payload) == nil {
+			m.SetNWProto(uint8(arp.Operation))
+			m.SetNWSrc(append(net.IP{}, arp.SenderIP...))
+			m.SetNWDst(append(net.IP{}, arp.TargetIP...))
+		}
+	case packet.EtherTypeIPv4:
+		var ip packet.IPv4
+		if ip.UnmarshalBinary(eth.Payload) != nil {
+			break
+		}
+		// the lower two bits are ECN
+		m.SetNWTos(ip.TOS & 0xfc)
+		m.SetNWProto(ip.Protocol)
+		m.SetNWSrc(append(net.IP{}, ip.Src...))
+		m.SetNWDst(append(net.IP{}, ip.Dst...))
+		if ip.IsFragment() {
+			break
+		}
+		switch ip.Protocol {
+		case packet.IPProtocolTCP:
+			var tcp packet.TCP
+			if tcp.UnmarshalBinary(ip.Payload) == nil {
+				m.SetTPSrc(tcp.SrcPort)
+				m.SetTPDst(tcp.DstPort)
+			}
+		case packet.IPProtocolUDP:
+			var udp packet.UDP
+			if udp.UnmarshalBinary(ip.Payload) == nil {
+				m.SetTPSrc(udp.SrcPort)
+				m.SetTPDst(udp.DstPort)
+			}
+		case packet.IPProtocolICMP:
+			var icmp packet.ICMP
+			if icmp.UnmarshalBinary(ip.Payload) == nil {
+				m.SetTPSrc(uint16(icmp.Type))
+				m.SetTPDst(uint16(icmp.Code))
+			}
+		}
+	}
+	return m, nil
+}
+
+// matchFields maps the wildcard bits to the ofp_match bytes they cover
+var matchFields = []struct {
+	bit        uint32
+	start, end int
+}{
+	{OFPFW_IN_PORT, 4, 6},
+	{OFPFW_DL_SRC, 6, 12},
+	{OFPFW_DL_DST, 12, 18},
+	{OFPFW_DL_VLAN, 18, 20},
+	{OFPFW_DL_VLAN_PCP, 20, 21},
+	{OFPFW_DL_TYPE, 22, 24},
+	{OFPFW_NW_TOS, 24, 25},
+	{OFPFW_NW_PROTO, 25, 26},
+	{OFPFW_TP_SRC, 36, 38},
+	{OFPFW_TP_DST, 38, 40},
+}
+
+// prefixBits returns the number of significant bits of an IP wildcard
+func prefixBits(w uint32, shift uint) int {
+	wildcarded := int(w>>shift) & 0x3f
+	if wildcarded > 32 {
+		wildcarded = 32
+	}
+	return 32 - wildcarded
+}
+
+// CanonicalMatch returns the wire format of m with wildcarded fields and
+// host bits zeroed, equal matches have equal canonical forms
+func CanonicalMatch(m openflow.Match) ([]byte, error) {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	w := binary.BigEndian.Uint32(data[0:4])
+	src, dst := prefixBits(w, OFPFW_NW_SRC_SHIFT), prefixBits(w, OFPFW_NW_DST_SHIFT)
+	w &= OFPFW_ALL &^ (OFPFW_NW_SRC_MASK | OFPFW_NW_DST_MASK)
+	w |= uint32(32-src)<<OFPFW_NW_SRC_SHIFT | uint32(32-dst)<<OFPFW_NW_DST_SHIFT
+	binary.BigEndian.PutUint32(data[0:4], w)
+	for _, f := range matchFields {
+		if w&f.bit != 0 {
+			for i := f.start; i < f.end; i++ {
+				data[i] = 0
+			}
+		}
+	}
+	// padding
+	data[21], data[26], data[27] = 0, 0, 0
+	binary.BigEndian.PutUint32(data[28:32], binary.BigEndian.Uint32(data[28:32])&prefixMask(src))
+	binary.BigEndian.PutUint32(data[32:36], binary.BigEndian.Uint32(data[32:36])&prefixMask(dst))
+	return data, nil
+}
+
+func prefixMask(bits int) uint32 {
+	if bits <= 0 {
+		return 0
+	}
+	return ^uint32(0) << uint(32-bits)
+}
+
+// MatchCovers tells whether every packet matching m also matches filter,
+// as flows are selected by non strict modify and delete commands
+func MatchCovers(filter, m openflow.Match) bool {
+	f, err := CanonicalMatch(filter)
+	if err != nil {
+		return false
+	}
+	c, err := CanonicalMatch(m)
+	if err != nil {
+		return false
+	}
+	fw, cw := binary.BigEndian.Uint32(f[0:4]), binary.BigEndian.Uint32(c[0:4])
+	for _, field := range matchFields {
+		if fw&field.bit != 0 {
+			continue
+		}
+		if cw&field.bit != 0 || string(f[field.start:field.end]) != string(c[field.start:field.end]) {
+			return false
+		}
+	}
+	for _, nw := range []struct {
+		shift uint
+		off   int
+	}{{OFPFW_NW_SRC_SHIFT, 28}, {OFPFW_NW_DST_SHIFT, 32}} {
+		fbits, cbits := prefixBits(fw, nw.shift), prefixBits(cw, nw.shift)
+		mask := prefixMask(fbits)
+		if cbits < fbits || binary.BigEndian.Uint32(c[nw.off:nw.off+4])&mask != binary.BigEndian.Uint32(f[nw.off:nw.off+4]) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchOverlaps tells whether some packet matches both a and b, as
+// checked for flows added with OFPFF_CHECK_OVERLAP
+func MatchOverlaps(a, b openflow.Match) bool {
+	ca, err := CanonicalMatch(a)
+	if err != nil {
+		return false
+	}
+	cb, err := CanonicalMatch(b)
+	if err != nil {
+		return false
+	}
+	aw, bw := binary.BigEndian.Uint32(ca[0:4]), binary.BigEndian.Uint32(cb[0:4])
+	for _, field := range matchFields {
+		if aw&field.bit != 0 || bw&field.bit != 0 {
+			continue
+		}
+		if string(ca[field.start:field.end]) != string(cb[field.start:field.end]) {
+			return false
+		}
+	}
+	for _, nw := range []struct {
+		shift uint
+		off   int
+	}{{OFPFW_NW_SRC_SHIFT, 28}, {OFPFW_NW_DST_SHIFT, 32}} {
+		abits, bbits := prefixBits(aw, nw.shift), prefixBits(bw, nw.shift)
+		if bbits < abits {
+			abits = bbits
+		}
+		mask := prefixMask(abits)
+		if binary.BigEndian.Uint32(ca[nw.off:nw.off+4])&mask != binary.BigEndian.Uint32(cb[nw.off:nw.off+4])&mask {
+			return false
+		}
+	}
+	return true
+}
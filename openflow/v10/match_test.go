@@ -0,0 +1,69 @@
+package v10
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestMatchCovers(t *testing.T) {
+	subnet := NewMatch()
+	subnet.SetDLType(0x0800)
+	subnet.SetNWDst(net.IP{10, 0, 0, 0})
+	subnet.SetWildcardNWDst(8)
+	host := NewMatch()
+	host.SetInPort(1)
+	host.SetDLType(0x0800)
+	host.SetNWDst(net.IP{10, 1, 2, 3})
+
+	if !MatchCovers(NewMatch(), host) || !MatchCovers(subnet, host) || !MatchCovers(subnet, subnet) {
+		t.Error("expected subnet to cover host")
+	}
+	if MatchCovers(host, subnet) {
+		t.Error("host covers subnet")
+	}
+	host.SetNWDst(net.IP{11, 1, 2, 3})
+	if MatchCovers(subnet, host) {
+		t.Error("subnet covers a host outside of it")
+	}
+
+	// host bits and wildcarded fields are ignored
+	a := NewMatch()
+	a.SetNWSrc(net.IP{10, 0, 0, 7})
+	a.SetWildcardNWSrc(24)
+	a.SetTPDst(80)
+	a.SetWildcardTPDst()
+	b := NewMatch()
+	b.SetNWSrc(net.IP{10, 0, 0, 0})
+	b.SetWildcardNWSrc(24)
+	ca, _ := CanonicalMatch(a)
+	cb, _ := CanonicalMatch(b)
+	if !bytes.Equal(ca, cb) {
+		t.Errorf("canonical forms differ\n%x\n%x", ca, cb)
+	}
+}
+
+func TestMatchOverlaps(t *testing.T) {
+	web := NewMatch()
+	web.SetDLType(0x0800)
+	web.SetNWProto(6)
+	web.SetTPDst(80)
+	subnet := NewMatch()
+	subnet.SetDLType(0x0800)
+	subnet.SetNWDst(net.IP{10, 0, 0, 0})
+	subnet.SetWildcardNWDst(8)
+	if !MatchOverlaps(web, subnet) || !MatchOverlaps(subnet, web) || !MatchOverlaps(NewMatch(), web) {
+		t.Error("expected overlap")
+	}
+	host := NewMatch()
+	host.SetNWDst(net.IP{10, 1, 2, 3})
+	if !MatchOverlaps(subnet, host) {
+		t.Error("expected host in subnet to overlap")
+	}
+	host.SetNWDst(net.IP{11, 1, 2, 3})
+	arp := NewMatch()
+	arp.SetDLType(0x0806)
+	if MatchOverlaps(subnet, host) || MatchOverlaps(web, arp) {
+		t.Error("disjoint matches overlap")
+	}
+}
@@ -0,0 +1,194 @@
+package v10
+
+import (
+	"fmt"
+	"github.com/ksang/goflow/openflow"
+	"strconv"
+	"strings"
+)
+
+var messageTypeNames = []string{
+	OFPT_HELLO:                    "hello",
+	OFPT_ERROR:                    "error",
+	OFPT_ECHO_REQUEST:             "echo_request",
+	OFPT_ECHO_REPLY:               "echo_reply",
+	OFPT_VENDOR:                   "vendor",
+	OFPT_FEATURES_REQUEST:         "features_request",
+	OFPT_FEATURES_REPLY:           "features_reply",
+	OFPT_GET_CONFIG_REQUEST:       "get_config_request",
+	OFPT_GET_CONFIG_REPLY:         "get_config_reply",
+	OFPT_SET_CONFIG:               "set_config",
+	OFPT_PACKET_IN:                "packet_in",
+	OFPT_FLOW_REMOVED:             "flow_removed",
+	OFPT_PORT_STATUS:              "port_status",
+	OFPT_PACKET_OUT:               "packet_out",
+	OFPT_FLOW_MOD:                 "flow_mod",
+	OFPT_PORT_MOD:                 "port_mod",
+	OFPT_STATS_REQUEST:            "stats_request",
+	OFPT_STATS_REPLY:              "stats_reply",
+	OFPT_BARRIER_REQUEST:          "barrier_request",
+	OFPT_BARRIER_REPLY:            "barrier_reply",
+	OFPT_QUEUE_GET_CONFIG_REQUEST: "queue_get_config_request",
+	OFPT_QUEUE_GET_CONFIG_REPLY:   "queue_get_config_reply",
+}
+
+// MessageTypeName returns the name of an openflow 1.0 message type
+func MessageTypeName(t uint8) string {
+	if int(t) < len(messageTypeNames) {
+		return messageTypeNames[t]
+	}
+	return fmt.Sprintf("unknown(%d)", t)
+}
+
+// ParseMessageType is the reverse of MessageTypeName
+func ParseMessageType(name string) (uint8, bool) {
+	for t, n := range messageTypeNames {
+		if n == name {
+			return uint8(t), true
+		}
+	}
+	return 0, false
+}
+
+var actionTypeNames = map[uint16]string{
+	OFPAT_OUTPUT:       "output",
+	OFPAT_SET_VLAN_VID: "set_vlan_vid",
+	OFPAT_SET_VLAN_PCP: "set_vlan_pcp",
+	OFPAT_STRIP_VLAN:   "strip_vlan",
+	OFPAT_SET_DL_SRC:   "set_dl_src",
+	OFPAT_SET_DL_DST:   "set_dl_dst",
+	OFPAT_SET_NW_SRC:   "set_nw_src",
+	OFPAT_SET_NW_DST:   "set_nw_dst",
+	OFPAT_SET_NW_TOS:   "set_nw_tos",
+	OFPAT_SET_TP_SRC:   "set_tp_src",
+	OFPAT_SET_TP_DST:   "set_tp_dst",
+	OFPAT_ENQUEUE:      "enqueue",
+	OFPAT_VENDOR:       "vendor",
+}
+
+// ActionTypeName returns the name of an action type, unknown types in hex
+func ActionTypeName(t uint16) string {
+	if n, ok := actionTypeNames[t]; ok {
+		return n
+	}
+	return fmt.Sprintf("0x%04x", t)
+}
+
+// ParseActionType is the reverse of ActionTypeName
+func ParseActionType(name string) (uint16, bool) {
+	for t, n := range actionTypeNames {
+		if n == name {
+			return t, true
+		}
+	}
+	v, err := strconv.ParseUint(name, 0, 16)
+	if err != nil {
+		return 0, false
+	}
+	return uint16(v), true
+}
+
+var wildcardNames = []struct {
+	bit  uint32
+	name string
+}{
+	{OFPFW_IN_PORT, "in_port"},
+	{OFPFW_DL_VLAN, "dl_vlan"},
+	{OFPFW_DL_SRC, "dl_src"},
+	{OFPFW_DL_DST, "dl_dst"},
+	{OFPFW_DL_TYPE, "dl_type"},
+	{OFPFW_NW_PROTO, "nw_proto"},
+	{OFPFW_TP_SRC, "tp_src"},
+	{OFPFW_TP_DST, "tp_dst"},
+	{OFPFW_DL_VLAN_PCP, "dl_vlan_pcp"},
+	{OFPFW_NW_TOS, "nw_tos"},
+}
+
+// WildcardNames describes a wildcards field, e.g. in_port|dl_vlan|nw_src/8.
+// Address wildcards are shown with the number of ignored bits.
+func WildcardNames(w uint32) string {
+	names := []string{}
+	for _, n := range wildcardNames {
+		if w&n.bit != 0 {
+			names = append(names, n.name)
+		}
+	}
+	if src := (w >> 8) & 0x3f; src != 0 {
+		names = append(names, fmt.Sprintf("nw_src/%d", src))
+	}
+	if dst := (w >> 14) & 0x3f; dst != 0 {
+		names = append(names, fmt.Sprintf("nw_dst/%d", dst))
+	}
+	if len(names) == 0 {
+		return "exact"
+	}
+	return strings.Join(names, "|")
+}
+
+var configFlagNames = []string{
+	OFPC_FRAG_NORMAL: "frag_normal",
+	OFPC_FRAG_DROP:   "frag_drop",
+	OFPC_FRAG_REASM:  "frag_reasm",
+}
+
+var packetInReasonNames = []string{
+	OFPR_NO_MATCH: "no_match",
+	OFPR_ACTION:   "action",
+}
+
+var flowRemovedReasonNames = []string{
+	OFPRR_IDLE_TIMEOUT: "idle_timeout",
+	OFPRR_HARD_TIMEOUT: "hard_timeout",
+	OFPRR_DELETE:       "delete",
+}
+
+var errorTypeNames = []string{
+	OFPET_HELLO_FAILED:    "hello_failed",
+	OFPET_BAD_REQUEST:     "bad_request",
+	OFPET_BAD_ACTION:      "bad_action",
+	OFPET_FLOW_MOD_FAILED: "flow_mod_failed",
+	OFPET_PORT_MOD_FAILED: "port_mod_failed",
+	OFPET_QUEUE_OP_FAILED: "queue_op_failed",
+}
+
+// ConfigFlagsName returns the name of the fragment handling mode
+func ConfigFlagsName(f uint16) string {
+	return enumName(int(f), configFlagNames)
+}
+
+// PacketInReasonName returns the name of a packet in reason
+func PacketInReasonName(r uint8) string {
+	return enumName(int(r), packetInReasonNames)
+}
+
+// FlowRemovedReasonName returns the name of a flow removed reason
+func FlowRemovedReasonName(r uint8) string {
+	return enumName(int(r), flowRemovedReasonNames)
+}
+
+// ErrorTypeName returns the name of an error type
+func ErrorTypeName(t uint16) string {
+	return enumName(int(t), errorTypeNames)
+}
+
+// enumName looks up v in a dense name table, unknown values are numeric
+func enumName(v int, names []string) string {
+	if v >= 0 && v < len(names) && names[v] != "" {
+		return names[v]
+	}
+	return strconv.Itoa(v)
+}
+
+// parseEnum is the reverse of enumName
+func parseEnum(s string, names []string) (int, error) {
+	for v, n := range names {
+		if n != "" && n == s {
+			return v, nil
+		}
+	}
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, openflow.ErrInvalidValueProvided
+	}
+	return int(v), nil
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package v10
+
+const raceEnabled = false
@@ -43,6 +43,12 @@ func (p *packetIn) TotalLength() uint16 {
 	return p.totalLength
 }
 
+// SetTotalLength sets the length of the whole packet, buffered packets
+// may carry less data
+func (p *packetIn) SetTotalLength(l uint16) {
+	p.totalLength = l
+}
+
 func (p *packetIn) TableID() uint8 {
 	// OpenFlow 1.0 does not have table ID
 	return 0
@@ -72,20 +78,21 @@ func (p *packetIn) SetCookie(c uint64) {
 }
 
 func (p *packetIn) MarshalBinary() ([]byte, error) {
-	if int(p.totalLength) != len(p.data) {
+	return p.AppendBinary(nil)
+}
+
+func (p *packetIn) AppendBinary(dst []byte) ([]byte, error) {
+	if int(p.totalLength) < len(p.data) {
 		return nil, openflow.ErrInvalidDataLength
 	}
-	v := make([]byte, p.totalLength+10)
+	dst, v := appendMessage(&p.Message, dst, 10+len(p.data))
 	binary.BigEndian.PutUint32(v[0:4], p.bufferID)
 	binary.BigEndian.PutUint16(v[4:6], p.totalLength)
 	binary.BigEndian.PutUint16(v[6:8], p.inPort)
 	v[8] = p.reason
 	//v[9] is padding
-	if p.totalLength > 0 {
-		copy(v[10:], p.data)
-	}
-	p.SetPayload(v)
-	return p.Message.MarshalBinary()
+	copy(v[10:], p.data)
+	return dst, nil
 }
 
 func (p *packetIn) UnmarshalBinary(data []byte) error {
@@ -105,7 +112,8 @@ func (p *packetIn) UnmarshalBinary(data []byte) error {
 	if len(payload) >= 10 {
 		p.data = payload[10:]
 	}
-	if int(p.totalLength) != len(p.data) {
+	// the data of buffered packets may be truncated
+	if int(p.totalLength) < len(p.data) {
 		return openflow.ErrInvalidDataLength
 	}
 
@@ -27,7 +27,8 @@ func (p *packetOut) InPort() uint16 {
 }
 
 func (p *packetOut) SetInPort(ip uint16) error {
-	if ip > 0xff00 {
+	// physical ports or reserved ports such as OFPP_CONTROLLER/OFPP_NONE
+	if ip > uint16(openflow.Max) && ip < uint16(openflow.InPort) {
 		return openflow.ErrInvalidValueProvided
 	}
 	p.inPort = ip
@@ -56,6 +57,10 @@ func (p *packetOut) AddAction(action openflow.Action) {
 }
 
 func (p *packetOut) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(nil)
+}
+
+func (p *packetOut) AppendBinary(dst []byte) ([]byte, error) {
 	actionsLen := 0
 	for _, act := range p.action {
 		actionsLen += int(act.Length())
@@ -66,28 +71,16 @@ func (p *packetOut) MarshalBinary() ([]byte, error) {
 	}
 	// packetOutLen = bufferID(4 bytes) + inPort(2 bytes) +
 	//			actionsLength(2 bytes) + actionsLen + dataLen
-	packetOutLen := 8 + int(actionsLen) + len(p.data)
-	v := make([]byte, packetOutLen)
+	dst = p.AppendHeader(dst, 8+actionsLen+len(p.data))
+	dst, v := openflow.Grow(dst, 8)
 	binary.BigEndian.PutUint32(v[0:4], p.bufferID)
 	binary.BigEndian.PutUint16(v[4:6], p.inPort)
 	binary.BigEndian.PutUint16(v[6:8], p.actionsLength)
-	// Marshal actions
-	lastPosition := 8
-	for _, act := range p.action {
-		actLen := int(act.Length())
-		actPayload, err := act.MarshalBinary()
-		if err != nil {
-			return nil, err
-		}
-		copy(v[lastPosition:lastPosition+actLen], actPayload)
-		lastPosition += actLen
+	dst, err := AppendActions(dst, p.action)
+	if err != nil {
+		return nil, err
 	}
-	if len(p.data) > 0 {
-		copy(v[lastPosition:], p.data)
-	}
-	// Call Message's SetPayload method
-	p.SetPayload(v)
-	return p.Message.MarshalBinary()
+	return append(dst, p.data...), nil
 }
 
 func (p *packetOut) UnmarshalBinary(data []byte) error {
@@ -109,14 +102,12 @@ func (p *packetOut) UnmarshalBinary(data []byte) error {
 	if actLen+8 > len(payload) {
 		return openflow.ErrInvalidDataLength
 	}
-	for i := 8; i < actLen+8; {
-		act := NewActionHeader()
-		if err := act.UnmarshalBinary(payload[i:]); err != nil {
-			return err
-		}
-		i += int(act.Length())
-		p.action = append(p.action, act)
+	actions, err := decodeActions(p.action, payload[8:actLen+8])
+	if err != nil {
+		return err
 	}
+	p.action = actions
+	p.data = nil
 	// has data
 	if len(payload) > actLen+8 {
 		p.data = payload[actLen+8:]
@@ -0,0 +1,151 @@
+package v10
+
+import (
+	"encoding"
+	"encoding/binary"
+	"github.com/ksang/goflow/openflow"
+)
+
+// Parse decodes a single openflow 1.0 message,
+// the returned value implements the interface of its message type
+// e.g. openflow.FlowMod, and v10 stats interfaces for stats messages.
+//
+// Decoded messages alias data: payloads, packet and error data, hardware
+// addresses of ports and actions refer to it, so data must not be reused
+// while the message is in use. Fixed size fields and the addresses of
+// matches are copied. UnmarshalBinary on a decoded message overwrites
+// every field and decodes again into its match, ports, queues and the
+// actions of the same types, see ParsePooled.
+func Parse(data []byte) (openflow.MessageDecoder, error) {
+	statsType, err := parseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := newMessage(data[1], statsType, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := msg.(encoding.BinaryUnmarshaler).UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// parseHeader checks the header of data and returns its stats type, zero
+// for messages other than stats
+func parseHeader(data []byte) (openflow.StatsType, error) {
+	if len(data) < openflow.OF_HEADER_SIZE {
+		return 0, openflow.ErrInvalidPacketLength
+	}
+	if data[0] != openflow.OF10_VERSION {
+		return 0, openflow.ErrUnsupportedVersion
+	}
+	if data[1] == OFPT_STATS_REQUEST || data[1] == OFPT_STATS_REPLY {
+		if len(data) < openflow.OF_HEADER_SIZE+2 {
+			return 0, openflow.ErrInvalidPacketLength
+		}
+		return openflow.StatsType(binary.BigEndian.Uint16(data[8:10])), nil
+	}
+	return 0, nil
+}
+
+// newMessage returns an empty message of the given type
+func newMessage(msgType uint8, statsType openflow.StatsType, xid uint32) (openflow.MessageDecoder, error) {
+	switch msgType {
+	case OFPT_HELLO:
+		return NewHello(xid), nil
+	case OFPT_ERROR:
+		return NewError(xid), nil
+	case OFPT_ECHO_REQUEST:
+		return NewEchoRequest(xid), nil
+	case OFPT_ECHO_REPLY:
+		return NewEchoReply(xid), nil
+	case OFPT_VENDOR:
+		return NewVendor(xid), nil
+	case OFPT_FEATURES_REQUEST:
+		return NewFeatureRequest(xid), nil
+	case OFPT_FEATURES_REPLY:
+		return NewFeatureReply(xid), nil
+	case OFPT_GET_CONFIG_REQUEST:
+		return NewGetConfigRequest(xid), nil
+	case OFPT_GET_CONFIG_REPLY:
+		return NewGetConfigReply(xid), nil
+	case OFPT_SET_CONFIG:
+		return NewSetConfig(xid), nil
+	case OFPT_PACKET_IN:
+		return NewPacketIn(xid), nil
+	case OFPT_FLOW_REMOVED:
+		return NewFlowRemoved(xid), nil
+	case OFPT_PORT_STATUS:
+		return NewPortStatus(xid), nil
+	case OFPT_PACKET_OUT:
+		return NewPacketOut(xid), nil
+	case OFPT_FLOW_MOD:
+		return NewFlowMod(xid), nil
+	case OFPT_PORT_MOD:
+		return NewPortMod(xid), nil
+	case OFPT_STATS_REQUEST:
+		return newStatsRequest(statsType, xid), nil
+	case OFPT_STATS_REPLY:
+		return newStatsReply(statsType, xid), nil
+	case OFPT_BARRIER_REQUEST:
+		return NewBarrierRequest(xid), nil
+	case OFPT_BARRIER_REPLY:
+		return NewBarrierReply(xid), nil
+	case OFPT_QUEUE_GET_CONFIG_REQUEST:
+		return NewQueueGetConfigRequest(xid), nil
+	case OFPT_QUEUE_GET_CONFIG_REPLY:
+		return NewQueueGetConfigReply(xid), nil
+	}
+	return nil, openflow.ErrUnsupportedMessage
+}
+
+func newStatsRequest(t openflow.StatsType, xid uint32) openflow.StatsRequest {
+	switch t {
+	case openflow.STATS_Flow:
+		return NewStatsReuqestFlow(xid)
+	case openflow.STATS_Aggregate:
+		return NewStatsReuqestAggregate(xid)
+	case openflow.STATS_Port:
+		return NewStatsReuqestPort(xid)
+	case openflow.STATS_Queue:
+		return NewStatsReuqestQueue(xid)
+	case openflow.STATS_Vendor:
+		return NewStatsReuqestVendor(xid)
+	}
+	s := NewStatsRequestHeader(xid)
+	s.SetType(t)
+	return s
+}
+
+func newStatsReply(t openflow.StatsType, xid uint32) openflow.StatsReply {
+	switch t {
+	case openflow.STATS_Description:
+		return NewStatsReplyDescription(xid)
+	case openflow.STATS_Flow:
+		return NewStatsReplyFlow(xid)
+	case openflow.STATS_Aggregate:
+		return NewStatsReplyAggregate(xid)
+	case openflow.STATS_Table:
+		return NewStatsReplyTable(xid)
+	case openflow.STATS_Port:
+		return NewStatsReplyPort(xid)
+	case openflow.STATS_Queue:
+		return NewStatsReplyQueue(xid)
+	}
+	s := NewStatsReplyHeader(xid)
+	s.SetType(t)
+	return s
+}
+
+// IsReply tells whether messages of type msgType answer a request,
+// errors are included as they answer the request that failed
+func IsReply(msgType uint8) bool {
+	switch msgType {
+	case OFPT_ECHO_REPLY, OFPT_FEATURES_REPLY, OFPT_GET_CONFIG_REPLY,
+		OFPT_STATS_REPLY, OFPT_BARRIER_REPLY, OFPT_QUEUE_GET_CONFIG_REPLY,
+		OFPT_ERROR:
+		return true
+	}
+	return false
+}
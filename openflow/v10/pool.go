@@ -0,0 +1,59 @@
+package v10
+
+import (
+	"encoding"
+	"github.com/ksang/goflow/openflow"
+	"sync"
+)
+
+// pools hold the released messages by message type, stats messages are
+// not pooled as their structure depends on the stats type
+var pools [OFPT_QUEUE_GET_CONFIG_REPLY + 1]sync.Pool
+
+func pooled(msgType uint8) bool {
+	return int(msgType) < len(pools) && msgType != OFPT_STATS_REQUEST && msgType != OFPT_STATS_REPLY
+}
+
+// ParsePooled decodes a message as Parse does, into a message of the same
+// type given to Release before when there is one, so that decoding a
+// packet in does not allocate. The caller owns the message until it
+// releases it.
+func ParsePooled(data []byte) (openflow.MessageDecoder, error) {
+	statsType, err := parseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if !pooled(data[1]) {
+		return Parse(data)
+	}
+	msg, ok := pools[data[1]].Get().(openflow.MessageDecoder)
+	if !ok {
+		if msg, err = newMessage(data[1], statsType, 0); err != nil {
+			return nil, err
+		}
+	}
+	if err := msg.(encoding.BinaryUnmarshaler).UnmarshalBinary(data); err != nil {
+		Release(msg)
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Release gives a message back for ParsePooled to reuse, neither the
+// message nor what its methods returned may be used afterwards. Only
+// messages of this package are pooled, stats messages and messages of
+// other packages are left to the garbage collector.
+func Release(msg openflow.MessageDecoder) {
+	switch msg.(type) {
+	case *echo, *errorMessage, *vendor, *featureRequest, *featureReply,
+		*getConfigRequest, *setConfig, *packetIn, *flowRemoved, *portStatus,
+		*packetOut, *flowMod, *portMod, *barrier, *queueGetConfigRequest,
+		*queueGetConfigReply:
+	default:
+		return
+	}
+	if msg.Version() != openflow.OF10_VERSION || !pooled(msg.MsgType()) {
+		return
+	}
+	pools[msg.MsgType()].Put(msg)
+}
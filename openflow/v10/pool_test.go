@@ -0,0 +1,171 @@
+package v10
+
+import (
+	"bytes"
+	"github.com/ksang/goflow/openflow"
+	"testing"
+)
+
+func testPacketIn() []byte {
+	p := NewPacketIn(uint32(5))
+	p.SetBufferID(uint32(300))
+	p.SetInPort(uint16(2))
+	p.SetReason(OFPR_ACTION)
+	p.SetData(bytes.Repeat([]byte{0xab}, 128))
+	data, _ := p.(openflow.BinaryAppender).AppendBinary(nil)
+	return data
+}
+
+func TestParsePooled(t *testing.T) {
+	first := testFlowMod()
+	first.AddAction(NewActionStripVLAN())
+	a, _ := first.(openflow.BinaryAppender).AppendBinary(nil)
+	second := NewFlowMod(uint32(9))
+	second.SetCookie(uint64(3))
+	second.SetAction(NewActionEnqueue())
+	b, _ := second.(openflow.BinaryAppender).AppendBinary(nil)
+
+	msg, err := ParsePooled(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Release(msg)
+	// a released message decodes the next message of its type entirely
+	for i := 0; i < 2; i++ {
+		msg, err := ParsePooled(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fm := msg.(openflow.FlowMod)
+		if fm.Cookie() != 3 || len(fm.Actions()) != 1 || fm.Actions()[0].Type() != OFPAT_ENQUEUE {
+			t.Errorf("unexpected flow mod %+v", fm)
+		}
+		again, _ := fm.(openflow.BinaryAppender).AppendBinary(nil)
+		if !bytes.Equal(again, b) {
+			t.Errorf("flow mod decoded as %x, want %x", again, b)
+		}
+		Release(msg)
+	}
+
+	// messages of other packages are not pooled
+	held, _ := ParsePooled(b)
+	foreign := openflow.NewMessage(openflow.OF10_VERSION, OFPT_FLOW_MOD, 1)
+	Release(&foreign)
+	msg, err = ParsePooled(b)
+	if _, ok := msg.(openflow.FlowMod); !ok || err != nil {
+		t.Errorf("flow mod parsed as %T, %v", msg, err)
+	}
+	Release(msg)
+	Release(held)
+
+	// stats messages are not pooled
+	reply, _ := NewStatsReplyAggregate(uint32(1)).(openflow.BinaryAppender).AppendBinary(nil)
+	msg, err = ParsePooled(reply)
+	if _, ok := msg.(StatsReplyAggregate); !ok || err != nil {
+		t.Errorf("aggregate reply parsed as %T, %v", msg, err)
+	}
+	Release(msg)
+
+	if raceEnabled {
+		return
+	}
+	data := testPacketIn()
+	msg, _ = ParsePooled(data)
+	Release(msg)
+	allocs := testing.AllocsPerRun(100, func() {
+		msg, err := ParsePooled(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Release(msg)
+	})
+	if allocs != 0 {
+		t.Errorf("decoding a pooled packet in allocates %v times", allocs)
+	}
+	allocs = testing.AllocsPerRun(100, func() {
+		msg, err := ParsePooled(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Release(msg)
+	})
+	if allocs != 0 {
+		t.Errorf("decoding a pooled flow mod allocates %v times", allocs)
+	}
+}
+
+func TestPacketInView(t *testing.T) {
+	data := testPacketIn()
+	v, err := NewPacketInView(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, _ := Parse(data)
+	p := msg.(openflow.PacketIn)
+	if v.TransactionID() != 5 || v.BufferID() != p.BufferID() || v.InPort() != p.InPort() ||
+		v.Reason() != p.Reason() || v.TotalLength() != p.TotalLength() || !bytes.Equal(v.Data(), p.Data()) {
+		t.Errorf("view differs from the decoded packet in")
+	}
+	if _, err := NewPacketInView(data[:16]); err != openflow.ErrInvalidPacketLength {
+		t.Errorf("truncated packet in viewed with %v", err)
+	}
+	echo, _ := NewEchoRequest(uint32(1)).(openflow.BinaryAppender).AppendBinary(nil)
+	if _, err := NewPacketInView(echo); err != openflow.ErrUnsupportedMessage {
+		t.Errorf("echo request viewed with %v", err)
+	}
+}
+
+func BenchmarkParsePacketIn(b *testing.B) {
+	data := testPacketIn()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParsePooledPacketIn(b *testing.B) {
+	data := testPacketIn()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msg, err := ParsePooled(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		Release(msg)
+	}
+}
+
+func BenchmarkPacketInView(b *testing.B) {
+	data := testPacketIn()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v, err := NewPacketInView(data)
+		if err != nil || v.InPort() != 2 {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseFlowMod(b *testing.B) {
+	data, _ := testFlowMod().(openflow.BinaryAppender).AppendBinary(nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParsePooledFlowMod(b *testing.B) {
+	data, _ := testFlowMod().(openflow.BinaryAppender).AppendBinary(nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msg, err := ParsePooled(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		Release(msg)
+	}
+}
@@ -1,6 +1,7 @@
 package v10
 
 import (
+	"bytes"
 	"encoding/binary"
 	"github.com/ksang/goflow/openflow"
 	"net"
@@ -91,7 +92,7 @@ func (p *port) SetSupported(value openflow.PortFeature) {
 }
 
 func (p *port) Peer() openflow.PortFeature {
-	return p.supported
+	return p.peer
 }
 
 func (p *port) SetPeer(value openflow.PortFeature) {
@@ -99,17 +100,21 @@ func (p *port) SetPeer(value openflow.PortFeature) {
 }
 
 func (p *port) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 48)
+	return p.AppendBinary(nil)
+}
+
+func (p *port) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := openflow.Grow(dst, 48)
 	binary.BigEndian.PutUint16(v[0:2], uint16(p.portID))
 	copy(v[2:8], p.hwAddr)
-	copy(v[8:24], []byte(p.name))
+	copy(v[8:24], p.name)
 	binary.BigEndian.PutUint32(v[24:28], uint32(p.config))
 	binary.BigEndian.PutUint32(v[28:32], uint32(p.state))
 	binary.BigEndian.PutUint32(v[32:36], uint32(p.curr))
 	binary.BigEndian.PutUint32(v[36:40], uint32(p.advertised))
 	binary.BigEndian.PutUint32(v[40:44], uint32(p.supported))
 	binary.BigEndian.PutUint32(v[44:48], uint32(p.peer))
-	return v, nil
+	return dst, nil
 }
 
 func (p *port) UnmarshalBinary(data []byte) error {
@@ -118,7 +123,10 @@ func (p *port) UnmarshalBinary(data []byte) error {
 	}
 	p.portID = openflow.PortID(binary.BigEndian.Uint16(data[0:2]))
 	p.hwAddr = data[2:8]
-	p.name = string(data[8:24])
+	// the name of a reused port is kept when it did not change
+	if name := bytes.TrimRight(data[8:24], "\x00"); string(name) != p.name {
+		p.name = string(name)
+	}
 	p.config = openflow.PortConfig(binary.BigEndian.Uint32(data[24:28]))
 	p.state = openflow.PortState(binary.BigEndian.Uint32(data[28:32]))
 	p.curr = openflow.PortFeature(binary.BigEndian.Uint32(data[32:36]))
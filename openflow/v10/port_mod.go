@@ -60,15 +60,18 @@ func (p *portMod) SetAdvertise(pf openflow.PortFeature) {
 }
 
 func (p *portMod) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 24)
+	return p.AppendBinary(nil)
+}
+
+func (p *portMod) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := appendMessage(&p.Message, dst, 24)
 	binary.BigEndian.PutUint16(v[0:2], uint16(p.port))
 	copy(v[2:8], p.hwAddr)
 	binary.BigEndian.PutUint32(v[8:12], uint32(p.config))
 	binary.BigEndian.PutUint32(v[12:16], p.mask)
 	binary.BigEndian.PutUint32(v[16:20], uint32(p.advertise))
 	//v[20:24] is pad
-	p.SetPayload(v)
-	return p.Message.MarshalBinary()
+	return dst, nil
 }
 
 func (p *portMod) UnmarshalBinary(data []byte) error {
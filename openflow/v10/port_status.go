@@ -27,16 +27,17 @@ func (p *portStatus) SetPort(port openflow.Port) {
 }
 
 func (p *portStatus) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 56)
+	return p.AppendBinary(nil)
+}
+
+func (p *portStatus) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := appendMessage(&p.Message, dst, 56)
 	v[0] = uint8(p.reason)
 	//v[1:8] is pad
-	port, err := p.port.MarshalBinary()
-	if err != nil {
+	if err := put(v[8:56], p.port); err != nil {
 		return nil, err
 	}
-	copy(v[8:], port)
-	p.SetPayload(v)
-	return p.Message.MarshalBinary()
+	return dst, nil
 }
 
 func (p *portStatus) UnmarshalBinary(data []byte) error {
@@ -50,7 +51,9 @@ func (p *portStatus) UnmarshalBinary(data []byte) error {
 	}
 	p.reason = openflow.PortReason(payload[0])
 	// payload[1:8] is padding
-	p.port = new(port)
+	if p.port == nil {
+		p.port = new(port)
+	}
 	if err := p.port.UnmarshalBinary(payload[8:]); err != nil {
 		return err
 	}
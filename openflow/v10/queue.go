@@ -33,7 +33,11 @@ func (q *queue) SetRate(r uint16) {
 }
 
 func (q *queue) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 24)
+	return q.AppendBinary(nil)
+}
+
+func (q *queue) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := openflow.Grow(dst, 24)
 	binary.BigEndian.PutUint32(v[0:4], q.queueID)
 	binary.BigEndian.PutUint16(v[4:6], q.length)
 	// v[6:8] is pad
@@ -41,7 +45,7 @@ func (q *queue) MarshalBinary() ([]byte, error) {
 	binary.BigEndian.PutUint16(v[10:12], uint16(16))
 	binary.BigEndian.PutUint16(v[16:18], q.rate)
 	// v[18:24] is pad
-	return v, nil
+	return dst, nil
 }
 
 func (q *queue) UnmarshalBinary(data []byte) error {
@@ -51,6 +55,7 @@ func (q *queue) UnmarshalBinary(data []byte) error {
 	q.queueID = binary.BigEndian.Uint32(data[0:4])
 	q.length = binary.BigEndian.Uint16(data[4:6])
 	// data[6:8] is pad
+	q.rate = 0
 	property := binary.BigEndian.Uint16(data[8:10])
 	if property != 0x01 {
 		// Unknown property
@@ -84,11 +89,14 @@ func (q *queueGetConfigRequest) SetPort(p uint16) error {
 }
 
 func (q *queueGetConfigRequest) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 4)
+	return q.AppendBinary(nil)
+}
+
+func (q *queueGetConfigRequest) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := appendMessage(&q.Message, dst, 4)
 	binary.BigEndian.PutUint16(v[0:2], q.port)
 	// v[2:4] is pad
-	q.SetPayload(v)
-	return q.Message.MarshalBinary()
+	return dst, nil
 }
 
 func (q *queueGetConfigRequest) UnmarshalBinary(data []byte) error {
@@ -96,6 +104,9 @@ func (q *queueGetConfigRequest) UnmarshalBinary(data []byte) error {
 		return err
 	}
 	payload := q.Payload()
+	if len(payload) != 4 {
+		return openflow.ErrInvalidPacketLength
+	}
 	q.port = binary.BigEndian.Uint16(payload[0:2])
 	// payload[2:4] is pad
 	return nil
@@ -130,18 +141,19 @@ func (q *queueGetConfigReply) AddQueue(nq openflow.Queue)  {
 }
 
 func (q *queueGetConfigReply) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 8 + 24*len(q.queue))
+	return q.AppendBinary(nil)
+}
+
+func (q *queueGetConfigReply) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := appendMessage(&q.Message, dst, 8+24*len(q.queue))
 	binary.BigEndian.PutUint16(v[0:2], q.port)
 	// v[2:8] is pad
 	for i, nq := range q.queue {
-		data, err := nq.MarshalBinary()
-		if err != nil {
+		if err := put(v[8+i*24:8+(i+1)*24], nq); err != nil {
 			return nil, err
 		}
-		copy(v[8+i*24:8+(i+1)*24], data)
 	}
-	q.SetPayload(v)
-	return q.Message.MarshalBinary()
+	return dst, nil
 }
 
 func (q *queueGetConfigReply) UnmarshalBinary(data []byte) error {
@@ -156,9 +168,16 @@ func (q *queueGetConfigReply) UnmarshalBinary(data []byte) error {
 	if (len(payload) - 8) % 24 != 0 {
 		return openflow.ErrInvalidPacketLength
 	}
-	// Unmarshal Queues
+	// Unmarshal Queues, the queues of a reused reply are decoded again
+	queues := q.queue
+	q.queue = q.queue[:0]
 	for i := 8; i < len(payload); i += 24 {
-		nq := NewQueue()
+		var nq openflow.Queue
+		if n := len(q.queue); n < len(queues) {
+			nq = queues[n]
+		} else {
+			nq = NewQueue()
+		}
 		if err := nq.UnmarshalBinary(payload[i:i+24]); err != nil {
 			return err
 		}
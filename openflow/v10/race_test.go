@@ -0,0 +1,7 @@
+//go:build race
+
+package v10
+
+// raceEnabled skips the allocation checks, sync.Pool drops released
+// messages at random under the race detector
+const raceEnabled = true
@@ -37,14 +37,22 @@ func (s *statsHeader) SetStatsPayload(pl []byte) {
 }
 
 func (s *statsHeader) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 4 + len(s.statsPayload))
+	return s.AppendBinary(nil)
+}
+
+func (s *statsHeader) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := s.appendStats(dst, len(s.statsPayload))
+	copy(v, s.statsPayload)
+	return dst, nil
+}
+
+// appendStats appends the stats header with a body of n bytes to dst, it
+// returns dst and the zeroed body to fill
+func (s *statsHeader) appendStats(dst []byte, n int) ([]byte, []byte) {
+	dst, v := appendMessage(&s.Message, dst, 4+n)
 	binary.BigEndian.PutUint16(v[0:2], uint16(s.typ))
 	binary.BigEndian.PutUint16(v[2:4], uint16(s.flags))
-	if len(s.statsPayload) > 0 {
-		copy(v[4:], s.statsPayload)
-	}
-	s.SetPayload(v)
-	return s.Message.MarshalBinary()
+	return dst, v[4:]
 }
 
 func (s *statsHeader) UnmarshalBinary(data []byte) error {
@@ -58,6 +66,7 @@ func (s *statsHeader) UnmarshalBinary(data []byte) error {
 	}
 	s.typ = openflow.StatsType(binary.BigEndian.Uint16(payload[0:2]))
 	s.flags = binary.BigEndian.Uint16(payload[2:4])
+	s.statsPayload = nil
 	if len(payload) > 4 {
 		s.SetStatsPayload(payload[4:])
 	}
@@ -85,7 +94,11 @@ type StatsRequestDescription interface {
 }
 
 func (s *statsRequestDescription) MarshalBinary() ([]byte, error) {
-	return s.statsHeader.MarshalBinary()
+	return s.AppendBinary(nil)
+}
+
+func (s *statsRequestDescription) AppendBinary(dst []byte) ([]byte, error) {
+	return s.statsHeader.AppendBinary(dst)
 }
 
 func (s *statsRequestDescription) UnmarshalBinary(data []byte) error {
@@ -140,17 +153,18 @@ func (s *statsRequestFlow) SetOutPort(op uint16) {
 }
 
 func (s *statsRequestFlow) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 44)
-	m ,err := s.match.MarshalBinary()
-	if err != nil {
+	return s.AppendBinary(nil)
+}
+
+func (s *statsRequestFlow) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := s.appendStats(dst, 44)
+	if err := put(v[0:40], s.match); err != nil {
 		return nil, err
 	}
-	copy(v[0:40], m)
 	v[40] = s.tableID
 	//v[41] is pad
 	binary.BigEndian.PutUint16(v[42:44], s.outPort)
-	s.SetStatsPayload(v)
-	return s.statsHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (s *statsRequestFlow) UnmarshalBinary(data []byte) error {
@@ -230,8 +244,9 @@ func (s *statsRequestPort) PortNumber() uint16 {
 	return s.portNumber
 }
 
+// SetPortNumber sets the port to query, OFPP_NONE queries all ports
 func (s *statsRequestPort) SetPortNumber(pn uint16) error {
-	if pn > 0xffef {
+	if pn > 0xffef && pn != uint16(openflow.None) {
 		return openflow.ErrInvalidValueProvided
 	}
 	s.portNumber = pn
@@ -239,11 +254,14 @@ func (s *statsRequestPort) SetPortNumber(pn uint16) error {
 }
 
 func (s *statsRequestPort) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 8)
+	return s.AppendBinary(nil)
+}
+
+func (s *statsRequestPort) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := s.appendStats(dst, 8)
 	binary.BigEndian.PutUint16(v[0:2], s.portNumber)
 	// v[2:8] is pad
-	s.SetStatsPayload(v)
-	return s.statsHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (s *statsRequestPort) UnmarshalBinary(data []byte) error {
@@ -285,8 +303,9 @@ func (s *statsRequestQueue) PortNumber() uint16 {
 	return s.portNumber
 }
 
+// SetPortNumber sets the port to query, OFPP_ALL queries all ports
 func (s *statsRequestQueue) SetPortNumber(pn uint16) error {
-	if pn > 0xffef {
+	if pn > 0xffef && pn != uint16(openflow.All) {
 		return openflow.ErrInvalidValueProvided
 	}
 	s.portNumber = pn
@@ -302,12 +321,15 @@ func (s *statsRequestQueue) SetQueueID(qid uint32) {
 }
 
 func (s *statsRequestQueue) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 8)
+	return s.AppendBinary(nil)
+}
+
+func (s *statsRequestQueue) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := s.appendStats(dst, 8)
 	binary.BigEndian.PutUint16(v[0:2], s.portNumber)
 	binary.BigEndian.PutUint32(v[4:8], s.queueID)
 	// v[2:4] is pad
-	s.SetStatsPayload(v)
-	return s.statsHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (s *statsRequestQueue) UnmarshalBinary(data []byte) error {
@@ -352,10 +374,13 @@ func (s *statsRequestVendor) SetVendorID(vid uint32) {
 }
 
 func (s *statsRequestVendor) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 4)
+	return s.AppendBinary(nil)
+}
+
+func (s *statsRequestVendor) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := s.appendStats(dst, 4)
 	binary.BigEndian.PutUint32(v[0:4], s.vendorID)
-	s.SetStatsPayload(v)
-	return s.statsHeader.MarshalBinary()
+	return dst, nil
 }
 
 func (s *statsRequestVendor) UnmarshalBinary(data []byte) error {
@@ -442,14 +467,17 @@ func (s *statsReplyDescription) SetDpDesc(dd *[256]byte) {
 }
 
 func (s *statsReplyDescription) MarshalBinary() ([]byte, error) {
-	v := make([]byte, 1056)
-	copy(v, s.mfrDesc[:])
-	copy(v, s.hwDesc[:])
-	copy(v, s.swDesc[:])
-	copy(v, s.serialNum[:])
-	copy(v, s.dpDesc[:])
-	s.SetStatsPayload(v)
-	return s.statsHeader.MarshalBinary()
+	return s.AppendBinary(nil)
+}
+
+func (s *statsReplyDescription) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := s.appendStats(dst, 1056)
+	copy(v[0:256], s.mfrDesc[:])
+	copy(v[256:512], s.hwDesc[:])
+	copy(v[512:768], s.swDesc[:])
+	copy(v[768:800], s.serialNum[:])
+	copy(v[800:1056], s.dpDesc[:])
+	return dst, nil
 }
 
 func (s *statsReplyDescription) UnmarshalBinary(data []byte) error {
@@ -482,7 +510,7 @@ func (s *statsReplyDescription) UnmarshalBinary(data []byte) error {
 
 func NewStatsReplyDescription(xid uint32) StatsReplyDescription {
 	srd := &statsReplyDescription{
-		statsHeader 	: NewStatsRequestHeader(xid).(*statsHeader),
+		statsHeader 	: NewStatsReplyHeader(xid).(*statsHeader),
 	}
 	srd.statsHeader.SetType(openflow.STATS_Description)
 	var (
@@ -499,5 +527,3 @@ func NewStatsReplyDescription(xid uint32) StatsReplyDescription {
 	srd.dpDesc = &dd
 	return srd
 }
-
-//TODO other StatsReply types
\ No newline at end of file
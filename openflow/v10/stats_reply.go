@@ -0,0 +1,965 @@
+package v10
+
+import (
+	"encoding"
+	"encoding/binary"
+	"github.com/ksang/goflow/openflow"
+)
+
+// EncodeActions is the reverse of DecodeActions
+func EncodeActions(actions []openflow.Action) ([]byte, error) {
+	return AppendActions(nil, actions)
+}
+
+// AppendActions appends the encoding of actions to dst
+func AppendActions(dst []byte, actions []openflow.Action) ([]byte, error) {
+	for _, act := range actions {
+		pos := len(dst)
+		var err error
+		if dst, err = openflow.AppendBinary(dst, act); err != nil {
+			return nil, err
+		}
+		if len(dst)-pos != int(act.Length()) {
+			return nil, openflow.ErrInvalidDataLength
+		}
+	}
+	return dst, nil
+}
+
+// FlowStats is a flow entry of a flow stats reply
+type FlowStats interface {
+	TableID() uint8
+	SetTableID(uint8)
+	Match() openflow.Match
+	SetMatch(openflow.Match)
+	DurationSec() uint32
+	SetDurationSec(uint32)
+	DurationNanoSec() uint32
+	SetDurationNanoSec(uint32)
+	Priority() uint16
+	SetPriority(uint16)
+	IdleTimeout() uint16
+	SetIdleTimeout(uint16)
+	HardTimeout() uint16
+	SetHardTimeout(uint16)
+	Cookie() uint64
+	SetCookie(uint64)
+	PacketCount() uint64
+	SetPacketCount(uint64)
+	ByteCount() uint64
+	SetByteCount(uint64)
+	Actions() []openflow.Action
+	AddAction(openflow.Action)
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+type flowStats struct {
+	tableID         uint8
+	match           openflow.Match
+	durationSec     uint32
+	durationNanoSec uint32
+	priority        uint16
+	idleTimeout     uint16
+	hardTimeout     uint16
+	cookie          uint64
+	packetCount     uint64
+	byteCount       uint64
+	actions         []openflow.Action
+}
+
+func (f *flowStats) TableID() uint8 {
+	return f.tableID
+}
+
+func (f *flowStats) SetTableID(id uint8) {
+	f.tableID = id
+}
+
+func (f *flowStats) Match() openflow.Match {
+	return f.match
+}
+
+func (f *flowStats) SetMatch(m openflow.Match) {
+	f.match = m
+}
+
+func (f *flowStats) DurationSec() uint32 {
+	return f.durationSec
+}
+
+func (f *flowStats) SetDurationSec(d uint32) {
+	f.durationSec = d
+}
+
+func (f *flowStats) DurationNanoSec() uint32 {
+	return f.durationNanoSec
+}
+
+func (f *flowStats) SetDurationNanoSec(d uint32) {
+	f.durationNanoSec = d
+}
+
+func (f *flowStats) Priority() uint16 {
+	return f.priority
+}
+
+func (f *flowStats) SetPriority(p uint16) {
+	f.priority = p
+}
+
+func (f *flowStats) IdleTimeout() uint16 {
+	return f.idleTimeout
+}
+
+func (f *flowStats) SetIdleTimeout(t uint16) {
+	f.idleTimeout = t
+}
+
+func (f *flowStats) HardTimeout() uint16 {
+	return f.hardTimeout
+}
+
+func (f *flowStats) SetHardTimeout(t uint16) {
+	f.hardTimeout = t
+}
+
+func (f *flowStats) Cookie() uint64 {
+	return f.cookie
+}
+
+func (f *flowStats) SetCookie(c uint64) {
+	f.cookie = c
+}
+
+func (f *flowStats) PacketCount() uint64 {
+	return f.packetCount
+}
+
+func (f *flowStats) SetPacketCount(c uint64) {
+	f.packetCount = c
+}
+
+func (f *flowStats) ByteCount() uint64 {
+	return f.byteCount
+}
+
+func (f *flowStats) SetByteCount(c uint64) {
+	f.byteCount = c
+}
+
+func (f *flowStats) Actions() []openflow.Action {
+	return f.actions
+}
+
+func (f *flowStats) AddAction(a openflow.Action) {
+	f.actions = append(f.actions, a)
+}
+
+func (f *flowStats) MarshalBinary() ([]byte, error) {
+	return f.AppendBinary(nil)
+}
+
+func (f *flowStats) AppendBinary(dst []byte) ([]byte, error) {
+	start := len(dst)
+	dst, v := openflow.Grow(dst, 88)
+	v[2] = f.tableID
+	// v[3] is pad
+	if err := put(v[4:44], f.match); err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint32(v[44:48], f.durationSec)
+	binary.BigEndian.PutUint32(v[48:52], f.durationNanoSec)
+	binary.BigEndian.PutUint16(v[52:54], f.priority)
+	binary.BigEndian.PutUint16(v[54:56], f.idleTimeout)
+	binary.BigEndian.PutUint16(v[56:58], f.hardTimeout)
+	// v[58:64] is pad
+	binary.BigEndian.PutUint64(v[64:72], f.cookie)
+	binary.BigEndian.PutUint64(v[72:80], f.packetCount)
+	binary.BigEndian.PutUint64(v[80:88], f.byteCount)
+	dst, err := AppendActions(dst, f.actions)
+	if err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint16(dst[start:start+2], uint16(len(dst)-start))
+	return dst, nil
+}
+
+// UnmarshalBinary decodes a single entry, data must have its exact length
+func (f *flowStats) UnmarshalBinary(data []byte) error {
+	if len(data) < 88 || int(binary.BigEndian.Uint16(data[0:2])) != len(data) {
+		return openflow.ErrInvalidDataLength
+	}
+	f.tableID = data[2]
+	f.match = NewMatch()
+	if err := f.match.UnmarshalBinary(data[4:44]); err != nil {
+		return err
+	}
+	f.durationSec = binary.BigEndian.Uint32(data[44:48])
+	f.durationNanoSec = binary.BigEndian.Uint32(data[48:52])
+	f.priority = binary.BigEndian.Uint16(data[52:54])
+	f.idleTimeout = binary.BigEndian.Uint16(data[54:56])
+	f.hardTimeout = binary.BigEndian.Uint16(data[56:58])
+	f.cookie = binary.BigEndian.Uint64(data[64:72])
+	f.packetCount = binary.BigEndian.Uint64(data[72:80])
+	f.byteCount = binary.BigEndian.Uint64(data[80:88])
+	actions, err := DecodeActions(data[88:])
+	if err != nil {
+		return err
+	}
+	f.actions = actions
+	return nil
+}
+
+func NewFlowStats() FlowStats {
+	return &flowStats{
+		match: NewMatch(),
+	}
+}
+
+type statsReplyFlow struct {
+	*statsHeader
+	flows []FlowStats
+}
+
+// StatsReplyFlow is a flow stats reply, large tables are split across
+// several replies flagged with OFPSF_REPLY_MORE
+type StatsReplyFlow interface {
+	openflow.StatsReply
+	Flows() []FlowStats
+	AddFlow(FlowStats)
+}
+
+func (s *statsReplyFlow) Flows() []FlowStats {
+	return s.flows
+}
+
+func (s *statsReplyFlow) AddFlow(f FlowStats) {
+	s.flows = append(s.flows, f)
+}
+
+func (s *statsReplyFlow) MarshalBinary() ([]byte, error) {
+	return s.AppendBinary(nil)
+}
+
+func (s *statsReplyFlow) AppendBinary(dst []byte) ([]byte, error) {
+	start := len(dst)
+	dst, _ = s.appendStats(dst, 0)
+	for _, f := range s.flows {
+		var err error
+		if dst, err = openflow.AppendBinary(dst, f); err != nil {
+			return nil, err
+		}
+	}
+	s.FixLength(dst[start:])
+	return dst, nil
+}
+
+func (s *statsReplyFlow) UnmarshalBinary(data []byte) error {
+	if err := s.statsHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	s.flows = nil
+	for payload := s.statsPayload; len(payload) > 0; {
+		if len(payload) < 2 {
+			return openflow.ErrInvalidDataLength
+		}
+		length := int(binary.BigEndian.Uint16(payload[0:2]))
+		if length < 88 || length > len(payload) {
+			return openflow.ErrInvalidDataLength
+		}
+		f := &flowStats{}
+		if err := f.UnmarshalBinary(payload[:length]); err != nil {
+			return err
+		}
+		s.flows = append(s.flows, f)
+		payload = payload[length:]
+	}
+	return nil
+}
+
+func NewStatsReplyFlow(xid uint32) StatsReplyFlow {
+	srf := &statsReplyFlow{
+		statsHeader: NewStatsReplyHeader(xid).(*statsHeader),
+	}
+	srf.statsHeader.SetType(openflow.STATS_Flow)
+	return srf
+}
+
+// StatsReplyAggregate sums the counters of the flows matching a request
+type StatsReplyAggregate interface {
+	openflow.StatsReply
+	PacketCount() uint64
+	SetPacketCount(uint64)
+	ByteCount() uint64
+	SetByteCount(uint64)
+	FlowCount() uint32
+	SetFlowCount(uint32)
+}
+
+type statsReplyAggregate struct {
+	*statsHeader
+	packetCount uint64
+	byteCount   uint64
+	flowCount   uint32
+}
+
+func (s *statsReplyAggregate) PacketCount() uint64 {
+	return s.packetCount
+}
+
+func (s *statsReplyAggregate) SetPacketCount(c uint64) {
+	s.packetCount = c
+}
+
+func (s *statsReplyAggregate) ByteCount() uint64 {
+	return s.byteCount
+}
+
+func (s *statsReplyAggregate) SetByteCount(c uint64) {
+	s.byteCount = c
+}
+
+func (s *statsReplyAggregate) FlowCount() uint32 {
+	return s.flowCount
+}
+
+func (s *statsReplyAggregate) SetFlowCount(c uint32) {
+	s.flowCount = c
+}
+
+func (s *statsReplyAggregate) MarshalBinary() ([]byte, error) {
+	return s.AppendBinary(nil)
+}
+
+func (s *statsReplyAggregate) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := s.appendStats(dst, 24)
+	binary.BigEndian.PutUint64(v[0:8], s.packetCount)
+	binary.BigEndian.PutUint64(v[8:16], s.byteCount)
+	binary.BigEndian.PutUint32(v[16:20], s.flowCount)
+	// v[20:24] is pad
+	return dst, nil
+}
+
+func (s *statsReplyAggregate) UnmarshalBinary(data []byte) error {
+	if err := s.statsHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	payload := s.statsPayload
+	if len(payload) != 24 {
+		return openflow.ErrInvalidDataLength
+	}
+	s.packetCount = binary.BigEndian.Uint64(payload[0:8])
+	s.byteCount = binary.BigEndian.Uint64(payload[8:16])
+	s.flowCount = binary.BigEndian.Uint32(payload[16:20])
+	return nil
+}
+
+func NewStatsReplyAggregate(xid uint32) StatsReplyAggregate {
+	sra := &statsReplyAggregate{
+		statsHeader: NewStatsReplyHeader(xid).(*statsHeader),
+	}
+	sra.statsHeader.SetType(openflow.STATS_Aggregate)
+	return sra
+}
+
+// TableStats describes a flow table of a table stats reply
+type TableStats interface {
+	TableID() uint8
+	SetTableID(uint8)
+	Name() string
+	SetName(string) error
+	Wildcards() uint32
+	SetWildcards(uint32)
+	MaxEntries() uint32
+	SetMaxEntries(uint32)
+	ActiveCount() uint32
+	SetActiveCount(uint32)
+	LookupCount() uint64
+	SetLookupCount(uint64)
+	MatchedCount() uint64
+	SetMatchedCount(uint64)
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+type tableStats struct {
+	tableID      uint8
+	name         [32]byte
+	wildcards    uint32
+	maxEntries   uint32
+	activeCount  uint32
+	lookupCount  uint64
+	matchedCount uint64
+}
+
+func (t *tableStats) TableID() uint8 {
+	return t.tableID
+}
+
+func (t *tableStats) SetTableID(id uint8) {
+	t.tableID = id
+}
+
+func (t *tableStats) Name() string {
+	return cString(t.name[:])
+}
+
+func (t *tableStats) SetName(name string) error {
+	if len(name) > 32 {
+		return openflow.ErrInvalidDataLength
+	}
+	t.name = [32]byte{}
+	copy(t.name[:], name)
+	return nil
+}
+
+func (t *tableStats) Wildcards() uint32 {
+	return t.wildcards
+}
+
+func (t *tableStats) SetWildcards(w uint32) {
+	t.wildcards = w
+}
+
+func (t *tableStats) MaxEntries() uint32 {
+	return t.maxEntries
+}
+
+func (t *tableStats) SetMaxEntries(n uint32) {
+	t.maxEntries = n
+}
+
+func (t *tableStats) ActiveCount() uint32 {
+	return t.activeCount
+}
+
+func (t *tableStats) SetActiveCount(n uint32) {
+	t.activeCount = n
+}
+
+func (t *tableStats) LookupCount() uint64 {
+	return t.lookupCount
+}
+
+func (t *tableStats) SetLookupCount(n uint64) {
+	t.lookupCount = n
+}
+
+func (t *tableStats) MatchedCount() uint64 {
+	return t.matchedCount
+}
+
+func (t *tableStats) SetMatchedCount(n uint64) {
+	t.matchedCount = n
+}
+
+func (t *tableStats) MarshalBinary() ([]byte, error) {
+	return t.AppendBinary(nil)
+}
+
+func (t *tableStats) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := openflow.Grow(dst, 64)
+	v[0] = t.tableID
+	// v[1:4] is pad
+	copy(v[4:36], t.name[:])
+	binary.BigEndian.PutUint32(v[36:40], t.wildcards)
+	binary.BigEndian.PutUint32(v[40:44], t.maxEntries)
+	binary.BigEndian.PutUint32(v[44:48], t.activeCount)
+	binary.BigEndian.PutUint64(v[48:56], t.lookupCount)
+	binary.BigEndian.PutUint64(v[56:64], t.matchedCount)
+	return dst, nil
+}
+
+func (t *tableStats) UnmarshalBinary(data []byte) error {
+	if len(data) != 64 {
+		return openflow.ErrInvalidDataLength
+	}
+	t.tableID = data[0]
+	copy(t.name[:], data[4:36])
+	t.wildcards = binary.BigEndian.Uint32(data[36:40])
+	t.maxEntries = binary.BigEndian.Uint32(data[40:44])
+	t.activeCount = binary.BigEndian.Uint32(data[44:48])
+	t.lookupCount = binary.BigEndian.Uint64(data[48:56])
+	t.matchedCount = binary.BigEndian.Uint64(data[56:64])
+	return nil
+}
+
+func NewTableStats() TableStats {
+	return &tableStats{}
+}
+
+// StatsReplyTable is a table stats reply
+type StatsReplyTable interface {
+	openflow.StatsReply
+	Tables() []TableStats
+	AddTable(TableStats)
+}
+
+type statsReplyTable struct {
+	*statsHeader
+	tables []TableStats
+}
+
+func (s *statsReplyTable) Tables() []TableStats {
+	return s.tables
+}
+
+func (s *statsReplyTable) AddTable(t TableStats) {
+	s.tables = append(s.tables, t)
+}
+
+func (s *statsReplyTable) MarshalBinary() ([]byte, error) {
+	return s.AppendBinary(nil)
+}
+
+func (s *statsReplyTable) AppendBinary(dst []byte) ([]byte, error) {
+	start := len(dst)
+	dst, _ = s.appendStats(dst, 0)
+	for _, t := range s.tables {
+		var err error
+		if dst, err = openflow.AppendBinary(dst, t); err != nil {
+			return nil, err
+		}
+	}
+	s.FixLength(dst[start:])
+	return dst, nil
+}
+
+func (s *statsReplyTable) UnmarshalBinary(data []byte) error {
+	if err := s.statsHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	payload := s.statsPayload
+	if len(payload)%64 != 0 {
+		return openflow.ErrInvalidDataLength
+	}
+	s.tables = nil
+	for ; len(payload) > 0; payload = payload[64:] {
+		t := &tableStats{}
+		if err := t.UnmarshalBinary(payload[:64]); err != nil {
+			return err
+		}
+		s.tables = append(s.tables, t)
+	}
+	return nil
+}
+
+func NewStatsReplyTable(xid uint32) StatsReplyTable {
+	srt := &statsReplyTable{
+		statsHeader: NewStatsReplyHeader(xid).(*statsHeader),
+	}
+	srt.statsHeader.SetType(openflow.STATS_Table)
+	return srt
+}
+
+// PortStats holds the counters of a port of a port stats reply, counters
+// a switch does not support are all ones
+type PortStats interface {
+	PortNumber() uint16
+	SetPortNumber(uint16)
+	RxPackets() uint64
+	SetRxPackets(uint64)
+	TxPackets() uint64
+	SetTxPackets(uint64)
+	RxBytes() uint64
+	SetRxBytes(uint64)
+	TxBytes() uint64
+	SetTxBytes(uint64)
+	RxDropped() uint64
+	SetRxDropped(uint64)
+	TxDropped() uint64
+	SetTxDropped(uint64)
+	RxErrors() uint64
+	SetRxErrors(uint64)
+	TxErrors() uint64
+	SetTxErrors(uint64)
+	RxFrameErr() uint64
+	SetRxFrameErr(uint64)
+	RxOverErr() uint64
+	SetRxOverErr(uint64)
+	RxCRCErr() uint64
+	SetRxCRCErr(uint64)
+	Collisions() uint64
+	SetCollisions(uint64)
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// port counters in wire order
+const (
+	rxPackets = iota
+	txPackets
+	rxBytes
+	txBytes
+	rxDropped
+	txDropped
+	rxErrors
+	txErrors
+	rxFrameErr
+	rxOverErr
+	rxCRCErr
+	collisions
+	portCounters
+)
+
+type portStats struct {
+	portNumber uint16
+	counters   [portCounters]uint64
+}
+
+func (p *portStats) PortNumber() uint16 {
+	return p.portNumber
+}
+
+func (p *portStats) SetPortNumber(n uint16) {
+	p.portNumber = n
+}
+
+func (p *portStats) RxPackets() uint64 {
+	return p.counters[rxPackets]
+}
+
+func (p *portStats) SetRxPackets(c uint64) {
+	p.counters[rxPackets] = c
+}
+
+func (p *portStats) TxPackets() uint64 {
+	return p.counters[txPackets]
+}
+
+func (p *portStats) SetTxPackets(c uint64) {
+	p.counters[txPackets] = c
+}
+
+func (p *portStats) RxBytes() uint64 {
+	return p.counters[rxBytes]
+}
+
+func (p *portStats) SetRxBytes(c uint64) {
+	p.counters[rxBytes] = c
+}
+
+func (p *portStats) TxBytes() uint64 {
+	return p.counters[txBytes]
+}
+
+func (p *portStats) SetTxBytes(c uint64) {
+	p.counters[txBytes] = c
+}
+
+func (p *portStats) RxDropped() uint64 {
+	return p.counters[rxDropped]
+}
+
+func (p *portStats) SetRxDropped(c uint64) {
+	p.counters[rxDropped] = c
+}
+
+func (p *portStats) TxDropped() uint64 {
+	return p.counters[txDropped]
+}
+
+func (p *portStats) SetTxDropped(c uint64) {
+	p.counters[txDropped] = c
+}
+
+func (p *portStats) RxErrors() uint64 {
+	return p.counters[rxErrors]
+}
+
+func (p *portStats) SetRxErrors(c uint64) {
+	p.counters[rxErrors] = c
+}
+
+func (p *portStats) TxErrors() uint64 {
+	return p.counters[txErrors]
+}
+
+func (p *portStats) SetTxErrors(c uint64) {
+	p.counters[txErrors] = c
+}
+
+func (p *portStats) RxFrameErr() uint64 {
+	return p.counters[rxFrameErr]
+}
+
+func (p *portStats) SetRxFrameErr(c uint64) {
+	p.counters[rxFrameErr] = c
+}
+
+func (p *portStats) RxOverErr() uint64 {
+	return p.counters[rxOverErr]
+}
+
+func (p *portStats) SetRxOverErr(c uint64) {
+	p.counters[rxOverErr] = c
+}
+
+func (p *portStats) RxCRCErr() uint64 {
+	return p.counters[rxCRCErr]
+}
+
+func (p *portStats) SetRxCRCErr(c uint64) {
+	p.counters[rxCRCErr] = c
+}
+
+func (p *portStats) Collisions() uint64 {
+	return p.counters[collisions]
+}
+
+func (p *portStats) SetCollisions(c uint64) {
+	p.counters[collisions] = c
+}
+
+func (p *portStats) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(nil)
+}
+
+func (p *portStats) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := openflow.Grow(dst, 104)
+	binary.BigEndian.PutUint16(v[0:2], p.portNumber)
+	// v[2:8] is pad
+	for i, c := range p.counters {
+		binary.BigEndian.PutUint64(v[8+8*i:16+8*i], c)
+	}
+	return dst, nil
+}
+
+func (p *portStats) UnmarshalBinary(data []byte) error {
+	if len(data) != 104 {
+		return openflow.ErrInvalidDataLength
+	}
+	p.portNumber = binary.BigEndian.Uint16(data[0:2])
+	for i := range p.counters {
+		p.counters[i] = binary.BigEndian.Uint64(data[8+8*i : 16+8*i])
+	}
+	return nil
+}
+
+func NewPortStats() PortStats {
+	return &portStats{}
+}
+
+// StatsReplyPort is a port stats reply
+type StatsReplyPort interface {
+	openflow.StatsReply
+	Ports() []PortStats
+	AddPort(PortStats)
+}
+
+type statsReplyPort struct {
+	*statsHeader
+	ports []PortStats
+}
+
+func (s *statsReplyPort) Ports() []PortStats {
+	return s.ports
+}
+
+func (s *statsReplyPort) AddPort(p PortStats) {
+	s.ports = append(s.ports, p)
+}
+
+func (s *statsReplyPort) MarshalBinary() ([]byte, error) {
+	return s.AppendBinary(nil)
+}
+
+func (s *statsReplyPort) AppendBinary(dst []byte) ([]byte, error) {
+	start := len(dst)
+	dst, _ = s.appendStats(dst, 0)
+	for _, p := range s.ports {
+		var err error
+		if dst, err = openflow.AppendBinary(dst, p); err != nil {
+			return nil, err
+		}
+	}
+	s.FixLength(dst[start:])
+	return dst, nil
+}
+
+func (s *statsReplyPort) UnmarshalBinary(data []byte) error {
+	if err := s.statsHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	payload := s.statsPayload
+	if len(payload)%104 != 0 {
+		return openflow.ErrInvalidDataLength
+	}
+	s.ports = nil
+	for ; len(payload) > 0; payload = payload[104:] {
+		p := &portStats{}
+		if err := p.UnmarshalBinary(payload[:104]); err != nil {
+			return err
+		}
+		s.ports = append(s.ports, p)
+	}
+	return nil
+}
+
+func NewStatsReplyPort(xid uint32) StatsReplyPort {
+	srp := &statsReplyPort{
+		statsHeader: NewStatsReplyHeader(xid).(*statsHeader),
+	}
+	srp.statsHeader.SetType(openflow.STATS_Port)
+	return srp
+}
+
+// QueueStats holds the counters of a queue of a queue stats reply
+type QueueStats interface {
+	PortNumber() uint16
+	SetPortNumber(uint16)
+	QueueID() uint32
+	SetQueueID(uint32)
+	TxBytes() uint64
+	SetTxBytes(uint64)
+	TxPackets() uint64
+	SetTxPackets(uint64)
+	TxErrors() uint64
+	SetTxErrors(uint64)
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+type queueStats struct {
+	portNumber uint16
+	queueID    uint32
+	txBytes    uint64
+	txPackets  uint64
+	txErrors   uint64
+}
+
+func (q *queueStats) PortNumber() uint16 {
+	return q.portNumber
+}
+
+func (q *queueStats) SetPortNumber(n uint16) {
+	q.portNumber = n
+}
+
+func (q *queueStats) QueueID() uint32 {
+	return q.queueID
+}
+
+func (q *queueStats) SetQueueID(id uint32) {
+	q.queueID = id
+}
+
+func (q *queueStats) TxBytes() uint64 {
+	return q.txBytes
+}
+
+func (q *queueStats) SetTxBytes(c uint64) {
+	q.txBytes = c
+}
+
+func (q *queueStats) TxPackets() uint64 {
+	return q.txPackets
+}
+
+func (q *queueStats) SetTxPackets(c uint64) {
+	q.txPackets = c
+}
+
+func (q *queueStats) TxErrors() uint64 {
+	return q.txErrors
+}
+
+func (q *queueStats) SetTxErrors(c uint64) {
+	q.txErrors = c
+}
+
+func (q *queueStats) MarshalBinary() ([]byte, error) {
+	return q.AppendBinary(nil)
+}
+
+func (q *queueStats) AppendBinary(dst []byte) ([]byte, error) {
+	dst, v := openflow.Grow(dst, 32)
+	binary.BigEndian.PutUint16(v[0:2], q.portNumber)
+	// v[2:4] is pad
+	binary.BigEndian.PutUint32(v[4:8], q.queueID)
+	binary.BigEndian.PutUint64(v[8:16], q.txBytes)
+	binary.BigEndian.PutUint64(v[16:24], q.txPackets)
+	binary.BigEndian.PutUint64(v[24:32], q.txErrors)
+	return dst, nil
+}
+
+func (q *queueStats) UnmarshalBinary(data []byte) error {
+	if len(data) != 32 {
+		return openflow.ErrInvalidDataLength
+	}
+	q.portNumber = binary.BigEndian.Uint16(data[0:2])
+	q.queueID = binary.BigEndian.Uint32(data[4:8])
+	q.txBytes = binary.BigEndian.Uint64(data[8:16])
+	q.txPackets = binary.BigEndian.Uint64(data[16:24])
+	q.txErrors = binary.BigEndian.Uint64(data[24:32])
+	return nil
+}
+
+func NewQueueStats() QueueStats {
+	return &queueStats{}
+}
+
+// StatsReplyQueue is a queue stats reply
+type StatsReplyQueue interface {
+	openflow.StatsReply
+	Queues() []QueueStats
+	AddQueue(QueueStats)
+}
+
+type statsReplyQueue struct {
+	*statsHeader
+	queues []QueueStats
+}
+
+func (s *statsReplyQueue) Queues() []QueueStats {
+	return s.queues
+}
+
+func (s *statsReplyQueue) AddQueue(q QueueStats) {
+	s.queues = append(s.queues, q)
+}
+
+func (s *statsReplyQueue) MarshalBinary() ([]byte, error) {
+	return s.AppendBinary(nil)
+}
+
+func (s *statsReplyQueue) AppendBinary(dst []byte) ([]byte, error) {
+	start := len(dst)
+	dst, _ = s.appendStats(dst, 0)
+	for _, q := range s.queues {
+		var err error
+		if dst, err = openflow.AppendBinary(dst, q); err != nil {
+			return nil, err
+		}
+	}
+	s.FixLength(dst[start:])
+	return dst, nil
+}
+
+func (s *statsReplyQueue) UnmarshalBinary(data []byte) error {
+	if err := s.statsHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	payload := s.statsPayload
+	if len(payload)%32 != 0 {
+		return openflow.ErrInvalidDataLength
+	}
+	s.queues = nil
+	for ; len(payload) > 0; payload = payload[32:] {
+		q := &queueStats{}
+		if err := q.UnmarshalBinary(payload[:32]); err != nil {
+			return err
+		}
+		s.queues = append(s.queues, q)
+	}
+	return nil
+}
+
+func NewStatsReplyQueue(xid uint32) StatsReplyQueue {
+	srq := &statsReplyQueue{
+		statsHeader: NewStatsReplyHeader(xid).(*statsHeader),
+	}
+	srq.statsHeader.SetType(openflow.STATS_Queue)
+	return srq
+}
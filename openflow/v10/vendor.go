@@ -32,13 +32,14 @@ func (v *vendor) SetData(data []byte) error {
 }
 
 func (v *vendor) MarshalBinary() ([]byte, error) {
-	tmp := make([]byte, 4+len(v.data))
+	return v.AppendBinary(nil)
+}
+
+func (v *vendor) AppendBinary(dst []byte) ([]byte, error) {
+	dst, tmp := appendMessage(&v.Message, dst, 4+len(v.data))
 	binary.BigEndian.PutUint32(tmp[0:4], v.vendorID)
-	if len(v.data) > 0 {
-		copy(tmp[4:], v.data)
-	}
-	v.SetPayload(tmp)
-	return v.Message.MarshalBinary()
+	copy(tmp[4:], v.data)
+	return dst, nil
 }
 
 func (v *vendor) UnmarshalBinary(data []byte) error {
@@ -51,6 +52,7 @@ func (v *vendor) UnmarshalBinary(data []byte) error {
 		return openflow.ErrInvalidPacketLength
 	}
 	v.vendorID = binary.BigEndian.Uint32(payload[0:4])
+	v.data = nil
 	if len(payload) > 4 {
 		v.data = payload[4:]
 	}
@@ -0,0 +1,56 @@
+package v10
+
+import (
+	"encoding/binary"
+	"github.com/ksang/goflow/openflow"
+)
+
+// PacketInView reads the fields of an encoded packet in straight from the
+// buffer, it aliases the buffer as openflow.View does
+type PacketInView struct {
+	openflow.View
+}
+
+// NewPacketInView checks that data holds an openflow 1.0 packet in
+func NewPacketInView(data []byte) (PacketInView, error) {
+	v, err := openflow.NewView(data)
+	if err != nil {
+		return PacketInView{}, err
+	}
+	if v.Version() != openflow.OF10_VERSION {
+		return PacketInView{}, openflow.ErrUnsupportedVersion
+	}
+	if v.MsgType() != OFPT_PACKET_IN {
+		return PacketInView{}, openflow.ErrUnsupportedMessage
+	}
+	if len(v) < openflow.OF_HEADER_SIZE+10 {
+		return PacketInView{}, openflow.ErrInvalidPacketLength
+	}
+	p := PacketInView{v}
+	// the data of buffered packets may be truncated
+	if int(p.TotalLength()) < len(p.Data()) {
+		return PacketInView{}, openflow.ErrInvalidDataLength
+	}
+	return p, nil
+}
+
+func (p PacketInView) BufferID() uint32 {
+	return binary.BigEndian.Uint32(p.View[8:12])
+}
+
+func (p PacketInView) TotalLength() uint16 {
+	return binary.BigEndian.Uint16(p.View[12:14])
+}
+
+func (p PacketInView) InPort() uint16 {
+	return binary.BigEndian.Uint16(p.View[14:16])
+}
+
+func (p PacketInView) Reason() uint8 {
+	return p.View[16]
+}
+
+// Data returns the packet, aliasing the buffer
+func (p PacketInView) Data() []byte {
+	return p.View[18:]
+}
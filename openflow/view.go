@@ -0,0 +1,44 @@
+package openflow
+
+import (
+	"encoding/binary"
+)
+
+// View reads the header of an encoded message, as returned by ReadMessage,
+// straight from the buffer without decoding it. A view aliases the buffer
+// and is valid as long as the buffer is not reused.
+type View []byte
+
+// NewView checks the header of data, the view ends at the length of the
+// message
+func NewView(data []byte) (View, error) {
+	if len(data) < OF_HEADER_SIZE {
+		return nil, ErrInvalidPacketLength
+	}
+	length := int(binary.BigEndian.Uint16(data[2:4]))
+	if length < OF_HEADER_SIZE || length > len(data) {
+		return nil, ErrInvalidPacketLength
+	}
+	return View(data[:length]), nil
+}
+
+func (v View) Version() uint8 {
+	return v[0]
+}
+
+func (v View) MsgType() uint8 {
+	return v[1]
+}
+
+func (v View) Length() uint16 {
+	return binary.BigEndian.Uint16(v[2:4])
+}
+
+func (v View) TransactionID() uint32 {
+	return binary.BigEndian.Uint32(v[4:8])
+}
+
+// Payload returns the body of the message, aliasing the buffer
+func (v View) Payload() []byte {
+	return v[OF_HEADER_SIZE:]
+}
@@ -0,0 +1,55 @@
+package packet
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+// ARP operations
+const (
+	ARPRequest uint16 = 1
+	ARPReply   uint16 = 2
+)
+
+// ARP is an ethernet/IPv4 ARP packet
+type ARP struct {
+	Operation uint16
+	SenderMAC net.HardwareAddr
+	SenderIP  net.IP
+	TargetMAC net.HardwareAddr
+	TargetIP  net.IP
+}
+
+func (a *ARP) MarshalBinary() ([]byte, error) {
+	if len(a.SenderMAC) != 6 || len(a.TargetMAC) != 6 ||
+		a.SenderIP.To4() == nil || a.TargetIP.To4() == nil {
+		return nil, ErrInvalidHeader
+	}
+	v := make([]byte, 28)
+	binary.BigEndian.PutUint16(v[0:2], 1)
+	binary.BigEndian.PutUint16(v[2:4], EtherTypeIPv4)
+	v[4] = 6
+	v[5] = 4
+	binary.BigEndian.PutUint16(v[6:8], a.Operation)
+	copy(v[8:14], a.SenderMAC)
+	copy(v[14:18], a.SenderIP.To4())
+	copy(v[18:24], a.TargetMAC)
+	copy(v[24:28], a.TargetIP.To4())
+	return v, nil
+}
+
+func (a *ARP) UnmarshalBinary(data []byte) error {
+	if len(data) < 28 {
+		return ErrTruncated
+	}
+	if binary.BigEndian.Uint16(data[0:2]) != 1 || binary.BigEndian.Uint16(data[2:4]) != EtherTypeIPv4 ||
+		data[4] != 6 || data[5] != 4 {
+		return ErrUnsupportedType
+	}
+	a.Operation = binary.BigEndian.Uint16(data[6:8])
+	a.SenderMAC = net.HardwareAddr(data[8:14])
+	a.SenderIP = net.IP(data[14:18])
+	a.TargetMAC = net.HardwareAddr(data[18:24])
+	a.TargetIP = net.IP(data[24:28])
+	return nil
+}
@@ -0,0 +1,146 @@
+package packet
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+// DHCP ports
+const (
+	DHCPServerPort uint16 = 67
+	DHCPClientPort uint16 = 68
+)
+
+// DHCP operations
+const (
+	DHCPBootRequest uint8 = 1
+	DHCPBootReply   uint8 = 2
+)
+
+// DHCP message types, carried by option 53
+const (
+	DHCPDiscover uint8 = 1 + iota
+	DHCPOffer
+	DHCPRequest
+	DHCPDecline
+	DHCPAck
+	DHCPNak
+	DHCPRelease
+	DHCPInform
+)
+
+// DHCP options
+const (
+	DHCPOptionPad         uint8 = 0
+	DHCPOptionRequestedIP uint8 = 50
+	DHCPOptionMessageType uint8 = 53
+	DHCPOptionEnd         uint8 = 255
+)
+
+var dhcpMagic = []byte{99, 130, 83, 99}
+
+type DHCPOption struct {
+	Code uint8
+	Data []byte
+}
+
+// DHCP is a BOOTP message with DHCP options, server name and boot file
+// are not kept
+type DHCP struct {
+	Op        uint8
+	XID       uint32
+	Secs      uint16
+	Flags     uint16
+	ClientIP  net.IP
+	YourIP    net.IP
+	ServerIP  net.IP
+	RelayIP   net.IP
+	ClientMAC net.HardwareAddr
+	Options   []DHCPOption
+}
+
+// MessageType returns the type of option 53, zero without it
+func (d *DHCP) MessageType() uint8 {
+	if o, ok := d.Option(DHCPOptionMessageType); ok && len(o) == 1 {
+		return o[0]
+	}
+	return 0
+}
+
+// Option returns the data of the first option with code
+func (d *DHCP) Option(code uint8) ([]byte, bool) {
+	for _, o := range d.Options {
+		if o.Code == code {
+			return o.Data, true
+		}
+	}
+	return nil, false
+}
+
+func putIPv4(v []byte, ip net.IP) {
+	if ip4 := ip.To4(); ip4 != nil {
+		copy(v, ip4)
+	}
+}
+
+func (d *DHCP) MarshalBinary() ([]byte, error) {
+	if len(d.ClientMAC) > 16 {
+		return nil, ErrInvalidHeader
+	}
+	v := make([]byte, 240)
+	v[0] = d.Op
+	v[1] = 1
+	v[2] = uint8(len(d.ClientMAC))
+	binary.BigEndian.PutUint32(v[4:8], d.XID)
+	binary.BigEndian.PutUint16(v[8:10], d.Secs)
+	binary.BigEndian.PutUint16(v[10:12], d.Flags)
+	putIPv4(v[12:16], d.ClientIP)
+	putIPv4(v[16:20], d.YourIP)
+	putIPv4(v[20:24], d.ServerIP)
+	putIPv4(v[24:28], d.RelayIP)
+	copy(v[28:44], d.ClientMAC)
+	copy(v[236:240], dhcpMagic)
+	for _, o := range d.Options {
+		if len(o.Data) > 255 {
+			return nil, ErrInvalidHeader
+		}
+		v = append(v, o.Code, uint8(len(o.Data)))
+		v = append(v, o.Data...)
+	}
+	return append(v, DHCPOptionEnd), nil
+}
+
+func (d *DHCP) UnmarshalBinary(data []byte) error {
+	if len(data) < 240 {
+		return ErrTruncated
+	}
+	if data[2] > 16 || string(data[236:240]) != string(dhcpMagic) {
+		return ErrInvalidHeader
+	}
+	d.Op = data[0]
+	d.XID = binary.BigEndian.Uint32(data[4:8])
+	d.Secs = binary.BigEndian.Uint16(data[8:10])
+	d.Flags = binary.BigEndian.Uint16(data[10:12])
+	d.ClientIP = net.IP(data[12:16])
+	d.YourIP = net.IP(data[16:20])
+	d.ServerIP = net.IP(data[20:24])
+	d.RelayIP = net.IP(data[24:28])
+	d.ClientMAC = net.HardwareAddr(data[28 : 28+int(data[2])])
+	d.Options = nil
+	for opts := data[240:]; len(opts) > 0; {
+		code := opts[0]
+		if code == DHCPOptionEnd {
+			break
+		}
+		if code == DHCPOptionPad {
+			opts = opts[1:]
+			continue
+		}
+		if len(opts) < 2 || len(opts) < 2+int(opts[1]) {
+			return ErrTruncated
+		}
+		d.Options = append(d.Options, DHCPOption{Code: code, Data: opts[2 : 2+int(opts[1])]})
+		opts = opts[2+int(opts[1]):]
+	}
+	return nil
+}
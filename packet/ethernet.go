@@ -0,0 +1,102 @@
+/*
+Package packet decodes and encodes the network packets carried by openflow
+messages, such as the data of PacketIn and PacketOut.
+*/
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+)
+
+var (
+	ErrTruncated       = errors.New("truncated packet")
+	ErrInvalidHeader   = errors.New("invalid header")
+	ErrUnsupportedType = errors.New("unsupported packet type")
+)
+
+// Ethernet types
+const (
+	EtherTypeIPv4 uint16 = 0x0800
+	EtherTypeARP  uint16 = 0x0806
+	EtherTypeVLAN uint16 = 0x8100
+	EtherTypeIPv6 uint16 = 0x86dd
+	EtherTypeLLDP uint16 = 0x88cc
+)
+
+// NoVLAN is the VLAN id of untagged frames, as in openflow 1.0 matches
+const NoVLAN uint16 = 0xffff
+
+var Broadcast = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// IsMulticast tells whether mac is a group address, broadcast included
+func IsMulticast(mac net.HardwareAddr) bool {
+	return len(mac) > 0 && mac[0]&0x01 != 0
+}
+
+// Ethernet is an ethernet II frame with an optional 802.1q tag
+type Ethernet struct {
+	Dst net.HardwareAddr
+	Src net.HardwareAddr
+	// VLANID is NoVLAN for untagged frames
+	VLANID       uint16
+	VLANPriority uint8
+	EtherType    uint16
+	Payload      []byte
+}
+
+// NewEthernet returns an untagged frame
+func NewEthernet(src, dst net.HardwareAddr, etherType uint16, payload []byte) *Ethernet {
+	return &Ethernet{
+		Dst:       dst,
+		Src:       src,
+		VLANID:    NoVLAN,
+		EtherType: etherType,
+		Payload:   payload,
+	}
+}
+
+func (e *Ethernet) MarshalBinary() ([]byte, error) {
+	if len(e.Dst) != 6 || len(e.Src) != 6 {
+		return nil, ErrInvalidHeader
+	}
+	header := 14
+	if e.VLANID != NoVLAN {
+		header += 4
+	}
+	v := make([]byte, header+len(e.Payload))
+	copy(v[0:6], e.Dst)
+	copy(v[6:12], e.Src)
+	if e.VLANID != NoVLAN {
+		binary.BigEndian.PutUint16(v[12:14], EtherTypeVLAN)
+		binary.BigEndian.PutUint16(v[14:16], uint16(e.VLANPriority)<<13|e.VLANID&0x0fff)
+	}
+	binary.BigEndian.PutUint16(v[header-2:header], e.EtherType)
+	copy(v[header:], e.Payload)
+	return v, nil
+}
+
+func (e *Ethernet) UnmarshalBinary(data []byte) error {
+	if len(data) < 14 {
+		return ErrTruncated
+	}
+	e.Dst = net.HardwareAddr(data[0:6])
+	e.Src = net.HardwareAddr(data[6:12])
+	e.VLANID = NoVLAN
+	e.VLANPriority = 0
+	e.EtherType = binary.BigEndian.Uint16(data[12:14])
+	data = data[14:]
+	if e.EtherType == EtherTypeVLAN {
+		if len(data) < 4 {
+			return ErrTruncated
+		}
+		tci := binary.BigEndian.Uint16(data[0:2])
+		e.VLANID = tci & 0x0fff
+		e.VLANPriority = uint8(tci >> 13)
+		e.EtherType = binary.BigEndian.Uint16(data[2:4])
+		data = data[4:]
+	}
+	e.Payload = data
+	return nil
+}
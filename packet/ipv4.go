@@ -0,0 +1,98 @@
+package packet
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+// IP protocols
+const (
+	IPProtocolICMP uint8 = 1
+	IPProtocolTCP  uint8 = 6
+	IPProtocolUDP  uint8 = 17
+)
+
+// IPv4 is an IPv4 packet, options are kept undecoded
+type IPv4 struct {
+	TOS      uint8
+	ID       uint16
+	Flags    uint8
+	FragOff  uint16
+	TTL      uint8
+	Protocol uint8
+	Src      net.IP
+	Dst      net.IP
+	Options  []byte
+	Payload  []byte
+}
+
+// IsFragment tells whether the packet is a fragment other than the first,
+// which carries no transport header
+func (ip *IPv4) IsFragment() bool {
+	return ip.FragOff != 0
+}
+
+// MarshalBinary encodes the packet and computes its length and checksum
+func (ip *IPv4) MarshalBinary() ([]byte, error) {
+	if ip.Src.To4() == nil || ip.Dst.To4() == nil || len(ip.Options)%4 != 0 {
+		return nil, ErrInvalidHeader
+	}
+	header := 20 + len(ip.Options)
+	v := make([]byte, header+len(ip.Payload))
+	v[0] = 4<<4 | uint8(header/4)
+	v[1] = ip.TOS
+	binary.BigEndian.PutUint16(v[2:4], uint16(len(v)))
+	binary.BigEndian.PutUint16(v[4:6], ip.ID)
+	binary.BigEndian.PutUint16(v[6:8], uint16(ip.Flags)<<13|ip.FragOff&0x1fff)
+	v[8] = ip.TTL
+	v[9] = ip.Protocol
+	copy(v[12:16], ip.Src.To4())
+	copy(v[16:20], ip.Dst.To4())
+	copy(v[20:header], ip.Options)
+	binary.BigEndian.PutUint16(v[10:12], Checksum(v[:header], 0))
+	copy(v[header:], ip.Payload)
+	return v, nil
+}
+
+func (ip *IPv4) UnmarshalBinary(data []byte) error {
+	if len(data) < 20 {
+		return ErrTruncated
+	}
+	if data[0]>>4 != 4 {
+		return ErrInvalidHeader
+	}
+	header := int(data[0]&0x0f) * 4
+	length := int(binary.BigEndian.Uint16(data[2:4]))
+	if header < 20 || length < header || len(data) < header {
+		return ErrInvalidHeader
+	}
+	// frames may be padded, captures may be truncated
+	if length < len(data) {
+		data = data[:length]
+	}
+	ip.TOS = data[1]
+	ip.ID = binary.BigEndian.Uint16(data[4:6])
+	ip.Flags = data[6] >> 5
+	ip.FragOff = binary.BigEndian.Uint16(data[6:8]) & 0x1fff
+	ip.TTL = data[8]
+	ip.Protocol = data[9]
+	ip.Src = net.IP(data[12:16])
+	ip.Dst = net.IP(data[16:20])
+	ip.Options = data[20:header]
+	ip.Payload = data[header:]
+	return nil
+}
+
+// Checksum computes the internet checksum of data added to an initial sum
+func Checksum(data []byte, sum uint32) uint16 {
+	for ; len(data) >= 2; data = data[2:] {
+		sum += uint32(data[0])<<8 | uint32(data[1])
+	}
+	if len(data) == 1 {
+		sum += uint32(data[0]) << 8
+	}
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,104 @@
+package packet
+
+import (
+	"encoding/binary"
+	"net"
+)
+
+// LLDPMulticast is the nearest bridge address, frames sent to it are not
+// forwarded by 802.1d bridges
+var LLDPMulticast = net.HardwareAddr{0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e}
+
+// LLDP TLV types
+const (
+	LLDPTLVEnd       uint8 = 0
+	LLDPTLVChassisID uint8 = 1
+	LLDPTLVPortID    uint8 = 2
+	LLDPTLVTTL       uint8 = 3
+)
+
+// LLDP chassis and port ID subtypes
+const (
+	LLDPChassisIDMAC   uint8 = 4
+	LLDPChassisIDLocal uint8 = 7
+	LLDPPortIDName     uint8 = 5
+	LLDPPortIDLocal    uint8 = 7
+)
+
+// LLDPTLV is an optional TLV, type and value are kept raw
+type LLDPTLV struct {
+	Type  uint8
+	Value []byte
+}
+
+// LLDP is a link layer discovery protocol data unit
+type LLDP struct {
+	ChassisIDSubtype uint8
+	ChassisID        []byte
+	PortIDSubtype    uint8
+	PortID           []byte
+	TTL              uint16
+	Optional         []LLDPTLV
+}
+
+func appendTLV(v []byte, typ uint8, value []byte) []byte {
+	header := uint16(typ)<<9 | uint16(len(value))
+	v = append(v, byte(header>>8), byte(header))
+	return append(v, value...)
+}
+
+func (l *LLDP) MarshalBinary() ([]byte, error) {
+	if len(l.ChassisID) == 0 || len(l.ChassisID) > 255 || len(l.PortID) == 0 || len(l.PortID) > 255 {
+		return nil, ErrInvalidHeader
+	}
+	var v []byte
+	v = appendTLV(v, LLDPTLVChassisID, append([]byte{l.ChassisIDSubtype}, l.ChassisID...))
+	v = appendTLV(v, LLDPTLVPortID, append([]byte{l.PortIDSubtype}, l.PortID...))
+	v = appendTLV(v, LLDPTLVTTL, []byte{byte(l.TTL >> 8), byte(l.TTL)})
+	for _, tlv := range l.Optional {
+		if tlv.Type <= LLDPTLVTTL || tlv.Type > 127 || len(tlv.Value) > 511 {
+			return nil, ErrInvalidHeader
+		}
+		v = appendTLV(v, tlv.Type, tlv.Value)
+	}
+	return appendTLV(v, LLDPTLVEnd, nil), nil
+}
+
+// UnmarshalBinary decodes the mandatory TLVs, which must come first and in order
+func (l *LLDP) UnmarshalBinary(data []byte) error {
+	l.Optional = nil
+	for i := 0; ; i++ {
+		if len(data) < 2 {
+			return ErrTruncated
+		}
+		header := binary.BigEndian.Uint16(data[0:2])
+		typ, length := uint8(header>>9), int(header&0x1ff)
+		if len(data) < 2+length {
+			return ErrTruncated
+		}
+		value := data[2 : 2+length]
+		data = data[2+length:]
+		switch {
+		case i < 3 && typ != uint8(i+1):
+			return ErrInvalidHeader
+		case typ == LLDPTLVEnd:
+			return nil
+		case typ == LLDPTLVChassisID || typ == LLDPTLVPortID:
+			if length < 2 {
+				return ErrInvalidHeader
+			}
+			if typ == LLDPTLVChassisID {
+				l.ChassisIDSubtype, l.ChassisID = value[0], value[1:]
+			} else {
+				l.PortIDSubtype, l.PortID = value[0], value[1:]
+			}
+		case typ == LLDPTLVTTL:
+			if length < 2 {
+				return ErrInvalidHeader
+			}
+			l.TTL = binary.BigEndian.Uint16(value)
+		default:
+			l.Optional = append(l.Optional, LLDPTLV{Type: typ, Value: value})
+		}
+	}
+}
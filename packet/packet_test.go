@@ -0,0 +1,85 @@
+package packet
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestEthernetVLAN(t *testing.T) {
+	src := net.HardwareAddr{0x0a, 0, 0, 0, 0, 1}
+	e := NewEthernet(src, Broadcast, EtherTypeARP, []byte{1, 2, 3})
+	e.VLANID = 100
+	e.VLANPriority = 5
+	data, err := e.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 21 {
+		t.Fatalf("frame length %d", len(data))
+	}
+	var d Ethernet
+	if err := d.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if d.VLANID != 100 || d.VLANPriority != 5 || d.EtherType != EtherTypeARP ||
+		!bytes.Equal(d.Src, src) || !IsMulticast(d.Dst) || !bytes.Equal(d.Payload, e.Payload) {
+		t.Errorf("unexpected frame %+v", d)
+	}
+	if err := d.UnmarshalBinary(data[:16]); err != ErrTruncated {
+		t.Errorf("expected truncated error, got %v", err)
+	}
+}
+
+func TestIPv4UDP(t *testing.T) {
+	u := &UDP{SrcPort: 68, DstPort: 67, Payload: []byte("dhcp")}
+	payload, _ := u.MarshalBinary()
+	ip := &IPv4{TTL: 64, Protocol: IPProtocolUDP, Src: net.IPv4zero, Dst: net.IPv4bcast, Payload: payload}
+	data, err := ip.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Checksum(data[:20], 0) != 0 {
+		t.Error("invalid header checksum")
+	}
+	// trailing ethernet padding is ignored
+	data = append(data, 0, 0, 0, 0)
+	var dip IPv4
+	if err := dip.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	var du UDP
+	if err := du.UnmarshalBinary(dip.Payload); err != nil {
+		t.Fatal(err)
+	}
+	if !dip.Dst.Equal(net.IPv4bcast) || du.DstPort != 67 || string(du.Payload) != "dhcp" {
+		t.Errorf("unexpected packet %+v %+v", dip, du)
+	}
+}
+
+func TestLLDP(t *testing.T) {
+	l := &LLDP{
+		ChassisIDSubtype: LLDPChassisIDLocal,
+		ChassisID:        []byte("0000000000000001"),
+		PortIDSubtype:    LLDPPortIDLocal,
+		PortID:           []byte{0, 3},
+		TTL:              120,
+		Optional:         []LLDPTLV{{Type: 5, Value: []byte("sw1")}},
+	}
+	data, err := l.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d LLDP
+	if err := d.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if string(d.ChassisID) != "0000000000000001" || !bytes.Equal(d.PortID, []byte{0, 3}) || d.TTL != 120 ||
+		len(d.Optional) != 1 || string(d.Optional[0].Value) != "sw1" {
+		t.Errorf("unexpected lldp %+v", d)
+	}
+	// the port id must follow the chassis id
+	if err := d.UnmarshalBinary(data[19:]); err != ErrInvalidHeader {
+		t.Errorf("expected invalid header, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package packet
+
+import (
+	"encoding/binary"
+)
+
+// TCP flags
+const (
+	TCPFin uint8 = 1 << iota
+	TCPSyn
+	TCPRst
+	TCPPsh
+	TCPAck
+	TCPUrg
+)
+
+// TCP is a TCP segment, options are kept undecoded
+type TCP struct {
+	SrcPort  uint16
+	DstPort  uint16
+	Seq      uint32
+	Ack      uint32
+	Flags    uint8
+	Window   uint16
+	Checksum uint16
+	Urgent   uint16
+	Options  []byte
+	Payload  []byte
+}
+
+// MarshalBinary encodes the segment, the checksum is written as is
+func (t *TCP) MarshalBinary() ([]byte, error) {
+	if len(t.Options)%4 != 0 {
+		return nil, ErrInvalidHeader
+	}
+	header := 20 + len(t.Options)
+	v := make([]byte, header+len(t.Payload))
+	binary.BigEndian.PutUint16(v[0:2], t.SrcPort)
+	binary.BigEndian.PutUint16(v[2:4], t.DstPort)
+	binary.BigEndian.PutUint32(v[4:8], t.Seq)
+	binary.BigEndian.PutUint32(v[8:12], t.Ack)
+	v[12] = uint8(header/4) << 4
+	v[13] = t.Flags
+	binary.BigEndian.PutUint16(v[14:16], t.Window)
+	binary.BigEndian.PutUint16(v[16:18], t.Checksum)
+	binary.BigEndian.PutUint16(v[18:20], t.Urgent)
+	copy(v[20:header], t.Options)
+	copy(v[header:], t.Payload)
+	return v, nil
+}
+
+func (t *TCP) UnmarshalBinary(data []byte) error {
+	if len(data) < 20 {
+		return ErrTruncated
+	}
+	header := int(data[12]>>4) * 4
+	if header < 20 || len(data) < header {
+		return ErrInvalidHeader
+	}
+	t.SrcPort = binary.BigEndian.Uint16(data[0:2])
+	t.DstPort = binary.BigEndian.Uint16(data[2:4])
+	t.Seq = binary.BigEndian.Uint32(data[4:8])
+	t.Ack = binary.BigEndian.Uint32(data[8:12])
+	t.Flags = data[13]
+	t.Window = binary.BigEndian.Uint16(data[14:16])
+	t.Checksum = binary.BigEndian.Uint16(data[16:18])
+	t.Urgent = binary.BigEndian.Uint16(data[18:20])
+	t.Options = data[20:header]
+	t.Payload = data[header:]
+	return nil
+}
+
+// UDP is a UDP datagram
+type UDP struct {
+	SrcPort  uint16
+	DstPort  uint16
+	Checksum uint16
+	Payload  []byte
+}
+
+// MarshalBinary encodes the datagram, the checksum is written as is,
+// zero meaning none for IPv4
+func (u *UDP) MarshalBinary() ([]byte, error) {
+	v := make([]byte, 8+len(u.Payload))
+	binary.BigEndian.PutUint16(v[0:2], u.SrcPort)
+	binary.BigEndian.PutUint16(v[2:4], u.DstPort)
+	binary.BigEndian.PutUint16(v[4:6], uint16(len(v)))
+	binary.BigEndian.PutUint16(v[6:8], u.Checksum)
+	copy(v[8:], u.Payload)
+	return v, nil
+}
+
+func (u *UDP) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return ErrTruncated
+	}
+	length := int(binary.BigEndian.Uint16(data[4:6]))
+	if length < 8 {
+		return ErrInvalidHeader
+	}
+	if length < len(data) {
+		data = data[:length]
+	}
+	u.SrcPort = binary.BigEndian.Uint16(data[0:2])
+	u.DstPort = binary.BigEndian.Uint16(data[2:4])
+	u.Checksum = binary.BigEndian.Uint16(data[6:8])
+	u.Payload = data[8:]
+	return nil
+}
+
+// ICMP is an ICMP message, the rest of the header is part of the payload
+type ICMP struct {
+	Type    uint8
+	Code    uint8
+	Payload []byte
+}
+
+// MarshalBinary encodes the message and computes its checksum
+func (i *ICMP) MarshalBinary() ([]byte, error) {
+	v := make([]byte, 4+len(i.Payload))
+	v[0] = i.Type
+	v[1] = i.Code
+	copy(v[4:], i.Payload)
+	binary.BigEndian.PutUint16(v[2:4], Checksum(v, 0))
+	return v, nil
+}
+
+func (i *ICMP) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return ErrTruncated
+	}
+	i.Type = data[0]
+	i.Code = data[1]
+	i.Payload = data[4:]
+	return nil
+}
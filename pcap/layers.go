@@ -0,0 +1,231 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"net"
+	"strconv"
+)
+
+// TCP flags
+const (
+	tcpFIN = 0x01
+	tcpSYN = 0x02
+	tcpRST = 0x04
+	tcpPSH = 0x08
+	tcpACK = 0x10
+)
+
+const (
+	etherTypeIPv4  = 0x0800
+	etherTypeIPv6  = 0x86dd
+	etherTypeVLAN  = 0x8100
+	etherTypeQinQ  = 0x88a8
+	ipProtocolTCP  = 6
+	ethernetLength = 14
+	ipv4Length     = 20
+	tcpLength      = 20
+)
+
+// segment is a decoded TCP segment
+type segment struct {
+	srcIP   net.IP
+	dstIP   net.IP
+	srcPort uint16
+	dstPort uint16
+	seq     uint32
+	ack     uint32
+	flags   uint8
+	payload []byte
+}
+
+func (s *segment) src() string {
+	return net.JoinHostPort(s.srcIP.String(), strconv.Itoa(int(s.srcPort)))
+}
+
+func (s *segment) dst() string {
+	return net.JoinHostPort(s.dstIP.String(), strconv.Itoa(int(s.dstPort)))
+}
+
+// decodeSegment strips the link and network layers of a frame
+func decodeSegment(linkType uint32, data []byte) (*segment, error) {
+	var etherType uint16
+	switch linkType {
+	case LinkTypeEthernet:
+		if len(data) < ethernetLength {
+			return nil, ErrTruncatedPacket
+		}
+		etherType = binary.BigEndian.Uint16(data[12:14])
+		data = data[ethernetLength:]
+		for etherType == etherTypeVLAN || etherType == etherTypeQinQ {
+			if len(data) < 4 {
+				return nil, ErrTruncatedPacket
+			}
+			etherType = binary.BigEndian.Uint16(data[2:4])
+			data = data[4:]
+		}
+	case LinkTypeNull:
+		if len(data) < 4 {
+			return nil, ErrTruncatedPacket
+		}
+		// address family in host byte order of the capturing machine
+		family := binary.LittleEndian.Uint32(data[0:4])
+		if family > 0xffff {
+			family = binary.BigEndian.Uint32(data[0:4])
+		}
+		switch family {
+		case 2:
+			etherType = etherTypeIPv4
+		case 24, 28, 30:
+			etherType = etherTypeIPv6
+		}
+		data = data[4:]
+	case LinkTypeRaw:
+		if len(data) < 1 {
+			return nil, ErrTruncatedPacket
+		}
+		switch data[0] >> 4 {
+		case 4:
+			etherType = etherTypeIPv4
+		case 6:
+			etherType = etherTypeIPv6
+		}
+	case LinkTypeLinuxSLL:
+		if len(data) < 16 {
+			return nil, ErrTruncatedPacket
+		}
+		etherType = binary.BigEndian.Uint16(data[14:16])
+		data = data[16:]
+	case LinkTypeLinuxSLL2:
+		if len(data) < 20 {
+			return nil, ErrTruncatedPacket
+		}
+		etherType = binary.BigEndian.Uint16(data[0:2])
+		data = data[20:]
+	default:
+		return nil, ErrUnsupportedLink
+	}
+
+	s := &segment{}
+	switch etherType {
+	case etherTypeIPv4:
+		if len(data) < ipv4Length {
+			return nil, ErrTruncatedPacket
+		}
+		ihl := int(data[0]&0x0f) * 4
+		total := int(binary.BigEndian.Uint16(data[2:4]))
+		// segmentation offload leaves the length unset
+		if total == 0 {
+			total = len(data)
+		}
+		if ihl < ipv4Length || total < ihl || len(data) < ihl {
+			return nil, ErrTruncatedPacket
+		}
+		// fragments are not reassembled
+		if data[9] != ipProtocolTCP || binary.BigEndian.Uint16(data[6:8])&0x3fff != 0 {
+			return nil, ErrNotTCP
+		}
+		s.srcIP = net.IP(data[12:16])
+		s.dstIP = net.IP(data[16:20])
+		// drop ethernet padding
+		if total < len(data) {
+			data = data[:total]
+		}
+		data = data[ihl:]
+	case etherTypeIPv6:
+		if len(data) < 40 {
+			return nil, ErrTruncatedPacket
+		}
+		next := data[6]
+		payloadLen := int(binary.BigEndian.Uint16(data[4:6]))
+		s.srcIP = net.IP(data[8:24])
+		s.dstIP = net.IP(data[24:40])
+		data = data[40:]
+		if payloadLen < len(data) {
+			data = data[:payloadLen]
+		}
+		// skip hop-by-hop, routing and destination options headers
+		for next == 0 || next == 43 || next == 60 {
+			if len(data) < 8 {
+				return nil, ErrTruncatedPacket
+			}
+			extLen := (int(data[1]) + 1) * 8
+			if len(data) < extLen {
+				return nil, ErrTruncatedPacket
+			}
+			next = data[0]
+			data = data[extLen:]
+		}
+		if next != ipProtocolTCP {
+			return nil, ErrNotTCP
+		}
+	default:
+		return nil, ErrNotTCP
+	}
+
+	if len(data) < tcpLength {
+		return nil, ErrTruncatedPacket
+	}
+	offset := int(data[12]>>4) * 4
+	if offset < tcpLength || len(data) < offset {
+		return nil, ErrTruncatedPacket
+	}
+	s.srcPort = binary.BigEndian.Uint16(data[0:2])
+	s.dstPort = binary.BigEndian.Uint16(data[2:4])
+	s.seq = binary.BigEndian.Uint32(data[4:8])
+	s.ack = binary.BigEndian.Uint32(data[8:12])
+	s.flags = data[13]
+	s.payload = data[offset:]
+	return s, nil
+}
+
+// encodeSegment builds an ethernet frame carrying an IPv4 TCP segment
+func encodeSegment(srcMAC, dstMAC net.HardwareAddr, s *segment, ipID uint16) []byte {
+	frame := make([]byte, ethernetLength+ipv4Length+tcpLength+len(s.payload))
+	copy(frame[0:6], dstMAC)
+	copy(frame[6:12], srcMAC)
+	binary.BigEndian.PutUint16(frame[12:14], etherTypeIPv4)
+
+	ip := frame[ethernetLength:]
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:4], uint16(len(ip)))
+	binary.BigEndian.PutUint16(ip[4:6], ipID)
+	// don't fragment
+	ip[6] = 0x40
+	ip[8] = 64
+	ip[9] = ipProtocolTCP
+	copy(ip[12:16], s.srcIP.To4())
+	copy(ip[16:20], s.dstIP.To4())
+	binary.BigEndian.PutUint16(ip[10:12], checksum(ip[:ipv4Length], 0))
+
+	tcp := ip[ipv4Length:]
+	binary.BigEndian.PutUint16(tcp[0:2], s.srcPort)
+	binary.BigEndian.PutUint16(tcp[2:4], s.dstPort)
+	binary.BigEndian.PutUint32(tcp[4:8], s.seq)
+	binary.BigEndian.PutUint32(tcp[8:12], s.ack)
+	tcp[12] = (tcpLength / 4) << 4
+	tcp[13] = s.flags
+	binary.BigEndian.PutUint16(tcp[14:16], 65535)
+	copy(tcp[tcpLength:], s.payload)
+
+	// pseudo header
+	var sum uint32
+	sum += uint32(binary.BigEndian.Uint16(ip[12:14])) + uint32(binary.BigEndian.Uint16(ip[14:16]))
+	sum += uint32(binary.BigEndian.Uint16(ip[16:18])) + uint32(binary.BigEndian.Uint16(ip[18:20]))
+	sum += ipProtocolTCP + uint32(len(tcp))
+	binary.BigEndian.PutUint16(tcp[16:18], checksum(tcp, sum))
+	return frame
+}
+
+// checksum computes the internet checksum of data added to an initial sum
+func checksum(data []byte, sum uint32) uint16 {
+	for ; len(data) >= 2; data = data[2:] {
+		sum += uint32(data[0])<<8 | uint32(data[1])
+	}
+	if len(data) == 1 {
+		sum += uint32(data[0]) << 8
+	}
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+	return ^uint16(sum)
+}
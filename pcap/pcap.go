@@ -0,0 +1,315 @@
+/*
+Package pcap reads and writes packet captures of openflow sessions.
+
+Reader reads both pcap and pcapng files, MessageReader reassembles the TCP
+streams found in a capture and returns the openflow messages they carry,
+with timestamps and direction. SessionWriter synthesizes a capture from
+a sequence of openflow messages.
+*/
+package pcap
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"time"
+)
+
+var (
+	ErrInvalidFormat      = errors.New("invalid capture file format")
+	ErrUnsupportedLink    = errors.New("unsupported link type")
+	ErrNotTCP             = errors.New("not a TCP segment")
+	ErrTruncatedPacket    = errors.New("truncated packet")
+	ErrUnsupportedAddress = errors.New("unsupported address")
+)
+
+// Link types of captured packets
+const (
+	LinkTypeNull      uint32 = 0
+	LinkTypeEthernet  uint32 = 1
+	LinkTypeRaw       uint32 = 101
+	LinkTypeLinuxSLL  uint32 = 113
+	LinkTypeLinuxSLL2 uint32 = 276
+)
+
+const (
+	magicMicroseconds = 0xa1b2c3d4
+	magicNanoseconds  = 0xa1b23c4d
+	pcapngSection     = 0x0a0d0d0a
+	pcapngByteOrder   = 0x1a2b3c4d
+
+	pcapngInterface      = 0x00000001
+	pcapngPacketObsolete = 0x00000002
+	pcapngSimplePacket   = 0x00000003
+	pcapngEnhancedPacket = 0x00000006
+
+	// blocks larger than this are treated as corruption
+	maxBlockLength = 16 << 20
+)
+
+// Packet is a single captured frame
+type Packet struct {
+	Timestamp time.Time
+	LinkType  uint32
+	// Length is the length of the frame on the wire,
+	// Data may be shorter if the capture was truncated
+	Length int
+	Data   []byte
+}
+
+type pcapngInterfaceInfo struct {
+	linkType uint32
+	snapLen  uint32
+	// units per second of timestamps
+	resolution uint64
+}
+
+// Reader reads packets from a pcap or pcapng file
+type Reader struct {
+	r     io.Reader
+	order binary.ByteOrder
+	ng    bool
+
+	// pcap only
+	linkType uint32
+	nano     bool
+
+	// pcapng only
+	interfaces []pcapngInterfaceInfo
+}
+
+// NewReader detects the file format and reads the file header
+func NewReader(r io.Reader) (*Reader, error) {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, err
+	}
+	reader := &Reader{r: r}
+	if binary.BigEndian.Uint32(magic) == pcapngSection {
+		reader.ng = true
+		length := make([]byte, 4)
+		if _, err := io.ReadFull(r, length); err != nil {
+			return nil, unexpected(err)
+		}
+		if err := reader.readSection(length); err != nil {
+			return nil, err
+		}
+		return reader, nil
+	}
+	switch {
+	case binary.LittleEndian.Uint32(magic) == magicMicroseconds:
+		reader.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(magic) == magicMicroseconds:
+		reader.order = binary.BigEndian
+	case binary.LittleEndian.Uint32(magic) == magicNanoseconds:
+		reader.order = binary.LittleEndian
+		reader.nano = true
+	case binary.BigEndian.Uint32(magic) == magicNanoseconds:
+		reader.order = binary.BigEndian
+		reader.nano = true
+	default:
+		return nil, ErrInvalidFormat
+	}
+	header := make([]byte, 20)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, unexpected(err)
+	}
+	reader.linkType = reader.order.Uint32(header[16:20]) & 0xffff
+	return reader, nil
+}
+
+// LinkType returns the link type of the capture, for pcapng it is the link
+// type of the first interface
+func (r *Reader) LinkType() uint32 {
+	if r.ng {
+		if len(r.interfaces) == 0 {
+			return LinkTypeEthernet
+		}
+		return r.interfaces[0].linkType
+	}
+	return r.linkType
+}
+
+// ReadPacket returns the next packet, io.EOF at the end of the file
+func (r *Reader) ReadPacket() (*Packet, error) {
+	if r.ng {
+		return r.readBlocks()
+	}
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r.r, header); err != nil {
+		return nil, err
+	}
+	sec := int64(r.order.Uint32(header[0:4]))
+	frac := int64(r.order.Uint32(header[4:8]))
+	capLen := r.order.Uint32(header[8:12])
+	if capLen > maxBlockLength {
+		return nil, ErrInvalidFormat
+	}
+	if !r.nano {
+		frac *= 1000
+	}
+	p := &Packet{
+		Timestamp: time.Unix(sec, frac),
+		LinkType:  r.linkType,
+		Length:    int(r.order.Uint32(header[12:16])),
+		Data:      make([]byte, capLen),
+	}
+	if _, err := io.ReadFull(r.r, p.Data); err != nil {
+		return nil, unexpected(err)
+	}
+	return p, nil
+}
+
+// readSection reads a section header block whose type and length were
+// already consumed, the length is decoded once the byte order is known
+func (r *Reader) readSection(rawLength []byte) error {
+	bom := make([]byte, 4)
+	if _, err := io.ReadFull(r.r, bom); err != nil {
+		return unexpected(err)
+	}
+	switch {
+	case binary.BigEndian.Uint32(bom) == pcapngByteOrder:
+		r.order = binary.BigEndian
+	case binary.LittleEndian.Uint32(bom) == pcapngByteOrder:
+		r.order = binary.LittleEndian
+	default:
+		return ErrInvalidFormat
+	}
+	length := r.order.Uint32(rawLength)
+	if length < 28 || length > maxBlockLength || length%4 != 0 {
+		return ErrInvalidFormat
+	}
+	// interface ids are scoped to the section
+	r.interfaces = nil
+	_, err := io.CopyN(ioutil.Discard, r.r, int64(length-12))
+	return unexpected(err)
+}
+
+func (r *Reader) readBlocks() (*Packet, error) {
+	for {
+		head := make([]byte, 8)
+		if _, err := io.ReadFull(r.r, head); err != nil {
+			return nil, err
+		}
+		blockType := r.order.Uint32(head[0:4])
+		if blockType == pcapngSection {
+			// the section may use another byte order
+			if err := r.readSection(head[4:8]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		length := r.order.Uint32(head[4:8])
+		if length < 12 || length > maxBlockLength || length%4 != 0 {
+			return nil, ErrInvalidFormat
+		}
+		body := make([]byte, length-8)
+		if _, err := io.ReadFull(r.r, body); err != nil {
+			return nil, unexpected(err)
+		}
+		// strip the trailing length
+		body = body[:len(body)-4]
+		switch blockType {
+		case pcapngInterface:
+			if err := r.readInterface(body); err != nil {
+				return nil, err
+			}
+		case pcapngEnhancedPacket:
+			if len(body) < 20 {
+				return nil, ErrInvalidFormat
+			}
+			return r.packet(r.order.Uint32(body[0:4]), body[4:12], body[12:16], body[16:20], body[20:])
+		case pcapngPacketObsolete:
+			if len(body) < 20 {
+				return nil, ErrInvalidFormat
+			}
+			return r.packet(uint32(r.order.Uint16(body[0:2])), body[4:12], body[12:16], body[16:20], body[20:])
+		case pcapngSimplePacket:
+			if len(body) < 4 || len(r.interfaces) == 0 {
+				return nil, ErrInvalidFormat
+			}
+			origLen := r.order.Uint32(body[0:4])
+			capLen := origLen
+			if snap := r.interfaces[0].snapLen; snap != 0 && capLen > snap {
+				capLen = snap
+			}
+			if int(capLen) > len(body)-4 {
+				capLen = uint32(len(body) - 4)
+			}
+			return &Packet{
+				LinkType: r.interfaces[0].linkType,
+				Length:   int(origLen),
+				Data:     body[4 : 4+capLen],
+			}, nil
+		}
+	}
+}
+
+func (r *Reader) readInterface(body []byte) error {
+	if len(body) < 8 {
+		return ErrInvalidFormat
+	}
+	info := pcapngInterfaceInfo{
+		linkType:   uint32(r.order.Uint16(body[0:2])),
+		snapLen:    r.order.Uint32(body[4:8]),
+		resolution: 1000000,
+	}
+	for opts := body[8:]; len(opts) >= 4; {
+		code := r.order.Uint16(opts[0:2])
+		length := int(r.order.Uint16(opts[2:4]))
+		if 4+length > len(opts) {
+			break
+		}
+		// if_tsresol
+		if code == 9 && length >= 1 {
+			v := opts[4]
+			switch {
+			case v&0x80 != 0 && v&0x7f < 64:
+				info.resolution = uint64(1) << (v & 0x7f)
+			case v&0x80 == 0 && v <= 19:
+				info.resolution = uint64(math.Pow10(int(v)))
+			default:
+				return ErrInvalidFormat
+			}
+		}
+		if code == 0 {
+			break
+		}
+		next := 4 + (length+3)&^3
+		if next > len(opts) {
+			break
+		}
+		opts = opts[next:]
+	}
+	r.interfaces = append(r.interfaces, info)
+	return nil
+}
+
+func (r *Reader) packet(ifID uint32, ts, capLen, origLen, data []byte) (*Packet, error) {
+	if int(ifID) >= len(r.interfaces) {
+		return nil, ErrInvalidFormat
+	}
+	info := r.interfaces[ifID]
+	n := r.order.Uint32(capLen)
+	if int(n) > len(data) {
+		return nil, ErrInvalidFormat
+	}
+	units := uint64(r.order.Uint32(ts[0:4]))<<32 | uint64(r.order.Uint32(ts[4:8]))
+	sec := units / info.resolution
+	nsec := (units % info.resolution) * 1000000000 / info.resolution
+	return &Packet{
+		Timestamp: time.Unix(int64(sec), int64(nsec)),
+		LinkType:  info.linkType,
+		Length:    int(r.order.Uint32(origLen)),
+		Data:      data[:n],
+	}, nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+var (
+	testSwitch     = &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 40000}
+	testController = &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 6653}
+)
+
+func TestSessionRoundTrip(t *testing.T) {
+	hello, _ := v10.NewHello(uint32(1)).MarshalBinary()
+	features, _ := v10.NewFeatureRequest(uint32(2)).MarshalBinary()
+	packetOut := v10.NewPacketOut(uint32(3))
+	packetOut.SetData(make([]byte, 3000))
+	large, _ := packetOut.MarshalBinary()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, LinkTypeEthernet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sw, err := NewSessionWriter(w, testSwitch, testController)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1500000000, 123456000)
+	sent := []struct {
+		from Direction
+		data []byte
+	}{
+		{FromSwitch, hello},
+		{FromController, hello},
+		{FromController, features},
+		{FromController, large},
+	}
+	for i, s := range sent {
+		if err := sw.WriteMessage(start.Add(time.Duration(i)*time.Second), s.from, s.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sw.Close(start.Add(time.Minute))
+
+	r, err := NewMessageReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range sent {
+		msg, err := r.Next()
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		if msg.Direction != s.from || !bytes.Equal(msg.Data, s.data) {
+			t.Errorf("message %d: got %s %x", i, msg.Direction, msg.Data)
+		}
+		if !msg.Timestamp.Equal(start.Add(time.Duration(i) * time.Second)) {
+			t.Errorf("message %d: unexpected timestamp %v", i, msg.Timestamp)
+		}
+		if _, err := msg.Parse(); err != nil {
+			t.Errorf("message %d: %v", i, err)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+// pcapngFile builds a little endian pcapng capture of ethernet frames
+func pcapngFile(frames [][]byte) []byte {
+	var buf bytes.Buffer
+	block := func(typ uint32, body []byte) {
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+		b := make([]byte, 12+len(body))
+		binary.LittleEndian.PutUint32(b[0:4], typ)
+		binary.LittleEndian.PutUint32(b[4:8], uint32(len(b)))
+		copy(b[8:], body)
+		binary.LittleEndian.PutUint32(b[len(b)-4:], uint32(len(b)))
+		buf.Write(b)
+	}
+	shb := make([]byte, 16)
+	binary.LittleEndian.PutUint32(shb[0:4], pcapngByteOrder)
+	binary.LittleEndian.PutUint16(shb[4:6], 1)
+	binary.LittleEndian.PutUint64(shb[8:16], 0xffffffffffffffff)
+	block(pcapngSection, shb)
+	// interface with nanosecond timestamps
+	idb := []byte{1, 0, 0, 0, 0, 0, 0, 0, 9, 0, 1, 0, 9, 0, 0, 0}
+	block(pcapngInterface, idb)
+	for i, f := range frames {
+		epb := make([]byte, 20+len(f))
+		binary.LittleEndian.PutUint32(epb[8:12], uint32(i))
+		binary.LittleEndian.PutUint32(epb[12:16], uint32(len(f)))
+		binary.LittleEndian.PutUint32(epb[16:20], uint32(len(f)))
+		copy(epb[20:], f)
+		block(pcapngEnhancedPacket, epb)
+	}
+	return buf.Bytes()
+}
+
+func TestReassembly(t *testing.T) {
+	fm := v10.NewFlowMod(uint32(7))
+	act := v10.NewActionOutput()
+	act.SetPort(uint16(1))
+	fm.SetAction(act)
+	data, _ := fm.MarshalBinary()
+	echo, _ := v10.NewEchoRequest(uint32(8)).MarshalBinary()
+	stream := append(append([]byte{}, data...), echo...)
+
+	mac := net.HardwareAddr{0, 0, 0, 0, 0, 1}
+	seg := func(off, end int) []byte {
+		return encodeSegment(mac, mac, &segment{
+			srcIP:   testController.IP.To4(),
+			dstIP:   testSwitch.IP.To4(),
+			srcPort: uint16(testController.Port),
+			dstPort: uint16(testSwitch.Port),
+			seq:     uint32(5000 + off),
+			flags:   tcpACK,
+			payload: stream[off:end],
+		}, 0)
+	}
+	// out of order, with a retransmission overlapping both neighbours
+	frames := [][]byte{
+		seg(0, 20),
+		seg(30, 70),
+		seg(10, 40),
+		seg(70, len(stream)),
+	}
+	r, err := NewMessageReader(bytes.NewReader(pcapngFile(frames)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range [][]byte{data, echo} {
+		msg, err := r.Next()
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		if !bytes.Equal(msg.Data, want) {
+			t.Errorf("message %d: got %x", i, msg.Data)
+		}
+		if msg.Direction != FromController {
+			t.Errorf("message %d: unexpected direction %s", i, msg.Direction)
+		}
+	}
+	decoded, err := (&Message{Data: data}).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.(openflow.FlowMod).Action() == nil {
+		t.Error("missing action")
+	}
+}
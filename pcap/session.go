@@ -0,0 +1,384 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"io"
+	"net"
+	"time"
+)
+
+// DefaultPorts are the well known openflow controller ports
+var DefaultPorts = []uint16{6633, 6653}
+
+// Direction tells which side of the session sent a message
+type Direction uint8
+
+const (
+	DirectionUnknown Direction = iota
+	FromSwitch
+	FromController
+)
+
+func (d Direction) String() string {
+	switch d {
+	case FromSwitch:
+		return "switch->controller"
+	case FromController:
+		return "controller->switch"
+	}
+	return "unknown"
+}
+
+// Message is an openflow message extracted from a capture
+type Message struct {
+	// Timestamp of the packet completing the message
+	Timestamp time.Time
+	Src       string
+	Dst       string
+	Direction Direction
+	Data      []byte
+}
+
+// Parse decodes the message with the codec of its version
+func (m *Message) Parse() (openflow.MessageDecoder, error) {
+	if len(m.Data) > 0 && m.Data[0] != openflow.OF10_VERSION {
+		return nil, openflow.ErrUnsupportedVersion
+	}
+	return v10.Parse(m.Data)
+}
+
+// maxPending limits the out of order segments kept per stream, past it
+// the gap is considered lost
+const maxPending = 1024
+
+type flowKey struct {
+	src, dst string
+}
+
+// stream reassembles one direction of a TCP connection
+type stream struct {
+	src, dst  string
+	direction Direction
+	started   bool
+	// synced is false when the stream was picked up mid-connection or
+	// lost data, the buffer is then scanned for a plausible header
+	synced  bool
+	next    uint32
+	buf     []byte
+	pending map[uint32][]byte
+}
+
+// MessageReader extracts openflow messages from TCP streams in a capture
+type MessageReader struct {
+	// Ports of the controllers, only connections to these ports are
+	// decoded. Empty means every TCP connection.
+	Ports []uint16
+
+	r       *Reader
+	streams map[flowKey]*stream
+	// switch side of connections whose handshake was seen
+	initiators map[string]bool
+	queue      []*Message
+}
+
+// NewMessageReader reads a pcap or pcapng capture from r
+func NewMessageReader(r io.Reader) (*MessageReader, error) {
+	reader, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &MessageReader{
+		Ports:      DefaultPorts,
+		r:          reader,
+		streams:    make(map[flowKey]*stream),
+		initiators: make(map[string]bool),
+	}, nil
+}
+
+// Next returns the next complete message, io.EOF at the end of the capture
+func (m *MessageReader) Next() (*Message, error) {
+	for len(m.queue) == 0 {
+		p, err := m.r.ReadPacket()
+		if err != nil {
+			return nil, err
+		}
+		s, err := decodeSegment(p.LinkType, p.Data)
+		if err != nil {
+			// anything else on the wire is skipped
+			continue
+		}
+		if !m.watched(s) {
+			continue
+		}
+		m.handle(p.Timestamp, s)
+	}
+	msg := m.queue[0]
+	m.queue = m.queue[1:]
+	return msg, nil
+}
+
+func (m *MessageReader) watched(s *segment) bool {
+	if len(m.Ports) == 0 {
+		return true
+	}
+	for _, p := range m.Ports {
+		if s.srcPort == p || s.dstPort == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MessageReader) direction(s *segment) Direction {
+	if m.initiators[s.src()] {
+		return FromSwitch
+	}
+	if m.initiators[s.dst()] {
+		return FromController
+	}
+	for _, p := range m.Ports {
+		if s.dstPort == p {
+			return FromSwitch
+		}
+		if s.srcPort == p {
+			return FromController
+		}
+	}
+	return DirectionUnknown
+}
+
+func (m *MessageReader) handle(ts time.Time, s *segment) {
+	key := flowKey{s.src(), s.dst()}
+	if s.flags&tcpSYN != 0 {
+		// switches connect to controllers
+		if s.flags&tcpACK == 0 {
+			m.initiators[s.src()] = true
+		}
+		m.streams[key] = &stream{
+			src:     key.src,
+			dst:     key.dst,
+			started: true,
+			synced:  true,
+			next:    s.seq + 1,
+			pending: make(map[uint32][]byte),
+		}
+	}
+	st, ok := m.streams[key]
+	if !ok {
+		st = &stream{
+			src:     key.src,
+			dst:     key.dst,
+			pending: make(map[uint32][]byte),
+		}
+		m.streams[key] = st
+	}
+	st.direction = m.direction(s)
+	if len(s.payload) > 0 {
+		if !st.started {
+			st.started = true
+			st.next = s.seq
+		}
+		st.add(s.seq, s.payload)
+		m.extract(ts, st)
+	}
+	if s.flags&(tcpFIN|tcpRST) != 0 {
+		delete(m.streams, key)
+	}
+}
+
+// add places a payload in sequence, keeping out of order data aside
+func (st *stream) add(seq uint32, payload []byte) {
+	st.pending[seq] = payload
+	for {
+		progressed := false
+		for seq, payload := range st.pending {
+			diff := int32(seq - st.next)
+			if diff > 0 {
+				continue
+			}
+			delete(st.pending, seq)
+			// retransmitted data overlapping what was already seen
+			if int(-diff) < len(payload) {
+				st.buf = append(st.buf, payload[-diff:]...)
+				st.next += uint32(len(payload) + int(diff))
+			}
+			progressed = true
+		}
+		if !progressed {
+			break
+		}
+	}
+	if len(st.pending) > maxPending {
+		// skip the hole up to the lowest pending segment
+		lowest := st.next
+		first := true
+		for seq := range st.pending {
+			if first || int32(seq-lowest) < 0 {
+				lowest = seq
+				first = false
+			}
+		}
+		st.next = lowest
+		st.buf = nil
+		st.synced = false
+		st.add(lowest, st.pending[lowest])
+	}
+}
+
+// extract queues every complete message in the stream buffer
+func (m *MessageReader) extract(ts time.Time, st *stream) {
+	for {
+		if !st.synced {
+			for len(st.buf) >= openflow.OF_HEADER_SIZE && !plausibleHeader(st.buf) {
+				st.buf = st.buf[1:]
+			}
+			if len(st.buf) < openflow.OF_HEADER_SIZE {
+				return
+			}
+			st.synced = true
+		}
+		if len(st.buf) < openflow.OF_HEADER_SIZE {
+			return
+		}
+		length := int(binary.BigEndian.Uint16(st.buf[2:4]))
+		if length < openflow.OF_HEADER_SIZE {
+			st.synced = false
+			st.buf = st.buf[1:]
+			continue
+		}
+		if len(st.buf) < length {
+			return
+		}
+		data := make([]byte, length)
+		copy(data, st.buf)
+		st.buf = st.buf[length:]
+		m.queue = append(m.queue, &Message{
+			Timestamp: ts,
+			Src:       st.src,
+			Dst:       st.dst,
+			Direction: st.direction,
+			Data:      data,
+		})
+	}
+}
+
+// plausibleHeader tells whether b may start an openflow message
+func plausibleHeader(b []byte) bool {
+	version := b[0]
+	length := binary.BigEndian.Uint16(b[2:4])
+	return version >= openflow.OF10_VERSION && version <= openflow.OF14_VERSION+1 &&
+		b[1] <= 35 && length >= openflow.OF_HEADER_SIZE
+}
+
+// SessionWriter synthesizes the capture of a TCP session between a switch
+// and a controller
+type SessionWriter struct {
+	w          *Writer
+	switchSide *segment
+	ctrlSide   *segment
+	switchMAC  net.HardwareAddr
+	ctrlMAC    net.HardwareAddr
+	opened     bool
+	ipID       uint16
+}
+
+// MSS is the largest payload written in a single segment
+const MSS = 1460
+
+// NewSessionWriter creates a session writer on w, which must use
+// the ethernet link type, addresses must be IPv4
+func NewSessionWriter(w *Writer, switchAddr, controllerAddr *net.TCPAddr) (*SessionWriter, error) {
+	if w.LinkType() != LinkTypeEthernet {
+		return nil, ErrUnsupportedLink
+	}
+	if switchAddr.IP.To4() == nil || controllerAddr.IP.To4() == nil {
+		return nil, ErrUnsupportedAddress
+	}
+	return &SessionWriter{
+		w: w,
+		switchSide: &segment{
+			srcIP:   switchAddr.IP.To4(),
+			dstIP:   controllerAddr.IP.To4(),
+			srcPort: uint16(switchAddr.Port),
+			dstPort: uint16(controllerAddr.Port),
+			seq:     0x1000,
+		},
+		ctrlSide: &segment{
+			srcIP:   controllerAddr.IP.To4(),
+			dstIP:   switchAddr.IP.To4(),
+			srcPort: uint16(controllerAddr.Port),
+			dstPort: uint16(switchAddr.Port),
+			seq:     0x2000,
+		},
+		switchMAC: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x01},
+		ctrlMAC:   net.HardwareAddr{0x02, 0, 0, 0, 0, 0x02},
+	}, nil
+}
+
+func (s *SessionWriter) send(ts time.Time, from Direction, flags uint8, payload []byte) error {
+	src, dst := s.switchSide, s.ctrlSide
+	srcMAC, dstMAC := s.switchMAC, s.ctrlMAC
+	if from == FromController {
+		src, dst = dst, src
+		srcMAC, dstMAC = dstMAC, srcMAC
+	}
+	seg := *src
+	seg.flags = flags
+	seg.payload = payload
+	if flags&tcpACK != 0 {
+		seg.ack = dst.seq
+	}
+	s.ipID++
+	if err := s.w.WritePacket(ts, encodeSegment(srcMAC, dstMAC, &seg, s.ipID)); err != nil {
+		return err
+	}
+	src.seq += uint32(len(payload))
+	if flags&(tcpSYN|tcpFIN) != 0 {
+		src.seq++
+	}
+	return nil
+}
+
+// WriteMessage writes a message sent at ts, the TCP handshake is written
+// before the first message
+func (s *SessionWriter) WriteMessage(ts time.Time, from Direction, data []byte) error {
+	if !s.opened {
+		s.opened = true
+		if err := s.send(ts, FromSwitch, tcpSYN, nil); err != nil {
+			return err
+		}
+		if err := s.send(ts, FromController, tcpSYN|tcpACK, nil); err != nil {
+			return err
+		}
+		if err := s.send(ts, FromSwitch, tcpACK, nil); err != nil {
+			return err
+		}
+	}
+	for len(data) > 0 {
+		n := len(data)
+		if n > MSS {
+			n = MSS
+		}
+		if err := s.send(ts, from, tcpACK|tcpPSH, data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// Close writes the connection teardown initiated by the switch
+func (s *SessionWriter) Close(ts time.Time) error {
+	if !s.opened {
+		return nil
+	}
+	if err := s.send(ts, FromSwitch, tcpFIN|tcpACK, nil); err != nil {
+		return err
+	}
+	if err := s.send(ts, FromController, tcpFIN|tcpACK, nil); err != nil {
+		return err
+	}
+	return s.send(ts, FromSwitch, tcpACK, nil)
+}
@@ -0,0 +1,56 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const defaultSnapLen = 65535
+
+// Writer writes packets in the pcap format with microsecond timestamps
+type Writer struct {
+	w        io.Writer
+	linkType uint32
+}
+
+// NewWriter writes the file header to w
+func NewWriter(w io.Writer, linkType uint32) (*Writer, error) {
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:4], magicMicroseconds)
+	binary.LittleEndian.PutUint16(header[4:6], 2)
+	binary.LittleEndian.PutUint16(header[6:8], 4)
+	binary.LittleEndian.PutUint32(header[16:20], defaultSnapLen)
+	binary.LittleEndian.PutUint32(header[20:24], linkType)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &Writer{
+		w:        w,
+		linkType: linkType,
+	}, nil
+}
+
+// LinkType returns the link type written in the file header
+func (w *Writer) LinkType() uint32 {
+	return w.linkType
+}
+
+// WritePacket writes a frame captured at ts, frames longer than the snap
+// length are truncated
+func (w *Writer) WritePacket(ts time.Time, data []byte) error {
+	capLen := len(data)
+	if capLen > defaultSnapLen {
+		capLen = defaultSnapLen
+	}
+	header := make([]byte, 16)
+	binary.LittleEndian.PutUint32(header[0:4], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(header[4:8], uint32(ts.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(header[8:12], uint32(capLen))
+	binary.LittleEndian.PutUint32(header[12:16], uint32(len(data)))
+	if _, err := w.w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.w.Write(data[:capLen])
+	return err
+}
@@ -0,0 +1,108 @@
+package pktgenerator
+
+import (
+	"github.com/ksang/goflow/openflow"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+// batchLength is the number of flow mods of a table update
+const batchLength = 64
+
+// sink returns a TCP connection whose peer discards what it reads
+func sink(b *testing.B) net.Conn {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		io.Copy(ioutil.Discard, conn)
+		conn.Close()
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	return conn
+}
+
+func BenchmarkFlowModMarshal(b *testing.B) {
+	fm := newFlowMod()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := fm.MarshalBinary(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFlowModAppend(b *testing.B) {
+	fm := newFlowMod()
+	var buf []byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = openflow.AppendBinary(buf[:0], fm); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkFlowModWrite writes each flow mod with its own system call
+func BenchmarkFlowModWrite(b *testing.B) {
+	conn := sink(b)
+	defer conn.Close()
+	fm := newFlowMod()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := openflow.WriteMessage(conn, fm); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkFlowModWriteBatch writes the flow mods of a table update
+// together
+func BenchmarkFlowModWriteBatch(b *testing.B) {
+	conn := sink(b)
+	defer conn.Close()
+	w := openflow.NewWriter(conn)
+	batch := make([]openflow.MessageDecoder, batchLength)
+	for i := range batch {
+		batch[i] = newFlowMod()
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i += batchLength {
+		if err := w.WriteMessages(batch...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkFlowModWriteParallel writes flow mods from several goroutines
+// sharing a connection, as applications sending to a datapath do
+func BenchmarkFlowModWriteParallel(b *testing.B) {
+	conn := sink(b)
+	defer conn.Close()
+	w := openflow.NewWriter(conn)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		fm := newFlowMod()
+		for pb.Next() {
+			if err := w.WriteMessages(fm); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
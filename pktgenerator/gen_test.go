@@ -1,7 +1,14 @@
 package pktgenerator
 
 import (
+	"bytes"
+	"crypto/tls"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/transport"
+	"github.com/ksang/goflow/transport/transporttest"
 	"log"
+	"net"
 	"testing"
 	"time"
 )
@@ -25,3 +32,96 @@ func TestOpenFlow(t *testing.T) {
 	server.Stop()
 
 }
+
+func TestOpenFlowTLS(t *testing.T) {
+	ca, err := transporttest.NewCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := transporttest.NewCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverCert, _ := ca.Issue("controller", "127.0.0.1")
+	clientCert, _ := ca.Issue("switch")
+	rogueCert, _ := other.Issue("switch")
+	server := NewTLSServer("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.Pool(),
+	})
+	received := make(chan []byte, 2)
+	server.SetHandler(func(data []byte) {
+		received <- data
+	})
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	addr := server.Addr().String()
+
+	// a client certificate of another CA fails the handshake, with TLS 1.3
+	// the client learns of it on its first read
+	conn, err := tls.Dial("tcp", addr, &tls.Config{
+		Certificates: []tls.Certificate{rogueCert},
+		RootCAs:      ca.Pool(),
+	})
+	if err == nil {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, err = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	if ne, ok := err.(net.Error); err == nil || ok && ne.Timeout() {
+		t.Errorf("untrusted client certificate not refused: %v", err)
+	}
+
+	ofpkt, err := NewQueueGetConfigReplyPkt(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ofpkt.SetTLS(&tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      ca.Pool(),
+	})
+	if err = SendMany(1, &ofpkt); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case data := <-received:
+		if !bytes.Equal(data, ofpkt.payload) {
+			t.Errorf("server received %x, sent %x", data, ofpkt.payload)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not received over TLS")
+	}
+	select {
+	case data := <-received:
+		t.Errorf("unexpected message %x", data)
+	default:
+	}
+}
+
+func TestOpenFlowUDP(t *testing.T) {
+	ln, err := transport.ListenURI("udp:127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	hello, _ := v10.NewHello(7).MarshalBinary()
+	pkt := UDPPacket{dst: ln.Addr().String(), payload: hello}
+	if err := pkt.Send(); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	data, err := openflow.ReadMessage(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[1] != v10.OFPT_HELLO {
+		t.Errorf("received message type %d", data[1])
+	}
+}
@@ -5,6 +5,7 @@ Using this tool we can test openflow packets.
 package pktgenerator
 
 import (
+	"crypto/tls"
 	"errors"
 	"net"
 )
@@ -20,8 +21,14 @@ type UDPPacket struct {
 }
 
 type TCPPacket struct {
-	dst     string
-	payload []byte
+	dst       string
+	payload   []byte
+	tlsConfig *tls.Config
+}
+
+// SetTLS makes Send connect over TLS with config, nil connects over TCP
+func (pkt *TCPPacket) SetTLS(config *tls.Config) {
+	pkt.tlsConfig = config
 }
 
 func (pkt *UDPPacket) Send() error {
@@ -42,6 +49,15 @@ func (pkt *TCPPacket) Send() error {
 	if pkt.dst == "" {
 		return errors.New("No target specified.")
 	}
+	if pkt.tlsConfig != nil {
+		conn, err := tls.Dial("tcp", pkt.dst, pkt.tlsConfig)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, err = conn.Write(pkt.payload)
+		return err
+	}
 	raddr, err := net.ResolveTCPAddr("tcp", pkt.dst)
 	if err != nil {
 		return err
@@ -182,6 +182,15 @@ func NewPortStatusPkt(dst string) (OpenFlowPkt, error) {
 }
 
 func NewFlowModPkt(dst string) (OpenFlowPkt, error) {
+	data, err := newFlowMod().MarshalBinary()
+	if err != nil {
+		return OpenFlowPkt{}, err
+	}
+	return CreateOFPkt(dst, data), nil
+}
+
+// newFlowMod returns the flow mod sent by NewFlowModPkt
+func newFlowMod() openflow.FlowMod {
 	fm := v10.NewFlowMod(uint32(23334))
 	m := v10.NewMatch()
 	m.SetInPort(uint16(1))
@@ -199,11 +208,7 @@ func NewFlowModPkt(dst string) (OpenFlowPkt, error) {
 	actOut.SetMaxLen(uint16(65535))
 	fm.SetAction(actOut)
 	fm.SetFlags(openflow.CheckOverlap)
-	data, err := fm.MarshalBinary()
-	if err != nil {
-		return OpenFlowPkt{}, err
-	}
-	return CreateOFPkt(dst, data), nil
+	return fm
 }
 
 func NewPortModPkt(dst string) (OpenFlowPkt, error) {
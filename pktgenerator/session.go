@@ -0,0 +1,45 @@
+package pktgenerator
+
+import (
+	"errors"
+	"github.com/ksang/goflow/pcap"
+	"github.com/ksang/goflow/session"
+	"log"
+	"net"
+)
+
+// SessionPkt replays a recorded session against dst, playing the switch
+type SessionPkt struct {
+	dst      string
+	replayer *session.Replayer
+}
+
+func NewSessionPkt(dst string, records []*session.Record) SessionPkt {
+	return SessionPkt{
+		dst:      dst,
+		replayer: session.NewReplayer(records, pcap.FromSwitch),
+	}
+}
+
+// Replayer allows tuning the replay, e.g. its speed
+func (pkt *SessionPkt) Replayer() *session.Replayer {
+	return pkt.replayer
+}
+
+func (pkt *SessionPkt) Send() error {
+	if pkt.dst == "" {
+		return errors.New("No target specified.")
+	}
+	conn, err := net.Dial("tcp", pkt.dst)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	report, err := pkt.replayer.Replay(conn)
+	if err != nil {
+		return err
+	}
+	log.Printf("Session replayed: %d sent, %d received, %d missing, %d unexpected",
+		report.Sent, report.Received, len(report.Missing), len(report.Unexpected))
+	return nil
+}
@@ -1,26 +1,38 @@
 package pktgenerator
 
 import (
-	"encoding/hex"
+	"crypto/tls"
+	"github.com/ksang/goflow/dissector"
+	"github.com/ksang/goflow/openflow"
+	"io"
 	"log"
 	"net"
 )
 
 type TcpServer struct {
-	laddr    string
-	stopping chan chan error
-	conn     chan net.Conn
+	laddr     string
+	stopping  chan chan error
+	conn      chan net.Conn
+	tlsConfig *tls.Config
+	handler   func(data []byte)
+	addr      net.Addr
 }
 
-func handleConnection(conn net.Conn) {
+func (t *TcpServer) handleConnection(conn net.Conn) {
 	defer conn.Close()
-	buf := make([]byte, 1500)
-	len, err := conn.Read(buf)
-	if err != nil {
-		log.Print(err)
-		return
+	for {
+		data, err := openflow.ReadMessage(conn)
+		if err != nil {
+			if err != io.EOF {
+				log.Print(err)
+			}
+			return
+		}
+		log.Print("Packet Received: \n", dissector.Dissect(data), "\n")
+		if t.handler != nil {
+			t.handler(data)
+		}
 	}
-	log.Print("Packet Received: \n", hex.Dump(buf[:len]), "\n")
 }
 
 func NewTcpServer(laddr string) *TcpServer {
@@ -31,6 +43,26 @@ func NewTcpServer(laddr string) *TcpServer {
 	}
 }
 
+// NewTLSServer returns a server accepting TLS connections with config,
+// which may require client certificates
+func NewTLSServer(laddr string, config *tls.Config) *TcpServer {
+	t := NewTcpServer(laddr)
+	t.tlsConfig = config
+	return t
+}
+
+// SetHandler makes the server call handler with each message it
+// receives, it must be set before Start
+func (t *TcpServer) SetHandler(handler func(data []byte)) {
+	t.handler = handler
+}
+
+// Addr returns the address the server listens on once started, with the
+// port chosen by the system for port 0
+func (t *TcpServer) Addr() net.Addr {
+	return t.addr
+}
+
 func (t *TcpServer) Loop(ln net.Listener, ready chan bool) {
 	var err error
 	go func() {
@@ -50,7 +82,7 @@ func (t *TcpServer) Loop(ln net.Listener, ready chan bool) {
 			errc <- err
 			break
 		case conn := <-t.conn:
-			go handleConnection(conn)
+			go t.handleConnection(conn)
 		}
 	}
 }
@@ -58,10 +90,14 @@ func (t *TcpServer) Loop(ln net.Listener, ready chan bool) {
 func (t *TcpServer) Start() error {
 	ready := make(chan bool)
 	ln, err := net.Listen("tcp", t.laddr)
+	if err == nil && t.tlsConfig != nil {
+		ln = tls.NewListener(ln, t.tlsConfig)
+	}
 	if err != nil {
 		log.Println(err)
 		return err
 	}
+	t.addr = ln.Addr()
 	go t.Loop(ln, ready)
 	<-ready
 	return nil
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"github.com/ksang/goflow/dissector"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/pcap"
+	"log"
+)
+
+// errorDataLength is how much of a failed request an error carries
+const errorDataLength = 64
+
+// DenyDeleteAll drops flow deletions removing every flow, non strict
+// deletes with all wildcards and no out port, and answers the controller
+// with a permission error. Strict deletes only remove the catch-all entry
+// of their priority and are let through.
+func DenyDeleteAll(s *Session, m *Message) bool {
+	fm, ok := m.Msg.(openflow.FlowMod)
+	if !ok || m.From != pcap.FromController || fm.Command() != openflow.Delete {
+		return true
+	}
+	if fm.Match() != nil && !v10.MatchesAll(fm.Match()) || fm.OutPort() != uint16(openflow.None) {
+		return true
+	}
+	e := v10.NewError(fm.TransactionID())
+	e.SetType(v10.OFPET_FLOW_MOD_FAILED)
+	e.SetCode(v10.OFPFMFC_EPERM)
+	if data, err := marshal(fm); err == nil {
+		if len(data) > errorDataLength {
+			data = data[:errorDataLength]
+		}
+		e.SetData(data)
+	}
+	if err := s.SendToController(m.Controller, e); err != nil {
+		log.Println(err)
+	}
+	return false
+}
+
+// Logger returns a hook logging every message with the dissector
+func Logger(l *log.Logger) Hook {
+	return func(s *Session, m *Message) bool {
+		data, err := marshal(m.Msg)
+		if err != nil {
+			return true
+		}
+		l.Printf("session %d %s:\n%s", s.ID, m.From, dissector.Dissect(data))
+		return true
+	}
+}
@@ -0,0 +1,373 @@
+/*
+Package proxy implements a transparent openflow proxy between a switch and
+one or more controllers.
+
+Every message is decoded with the library's parser and passed through the
+registered hooks, which can inspect, modify, replace or drop it. Messages
+no hook changed are forwarded byte for byte. Hooks and
+other code can also inject messages into a session. When several
+controllers are configured, the switch is shared between them: transaction
+ids of controller requests are rewritten so replies reach the controller
+that asked, asynchronous switch messages reach every controller, and only
+the first controller gets the answers to switch requests.
+*/
+package proxy
+
+import (
+	"encoding/binary"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/pcap"
+	"log"
+	"net"
+	"sync"
+)
+
+// Message is a message travelling through the proxy
+type Message struct {
+	From pcap.Direction
+	// Controller is the index of the controller which sent the message,
+	// -1 for messages from the switch
+	Controller int
+	// Msg is the decoded message, hooks may modify or replace it
+	Msg openflow.MessageDecoder
+	// Modified is set by hooks which change Msg in place, only modified
+	// or replaced messages are encoded again, others are forwarded as
+	// they were received
+	Modified bool
+}
+
+// Hook inspects a message, returning false drops it
+type Hook func(s *Session, m *Message) bool
+
+// Proxy relays switch connections to controllers
+type Proxy struct {
+	// DropUnparsed drops messages the parser does not understand instead
+	// of forwarding them untouched, hooks never see such messages
+	DropUnparsed bool
+
+	controllers []string
+	mu          sync.Mutex
+	hooks       []Hook
+	sessions    int
+	wg          sync.WaitGroup
+}
+
+// New creates a proxy, the first controller is the primary one
+func New(controllers ...string) *Proxy {
+	return &Proxy{
+		controllers: controllers,
+	}
+}
+
+// AddHook registers a hook, hooks run in registration order
+func (p *Proxy) AddHook(h Hook) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hooks = append(p.hooks, h)
+}
+
+func (p *Proxy) hookList() []Hook {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.hooks
+}
+
+// Serve accepts switch connections on ln until it is closed,
+// then waits for the running sessions to end
+func (p *Proxy) Serve(ln net.Listener) error {
+	defer p.wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		p.mu.Lock()
+		id := p.sessions
+		p.sessions++
+		p.mu.Unlock()
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			s, err := p.dial(conn, id)
+			if err != nil {
+				log.Println(err)
+				conn.Close()
+				return
+			}
+			s.run()
+		}()
+	}
+}
+
+func (p *Proxy) dial(sw net.Conn, id int) (*Session, error) {
+	s := &Session{
+		ID:      id,
+		proxy:   p,
+		sw:      sw,
+		pending: make(map[uint32]route),
+		nextXID: firstXID,
+	}
+	for _, addr := range p.controllers {
+		c, err := net.Dial("tcp", addr)
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+		s.ctrls = append(s.ctrls, c)
+	}
+	s.ctrlMu = make([]sync.Mutex, len(s.ctrls))
+	return s, nil
+}
+
+// route remembers the origin of a request forwarded to the switch
+type route struct {
+	controller int
+	xid        uint32
+}
+
+const (
+	// firstXID is where xids allocated by the proxy start
+	firstXID = 0x80000000
+	// maxPending bounds the requests waiting for a reply, the oldest
+	// are forgotten, e.g. flow mods which never get one
+	maxPending = 4096
+	// injected marks requests injected by the proxy, their replies
+	// are not forwarded
+	injected = -1
+)
+
+// Session is a switch connection and its controller connections
+type Session struct {
+	ID    int
+	proxy *Proxy
+	sw    net.Conn
+	ctrls []net.Conn
+
+	mu        sync.Mutex
+	pending   map[uint32]route
+	order     []uint32
+	nextXID   uint32
+	helloSent bool
+	closed    bool
+	// writes are serialized per connection
+	swMu   sync.Mutex
+	ctrlMu []sync.Mutex
+}
+
+// SwitchAddr returns the address of the switch
+func (s *Session) SwitchAddr() net.Addr {
+	return s.sw.RemoteAddr()
+}
+
+// Controllers returns the number of controllers of the session
+func (s *Session) Controllers() int {
+	return len(s.ctrls)
+}
+
+func (s *Session) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.sw.Close()
+	for _, c := range s.ctrls {
+		c.Close()
+	}
+}
+
+func (s *Session) run() {
+	var wg sync.WaitGroup
+	wg.Add(1 + len(s.ctrls))
+	go func() {
+		defer wg.Done()
+		defer s.close()
+		s.relay(s.sw, pcap.FromSwitch, -1)
+	}()
+	for i, c := range s.ctrls {
+		go func(i int, c net.Conn) {
+			defer wg.Done()
+			defer s.close()
+			s.relay(c, pcap.FromController, i)
+		}(i, c)
+	}
+	wg.Wait()
+}
+
+func (s *Session) relay(conn net.Conn, from pcap.Direction, controller int) {
+	for {
+		data, err := openflow.ReadMessage(conn)
+		if err != nil {
+			return
+		}
+		msg, err := v10.Parse(data)
+		if err != nil {
+			if s.proxy.DropUnparsed {
+				continue
+			}
+		} else {
+			m := &Message{
+				From:       from,
+				Controller: controller,
+				Msg:        msg,
+			}
+			if !s.runHooks(m) {
+				continue
+			}
+			if m.Modified || m.Msg != msg {
+				if data, err = marshal(m.Msg); err != nil {
+					log.Println(err)
+					continue
+				}
+			}
+		}
+		if from == pcap.FromSwitch {
+			err = s.fromSwitch(data)
+		} else {
+			err = s.fromController(controller, data)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Session) runHooks(m *Message) bool {
+	for _, h := range s.proxy.hookList() {
+		if !h(s, m) {
+			return false
+		}
+	}
+	return true
+}
+
+func marshal(msg openflow.MessageDecoder) ([]byte, error) {
+	m, ok := msg.(interface {
+		MarshalBinary() ([]byte, error)
+	})
+	if !ok {
+		return nil, openflow.ErrUnsupportedMessage
+	}
+	return m.MarshalBinary()
+}
+
+// fromSwitch routes a switch message to the controllers
+func (s *Session) fromSwitch(data []byte) error {
+	xid := binary.BigEndian.Uint32(data[4:8])
+	if v10.IsReply(data[1]) {
+		s.mu.Lock()
+		r, ok := s.pending[xid]
+		// stats replies come in several parts
+		last := data[1] != v10.OFPT_STATS_REPLY || len(data) < 12 || binary.BigEndian.Uint16(data[10:12])&v10.OFPSF_REPLY_MORE == 0
+		if ok && last {
+			delete(s.pending, xid)
+		}
+		s.mu.Unlock()
+		switch {
+		case ok && r.controller == injected:
+			return nil
+		case ok:
+			binary.BigEndian.PutUint32(data[4:8], r.xid)
+			if data[1] == v10.OFPT_ERROR && len(data) >= 20 {
+				binary.BigEndian.PutUint32(data[16:20], r.xid)
+			}
+			return s.writeController(r.controller, data)
+		case data[1] != v10.OFPT_ERROR:
+			return s.writeController(0, data)
+		}
+	}
+	if data[1] == v10.OFPT_ECHO_REQUEST {
+		return s.writeController(0, data)
+	}
+	// asynchronous messages and hello reach every controller
+	for i := range s.ctrls {
+		if err := s.writeController(i, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fromController forwards a controller message to the switch
+func (s *Session) fromController(controller int, data []byte) error {
+	if len(s.ctrls) == 1 {
+		return s.writeSwitch(data)
+	}
+	if data[1] == v10.OFPT_HELLO {
+		s.mu.Lock()
+		sent := s.helloSent
+		s.helloSent = true
+		s.mu.Unlock()
+		if sent {
+			return nil
+		}
+		return s.writeSwitch(data)
+	}
+	if v10.IsReply(data[1]) {
+		return s.writeSwitch(data)
+	}
+	s.track(data, controller)
+	return s.writeSwitch(data)
+}
+
+// track rewrites the xid of a request and remembers its origin
+func (s *Session) track(data []byte, controller int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	xid := s.nextXID
+	s.nextXID++
+	if s.nextXID == 0 {
+		s.nextXID = firstXID
+	}
+	s.pending[xid] = route{
+		controller: controller,
+		xid:        binary.BigEndian.Uint32(data[4:8]),
+	}
+	s.order = append(s.order, xid)
+	for len(s.order) > maxPending {
+		delete(s.pending, s.order[0])
+		s.order = s.order[1:]
+	}
+	binary.BigEndian.PutUint32(data[4:8], xid)
+}
+
+func (s *Session) writeSwitch(data []byte) error {
+	s.swMu.Lock()
+	defer s.swMu.Unlock()
+	_, err := s.sw.Write(data)
+	return err
+}
+
+func (s *Session) writeController(i int, data []byte) error {
+	s.ctrlMu[i].Lock()
+	defer s.ctrlMu[i].Unlock()
+	_, err := s.ctrls[i].Write(data)
+	return err
+}
+
+// SendToSwitch injects a message to the switch, replies to injected
+// requests are not forwarded to the controllers
+func (s *Session) SendToSwitch(msg openflow.MessageDecoder) error {
+	data, err := marshal(msg)
+	if err != nil {
+		return err
+	}
+	if !v10.IsReply(data[1]) && data[1] != v10.OFPT_HELLO {
+		s.track(data, injected)
+	}
+	return s.writeSwitch(data)
+}
+
+// SendToController injects a message to the i-th controller
+func (s *Session) SendToController(i int, msg openflow.MessageDecoder) error {
+	if i < 0 || i >= len(s.ctrls) {
+		return openflow.ErrInvalidValueProvided
+	}
+	data, err := marshal(msg)
+	if err != nil {
+		return err
+	}
+	return s.writeController(i, data)
+}
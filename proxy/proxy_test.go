@@ -0,0 +1,141 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"net"
+	"testing"
+	"time"
+)
+
+func send(t *testing.T, conn net.Conn, msg openflow.MessageDecoder) {
+	if err := openflow.WriteMessage(conn, msg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func recv(t *testing.T, conn net.Conn) []byte {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	data, err := openflow.ReadMessage(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func xidOf(data []byte) uint32 {
+	return binary.BigEndian.Uint32(data[4:8])
+}
+
+func TestMultipleControllers(t *testing.T) {
+	var ctrlLns []net.Listener
+	var addrs []string
+	for i := 0; i < 2; i++ {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		ctrlLns = append(ctrlLns, ln)
+		addrs = append(addrs, ln.Addr().String())
+	}
+	p := New(addrs...)
+	p.AddHook(DenyDeleteAll)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go p.Serve(ln)
+
+	sw, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sw.Close()
+	var ctrls []net.Conn
+	for _, l := range ctrlLns {
+		c, err := l.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		ctrls = append(ctrls, c)
+	}
+
+	// both controllers use the same xid, replies go back to the sender
+	for _, c := range ctrls {
+		send(t, c, v10.NewFeatureRequest(7))
+	}
+	dpids := make(map[uint32]uint64)
+	for i := range ctrls {
+		req := recv(t, sw)
+		if req[1] != v10.OFPT_FEATURES_REQUEST {
+			t.Fatalf("unexpected message %x", req)
+		}
+		reply := v10.NewFeatureReply(xidOf(req))
+		reply.SetDPID(uint64(i + 1))
+		send(t, sw, reply)
+		dpids[xidOf(req)] = uint64(i + 1)
+	}
+	if len(dpids) != 2 {
+		t.Fatal("requests were not given distinct xids")
+	}
+	for i, c := range ctrls {
+		data := recv(t, c)
+		if data[1] != v10.OFPT_FEATURES_REPLY || xidOf(data) != 7 {
+			t.Errorf("controller %d: unexpected reply %x", i, data)
+		}
+	}
+
+	// asynchronous messages reach every controller
+	pin := v10.NewPacketIn(0)
+	pin.SetData([]byte{0xde, 0xad})
+	send(t, sw, pin)
+	for i, c := range ctrls {
+		if data := recv(t, c); data[1] != v10.OFPT_PACKET_IN {
+			t.Errorf("controller %d: unexpected message %x", i, data)
+		}
+	}
+
+	// messages no hook changed are forwarded untouched, padding included
+	raw, _ := pin.(encoding.BinaryMarshaler).MarshalBinary()
+	raw[17] = 0xff
+	if _, err := sw.Write(raw); err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range ctrls {
+		if data := recv(t, c); !bytes.Equal(data, raw) {
+			t.Errorf("controller %d: %x forwarded as %x", i, raw, data)
+		}
+	}
+
+	// deleting every flow is denied
+	fm := v10.NewFlowMod(9)
+	fm.SetCommand(openflow.Delete)
+	fm.SetOutPort(uint16(openflow.None))
+	send(t, ctrls[1], fm)
+	data := recv(t, ctrls[1])
+	if data[1] != v10.OFPT_ERROR || xidOf(data) != 9 {
+		t.Errorf("unexpected message %x", data)
+	}
+	// deleting the catch-all entry or the flows to a port is allowed
+	fm.SetCommand(openflow.DeleteStrict)
+	send(t, ctrls[1], fm)
+	if data := recv(t, sw); data[1] != v10.OFPT_FLOW_MOD {
+		t.Errorf("unexpected message %x", data)
+	}
+	fm.SetCommand(openflow.Delete)
+	fm.SetOutPort(2)
+	send(t, ctrls[1], fm)
+	if data := recv(t, sw); data[1] != v10.OFPT_FLOW_MOD {
+		t.Errorf("unexpected message %x", data)
+	}
+	send(t, ctrls[1], v10.NewBarrierRequest(10))
+	if data := recv(t, sw); data[1] != v10.OFPT_BARRIER_REQUEST {
+		t.Errorf("unexpected message %x", data)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Package session records controller-switch sessions and replays them.
+
+A Recorder relays connections between switches and a controller and logs
+every message in both directions with its timing. A Replayer then
+re-drives either side of a recorded session over a new connection,
+rewriting transaction ids and optionally scaling time.
+
+Recordings are JSON lines, one record per message. Captures read with
+the pcap package can be converted into records as well.
+*/
+package session
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/pcap"
+	"io"
+	"sync"
+	"time"
+)
+
+// Record is a message of a recorded session
+type Record struct {
+	Time time.Time
+	// Conn identifies the connection within a recording
+	Conn      int
+	Direction pcap.Direction
+	Data      []byte
+}
+
+type jsonRecord struct {
+	Time      time.Time       `json:"time"`
+	Conn      int             `json:"conn"`
+	Direction string          `json:"direction"`
+	Data      string          `json:"data"`
+	Message   json.RawMessage `json:"message,omitempty"`
+}
+
+func directionName(d pcap.Direction) string {
+	switch d {
+	case pcap.FromSwitch:
+		return "switch"
+	case pcap.FromController:
+		return "controller"
+	}
+	return "unknown"
+}
+
+func parseDirection(s string) (pcap.Direction, error) {
+	switch s {
+	case "switch":
+		return pcap.FromSwitch, nil
+	case "controller":
+		return pcap.FromController, nil
+	case "unknown":
+		return pcap.DirectionUnknown, nil
+	}
+	return pcap.DirectionUnknown, openflow.ErrInvalidValueProvided
+}
+
+// MarshalJSON encodes the raw message along with its decoded form,
+// which is only informative
+func (r *Record) MarshalJSON() ([]byte, error) {
+	j := jsonRecord{
+		Time:      r.Time,
+		Conn:      r.Conn,
+		Direction: directionName(r.Direction),
+		Data:      hex.EncodeToString(r.Data),
+	}
+	if msg, err := v10.Parse(r.Data); err == nil {
+		if decoded, err := json.Marshal(msg); err == nil {
+			j.Message = decoded
+		}
+	}
+	return json.Marshal(j)
+}
+
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var j jsonRecord
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	dir, err := parseDirection(j.Direction)
+	if err != nil {
+		return err
+	}
+	raw, err := hex.DecodeString(j.Data)
+	if err != nil {
+		return err
+	}
+	if len(raw) < openflow.OF_HEADER_SIZE {
+		return openflow.ErrInvalidPacketLength
+	}
+	r.Time = j.Time
+	r.Conn = j.Conn
+	r.Direction = dir
+	r.Data = raw
+	return nil
+}
+
+// Writer writes records as JSON lines, it is safe for concurrent use
+type Writer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		enc: json.NewEncoder(w),
+	}
+}
+
+func (w *Writer) WriteRecord(r *Record) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(r)
+}
+
+// Reader reads records written by Writer
+type Reader struct {
+	dec *json.Decoder
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		dec: json.NewDecoder(r),
+	}
+}
+
+// ReadRecord returns the next record, io.EOF at the end of the recording
+func (r *Reader) ReadRecord() (*Record, error) {
+	rec := &Record{}
+	if err := r.dec.Decode(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// ReadAll reads every record of a recording
+func ReadAll(r io.Reader) ([]*Record, error) {
+	reader := NewReader(r)
+	records := []*Record{}
+	for {
+		rec, err := reader.ReadRecord()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// FromPcap converts the openflow messages of a capture to records,
+// each TCP connection gets its own Conn number
+func FromPcap(r io.Reader) ([]*Record, error) {
+	mr, err := pcap.NewMessageReader(r)
+	if err != nil {
+		return nil, err
+	}
+	conns := make(map[[2]string]int)
+	records := []*Record{}
+	for {
+		msg, err := mr.Next()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		key := [2]string{msg.Src, msg.Dst}
+		if msg.Direction == pcap.FromController {
+			key = [2]string{msg.Dst, msg.Src}
+		}
+		id, ok := conns[key]
+		if !ok {
+			id = len(conns)
+			conns[key] = id
+		}
+		records = append(records, &Record{
+			Time:      msg.Timestamp,
+			Conn:      id,
+			Direction: msg.Direction,
+			Data:      msg.Data,
+		})
+	}
+}
+
+// Load reads a recording or a pcap/pcapng capture
+func Load(r io.Reader) ([]*Record, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if first[0] == '{' {
+		return ReadAll(br)
+	}
+	return FromPcap(br)
+}
+
+// Filter returns the records of a single connection
+func Filter(records []*Record, conn int) []*Record {
+	filtered := []*Record{}
+	for _, r := range records {
+		if r.Conn == conn {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
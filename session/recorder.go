@@ -0,0 +1,93 @@
+package session
+
+import (
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/pcap"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// Recorder relays switch connections to a controller and records
+// every message in both directions
+type Recorder struct {
+	controller string
+	w          *Writer
+
+	mu    sync.Mutex
+	conns int
+	wg    sync.WaitGroup
+}
+
+// NewRecorder creates a recorder relaying to the controller address
+func NewRecorder(controller string, w *Writer) *Recorder {
+	return &Recorder{
+		controller: controller,
+		w:          w,
+	}
+}
+
+// Serve accepts switch connections on ln until it is closed,
+// then waits for the relayed sessions to end
+func (r *Recorder) Serve(ln net.Listener) error {
+	defer r.wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		r.mu.Lock()
+		id := r.conns
+		r.conns++
+		r.mu.Unlock()
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			if err := r.relay(conn, id); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+}
+
+func (r *Recorder) relay(sw net.Conn, id int) error {
+	defer sw.Close()
+	ctrl, err := net.Dial("tcp", r.controller)
+	if err != nil {
+		return err
+	}
+	defer ctrl.Close()
+	done := make(chan struct{}, 2)
+	pipe := func(src, dst net.Conn, dir pcap.Direction) {
+		defer func() {
+			// unblock the other direction
+			src.Close()
+			dst.Close()
+			done <- struct{}{}
+		}()
+		for {
+			data, err := openflow.ReadMessage(src)
+			if err != nil {
+				return
+			}
+			rec := &Record{
+				Time:      time.Now(),
+				Conn:      id,
+				Direction: dir,
+				Data:      data,
+			}
+			if err := r.w.WriteRecord(rec); err != nil {
+				log.Println(err)
+			}
+			if _, err := dst.Write(data); err != nil {
+				return
+			}
+		}
+	}
+	go pipe(sw, ctrl, pcap.FromSwitch)
+	go pipe(ctrl, sw, pcap.FromController)
+	<-done
+	<-done
+	return nil
+}
@@ -0,0 +1,246 @@
+package session
+
+import (
+	"encoding/binary"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/pcap"
+	"io"
+	"sync"
+	"time"
+)
+
+// Report summarizes a replay
+type Report struct {
+	Sent     int
+	Received int
+	// Missing are the recorded peer messages that never arrived
+	Missing []*Record
+	// Unexpected are the received messages absent from the recording
+	Unexpected [][]byte
+}
+
+// Replayer re-drives one side of a recorded session
+type Replayer struct {
+	// Role is the side played by the replayer, FromSwitch pretends
+	// to be the switch
+	Role pcap.Direction
+	// Speed scales recorded delays, 2 replays twice as fast and
+	// 0 sends without delay
+	Speed float64
+	// RewriteXID assigns fresh xids to requests and maps replies to
+	// the xids used by the peer
+	RewriteXID bool
+	// Timeout bounds the wait for a peer message a reply depends on,
+	// and for outstanding peer messages at the end of the replay
+	Timeout time.Duration
+
+	records []*Record
+}
+
+// NewReplayer creates a real time replayer of a single session
+func NewReplayer(records []*Record, role pcap.Direction) *Replayer {
+	return &Replayer{
+		Role:       role,
+		Speed:      1,
+		RewriteXID: true,
+		Timeout:    5 * time.Second,
+		records:    records,
+	}
+}
+
+// firstXID is where fresh xids start, away from those usually recorded
+const firstXID = 0x10000000
+
+type replay struct {
+	mu      sync.Mutex
+	changed chan struct{}
+	closed  bool
+
+	peers      []*Record
+	matched    []bool
+	peerXID    map[uint32]uint32
+	ownXID     map[uint32]uint32
+	nextXID    uint32
+	received   int
+	unexpected [][]byte
+
+	wmu sync.Mutex
+	w   io.Writer
+}
+
+// notify wakes up waiters, called with mu held
+func (p *replay) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// wait blocks until cond holds, the peer is gone or the deadline passes
+func (p *replay) wait(cond func() bool, deadline time.Time) bool {
+	for {
+		p.mu.Lock()
+		ok, closed, changed := cond(), p.closed, p.changed
+		p.mu.Unlock()
+		if ok || closed {
+			return ok
+		}
+		remaining := deadline.Sub(time.Now())
+		if remaining <= 0 {
+			return false
+		}
+		select {
+		case <-changed:
+		case <-time.After(remaining):
+		}
+	}
+}
+
+func (p *replay) write(data []byte) error {
+	p.wmu.Lock()
+	defer p.wmu.Unlock()
+	_, err := p.w.Write(data)
+	return err
+}
+
+func (p *replay) read(r io.Reader) {
+	for {
+		data, err := openflow.ReadMessage(r)
+		p.mu.Lock()
+		if err != nil {
+			p.closed = true
+			p.notify()
+			p.mu.Unlock()
+			return
+		}
+		p.received++
+		found := false
+		for i, rec := range p.peers {
+			// tolerate reordering between messages of different types
+			if p.matched[i] || rec.Data[1] != data[1] {
+				continue
+			}
+			p.matched[i] = true
+			recorded := binary.BigEndian.Uint32(rec.Data[4:8])
+			if _, ok := p.peerXID[recorded]; !ok {
+				p.peerXID[recorded] = binary.BigEndian.Uint32(data[4:8])
+			}
+			found = true
+			break
+		}
+		if !found {
+			p.unexpected = append(p.unexpected, data)
+		}
+		p.notify()
+		p.mu.Unlock()
+		// keep the peer from timing out the session
+		if !found && data[0] == openflow.OF10_VERSION && data[1] == v10.OFPT_ECHO_REQUEST {
+			reply := append([]byte{}, data...)
+			reply[1] = v10.OFPT_ECHO_REPLY
+			p.write(reply)
+		}
+	}
+}
+
+// Replay plays the session over conn, it returns once every message
+// was sent and the peer messages arrived or timed out
+func (r *Replayer) Replay(conn io.ReadWriter) (*Report, error) {
+	p := &replay{
+		changed: make(chan struct{}),
+		peerXID: make(map[uint32]uint32),
+		ownXID:  make(map[uint32]uint32),
+		nextXID: firstXID,
+		w:       conn,
+	}
+	var own []*Record
+	// own messages answering a previous peer message
+	replies := make(map[*Record]bool)
+	peerXIDs := make(map[uint32]bool)
+	for _, rec := range r.records {
+		if rec.Direction == r.Role {
+			own = append(own, rec)
+			replies[rec] = peerXIDs[binary.BigEndian.Uint32(rec.Data[4:8])]
+		} else {
+			p.peers = append(p.peers, rec)
+			peerXIDs[binary.BigEndian.Uint32(rec.Data[4:8])] = true
+		}
+	}
+	p.matched = make([]bool, len(p.peers))
+	go p.read(conn)
+
+	report := &Report{}
+	start := time.Now()
+	for _, rec := range own {
+		if r.Speed > 0 {
+			offset := time.Duration(float64(rec.Time.Sub(r.records[0].Time)) / r.Speed)
+			time.Sleep(start.Add(offset).Sub(time.Now()))
+		}
+		data := append([]byte{}, rec.Data...)
+		if r.RewriteXID {
+			p.rewrite(data, replies[rec], r.Timeout)
+		}
+		if err := p.write(data); err != nil {
+			return report, err
+		}
+		report.Sent++
+	}
+
+	p.wait(func() bool {
+		for _, m := range p.matched {
+			if !m {
+				return false
+			}
+		}
+		return true
+	}, time.Now().Add(r.Timeout))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	report.Received = p.received
+	report.Unexpected = p.unexpected
+	for i, m := range p.matched {
+		if !m {
+			report.Missing = append(report.Missing, p.peers[i])
+		}
+	}
+	return report, nil
+}
+
+// rewrite replaces the xid of an outgoing message, replies wait for
+// the peer message they answer to learn its live xid
+func (p *replay) rewrite(data []byte, reply bool, timeout time.Duration) {
+	recorded := binary.BigEndian.Uint32(data[4:8])
+	if reply {
+		p.wait(func() bool {
+			_, ok := p.peerXID[recorded]
+			return ok
+		}, time.Now().Add(timeout))
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	binary.BigEndian.PutUint32(data[4:8], p.xid(recorded, reply))
+	// errors carry the header of the failed request
+	if data[0] == openflow.OF10_VERSION && data[1] == v10.OFPT_ERROR && len(data) >= 20 {
+		embedded := binary.BigEndian.Uint32(data[16:20])
+		if live, ok := p.peerXID[embedded]; ok {
+			binary.BigEndian.PutUint32(data[16:20], live)
+		}
+	}
+}
+
+// xid maps a recorded xid to the live one, called with mu held
+func (p *replay) xid(recorded uint32, reply bool) uint32 {
+	if reply {
+		if live, ok := p.peerXID[recorded]; ok {
+			return live
+		}
+		// the peer never sent it, keep the recorded value
+		return recorded
+	}
+	if live, ok := p.ownXID[recorded]; ok {
+		return live
+	}
+	live := p.nextXID
+	p.nextXID++
+	p.ownXID[recorded] = live
+	return live
+}
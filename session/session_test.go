@@ -0,0 +1,134 @@
+package session
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/pcap"
+	"net"
+	"testing"
+	"time"
+)
+
+func marshal(t *testing.T, msg interface {
+	MarshalBinary() ([]byte, error)
+}) []byte {
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func xidOf(data []byte) uint32 {
+	return binary.BigEndian.Uint32(data[4:8])
+}
+
+// controller sends hello and a features request then waits for the reply
+func controller(t *testing.T, conn net.Conn, xid uint32) []byte {
+	conn.Write(marshal(t, v10.NewHello(xid)))
+	conn.Write(marshal(t, v10.NewFeatureRequest(xid+1)))
+	for {
+		data, err := openflow.ReadMessage(conn)
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+		if data[1] == v10.OFPT_FEATURES_REPLY {
+			return data
+		}
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	ctrlLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctrlLn.Close()
+	go func() {
+		conn, err := ctrlLn.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		controller(t, conn, 100)
+	}()
+
+	var buf bytes.Buffer
+	recorder := NewRecorder(ctrlLn.Addr().String(), NewWriter(&buf))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error)
+	go func() { served <- recorder.Serve(ln) }()
+
+	// the switch answers the features request and waits for the end
+	sw, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sw.Write(marshal(t, v10.NewHello(1)))
+	for {
+		data, err := openflow.ReadMessage(sw)
+		if err != nil {
+			break
+		}
+		if data[1] == v10.OFPT_FEATURES_REQUEST {
+			reply := v10.NewFeatureReply(xidOf(data))
+			reply.SetDPID(42)
+			sw.Write(marshal(t, reply))
+		}
+	}
+	sw.Close()
+	ln.Close()
+	<-served
+
+	records, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(records))
+	}
+	last := records[3]
+	if last.Direction != pcap.FromSwitch || last.Data[1] != v10.OFPT_FEATURES_REPLY || xidOf(last.Data) != 101 {
+		t.Errorf("unexpected last record %s %x", last.Direction, last.Data)
+	}
+
+	// replay the switch against a controller using other xids
+	a, b := net.Pipe()
+	defer a.Close()
+	replayer := NewReplayer(records, pcap.FromSwitch)
+	replayer.Speed = 0
+	done := make(chan *Report)
+	go func() {
+		report, err := replayer.Replay(a)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- report
+	}()
+	go func() {
+		reply := controller(t, b, 500)
+		if reply != nil && xidOf(reply) != 501 {
+			t.Errorf("reply xid %d, expected 501", xidOf(reply))
+		}
+		// drain until the replayer is done
+		for {
+			if _, err := openflow.ReadMessage(b); err != nil {
+				return
+			}
+		}
+	}()
+	select {
+	case report := <-done:
+		if report.Sent != 2 || len(report.Missing) != 0 || len(report.Unexpected) != 0 {
+			t.Errorf("unexpected report %+v", report)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("replay timed out")
+	}
+}
@@ -0,0 +1,242 @@
+package softswitch
+
+import (
+	"encoding/binary"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/packet"
+)
+
+// output is where an output or enqueue action sends a frame
+type output struct {
+	port  uint16
+	queue uint32
+	// maxLen is how much of the frame an output to the controller sends
+	maxLen uint16
+	// enqueue tells whether queue is set
+	enqueue bool
+}
+
+// validateActions returns the OFPBAC code of the first invalid action,
+// only packet outs may output to the flow table
+func (s *Switch) validateActions(actions []openflow.Action, packetOut bool) (uint16, bool) {
+	for _, a := range actions {
+		switch a.Type() {
+		case v10.OFPAT_OUTPUT:
+			port := a.(v10.ActionOutput).Port()
+			if port == uint16(openflow.Table) && !packetOut {
+				return v10.OFPBAC_BAD_OUT_PORT, false
+			}
+			if port >= uint16(openflow.Max) && port != uint16(openflow.InPort) &&
+				port != uint16(openflow.Table) && port != uint16(openflow.Flood) &&
+				port != uint16(openflow.All) && port != uint16(openflow.Controller) &&
+				port != uint16(openflow.Local) {
+				return v10.OFPBAC_BAD_OUT_PORT, false
+			}
+		case v10.OFPAT_SET_VLAN_VID:
+			if a.(v10.ActionSetVLANVID).VLANVID() > 0x0fff {
+				return v10.OFPBAC_BAD_ARGUMENT, false
+			}
+		case v10.OFPAT_SET_VLAN_PCP:
+			if a.(v10.ActionSetVLANPCP).VLANPCP() > 7 {
+				return v10.OFPBAC_BAD_ARGUMENT, false
+			}
+		case v10.OFPAT_ENQUEUE:
+			enq := a.(v10.ActionEnqueue)
+			if enq.Port() == uint16(openflow.InPort) {
+				continue
+			}
+			port, ok := s.ports[enq.Port()]
+			if !ok {
+				return v10.OFPBAC_BAD_OUT_PORT, false
+			}
+			if _, ok := port.queues[enq.QueueID()]; !ok {
+				return v10.OFPBAC_BAD_QUEUE, false
+			}
+		case v10.OFPAT_VENDOR:
+			return v10.OFPBAC_BAD_VENDOR, false
+		case v10.OFPAT_STRIP_VLAN, v10.OFPAT_SET_DL_SRC, v10.OFPAT_SET_DL_DST,
+			v10.OFPAT_SET_NW_SRC, v10.OFPAT_SET_NW_DST, v10.OFPAT_SET_NW_TOS,
+			v10.OFPAT_SET_TP_SRC, v10.OFPAT_SET_TP_DST:
+		default:
+			return v10.OFPBAC_BAD_TYPE, false
+		}
+	}
+	return 0, true
+}
+
+// execute applies actions to a copy of frame in order, outputs see the
+// frame as modified by the actions before them
+func execute(actions []openflow.Action, frame []byte, out func([]byte, output)) {
+	frame = append([]byte(nil), frame...)
+	for _, a := range actions {
+		switch a.Type() {
+		case v10.OFPAT_OUTPUT:
+			act := a.(v10.ActionOutput)
+			out(append([]byte(nil), frame...), output{port: act.Port(), maxLen: act.MaxLen()})
+		case v10.OFPAT_ENQUEUE:
+			act := a.(v10.ActionEnqueue)
+			out(append([]byte(nil), frame...), output{port: act.Port(), queue: act.QueueID(), enqueue: true})
+		case v10.OFPAT_SET_VLAN_VID:
+			vid := a.(v10.ActionSetVLANVID).VLANVID()
+			frame = setVLAN(frame, func(tci uint16) uint16 { return tci&0xf000 | vid&0x0fff })
+		case v10.OFPAT_SET_VLAN_PCP:
+			pcp := uint16(a.(v10.ActionSetVLANPCP).VLANPCP())
+			frame = setVLAN(frame, func(tci uint16) uint16 { return tci&0x0fff | pcp<<13 })
+		case v10.OFPAT_STRIP_VLAN:
+			frame = stripVLAN(frame)
+		case v10.OFPAT_SET_DL_SRC:
+			if len(frame) >= 14 {
+				copy(frame[6:12], a.(v10.ActionSetDLSrc).DLSrc())
+			}
+		case v10.OFPAT_SET_DL_DST:
+			if len(frame) >= 14 {
+				copy(frame[0:6], a.(v10.ActionSetDLDst).DLDst())
+			}
+		case v10.OFPAT_SET_NW_SRC:
+			setNW(frame, 12, a.(v10.ActionSetNWSrc).NWSrc().To4())
+		case v10.OFPAT_SET_NW_DST:
+			setNW(frame, 16, a.(v10.ActionSetNWDst).NWDst().To4())
+		case v10.OFPAT_SET_NW_TOS:
+			setTOS(frame, a.(v10.ActionSetNWTos).NWTos())
+		case v10.OFPAT_SET_TP_SRC:
+			setTP(frame, 0, a.(v10.ActionSetTPSrc).Port())
+		case v10.OFPAT_SET_TP_DST:
+			setTP(frame, 2, a.(v10.ActionSetTPDst).Port())
+		}
+	}
+}
+
+// l3 returns the offset of the network header and the ethernet type
+func l3(frame []byte) (int, uint16) {
+	if len(frame) < 14 {
+		return len(frame), 0
+	}
+	etherType := binary.BigEndian.Uint16(frame[12:14])
+	if etherType == packet.EtherTypeVLAN && len(frame) >= 18 {
+		return 18, binary.BigEndian.Uint16(frame[16:18])
+	}
+	return 14, etherType
+}
+
+// setVLAN rewrites the tag control information of a frame, untagged
+// frames get a tag
+func setVLAN(frame []byte, tci func(uint16) uint16) []byte {
+	if len(frame) < 14 {
+		return frame
+	}
+	if binary.BigEndian.Uint16(frame[12:14]) != packet.EtherTypeVLAN {
+		tagged := make([]byte, len(frame)+4)
+		copy(tagged, frame[:12])
+		binary.BigEndian.PutUint16(tagged[12:14], packet.EtherTypeVLAN)
+		copy(tagged[16:], frame[12:])
+		frame = tagged
+	}
+	binary.BigEndian.PutUint16(frame[14:16], tci(binary.BigEndian.Uint16(frame[14:16])))
+	return frame
+}
+
+func stripVLAN(frame []byte) []byte {
+	if len(frame) < 18 || binary.BigEndian.Uint16(frame[12:14]) != packet.EtherTypeVLAN {
+		return frame
+	}
+	return append(frame[:12], frame[16:]...)
+}
+
+// adjustChecksum updates an internet checksum for data changed from old
+// to new as in RFC 1624, both of even length
+func adjustChecksum(sum uint16, old, new []byte) uint16 {
+	acc := uint32(^sum)
+	for i := 0; i+1 < len(old); i += 2 {
+		acc += uint32(^binary.BigEndian.Uint16(old[i:]) & 0xffff)
+		acc += uint32(binary.BigEndian.Uint16(new[i:]))
+	}
+	for acc > 0xffff {
+		acc = acc>>16 + acc&0xffff
+	}
+	return ^uint16(acc)
+}
+
+// ipv4 returns the offsets of the IPv4 and transport headers of a frame,
+// the transport offset is zero for fragments and unknown protocols
+func ipv4(frame []byte) (int, int) {
+	nw, etherType := l3(frame)
+	if etherType != packet.EtherTypeIPv4 || len(frame) < nw+20 {
+		return 0, 0
+	}
+	ihl := int(frame[nw]&0x0f) * 4
+	if ihl < 20 || len(frame) < nw+ihl {
+		return 0, 0
+	}
+	if binary.BigEndian.Uint16(frame[nw+6:nw+8])&0x1fff != 0 {
+		return nw, 0
+	}
+	return nw, nw + ihl
+}
+
+// tpChecksum returns the offset of the transport checksum, zero if it
+// must not be updated
+func tpChecksum(frame []byte, nw, tp int) int {
+	if tp == 0 {
+		return 0
+	}
+	switch frame[nw+9] {
+	case packet.IPProtocolTCP:
+		if len(frame) >= tp+18 {
+			return tp + 16
+		}
+	case packet.IPProtocolUDP:
+		// a zero UDP checksum is not computed
+		if len(frame) >= tp+8 && binary.BigEndian.Uint16(frame[tp+6:tp+8]) != 0 {
+			return tp + 6
+		}
+	}
+	return 0
+}
+
+func updateChecksum(frame []byte, off int, old, new []byte) {
+	sum := adjustChecksum(binary.BigEndian.Uint16(frame[off:off+2]), old, new)
+	binary.BigEndian.PutUint16(frame[off:off+2], sum)
+}
+
+// setNW rewrites an IPv4 address at offset off of the IP header
+func setNW(frame []byte, off int, ip []byte) {
+	nw, tp := ipv4(frame)
+	if nw == 0 || len(ip) != 4 {
+		return
+	}
+	old := append([]byte(nil), frame[nw+off:nw+off+4]...)
+	copy(frame[nw+off:nw+off+4], ip)
+	updateChecksum(frame, nw+10, old, ip)
+	// the pseudo header of TCP and UDP includes the addresses
+	if sum := tpChecksum(frame, nw, tp); sum != 0 {
+		updateChecksum(frame, sum, old, ip)
+	}
+}
+
+// setTOS rewrites the DSCP bits of an IPv4 packet
+func setTOS(frame []byte, tos uint8) {
+	nw, _ := ipv4(frame)
+	if nw == 0 {
+		return
+	}
+	old := []byte{frame[nw], frame[nw+1]}
+	frame[nw+1] = frame[nw+1]&0x03 | tos&0xfc
+	updateChecksum(frame, nw+10, old, frame[nw:nw+2])
+}
+
+// setTP rewrites a TCP or UDP port at offset off of the transport header
+func setTP(frame []byte, off int, port uint16) {
+	nw, tp := ipv4(frame)
+	if tp == 0 || len(frame) < tp+4 {
+		return
+	}
+	if proto := frame[nw+9]; proto != packet.IPProtocolTCP && proto != packet.IPProtocolUDP {
+		return
+	}
+	old := append([]byte(nil), frame[tp+off:tp+off+2]...)
+	binary.BigEndian.PutUint16(frame[tp+off:tp+off+2], port)
+	if sum := tpChecksum(frame, nw, tp); sum != 0 {
+		updateChecksum(frame, sum, old, frame[tp+off:tp+off+2])
+	}
+}
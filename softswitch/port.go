@@ -0,0 +1,146 @@
+package softswitch
+
+import (
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"net"
+	"sort"
+	"sync/atomic"
+)
+
+// Endpoint receives frames, such as a switch port or a host
+type Endpoint interface {
+	Input(frame []byte)
+}
+
+// EndpointFunc is a function receiving frames
+type EndpointFunc func(frame []byte)
+
+func (f EndpointFunc) Input(frame []byte) {
+	f(frame)
+}
+
+type portCounters struct {
+	rxPackets, txPackets uint64
+	rxBytes, txBytes     uint64
+	rxDropped, txDropped uint64
+	rxErrors             uint64
+}
+
+type queue struct {
+	id                 uint32
+	minRate            uint16
+	txPackets, txBytes uint64
+	txErrors           uint64
+}
+
+// Port is a virtual port of a switch, frames it transmits are delivered
+// to the endpoint attached to it
+type Port struct {
+	sw     *Switch
+	no     uint16
+	hwAddr net.HardwareAddr
+	name   string
+
+	// guarded by the switch
+	config   openflow.PortConfig
+	peer     Endpoint
+	counters portCounters
+	queues   map[uint32]*queue
+	// dropped counts frames dropped because the switch did not keep up
+	dropped uint64
+}
+
+// Number returns the port number
+func (p *Port) Number() uint16 {
+	return p.no
+}
+
+// HWAddr returns the hardware address of the port
+func (p *Port) HWAddr() net.HardwareAddr {
+	return p.hwAddr
+}
+
+// Input queues a frame received by the port, frames are dropped when
+// the switch does not keep up
+func (p *Port) Input(frame []byte) {
+	select {
+	case p.sw.frames <- received{port: p, data: frame}:
+	case <-p.sw.done:
+	default:
+		atomic.AddUint64(&p.dropped, 1)
+	}
+}
+
+// Attach connects an endpoint to the port and brings its link up, a nil
+// endpoint brings the link down
+func (p *Port) Attach(e Endpoint) {
+	p.sw.mu.Lock()
+	changed := (p.peer == nil) != (e == nil)
+	p.peer = e
+	status := p.sw.portStatus(p, openflow.PortModified)
+	p.sw.mu.Unlock()
+	if changed {
+		p.sw.send(status)
+	}
+}
+
+// Detach brings the link of the port down
+func (p *Port) Detach() {
+	p.Attach(nil)
+}
+
+// AddQueue configures a queue on the port with a minimum rate in tenth
+// of percent
+func (p *Port) AddQueue(id uint32, minRate uint16) {
+	p.sw.mu.Lock()
+	defer p.sw.mu.Unlock()
+	p.queues[id] = &queue{id: id, minRate: minRate}
+}
+
+// state returns the openflow description of the port
+func (p *Port) state() openflow.Port {
+	port, _ := v10.NewPort(openflow.PortID(p.no), p.hwAddr, p.name)
+	port.SetConfig(p.config)
+	if p.peer == nil {
+		port.SetState(openflow.LinkDown)
+	}
+	features := openflow.FD_10GB | openflow.Copper
+	port.SetCurr(features)
+	port.SetSupported(features)
+	port.SetAdvertised(features)
+	return port
+}
+
+// up tells whether the port is administratively and physically up
+func (p *Port) up() bool {
+	return p.config&openflow.PortDown == 0 && p.peer != nil
+}
+
+// transmit sends a frame out of the port and tells whether it was sent
+func (p *Port) transmit(frame []byte) bool {
+	if !p.up() || p.config&openflow.NoFwd != 0 {
+		p.counters.txDropped++
+		return false
+	}
+	p.counters.txPackets++
+	p.counters.txBytes += uint64(len(frame))
+	// flooded frames are shared by every port
+	p.peer.Input(append([]byte(nil), frame...))
+	return true
+}
+
+func (p *Port) sortedQueues() []*queue {
+	v := make([]*queue, 0, len(p.queues))
+	for _, q := range p.queues {
+		v = append(v, q)
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].id < v[j].id })
+	return v
+}
+
+// Link connects two ports with a virtual cable
+func Link(a, b *Port) {
+	a.Attach(b)
+	b.Attach(a)
+}
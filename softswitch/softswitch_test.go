@@ -0,0 +1,394 @@
+package softswitch
+
+import (
+	"bytes"
+	"github.com/ksang/goflow/agent"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"github.com/ksang/goflow/packet"
+	"net"
+	"testing"
+	"time"
+)
+
+var (
+	hostA = net.HardwareAddr{0x0a, 0, 0, 0, 0, 1}
+	hostB = net.HardwareAddr{0x0a, 0, 0, 0, 0, 2}
+)
+
+// events records the asynchronous messages of the controller
+type events chan openflow.MessageDecoder
+
+func (e events) PacketIn(dp *controller.Datapath, msg openflow.PacketIn) {
+	e <- msg
+}
+
+func (e events) FlowRemoved(dp *controller.Datapath, msg openflow.FlowRemoved) {
+	e <- msg
+}
+
+func (e events) PortStatus(dp *controller.Datapath, msg openflow.PortStatus) {
+	e <- msg
+}
+
+func (e events) next(t *testing.T) openflow.MessageDecoder {
+	select {
+	case msg := <-e:
+		return msg
+	case <-time.After(3 * time.Second):
+		t.Fatal("no event")
+	}
+	return nil
+}
+
+// host captures the frames a port transmits
+type host chan []byte
+
+func (h host) Input(frame []byte) {
+	h <- frame
+}
+
+func (h host) next(t *testing.T) []byte {
+	select {
+	case frame := <-h:
+		return frame
+	case <-time.After(time.Second):
+		t.Fatal("no frame")
+	}
+	return nil
+}
+
+// setup connects a switch with two ports attached to hosts to a
+// controller
+func setup(t *testing.T) (*Switch, *controller.Datapath, events, []host) {
+	s := New(1)
+	hosts := []host{make(host, 16), make(host, 16)}
+	for i, h := range hosts {
+		p, err := s.AddPort(uint16(i+1), "eth")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Attach(h)
+	}
+	c := controller.New()
+	ev := make(events, 16)
+	c.Register(ev)
+	a, b := net.Pipe()
+	go c.ServeConn(a)
+	go s.ServeConn(b)
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if dp, ok := c.Datapath(1); ok {
+			return s, dp, ev, hosts
+		}
+	}
+	t.Fatal("switch not connected")
+	return nil, nil, nil, nil
+}
+
+// udpSum returns the checksum of a datagram with its pseudo header, zero
+// when its checksum field is valid
+func udpSum(src, dst net.IP, udp []byte) uint16 {
+	pseudo := append(append(append([]byte{}, src.To4()...), dst.To4()...), 0, packet.IPProtocolUDP, byte(len(udp)>>8), byte(len(udp)))
+	return packet.Checksum(append(pseudo, udp...), 0)
+}
+
+func udpFrame(t *testing.T, src, dst net.HardwareAddr) []byte {
+	udp, _ := (&packet.UDP{SrcPort: 1000, DstPort: 53, Payload: []byte("query")}).MarshalBinary()
+	sum := udpSum(net.IP{10, 0, 0, 1}, net.IP{10, 0, 0, 2}, udp)
+	udp[6], udp[7] = byte(sum>>8), byte(sum)
+	ip, err := (&packet.IPv4{
+		TTL:      64,
+		Protocol: packet.IPProtocolUDP,
+		Src:      net.IP{10, 0, 0, 1},
+		Dst:      net.IP{10, 0, 0, 2},
+		Payload:  udp,
+	}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame, err := packet.NewEthernet(src, dst, packet.EtherTypeIPv4, ip).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frame
+}
+
+func TestFeatures(t *testing.T) {
+	s, dp, _, _ := setup(t)
+	defer s.Close()
+	if len(dp.Ports()) != 2 || dp.Features().NumTables() != 1 {
+		t.Errorf("unexpected features %v", dp.Features())
+	}
+	if p, ok := dp.Port(1); !ok || p.State()&openflow.LinkDown != 0 {
+		t.Errorf("port 1 not up")
+	}
+	replies, err := dp.Request(v10.NewStatsRequestDescription(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc := replies[0].(v10.StatsReplyDescription); !bytes.HasPrefix(desc.DpDesc()[:], []byte("softswitch")) {
+		t.Errorf("unexpected description %q", desc.DpDesc()[:16])
+	}
+	replies, err = dp.Request(v10.NewStatsReuqestTable(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tables := replies[0].(v10.StatsReplyTable).Tables(); len(tables) != 1 || tables[0].Name() != "classifier" {
+		t.Errorf("unexpected tables %v", tables)
+	}
+}
+
+func TestForwarding(t *testing.T) {
+	s, dp, ev, hosts := setup(t)
+	defer s.Close()
+
+	// table miss
+	frame := udpFrame(t, hostA, hostB)
+	p1, _ := s.Port(1)
+	p1.Input(frame)
+	pi, ok := ev.next(t).(openflow.PacketIn)
+	if !ok || pi.Reason() != v10.OFPR_NO_MATCH || pi.InPort() != 1 || !bytes.Equal(pi.Data(), frame) {
+		t.Fatalf("unexpected packet in %v", pi)
+	}
+
+	fm := v10.NewFlowMod(dp.NextXID())
+	match := v10.NewMatch()
+	match.SetInPort(1)
+	fm.SetMatch(match)
+	fm.SetFlags(openflow.SendFlowRem)
+	nw := v10.NewActionSetNWDst()
+	nw.SetNWDst(net.IP{10, 0, 0, 3})
+	tp := v10.NewActionSetTPDst()
+	tp.SetPort(5353)
+	vlan := v10.NewActionSetVLANVID()
+	vlan.SetVLANVID(10)
+	out := v10.NewActionOutput()
+	out.SetPort(2)
+	for _, a := range []openflow.Action{nw, tp, vlan, out} {
+		fm.AddAction(a)
+	}
+	if err := dp.Send(fm); err != nil {
+		t.Fatal(err)
+	}
+	if err := dp.Barrier(); err != nil {
+		t.Fatal(err)
+	}
+	p1.Input(frame)
+
+	var eth packet.Ethernet
+	var ip packet.IPv4
+	var udp packet.UDP
+	if err := eth.UnmarshalBinary(hosts[1].next(t)); err != nil {
+		t.Fatal(err)
+	}
+	if eth.VLANID != 10 || ip.UnmarshalBinary(eth.Payload) != nil || udp.UnmarshalBinary(ip.Payload) != nil {
+		t.Fatalf("unexpected frame %v", eth)
+	}
+	if !ip.Dst.Equal(net.IP{10, 0, 0, 3}) || udp.DstPort != 5353 {
+		t.Errorf("unexpected rewrite %v %d", ip.Dst, udp.DstPort)
+	}
+	header := eth.Payload[:20]
+	if packet.Checksum(header, 0) != 0 {
+		t.Errorf("invalid IP checksum")
+	}
+	if udpSum(ip.Src, ip.Dst, ip.Payload) != 0 {
+		t.Errorf("invalid UDP checksum")
+	}
+
+	req := v10.NewStatsReuqestFlow(0)
+	req.SetTableID(0xff)
+	req.SetOutPort(uint16(openflow.None))
+	replies, err := dp.Request(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flows := replies[0].(v10.StatsReplyFlow).Flows()
+	if len(flows) != 1 || flows[0].PacketCount() != 1 || flows[0].ByteCount() != uint64(len(frame)) {
+		t.Errorf("unexpected flow stats %v", flows)
+	}
+
+	del := v10.NewFlowMod(dp.NextXID())
+	del.SetCommand(openflow.Delete)
+	del.SetOutPort(uint16(openflow.None))
+	dp.Send(del)
+	fr, ok := ev.next(t).(openflow.FlowRemoved)
+	if !ok || fr.Reason() != v10.OFPRR_DELETE || fr.PacketCount() != 1 {
+		t.Errorf("unexpected flow removed %v", fr)
+	}
+}
+
+func TestPacketOut(t *testing.T) {
+	s, dp, _, hosts := setup(t)
+	defer s.Close()
+	frame := udpFrame(t, hostA, hostB)
+	po := v10.NewPacketOut(dp.NextXID())
+	po.SetBufferID(v10.OFP_NO_BUFFER)
+	po.SetInPort(uint16(openflow.Controller))
+	out := v10.NewActionOutput()
+	out.SetPort(uint16(openflow.Flood))
+	po.AddAction(out)
+	po.SetData(frame)
+	if err := dp.Send(po); err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range hosts {
+		if !bytes.Equal(h.next(t), frame) {
+			t.Error("unexpected flooded frame")
+		}
+	}
+
+	// only OFPP_IN_PORT outputs to the ingress port
+	back := v10.NewPacketOut(dp.NextXID())
+	back.SetBufferID(v10.OFP_NO_BUFFER)
+	back.SetInPort(1)
+	for _, port := range []openflow.PortID{1, openflow.InPort, 2} {
+		out := v10.NewActionOutput()
+		out.SetPort(uint16(port))
+		back.AddAction(out)
+	}
+	back.SetData(frame)
+	if err := dp.Send(back); err != nil {
+		t.Fatal(err)
+	}
+	hosts[1].next(t)
+	if len(hosts[0]) != 1 {
+		t.Errorf("%d frames sent back to the ingress port", len(hosts[0]))
+	}
+	hosts[0].next(t)
+
+	po.SetBufferID(7)
+	_, err := dp.Request(po)
+	if e, ok := err.(*controller.RequestError); !ok || e.Msg.Code() != v10.OFPBRC_BUFFER_UNKNOWN {
+		t.Errorf("unknown buffer answered %v", err)
+	}
+}
+
+func TestBuffers(t *testing.T) {
+	s, dp, ev, hosts := setup(t)
+	defer s.Close()
+	if dp.Features().NumBuffers() == 0 {
+		t.Error("no buffers announced")
+	}
+	frame := udpFrame(t, hostA, hostB)
+	p1, _ := s.Port(1)
+	p1.Input(frame)
+	pi := ev.next(t).(openflow.PacketIn)
+	if pi.BufferID() == v10.OFP_NO_BUFFER || !controller.Complete(pi) {
+		t.Fatalf("unexpected packet in %v", pi)
+	}
+	out := v10.NewActionOutput()
+	out.SetPort(2)
+	if err := dp.PacketOut(pi, out); err != nil {
+		t.Fatal(err)
+	}
+	hosts[1].next(t)
+	// the buffer is used, the data of the packet in is sent instead
+	if err := dp.PacketOut(pi, out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hosts[1].next(t), frame) {
+		t.Error("unexpected frame")
+	}
+
+	config := v10.NewSetConfig(0)
+	config.SetMissSendLength(16)
+	dp.Send(config)
+	p1.Input(frame)
+	pi = ev.next(t).(openflow.PacketIn)
+	if len(pi.Data()) != 16 || int(pi.TotalLength()) != len(frame) || controller.Complete(pi) {
+		t.Fatalf("packet in not truncated %d %d", len(pi.Data()), pi.TotalLength())
+	}
+	fm := v10.NewFlowMod(0)
+	fm.SetBufferID(pi.BufferID())
+	fm.AddAction(out)
+	if err := dp.Send(fm); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hosts[1].next(t), frame) {
+		t.Error("buffered frame not forwarded by the flow")
+	}
+	if err := dp.PacketOut(pi, out); !controller.BufferLost(err) {
+		t.Errorf("truncated packet in of a used buffer answered %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	s, dp, _, _ := setup(t)
+	defer s.Close()
+	fm := v10.NewFlowMod(0)
+	out := v10.NewActionOutput()
+	out.SetPort(uint16(openflow.Table))
+	fm.AddAction(out)
+	_, err := dp.Request(fm)
+	if e, ok := err.(*controller.RequestError); !ok || e.Msg.Type() != v10.OFPET_BAD_ACTION || e.Msg.Code() != v10.OFPBAC_BAD_OUT_PORT {
+		t.Errorf("output to table answered %v", err)
+	}
+	pm := v10.NewPortMod(0)
+	pm.SetPort(9)
+	_, err = dp.Request(pm)
+	if e, ok := err.(*controller.RequestError); !ok || e.Msg.Code() != v10.OFPPMFC_BAD_PORT {
+		t.Errorf("unknown port answered %v", err)
+	}
+}
+
+func TestHardTimeout(t *testing.T) {
+	s, dp, ev, _ := setup(t)
+	defer s.Close()
+	fm := v10.NewFlowMod(0)
+	fm.SetHardTimeout(1)
+	fm.SetFlags(openflow.SendFlowRem)
+	dp.Send(fm)
+	if err := dp.Barrier(); err != nil {
+		t.Fatal(err)
+	}
+	fr, ok := ev.next(t).(openflow.FlowRemoved)
+	if !ok || fr.Reason() != v10.OFPRR_HARD_TIMEOUT || fr.DurationSec() != 1 {
+		t.Errorf("unexpected flow removed %v", fr)
+	}
+}
+
+func TestFailMode(t *testing.T) {
+	s, dp, ev, hosts := setup(t)
+	defer s.Close()
+	s.Agent().FailMode = agent.FailStandalone
+	dp.Close()
+	for deadline := time.Now().Add(time.Second); s.Agent().Connected() != 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("controller still connected")
+		}
+	}
+	p1, _ := s.Port(1)
+	p2, _ := s.Port(2)
+	frame := udpFrame(t, hostA, hostB)
+	p1.Input(frame)
+	if !bytes.Equal(hosts[1].next(t), frame) {
+		t.Error("unexpected flooded frame")
+	}
+	// hostA was learned on port 1
+	p2.Input(udpFrame(t, hostB, hostA))
+	hosts[0].next(t)
+	select {
+	case msg := <-ev:
+		t.Errorf("controller got %v", msg)
+	case frame := <-hosts[1]:
+		t.Errorf("frame flooded back %x", frame)
+	default:
+	}
+}
+
+func TestPortStatus(t *testing.T) {
+	s, dp, ev, _ := setup(t)
+	defer s.Close()
+	p, _ := s.Port(2)
+	p.Detach()
+	ps, ok := ev.next(t).(openflow.PortStatus)
+	if !ok || ps.Reason() != openflow.PortModified || ps.Port().State()&openflow.LinkDown == 0 {
+		t.Errorf("unexpected port status %v", ps)
+	}
+	s.AddPort(3, "eth3")
+	ev.next(t)
+	if _, ok := dp.Port(3); !ok {
+		t.Error("added port unknown to the controller")
+	}
+}
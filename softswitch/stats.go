@@ -0,0 +1,151 @@
+package softswitch
+
+import (
+	"github.com/ksang/goflow/flowtable"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"sync/atomic"
+	"time"
+)
+
+// description strings of description stats
+const (
+	manufacturer = "goflow"
+	hardware     = "software switch"
+	software     = "goflow softswitch"
+)
+
+func descString(s string) *[256]byte {
+	var v [256]byte
+	// keep the terminating null
+	copy(v[:255], s)
+	return &v
+}
+
+// stats answers a stats request
+func (s *Switch) stats(req openflow.StatsRequest, data []byte) []openflow.MessageDecoder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	xid := req.TransactionID()
+	now := time.Now()
+	switch req.Type() {
+	case openflow.STATS_Description:
+		reply := v10.NewStatsReplyDescription(xid)
+		reply.SetMfrDesc(descString(manufacturer))
+		reply.SetHwDesc(descString(hardware))
+		reply.SetSwDesc(descString(software))
+		reply.SetDpDesc(descString(s.Description))
+		return []openflow.MessageDecoder{reply}
+	case openflow.STATS_Flow:
+		return s.flowStats(req.(v10.StatsRequestFlow), now)
+	case openflow.STATS_Aggregate:
+		reply := v10.NewStatsReplyAggregate(xid)
+		var packets, bytes uint64
+		flows := s.query(req.(v10.StatsRequestAggregate))
+		for _, f := range flows {
+			packets += f.Packets
+			bytes += f.Bytes
+		}
+		reply.SetPacketCount(packets)
+		reply.SetByteCount(bytes)
+		reply.SetFlowCount(uint32(len(flows)))
+		return []openflow.MessageDecoder{reply}
+	case openflow.STATS_Table:
+		reply := v10.NewStatsReplyTable(xid)
+		reply.AddTable(s.table.Stats(0, "classifier"))
+		return []openflow.MessageDecoder{reply}
+	case openflow.STATS_Port:
+		reply := v10.NewStatsReplyPort(xid)
+		no := req.(v10.StatsRequestPort).PortNumber()
+		for _, p := range s.sortedPorts() {
+			if no == uint16(openflow.None) || no == p.no {
+				reply.AddPort(p.stats())
+			}
+		}
+		return []openflow.MessageDecoder{reply}
+	case openflow.STATS_Queue:
+		return s.queueStats(req.(v10.StatsRequestQueue), data)
+	case openflow.STATS_Vendor:
+		return []openflow.MessageDecoder{errorReply(data, v10.OFPET_BAD_REQUEST, v10.OFPBRC_BAD_VENDOR)}
+	}
+	return []openflow.MessageDecoder{errorReply(data, v10.OFPET_BAD_REQUEST, v10.OFPBRC_BAD_STAT)}
+}
+
+// flowStats answers with as many replies as the flows need, all but the
+// last one flagged with OFPSF_REPLY_MORE
+func (s *Switch) flowStats(req v10.StatsRequestFlow, now time.Time) []openflow.MessageDecoder {
+	reply := v10.NewStatsReplyFlow(req.TransactionID())
+	replies := []openflow.MessageDecoder{reply}
+	length := 0
+	for _, f := range s.query(req) {
+		entry := f.Stats(now)
+		size := 88
+		for _, a := range f.Actions {
+			size += int(a.Length())
+		}
+		if length+size > maxStatsLength {
+			reply.SetFlags(v10.OFPSF_REPLY_MORE)
+			reply = v10.NewStatsReplyFlow(req.TransactionID())
+			replies = append(replies, reply)
+			length = 0
+		}
+		reply.AddFlow(entry)
+		length += size
+	}
+	return replies
+}
+
+// query returns the flows selected by a flow or aggregate stats request
+func (s *Switch) query(req v10.StatsRequestFlow) []flowtable.Entry {
+	if req.TableID() != 0 && req.TableID() != 0xff {
+		return nil
+	}
+	return s.table.Query(req.Match(), req.OutPort())
+}
+
+func (s *Switch) queueStats(req v10.StatsRequestQueue, data []byte) []openflow.MessageDecoder {
+	reply := v10.NewStatsReplyQueue(req.TransactionID())
+	no, id := req.PortNumber(), req.QueueID()
+	if no != uint16(openflow.All) {
+		p, ok := s.ports[no]
+		if !ok {
+			return []openflow.MessageDecoder{errorReply(data, v10.OFPET_QUEUE_OP_FAILED, v10.OFPQOFC_BAD_PORT)}
+		}
+		if _, ok := p.queues[id]; !ok && id != v10.OFPQ_ALL {
+			return []openflow.MessageDecoder{errorReply(data, v10.OFPET_QUEUE_OP_FAILED, v10.OFPQOFC_BAD_QUEUE)}
+		}
+	}
+	for _, p := range s.sortedPorts() {
+		if no != uint16(openflow.All) && no != p.no {
+			continue
+		}
+		for _, q := range p.sortedQueues() {
+			if id != v10.OFPQ_ALL && id != q.id {
+				continue
+			}
+			qs := v10.NewQueueStats()
+			qs.SetPortNumber(p.no)
+			qs.SetQueueID(q.id)
+			qs.SetTxPackets(q.txPackets)
+			qs.SetTxBytes(q.txBytes)
+			qs.SetTxErrors(q.txErrors)
+			reply.AddQueue(qs)
+		}
+	}
+	return []openflow.MessageDecoder{reply}
+}
+
+// stats returns the port stats entry of the port, counters the switch
+// does not track are zero
+func (p *Port) stats() v10.PortStats {
+	ps := v10.NewPortStats()
+	ps.SetPortNumber(p.no)
+	ps.SetRxPackets(p.counters.rxPackets)
+	ps.SetTxPackets(p.counters.txPackets)
+	ps.SetRxBytes(p.counters.rxBytes)
+	ps.SetTxBytes(p.counters.txBytes)
+	ps.SetRxDropped(p.counters.rxDropped + atomic.LoadUint64(&p.dropped))
+	ps.SetTxDropped(p.counters.txDropped)
+	ps.SetRxErrors(p.counters.rxErrors)
+	return ps
+}
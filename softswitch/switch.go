@@ -0,0 +1,569 @@
+/*
+Package softswitch emulates an OpenFlow 1.0 switch in memory.
+
+A Switch connects to a controller over any net.Conn and behaves as a
+datapath would: it answers handshake, configuration, barrier and stats
+requests, keeps a flow table with the wildcard and priority semantics of
+OpenFlow 1.0, executes every v10 action, buffers the packets it sends to
+the controller, and sends packet in, flow removed and port status
+messages. Its ports are virtual, frames they
+transmit are handed to the Endpoint attached to them, which may be the
+port of another switch joined by Link or a host of a test.
+
+Frames are processed one at a time by a goroutine of the switch, so
+endpoints may inject frames from anywhere, including from Input.
+
+The switch implements agent.Datapath, its agent connects it to any number
+of controllers. Without controller it uses its emergency flows in fail
+secure mode and forwards as a learning switch in fail standalone mode.
+*/
+package softswitch
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/ksang/goflow/agent"
+	"github.com/ksang/goflow/buffer"
+	"github.com/ksang/goflow/flowtable"
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrClosed       = errors.New("switch closed")
+	ErrNotConnected = agent.ErrNotConnected
+	ErrPortExists   = errors.New("port already exists")
+	ErrInvalidPort  = errors.New("invalid port number")
+)
+
+const (
+	// frameQueueLength is how many received frames may wait for the
+	// switch before ports drop them
+	frameQueueLength = 1024
+	// expireInterval is how often flow timeouts are checked
+	expireInterval = 100 * time.Millisecond
+	maxFlows       = 1 << 16
+	// errorDataLength is how much of a failed request errors carry
+	errorDataLength = 64
+	// maxStatsLength bounds the body of a single stats reply
+	maxStatsLength = 0xffff - 12
+)
+
+type received struct {
+	port *Port
+	data []byte
+}
+
+// Switch is an emulated OpenFlow 1.0 switch
+type Switch struct {
+	// Description is the datapath description of description stats
+	Description string
+
+	dpid   uint64
+	agent  *agent.Agent
+	frames chan received
+	done   chan struct{}
+	once   sync.Once
+
+	mu          sync.Mutex
+	ports       map[uint16]*Port
+	table       *flowtable.Table
+	buffers     *buffer.Pool
+	flags       uint16
+	missSendLen uint16
+	// learned maps addresses to ports in fail standalone mode, nil
+	// otherwise
+	learned map[string]uint16
+}
+
+// New returns a switch without ports, it runs until closed
+func New(dpid uint64) *Switch {
+	s := &Switch{
+		Description: fmt.Sprintf("softswitch %s", v10.DPIDString(dpid)),
+		dpid:        dpid,
+		frames:      make(chan received, frameQueueLength),
+		done:        make(chan struct{}),
+		ports:       make(map[uint16]*Port),
+		table:       flowtable.New(maxFlows),
+		buffers:     buffer.NewPool(buffer.DefaultSize),
+		missSendLen: 128,
+	}
+	s.agent = agent.New(s)
+	go s.run()
+	return s
+}
+
+// DPID returns the datapath id of the switch
+func (s *Switch) DPID() uint64 {
+	return s.dpid
+}
+
+// AddPort adds a port, its link is down until an endpoint is attached
+func (s *Switch) AddPort(no uint16, name string) (*Port, error) {
+	if no == 0 || no >= uint16(openflow.Max) {
+		return nil, ErrInvalidPort
+	}
+	s.mu.Lock()
+	if _, ok := s.ports[no]; ok {
+		s.mu.Unlock()
+		return nil, ErrPortExists
+	}
+	p := &Port{
+		sw:     s,
+		no:     no,
+		hwAddr: net.HardwareAddr{0x02, byte(s.dpid >> 16), byte(s.dpid >> 8), byte(s.dpid), byte(no >> 8), byte(no)},
+		name:   name,
+		queues: make(map[uint32]*queue),
+	}
+	s.ports[no] = p
+	status := s.portStatus(p, openflow.PortAdded)
+	s.mu.Unlock()
+	s.send(status)
+	return p, nil
+}
+
+// RemovePort removes a port, frames still queued for it are dropped
+func (s *Switch) RemovePort(no uint16) {
+	s.mu.Lock()
+	p, ok := s.ports[no]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.ports, no)
+	status := s.portStatus(p, openflow.PortDeleted)
+	s.mu.Unlock()
+	s.send(status)
+}
+
+// Port returns the port numbered no
+func (s *Switch) Port(no uint16) (*Port, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.ports[no]
+	return p, ok
+}
+
+// Ports returns the ports sorted by number
+func (s *Switch) Ports() []*Port {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedPorts()
+}
+
+func (s *Switch) sortedPorts() []*Port {
+	v := make([]*Port, 0, len(s.ports))
+	for _, p := range s.ports {
+		v = append(v, p)
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].no < v[j].no })
+	return v
+}
+
+// Agent returns the agent connecting the switch to controllers
+func (s *Switch) Agent() *agent.Agent {
+	return s.agent
+}
+
+// Close disconnects the switch and stops it
+func (s *Switch) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	return s.agent.Close()
+}
+
+// Dial connects the switch to controllers listening on TCP addresses
+// and redials them until the switch closes
+func (s *Switch) Dial(addrs ...string) {
+	s.agent.Dial(addrs...)
+}
+
+// DialAndServe connects to a controller listening on a TCP address and
+// serves the connection until it closes
+func (s *Switch) DialAndServe(addr string) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.ServeConn(conn)
+}
+
+// ServeConn runs the OpenFlow channel to a controller on conn until it
+// closes
+func (s *Switch) ServeConn(conn net.Conn) error {
+	select {
+	case <-s.done:
+		conn.Close()
+		return ErrClosed
+	default:
+	}
+	return s.agent.ServeConn(conn)
+}
+
+// send sends messages to the controllers, nil messages are skipped
+func (s *Switch) send(msgs ...openflow.MessageDecoder) error {
+	for _, msg := range msgs {
+		if msg == nil {
+			continue
+		}
+		if err := s.agent.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ControllerConnected leaves the fail mode
+func (s *Switch) ControllerConnected() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.learned = nil
+	s.table.SetEmergency(false)
+}
+
+// ControllerLost enters a fail mode
+func (s *Switch) ControllerLost(mode agent.FailMode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if mode == agent.FailStandalone {
+		s.learned = make(map[string]uint16)
+		return
+	}
+	s.table.SetEmergency(true)
+}
+
+// run processes received frames and expires flows until the switch closes
+func (s *Switch) run() {
+	ticker := time.NewTicker(expireInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case f := <-s.frames:
+			s.send(s.process(f.port, f.data)...)
+		case <-ticker.C:
+			for _, fr := range s.table.Expire() {
+				s.send(fr)
+			}
+			s.buffers.Expire()
+		}
+	}
+}
+
+// process runs a frame received on a port through the flow table
+func (s *Switch) process(p *Port, frame []byte) []openflow.MessageDecoder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ports[p.no] != p || !p.up() || p.config&openflow.NoRecv != 0 {
+		p.counters.rxDropped++
+		return nil
+	}
+	p.counters.rxPackets++
+	p.counters.rxBytes += uint64(len(frame))
+	if s.flags&v10.OFPC_FRAG_MASK == v10.OFPC_FRAG_DROP && isFragment(frame) {
+		p.counters.rxDropped++
+		return nil
+	}
+	if s.learned != nil {
+		s.standalone(p.no, frame)
+		return nil
+	}
+	match, err := v10.MatchFromPacket(p.no, frame)
+	if err != nil {
+		p.counters.rxErrors++
+		return nil
+	}
+	actions, ok := s.table.Lookup(match, len(frame))
+	if !ok {
+		if p.config&openflow.NoPacketIn != 0 {
+			return nil
+		}
+		return []openflow.MessageDecoder{s.packetIn(p.no, frame, v10.OFPR_NO_MATCH, s.missSendLen)}
+	}
+	return s.forward(p.no, actions, frame, false)
+}
+
+// standalone forwards a frame as a learning switch
+func (s *Switch) standalone(inPort uint16, frame []byte) {
+	if len(frame) < 14 {
+		return
+	}
+	if frame[6]&1 == 0 {
+		s.learned[string(frame[6:12])] = inPort
+	}
+	if no, ok := s.learned[string(frame[0:6])]; ok && frame[0]&1 == 0 {
+		if p, ok := s.ports[no]; ok && no != inPort {
+			p.transmit(frame)
+		}
+		return
+	}
+	for _, p := range s.sortedPorts() {
+		if p.no != inPort && p.config&openflow.NoFlood == 0 {
+			p.transmit(frame)
+		}
+	}
+}
+
+// lookup runs a frame sent by the controller through the flow table, a
+// miss sends it back in a packet in
+func (s *Switch) lookup(inPort uint16, frame []byte) []openflow.MessageDecoder {
+	match, err := v10.MatchFromPacket(inPort, frame)
+	if err != nil {
+		return nil
+	}
+	if actions, ok := s.table.Lookup(match, len(frame)); ok {
+		return s.forward(inPort, actions, frame, false)
+	}
+	return []openflow.MessageDecoder{s.packetIn(inPort, frame, v10.OFPR_NO_MATCH, s.missSendLen)}
+}
+
+// isFragment tells whether frame is an IPv4 fragment
+func isFragment(frame []byte) bool {
+	nw, _ := ipv4(frame)
+	return nw != 0 && binary.BigEndian.Uint16(frame[nw+6:nw+8])&0x3fff != 0
+}
+
+// forward executes actions on a frame, outputs to the table are only
+// allowed for packet outs. Only OFPP_IN_PORT sends a frame back out of its
+// ingress port, outputs naming that port drop it.
+func (s *Switch) forward(inPort uint16, actions []openflow.Action, frame []byte, packetOut bool) []openflow.MessageDecoder {
+	var msgs []openflow.MessageDecoder
+	execute(actions, frame, func(frame []byte, o output) {
+		switch openflow.PortID(o.port) {
+		case openflow.Flood, openflow.All:
+			for _, p := range s.sortedPorts() {
+				if p.no == inPort || o.port == uint16(openflow.Flood) && p.config&openflow.NoFlood != 0 {
+					continue
+				}
+				p.transmit(frame)
+			}
+		case openflow.Controller:
+			msgs = append(msgs, s.packetIn(inPort, frame, v10.OFPR_ACTION, o.maxLen))
+		case openflow.Table:
+			if packetOut {
+				msgs = append(msgs, s.lookup(inPort, frame)...)
+			}
+		default:
+			no := o.port
+			if no == uint16(openflow.InPort) {
+				no = inPort
+			} else if no == inPort {
+				return
+			}
+			p, ok := s.ports[no]
+			if !ok {
+				return
+			}
+			sent := p.transmit(frame)
+			if !o.enqueue {
+				return
+			}
+			if q, ok := p.queues[o.queue]; ok && sent {
+				q.txPackets++
+				q.txBytes += uint64(len(frame))
+			} else if ok {
+				q.txErrors++
+			}
+		}
+	})
+	return msgs
+}
+
+// packetIn returns a packet in carrying frame, buffered frames are
+// truncated to maxLen bytes and the whole frame is sent when every buffer
+// is in use
+func (s *Switch) packetIn(inPort uint16, frame []byte, reason uint8, maxLen uint16) openflow.PacketIn {
+	pi := v10.NewPacketIn(0)
+	pi.SetInPort(inPort)
+	pi.SetReason(reason)
+	id, ok := s.buffers.Store(inPort, frame)
+	pi.SetBufferID(id)
+	if ok && len(frame) > int(maxLen) {
+		pi.SetData(frame[:maxLen])
+		pi.SetTotalLength(uint16(len(frame)))
+	} else {
+		pi.SetData(frame)
+	}
+	return pi
+}
+
+func (s *Switch) portStatus(p *Port, reason openflow.PortReason) openflow.PortStatus {
+	ps := v10.NewPortStatus(0)
+	ps.SetReason(reason)
+	ps.SetPort(p.state())
+	return ps
+}
+
+// errorReply returns the error answering the request in data
+func errorReply(data []byte, typ, code uint16) openflow.Error {
+	e := v10.NewError(binary.BigEndian.Uint32(data[4:8]))
+	e.SetType(typ)
+	e.SetCode(code)
+	if len(data) > errorDataLength {
+		data = data[:errorDataLength]
+	}
+	e.SetData(append([]byte(nil), data...))
+	return e
+}
+
+// Handle processes a message of a controller and returns the messages
+// answering it, hello, echo and features requests are left to the agent
+func (s *Switch) Handle(data []byte) []openflow.MessageDecoder {
+	if data[0] != openflow.OF10_VERSION {
+		return []openflow.MessageDecoder{errorReply(data, v10.OFPET_BAD_REQUEST, v10.OFPBRC_BAD_VERSION)}
+	}
+	msg, err := v10.Parse(data)
+	if err != nil {
+		code := uint16(v10.OFPBRC_BAD_TYPE)
+		if err == openflow.ErrInvalidPacketLength || err == openflow.ErrInvalidDataLength {
+			code = v10.OFPBRC_BAD_LEN
+		}
+		return []openflow.MessageDecoder{errorReply(data, v10.OFPET_BAD_REQUEST, code)}
+	}
+	xid := msg.TransactionID()
+	var reply openflow.MessageDecoder
+	switch data[1] {
+	case v10.OFPT_ERROR:
+	case v10.OFPT_GET_CONFIG_REQUEST:
+		config := v10.NewGetConfigReply(xid)
+		s.mu.Lock()
+		config.SetFlags(s.flags)
+		config.SetMissSendLength(s.missSendLen)
+		s.mu.Unlock()
+		reply = config
+	case v10.OFPT_SET_CONFIG:
+		config := msg.(openflow.SetConfig)
+		s.mu.Lock()
+		s.flags = config.Flags()
+		s.missSendLen = config.MissSendLength()
+		s.mu.Unlock()
+	case v10.OFPT_PACKET_OUT:
+		return s.packetOut(msg.(openflow.PacketOut), data)
+	case v10.OFPT_FLOW_MOD:
+		return s.flowMod(msg.(openflow.FlowMod), data)
+	case v10.OFPT_PORT_MOD:
+		return s.portMod(msg.(openflow.PortMod), data)
+	case v10.OFPT_STATS_REQUEST:
+		return s.stats(msg.(openflow.StatsRequest), data)
+	case v10.OFPT_BARRIER_REQUEST:
+		// messages are processed in order, all earlier ones are done
+		reply = v10.NewBarrierReply(xid)
+	case v10.OFPT_QUEUE_GET_CONFIG_REQUEST:
+		reply = s.queueConfig(msg.(openflow.QueueGetConfigRequest), data)
+	case v10.OFPT_VENDOR:
+		reply = errorReply(data, v10.OFPET_BAD_REQUEST, v10.OFPBRC_BAD_VENDOR)
+	default:
+		reply = errorReply(data, v10.OFPET_BAD_REQUEST, v10.OFPBRC_BAD_TYPE)
+	}
+	if reply == nil {
+		return nil
+	}
+	return []openflow.MessageDecoder{reply}
+}
+
+// supportedActions are the OFPAT_* bits of every v10 action but vendor
+const supportedActions = openflow.FeatureAction(1<<(v10.OFPAT_ENQUEUE+1) - 1)
+
+// Features returns the features reply answering the request xid
+func (s *Switch) Features(xid uint32) openflow.FeatureReply {
+	reply := v10.NewFeatureReply(xid)
+	reply.SetDPID(s.dpid)
+	reply.SetNumBuffers(uint32(s.buffers.Size()))
+	reply.SetNumTables(1)
+	reply.SetCapabilities(openflow.FLOW_STATS | openflow.TABLE_STATS |
+		openflow.PORT_STATS | openflow.QUEUE_STATS | openflow.ARP_MATCH_IP)
+	reply.SetActions(supportedActions)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.sortedPorts() {
+		reply.AddPort(p.state())
+	}
+	return reply
+}
+
+func (s *Switch) packetOut(po openflow.PacketOut, data []byte) []openflow.MessageDecoder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if code, ok := s.validateActions(po.Action(), true); !ok {
+		return []openflow.MessageDecoder{errorReply(data, v10.OFPET_BAD_ACTION, code)}
+	}
+	frame := po.Data()
+	if po.BufferID() != v10.OFP_NO_BUFFER {
+		packet, err := s.buffers.Retrieve(po.BufferID())
+		if err != nil {
+			return []openflow.MessageDecoder{errorReply(data, v10.OFPET_BAD_REQUEST, buffer.Code(err))}
+		}
+		frame = packet.Data
+	}
+	return s.forward(po.InPort(), po.Action(), frame, true)
+}
+
+func (s *Switch) flowMod(fm openflow.FlowMod, data []byte) []openflow.MessageDecoder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if code, ok := s.validateActions(fm.Actions(), false); !ok {
+		return []openflow.MessageDecoder{errorReply(data, v10.OFPET_BAD_ACTION, code)}
+	}
+	removed, err := s.table.Apply(fm)
+	if err != nil {
+		return []openflow.MessageDecoder{errorReply(data, v10.OFPET_FLOW_MOD_FAILED, flowtable.Code(err))}
+	}
+	var msgs []openflow.MessageDecoder
+	for _, fr := range removed {
+		msgs = append(msgs, fr)
+	}
+	// the buffered packet goes through the table once the flow is added,
+	// deletions ignore the buffer
+	if fm.BufferID() != v10.OFP_NO_BUFFER && fm.Command() != openflow.Delete && fm.Command() != openflow.DeleteStrict {
+		packet, err := s.buffers.Retrieve(fm.BufferID())
+		if err != nil {
+			return append(msgs, errorReply(data, v10.OFPET_BAD_REQUEST, buffer.Code(err)))
+		}
+		msgs = append(msgs, s.lookup(packet.InPort, packet.Data)...)
+	}
+	return msgs
+}
+
+func (s *Switch) portMod(pm openflow.PortMod, data []byte) []openflow.MessageDecoder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.ports[uint16(pm.Port())]
+	if !ok {
+		return []openflow.MessageDecoder{errorReply(data, v10.OFPET_PORT_MOD_FAILED, v10.OFPPMFC_BAD_PORT)}
+	}
+	if pm.HWAddr().String() != p.hwAddr.String() {
+		return []openflow.MessageDecoder{errorReply(data, v10.OFPET_PORT_MOD_FAILED, v10.OFPPMFC_BAD_HW_ADDR)}
+	}
+	mask := openflow.PortConfig(pm.Mask())
+	config := p.config&^mask | pm.Config()&mask
+	if config == p.config {
+		return nil
+	}
+	p.config = config
+	return []openflow.MessageDecoder{s.portStatus(p, openflow.PortModified)}
+}
+
+func (s *Switch) queueConfig(req openflow.QueueGetConfigRequest, data []byte) openflow.MessageDecoder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.ports[req.Port()]
+	if !ok {
+		return errorReply(data, v10.OFPET_QUEUE_OP_FAILED, v10.OFPQOFC_BAD_PORT)
+	}
+	reply := v10.NewQueueGetConfigReply(req.TransactionID())
+	reply.SetPort(req.Port())
+	for _, q := range p.sortedQueues() {
+		queue := v10.NewQueue()
+		queue.SetQueueID(q.id)
+		queue.SetRate(q.minRate)
+		reply.AddQueue(queue)
+	}
+	return reply
+}
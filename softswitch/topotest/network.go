@@ -0,0 +1,332 @@
+/*
+Package topotest builds networks of emulated switches and hosts to test
+controllers and applications in memory.
+
+A Network is made of softswitch switches and hosts joined by virtual
+links, built one by one or from the Linear, Tree and FatTree topologies
+or a Spec loaded from a file. Connect joins every switch to a controller
+under test, hosts then inject frames and tests assert on the frames other
+hosts receive. No privileges, namespaces or sockets are needed.
+*/
+package topotest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/packet"
+	"github.com/ksang/goflow/softswitch"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTimeout      = errors.New("topotest: timeout")
+	ErrUnknownNode  = errors.New("topotest: unknown node")
+	ErrNodeExists   = errors.New("topotest: node already exists")
+	ErrInvalidLink  = errors.New("topotest: invalid link")
+	ErrNotDelivered = errors.New("topotest: frame not delivered")
+)
+
+// hostQueueLength is how many received frames a host keeps, later frames
+// are dropped
+const hostQueueLength = 1024
+
+// Host is an emulated host attached to a switch port, it records the
+// frames it receives
+type Host struct {
+	Name   string
+	HWAddr net.HardwareAddr
+	IP     net.IP
+
+	mu     sync.Mutex
+	port   *softswitch.Port
+	frames chan []byte
+}
+
+// Input receives a frame transmitted by the port of the host
+func (h *Host) Input(frame []byte) {
+	select {
+	case h.frames <- frame:
+	default:
+	}
+}
+
+// Port returns the switch port the host is attached to
+func (h *Host) Port() *softswitch.Port {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.port
+}
+
+// Send injects a frame into the network, frames of hosts without link are
+// dropped
+func (h *Host) Send(frame []byte) {
+	if p := h.Port(); p != nil {
+		p.Input(frame)
+	}
+}
+
+// UDP returns a UDP frame from h to dst carrying payload
+func (h *Host) UDP(dst *Host, payload []byte) []byte {
+	udp, _ := (&packet.UDP{SrcPort: 4000, DstPort: 4000, Payload: payload}).MarshalBinary()
+	ip, _ := (&packet.IPv4{
+		TTL:      64,
+		Protocol: packet.IPProtocolUDP,
+		Src:      h.IP,
+		Dst:      dst.IP,
+		Payload:  udp,
+	}).MarshalBinary()
+	frame, _ := packet.NewEthernet(h.HWAddr, dst.HWAddr, packet.EtherTypeIPv4, ip).MarshalBinary()
+	return frame
+}
+
+// Receive returns the next frame the host received
+func (h *Host) Receive(timeout time.Duration) ([]byte, error) {
+	select {
+	case frame := <-h.frames:
+		return frame, nil
+	case <-time.After(timeout):
+		return nil, ErrTimeout
+	}
+}
+
+// Expect waits until the host receives frame, other frames are discarded
+func (h *Host) Expect(frame []byte, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case received := <-h.frames:
+			if bytes.Equal(received, frame) {
+				return nil
+			}
+		case <-deadline:
+			return ErrNotDelivered
+		}
+	}
+}
+
+// Drain discards the frames received so far
+func (h *Host) Drain() {
+	for {
+		select {
+		case <-h.frames:
+		default:
+			return
+		}
+	}
+}
+
+// Network is a set of emulated switches and hosts
+type Network struct {
+	mu       sync.Mutex
+	switches map[string]*softswitch.Switch
+	hosts    map[string]*Host
+	names    []string
+}
+
+// New returns an empty network
+func New() *Network {
+	return &Network{
+		switches: make(map[string]*softswitch.Switch),
+		hosts:    make(map[string]*Host),
+	}
+}
+
+func (n *Network) exists(name string) bool {
+	_, sw := n.switches[name]
+	_, host := n.hosts[name]
+	return sw || host
+}
+
+// AddSwitch adds a switch without ports
+func (n *Network) AddSwitch(name string, dpid uint64) (*softswitch.Switch, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.exists(name) {
+		return nil, ErrNodeExists
+	}
+	s := softswitch.New(dpid)
+	s.Description = name
+	n.switches[name] = s
+	n.names = append(n.names, name)
+	return s, nil
+}
+
+// AddHost adds a host with the given addresses, nil addresses are derived
+// from the number of hosts
+func (n *Network) AddHost(name string, hwAddr net.HardwareAddr, ip net.IP) (*Host, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.exists(name) {
+		return nil, ErrNodeExists
+	}
+	i := len(n.hosts) + 1
+	if hwAddr == nil {
+		hwAddr = net.HardwareAddr{0x0a, 0, 0, byte(i >> 16), byte(i >> 8), byte(i)}
+	}
+	if ip == nil {
+		ip = net.IP{10, byte(i >> 16), byte(i >> 8), byte(i)}
+	}
+	h := &Host{
+		Name:   name,
+		HWAddr: hwAddr,
+		IP:     ip.To4(),
+		frames: make(chan []byte, hostQueueLength),
+	}
+	n.hosts[name] = h
+	n.names = append(n.names, name)
+	return h, nil
+}
+
+// Switch returns a switch by name
+func (n *Network) Switch(name string) (*softswitch.Switch, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	s, ok := n.switches[name]
+	return s, ok
+}
+
+// Host returns a host by name
+func (n *Network) Host(name string) (*Host, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	h, ok := n.hosts[name]
+	return h, ok
+}
+
+// Switches returns the switches sorted by datapath id
+func (n *Network) Switches() []*softswitch.Switch {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	v := make([]*softswitch.Switch, 0, len(n.switches))
+	for _, s := range n.switches {
+		v = append(v, s)
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].DPID() < v[j].DPID() })
+	return v
+}
+
+// Hosts returns the hosts in the order they were added
+func (n *Network) Hosts() []*Host {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var v []*Host
+	for _, name := range n.names {
+		if h, ok := n.hosts[name]; ok {
+			v = append(v, h)
+		}
+	}
+	return v
+}
+
+// addPort adds the next free port to a switch
+func addPort(s *softswitch.Switch, name string) (*softswitch.Port, error) {
+	no := uint16(1)
+	if ports := s.Ports(); len(ports) > 0 {
+		no = ports[len(ports)-1].Number() + 1
+	}
+	return s.AddPort(no, fmt.Sprintf("%s-eth%d", name, no))
+}
+
+// Link joins two nodes with a virtual cable, switches get a new port and
+// a host is attached to at most one switch. It returns the ports of the
+// link, nil for hosts.
+func (n *Network) Link(a, b string) (*softswitch.Port, *softswitch.Port, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if !n.exists(a) || !n.exists(b) {
+		return nil, nil, ErrUnknownNode
+	}
+	if a == b {
+		return nil, nil, ErrInvalidLink
+	}
+	sa, sb := n.switches[a], n.switches[b]
+	switch {
+	case sa != nil && sb != nil:
+		pa, err := addPort(sa, a)
+		if err != nil {
+			return nil, nil, err
+		}
+		pb, err := addPort(sb, b)
+		if err != nil {
+			return nil, nil, err
+		}
+		softswitch.Link(pa, pb)
+		return pa, pb, nil
+	case sa != nil:
+		pa, err := n.attach(sa, a, n.hosts[b])
+		return pa, nil, err
+	case sb != nil:
+		pb, err := n.attach(sb, b, n.hosts[a])
+		return nil, pb, err
+	}
+	return nil, nil, ErrInvalidLink
+}
+
+func (n *Network) attach(s *softswitch.Switch, name string, h *Host) (*softswitch.Port, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.port != nil {
+		return nil, ErrInvalidLink
+	}
+	p, err := addPort(s, name)
+	if err != nil {
+		return nil, err
+	}
+	p.Attach(h)
+	h.port = p
+	return p, nil
+}
+
+// Connect connects every switch to c and waits until their datapaths are
+// up
+func (n *Network) Connect(c *controller.Controller) error {
+	switches := n.Switches()
+	for _, s := range switches {
+		a, b := net.Pipe()
+		go c.ServeConn(a)
+		go s.ServeConn(b)
+	}
+	return wait(c, switches)
+}
+
+// Dial connects every switch to the controller listening on a TCP
+// address
+func (n *Network) Dial(addr string) {
+	for _, s := range n.Switches() {
+		go s.DialAndServe(addr)
+	}
+}
+
+func wait(c *controller.Controller, switches []*softswitch.Switch) error {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		up := 0
+		for _, s := range switches {
+			if _, ok := c.Datapath(s.DPID()); ok {
+				up++
+			}
+		}
+		if up == len(switches) {
+			return nil
+		}
+	}
+	return ErrTimeout
+}
+
+// Reach sends a UDP frame from src to dst and waits until dst receives it
+func (n *Network) Reach(src, dst *Host, timeout time.Duration) error {
+	frame := src.UDP(dst, []byte(fmt.Sprintf("%s to %s at %d", src.Name, dst.Name, time.Now().UnixNano())))
+	src.Send(frame)
+	return dst.Expect(frame, timeout)
+}
+
+// Close stops every switch
+func (n *Network) Close() {
+	for _, s := range n.Switches() {
+		s.Close()
+	}
+}
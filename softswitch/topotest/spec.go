@@ -0,0 +1,100 @@
+package topotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ksang/goflow/openflow/v10"
+	"io/ioutil"
+	"net"
+)
+
+// Spec describes a custom network, it decodes from JSON and YAML:
+//
+//	switches:
+//	  - name: s1
+//	    dpid: "00:00:00:00:00:00:00:01"
+//	hosts:
+//	  - name: h1
+//	    mac: "0a:00:00:00:00:01"
+//	    ip: 10.0.0.1
+//	links:
+//	  - [s1, h1]
+type Spec struct {
+	Switches []SwitchSpec `json:"switches" yaml:"switches"`
+	Hosts    []HostSpec   `json:"hosts" yaml:"hosts"`
+	Links    [][2]string  `json:"links" yaml:"links"`
+}
+
+// SwitchSpec describes a switch, the dpid defaults to its position
+type SwitchSpec struct {
+	Name string `json:"name" yaml:"name"`
+	DPID string `json:"dpid,omitempty" yaml:"dpid,omitempty"`
+}
+
+// HostSpec describes a host, addresses are derived when empty
+type HostSpec struct {
+	Name string `json:"name" yaml:"name"`
+	MAC  string `json:"mac,omitempty" yaml:"mac,omitempty"`
+	IP   string `json:"ip,omitempty" yaml:"ip,omitempty"`
+}
+
+// FromSpec builds the network described by spec
+func FromSpec(spec Spec) (*Network, error) {
+	b := &builder{n: New()}
+	for i, s := range spec.Switches {
+		dpid := uint64(i + 1)
+		if s.DPID != "" {
+			var err error
+			if dpid, err = v10.ParseDPID(s.DPID); err != nil {
+				b.err = fmt.Errorf("topotest: switch %s: %v", s.Name, err)
+				break
+			}
+		}
+		if _, err := b.n.AddSwitch(s.Name, dpid); err != nil {
+			b.err = err
+			break
+		}
+	}
+	for _, h := range spec.Hosts {
+		if b.err != nil {
+			break
+		}
+		var (
+			mac net.HardwareAddr
+			ip  net.IP
+		)
+		if h.MAC != "" {
+			if mac, b.err = net.ParseMAC(h.MAC); b.err != nil {
+				break
+			}
+		}
+		if h.IP != "" {
+			if ip = net.ParseIP(h.IP).To4(); ip == nil {
+				b.err = fmt.Errorf("topotest: host %s: invalid address %q", h.Name, h.IP)
+				break
+			}
+		}
+		_, b.err = b.n.AddHost(h.Name, mac, ip)
+	}
+	for _, l := range spec.Links {
+		b.link(l[0], l[1])
+	}
+	return b.network()
+}
+
+// Load builds the network described by a file, unmarshal decodes it and
+// may be yaml.Unmarshal of gopkg.in/yaml, nil decodes JSON
+func Load(path string, unmarshal func([]byte, interface{}) error) (*Network, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if unmarshal == nil {
+		unmarshal = json.Unmarshal
+	}
+	var spec Spec
+	if err := unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	return FromSpec(spec)
+}
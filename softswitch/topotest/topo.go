@@ -0,0 +1,115 @@
+package topotest
+
+import (
+	"fmt"
+)
+
+// builder adds nodes and links until the first error
+type builder struct {
+	n    *Network
+	err  error
+	dpid uint64
+	host int
+}
+
+func (b *builder) addSwitch() string {
+	b.dpid++
+	name := fmt.Sprintf("s%d", b.dpid)
+	if b.err == nil {
+		_, b.err = b.n.AddSwitch(name, b.dpid)
+	}
+	return name
+}
+
+// addHosts adds count hosts linked to the switch sw
+func (b *builder) addHosts(sw string, count int) {
+	for i := 0; i < count; i++ {
+		b.host++
+		name := fmt.Sprintf("h%d", b.host)
+		if b.err == nil {
+			_, b.err = b.n.AddHost(name, nil, nil)
+		}
+		b.link(sw, name)
+	}
+}
+
+func (b *builder) link(a, c string) {
+	if b.err == nil {
+		_, _, b.err = b.n.Link(a, c)
+	}
+}
+
+func (b *builder) network() (*Network, error) {
+	if b.err != nil {
+		b.n.Close()
+		return nil, b.err
+	}
+	return b.n, nil
+}
+
+// Linear returns a chain of switches s1 to sN, each with hosts hosts
+func Linear(switches, hosts int) (*Network, error) {
+	b := &builder{n: New()}
+	prev := ""
+	for i := 0; i < switches; i++ {
+		sw := b.addSwitch()
+		b.addHosts(sw, hosts)
+		if prev != "" {
+			b.link(prev, sw)
+		}
+		prev = sw
+	}
+	return b.network()
+}
+
+// Tree returns a tree of switches of the given depth where every switch
+// has fanout children, the switches of the last level have fanout hosts
+func Tree(depth, fanout int) (*Network, error) {
+	b := &builder{n: New()}
+	var grow func(level int) string
+	grow = func(level int) string {
+		sw := b.addSwitch()
+		if level == depth {
+			b.addHosts(sw, fanout)
+			return sw
+		}
+		for i := 0; i < fanout; i++ {
+			b.link(sw, grow(level+1))
+		}
+		return sw
+	}
+	if depth > 0 {
+		grow(1)
+	}
+	return b.network()
+}
+
+// FatTree returns a k-ary fat tree: (k/2)^2 core switches and k pods of
+// k/2 aggregation and k/2 edge switches, each edge switch with k/2 hosts.
+// Fat trees have loops, controllers must not flood blindly.
+func FatTree(k int) (*Network, error) {
+	b := &builder{n: New()}
+	half := k / 2
+	core := make([]string, half*half)
+	for i := range core {
+		core[i] = b.addSwitch()
+	}
+	for pod := 0; pod < k; pod++ {
+		agg := make([]string, half)
+		for i := range agg {
+			agg[i] = b.addSwitch()
+			// aggregation switch i joins core switches i*half to i*half+half-1
+			for j := 0; j < half; j++ {
+				b.link(agg[i], core[i*half+j])
+			}
+		}
+		for i := 0; i < half; i++ {
+			edge := b.addSwitch()
+			for _, a := range agg {
+				b.link(edge, a)
+			}
+			b.addHosts(edge, half)
+		}
+	}
+	return b.network()
+}
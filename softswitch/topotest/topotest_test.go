@@ -0,0 +1,269 @@
+package topotest
+
+import (
+	"fmt"
+	"github.com/ksang/goflow/apps/learning"
+	"github.com/ksang/goflow/controller"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func connect(t *testing.T, n *Network, err error) *Network {
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := controller.New()
+	c.Register(learning.New())
+	if err := n.Connect(c); err != nil {
+		n.Close()
+		t.Fatal(err)
+	}
+	return n
+}
+
+func reach(t *testing.T, n *Network, src, dst string) {
+	a, _ := n.Host(src)
+	b, _ := n.Host(dst)
+	if err := n.Reach(a, b, 2*time.Second); err != nil {
+		t.Errorf("%s to %s: %v", src, dst, err)
+	}
+}
+
+func TestLinear(t *testing.T) {
+	n, err := Linear(3, 1)
+	n = connect(t, n, err)
+	defer n.Close()
+	if len(n.Switches()) != 3 || len(n.Hosts()) != 3 {
+		t.Fatalf("unexpected network %d switches %d hosts", len(n.Switches()), len(n.Hosts()))
+	}
+	reach(t, n, "h1", "h3")
+	reach(t, n, "h3", "h1")
+	// learned flows forward without flooding
+	h2, _ := n.Host("h2")
+	h2.Drain()
+	reach(t, n, "h1", "h3")
+	if frame, err := h2.Receive(100 * time.Millisecond); err == nil {
+		t.Errorf("h2 received %x", frame)
+	}
+}
+
+func TestTree(t *testing.T) {
+	n, err := Tree(2, 2)
+	n = connect(t, n, err)
+	defer n.Close()
+	if len(n.Switches()) != 3 || len(n.Hosts()) != 4 {
+		t.Fatalf("unexpected network %d switches %d hosts", len(n.Switches()), len(n.Hosts()))
+	}
+	reach(t, n, "h1", "h4")
+	reach(t, n, "h4", "h1")
+}
+
+func TestFatTree(t *testing.T) {
+	n, err := FatTree(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+	if len(n.Switches()) != 20 || len(n.Hosts()) != 16 {
+		t.Errorf("unexpected network %d switches %d hosts", len(n.Switches()), len(n.Hosts()))
+	}
+	for _, s := range n.Switches() {
+		if len(s.Ports()) != 4 {
+			t.Errorf("%s has %d ports", s.Description, len(s.Ports()))
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "topotest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "net.json")
+	err = ioutil.WriteFile(path, []byte(`{
+		"switches": [{"name": "left", "dpid": "00:00:00:00:00:00:00:0a"}, {"name": "right"}],
+		"hosts": [{"name": "a", "ip": "192.168.0.1"}, {"name": "b", "mac": "02:00:00:00:00:0b"}],
+		"links": [["left", "a"], ["left", "right"], ["b", "right"]]
+	}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Load(path, nil)
+	n = connect(t, n, err)
+	defer n.Close()
+	if s, _ := n.Switch("left"); s.DPID() != 10 {
+		t.Errorf("unexpected dpid %d", s.DPID())
+	}
+	if b, _ := n.Host("b"); b.HWAddr.String() != "02:00:00:00:00:0b" || b.Port().Number() != 2 {
+		t.Errorf("unexpected host %v", b)
+	}
+	reach(t, n, "a", "b")
+
+	if _, err := FromSpec(Spec{Switches: []SwitchSpec{{Name: "s"}}, Links: [][2]string{{"s", "x"}}}); err != ErrUnknownNode {
+		t.Errorf("unknown node answered %v", err)
+	}
+}
+
+// yamlLine is a line of a YAML document, sequence entries are split in a
+// "-" line and their content indented past it
+type yamlLine struct {
+	indent int
+	text   string
+}
+
+// yamlUnmarshal stands in for yaml.Unmarshal of gopkg.in/yaml with the
+// block mappings, block sequences and flow sequences of string scalars
+// network files use, fields are named by their yaml tags
+func yamlUnmarshal(data []byte, v interface{}) error {
+	var lines []yamlLine
+	for _, l := range strings.Split(string(data), "\n") {
+		text := strings.TrimSpace(l)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		indent := len(l) - len(strings.TrimLeft(l, " "))
+		for strings.HasPrefix(text, "- ") {
+			lines = append(lines, yamlLine{indent, "-"})
+			indent += 2
+			text = strings.TrimSpace(text[2:])
+		}
+		lines = append(lines, yamlLine{indent, text})
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	node, _ := yamlBlock(lines)
+	return yamlDecode(node, reflect.ValueOf(v).Elem())
+}
+
+// yamlBlock parses the node starting at the first line, it returns the
+// lines following it
+func yamlBlock(lines []yamlLine) (interface{}, []yamlLine) {
+	indent := lines[0].indent
+	if lines[0].text == "-" {
+		var seq []interface{}
+		for len(lines) > 0 && lines[0].indent == indent && lines[0].text == "-" {
+			var item interface{}
+			if len(lines) > 1 && lines[1].indent > indent {
+				item, lines = yamlBlock(lines[1:])
+			} else {
+				lines = lines[1:]
+			}
+			seq = append(seq, item)
+		}
+		return seq, lines
+	}
+	if !strings.Contains(lines[0].text, ": ") && !strings.HasSuffix(lines[0].text, ":") {
+		return yamlScalar(lines[0].text), lines[1:]
+	}
+	m := make(map[string]interface{})
+	for len(lines) > 0 && lines[0].indent == indent {
+		kv := strings.SplitN(lines[0].text, ":", 2)
+		key, value := kv[0], strings.TrimSpace(kv[1])
+		lines = lines[1:]
+		if value != "" {
+			m[key] = yamlScalar(value)
+		} else if len(lines) > 0 && lines[0].indent > indent {
+			m[key], lines = yamlBlock(lines)
+		}
+	}
+	return m, lines
+}
+
+func yamlScalar(s string) interface{} {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		var seq []interface{}
+		for _, e := range strings.Split(s[1:len(s)-1], ",") {
+			seq = append(seq, yamlScalar(strings.TrimSpace(e)))
+		}
+		return seq
+	}
+	return strings.Trim(s, `"`)
+}
+
+// yamlDecode sets out from a parsed node, struct fields by their yaml tags
+func yamlDecode(node interface{}, out reflect.Value) error {
+	switch out.Kind() {
+	case reflect.Struct:
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("yaml: cannot decode %v into %s", node, out.Type())
+		}
+		for i := 0; i < out.NumField(); i++ {
+			name := strings.Split(out.Type().Field(i).Tag.Get("yaml"), ",")[0]
+			if v, ok := m[name]; ok {
+				if err := yamlDecode(v, out.Field(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		seq, ok := node.([]interface{})
+		if !ok || out.Kind() == reflect.Array && len(seq) != out.Len() {
+			return fmt.Errorf("yaml: cannot decode %v into %s", node, out.Type())
+		}
+		if out.Kind() == reflect.Slice {
+			out.Set(reflect.MakeSlice(out.Type(), len(seq), len(seq)))
+		}
+		for i, v := range seq {
+			if err := yamlDecode(v, out.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		s, ok := node.(string)
+		if !ok {
+			return fmt.Errorf("yaml: cannot decode %v into %s", node, out.Type())
+		}
+		out.SetString(s)
+	default:
+		return fmt.Errorf("yaml: cannot decode into %s", out.Type())
+	}
+	return nil
+}
+
+func TestLoadYAML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "topotest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "net.yaml")
+	err = ioutil.WriteFile(path, []byte(`# two switches in a row
+switches:
+  - name: s1
+    dpid: "00:00:00:00:00:00:00:0b"
+  - name: s2
+hosts:
+  - name: h1
+    mac: "0a:00:00:00:00:01"
+    ip: 10.0.0.1
+  - name: h2
+links:
+  - [s1, h1]
+  - [s1, s2]
+  - [s2, h2]
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Load(path, yamlUnmarshal)
+	n = connect(t, n, err)
+	defer n.Close()
+	if s, _ := n.Switch("s1"); s.DPID() != 11 {
+		t.Errorf("unexpected dpid %d", s.DPID())
+	}
+	if s, _ := n.Switch("s2"); s.DPID() != 2 {
+		t.Errorf("unexpected dpid %d", s.DPID())
+	}
+	if h, _ := n.Host("h1"); h.HWAddr.String() != "0a:00:00:00:00:01" || h.IP.String() != "10.0.0.1" {
+		t.Errorf("unexpected host %v", h)
+	}
+	reach(t, n, "h1", "h2")
+}
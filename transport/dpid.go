@@ -0,0 +1,83 @@
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/ksang/goflow/openflow/v10"
+	"net"
+)
+
+var (
+	ErrNoPeerCertificate = errors.New("transport: no peer certificate")
+	ErrUnknownPeer       = errors.New("transport: certificate maps to no datapath")
+)
+
+// DPIDMismatchError is returned when a switch claims a datapath id its
+// certificate does not map to
+type DPIDMismatchError struct {
+	Name string
+	DPID uint64
+}
+
+func (e *DPIDMismatchError) Error() string {
+	return fmt.Sprintf("transport: certificate %s may not claim datapath %s", e.Name, v10.DPIDString(e.DPID))
+}
+
+// CertificateNames returns the subject common name and the subject
+// alternative names of a certificate
+func CertificateNames(cert *x509.Certificate) []string {
+	var names []string
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
+
+// PeerCertificate returns the verified certificate of the peer of a TLS
+// connection
+func PeerCertificate(conn net.Conn) (*x509.Certificate, error) {
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil, ErrNoPeerCertificate
+	}
+	state := tc.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, ErrNoPeerCertificate
+	}
+	return state.PeerCertificates[0], nil
+}
+
+// DPIDMap maps certificate names to the datapath id their switch may
+// claim
+type DPIDMap map[string]uint64
+
+// Verify checks that the certificate of the peer of conn maps to dpid,
+// it fits Controller.VerifyDPID
+func (m DPIDMap) Verify(conn net.Conn, dpid uint64) error {
+	cert, err := PeerCertificate(conn)
+	if err != nil {
+		return err
+	}
+	err = ErrUnknownPeer
+	for _, name := range CertificateNames(cert) {
+		expected, ok := m[name]
+		if !ok {
+			continue
+		}
+		if expected == dpid {
+			return nil
+		}
+		err = &DPIDMismatchError{Name: name, DPID: dpid}
+	}
+	return err
+}
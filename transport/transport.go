@@ -0,0 +1,185 @@
+/*
+Package transport provides the secure connections of the openflow channel.
+
+Certificates loads a certificate, its key and the CA certificates peers are
+verified with, and builds TLS configurations for controllers and switches
+which require and verify the certificate of the peer. The files can be
+reloaded at any time, for instance on SIGHUP: new connections use the new
+certificates while established sessions keep going. DPIDMap ties the
+names of switch certificates to the datapath ids they may claim.
+
+Connections are also named by URIs as with ovs-vsctl: tcp:, ssl:, unix:
+and udp: followed by the address. ListenURI returns a net.Listener for
+Controller.Serve and URIDialer a dialer for agent.Agent, so that both
+ends work on any of them. Over UDP each datagram carries one message.
+*/
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultPort is the IANA port of openflow, switches connect over TLS to it
+const DefaultPort = 6653
+
+var (
+	ErrNoCertificates = errors.New("transport: no certificate in CA file")
+	// ErrNoServerName is returned by switch connections which do not know
+	// the name the controller certificate must have
+	ErrNoServerName = errors.New("transport: no server name to verify the peer with")
+)
+
+// Certificates is a reloadable certificate with the CA pool verifying
+// peers
+type Certificates struct {
+	CertFile string
+	KeyFile  string
+	// CAFile holds the PEM certificates peers are verified with, peers
+	// are not asked for certificates and servers are verified with the
+	// system roots when empty
+	CAFile string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	pool *x509.CertPool
+}
+
+// LoadCertificates loads a certificate, its key and the CA certificates
+func LoadCertificates(certFile, keyFile, caFile string) (*Certificates, error) {
+	c := &Certificates{
+		CertFile: certFile,
+		KeyFile:  keyFile,
+		CAFile:   caFile,
+	}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload reads the files again, the previous certificates are kept when
+// they fail to load
+func (c *Certificates) Reload() error {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return err
+	}
+	var pool *x509.CertPool
+	if c.CAFile != "" {
+		data, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return ErrNoCertificates
+		}
+	}
+	c.mu.Lock()
+	c.cert = &cert
+	c.pool = pool
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Certificates) current() (*tls.Certificate, *x509.CertPool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, c.pool
+}
+
+// ServerConfig returns the configuration of a controller, switches must
+// present a certificate signed by the CA
+func (c *Certificates) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, pool := c.current()
+			config := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cert},
+			}
+			if pool != nil {
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+				config.ClientCAs = pool
+			}
+			return config, nil
+		},
+	}
+}
+
+// ClientConfig returns the configuration of a switch, the controller
+// certificate must be signed by the CA and name the dialed host. The host
+// is only known to connections of Dialer, or set in ServerName, other
+// connections fail with ErrNoServerName.
+func (c *Certificates) ClientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := c.current()
+			return cert, nil
+		},
+		// the chain is verified by VerifyConnection with the current pool
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			_, pool := c.current()
+			return verify(state, pool)
+		},
+	}
+}
+
+func verify(state tls.ConnectionState, roots *x509.CertPool) error {
+	if len(state.PeerCertificates) == 0 {
+		return ErrNoPeerCertificate
+	}
+	// x509 skips the host check of empty names
+	if state.ServerName == "" {
+		return ErrNoServerName
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       state.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := state.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// Listen listens for TLS connections on a TCP address
+func Listen(addr string, config *tls.Config) (net.Listener, error) {
+	return tls.Listen("tcp", addr, config)
+}
+
+// Dialer returns a function dialing TLS connections to TCP addresses,
+// as used by agent.Agent. Unless config sets ServerName, the certificate
+// of the peer is verified against the dialed host, IP addresses included.
+func Dialer(config *tls.Config) func(addr string) (net.Conn, error) {
+	return func(addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		c := config.Clone()
+		if c.ServerName == "" {
+			c.ServerName = host
+		}
+		if verify := c.VerifyConnection; verify != nil {
+			name := c.ServerName
+			c.VerifyConnection = func(state tls.ConnectionState) error {
+				// crypto/tls leaves the server name of IP hosts empty
+				state.ServerName = name
+				return verify(state)
+			}
+		}
+		return tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", addr, c)
+	}
+}
@@ -0,0 +1,198 @@
+package transport
+
+import (
+	"crypto/tls"
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/softswitch"
+	"github.com/ksang/goflow/transport/transporttest"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func certificates(t *testing.T, ca *transporttest.CA, dir, prefix, cn string, names ...string) *Certificates {
+	cert, err := ca.Issue(cn, names...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile, caFile, err := ca.WriteFiles(dir, prefix, cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadCertificates(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func connected(c *controller.Controller, dpid uint64) bool {
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if _, ok := c.Datapath(dpid); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func dial(addr string, dpid uint64, dialer func(string) (net.Conn, error)) *softswitch.Switch {
+	s := softswitch.New(dpid)
+	s.Agent().MinBackoff = 10 * time.Millisecond
+	s.Agent().MaxBackoff = 10 * time.Millisecond
+	s.Agent().Dialer = dialer
+	s.Dial(addr)
+	return s
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca, err := transporttest.NewCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := certificates(t, ca, dir, "controller", "controller", "127.0.0.1")
+	client := certificates(t, ca, dir, "switch", "switch-1")
+
+	c := controller.New()
+	rejected := make(chan error, 16)
+	c.VerifyDPID = func(conn net.Conn, dpid uint64) error {
+		err := DPIDMap{"switch-1": 1, "switch-2": 2}.Verify(conn, dpid)
+		if err != nil {
+			select {
+			case rejected <- err:
+			default:
+			}
+		}
+		return err
+	}
+	ln, err := Listen("127.0.0.1:0", server.ServerConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go c.Serve(ln)
+	addr := ln.Addr().String()
+
+	s1 := dial(addr, 1, Dialer(client.ClientConfig()))
+	defer s1.Close()
+	if !connected(c, 1) {
+		t.Fatal("switch 1 not connected")
+	}
+	// the certificate of switch 1 may not claim datapath 3
+	s3 := dial(addr, 3, Dialer(client.ClientConfig()))
+	defer s3.Close()
+	select {
+	case err := <-rejected:
+		if e, ok := err.(*DPIDMismatchError); !ok || e.Name != "switch-1" || e.DPID != 3 {
+			t.Errorf("datapath 3 rejected with %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("datapath 3 not rejected")
+	}
+	if connected(c, 3) {
+		t.Error("switch 3 connected with the certificate of switch 1")
+	}
+	// certificates of another CA are refused
+	other, _ := transporttest.NewCA()
+	rogue, _ := other.Issue("switch-2")
+	config := client.ClientConfig()
+	config.GetClientCertificate = nil
+	config.Certificates = []tls.Certificate{rogue}
+	if conn, err := Dialer(config)(addr); err == nil {
+		// with TLS 1.3 the client learns of the rejection on its first read
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, err = conn.Read(make([]byte, 1))
+		conn.Close()
+		if ne, ok := err.(net.Error); err == nil || ok && ne.Timeout() {
+			t.Errorf("rogue certificate not refused by the handshake: %v", err)
+		}
+	}
+	s2 := dial(addr, 2, Dialer(config))
+	if connected(c, 2) {
+		t.Error("switch 2 connected with a rogue certificate")
+	}
+	s2.Close()
+
+	// reloading certificates keeps the sessions
+	certificates(t, ca, dir, "switch", "switch-2")
+	if err := client.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	s2 = dial(addr, 2, Dialer(client.ClientConfig()))
+	defer s2.Close()
+	if !connected(c, 2) {
+		t.Error("switch 2 not connected with reloaded certificates")
+	}
+	dp, _ := c.Datapath(1)
+	if err := dp.Barrier(); err != nil {
+		t.Errorf("session of switch 1 lost: %v", err)
+	}
+}
+
+func TestServerName(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca, err := transporttest.NewCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the controller certificate names another host than the dialed one
+	server := certificates(t, ca, dir, "controller", "controller", "controller.example.net", "10.0.0.1")
+	client := certificates(t, ca, dir, "switch", "switch-1")
+	ln, err := Listen("127.0.0.1:0", server.ServerConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}()
+		}
+	}()
+	if conn, err := Dialer(client.ClientConfig())(ln.Addr().String()); err == nil {
+		conn.Close()
+		t.Error("certificate of controller.example.net accepted for 127.0.0.1")
+	}
+	// crypto/tls gives no server name for IP addresses
+	if conn, err := tls.Dial("tcp", ln.Addr().String(), client.ClientConfig()); err == nil {
+		conn.Close()
+		t.Error("certificate of controller.example.net accepted without server name")
+	} else if err != ErrNoServerName {
+		t.Errorf("dialing without server name failed with %v", err)
+	}
+	config := client.ClientConfig()
+	config.ServerName = "controller.example.net"
+	conn, err := Dialer(config)(ln.Addr().String())
+	if err != nil {
+		t.Fatalf("certificate of controller.example.net refused: %v", err)
+	}
+	conn.Close()
+}
+
+func TestDPIDMap(t *testing.T) {
+	ca, _ := transporttest.NewCA()
+	cert, _ := ca.Issue("edge", "edge.example.net", "10.0.0.1")
+	names := CertificateNames(cert.Leaf)
+	if len(names) != 3 || names[0] != "edge" || names[1] != "edge.example.net" || names[2] != "10.0.0.1" {
+		t.Errorf("unexpected names %v", names)
+	}
+	if err := (DPIDMap{}).Verify(nil, 1); err != ErrNoPeerCertificate {
+		t.Errorf("plain connection answered %v", err)
+	}
+}
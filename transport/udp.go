@@ -0,0 +1,278 @@
+package transport
+
+import (
+	"errors"
+	"github.com/ksang/goflow/openflow"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrClosed = errors.New("transport: closed")
+
+// maxDatagram is the largest openflow message, its length is 16 bits
+const maxDatagram = 65535
+
+// datagramQueueLength is how many datagrams of a peer may wait to be
+// read, later datagrams are dropped
+const datagramQueueLength = 256
+
+// acceptQueueLength is how many new peers may wait for Accept, datagrams
+// of further peers are dropped until one is accepted
+const acceptQueueLength = 64
+
+// datagramConn carries openflow messages in datagrams: the message of a
+// datagram can be read in several calls, as openflow.ReadMessage does,
+// bytes after it are discarded and datagrams not starting with a whole
+// message are dropped, as lost ones would be. Each Write must hold whole messages, which are sent in
+// a datagram each. Write deadlines are ignored.
+type datagramConn struct {
+	local   net.Addr
+	remote  net.Addr
+	in      chan []byte
+	done    chan struct{}
+	once    sync.Once
+	write   func([]byte) (int, error)
+	release func()
+
+	// buf is the rest of the message being read
+	buf []byte
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+func newDatagramConn(local, remote net.Addr, write func([]byte) (int, error), release func()) *datagramConn {
+	return &datagramConn{
+		local:   local,
+		remote:  remote,
+		in:      make(chan []byte, datagramQueueLength),
+		done:    make(chan struct{}),
+		write:   write,
+		release: release,
+	}
+}
+
+// deliver queues a datagram for Read
+func (c *datagramConn) deliver(data []byte) {
+	select {
+	case c.in <- data:
+	default:
+	}
+}
+
+func (c *datagramConn) Read(b []byte) (int, error) {
+	var timeout <-chan time.Time
+	for len(c.buf) == 0 {
+		if timeout == nil {
+			c.mu.Lock()
+			deadline := c.deadline
+			c.mu.Unlock()
+			if !deadline.IsZero() {
+				timer := time.NewTimer(time.Until(deadline))
+				defer timer.Stop()
+				timeout = timer.C
+			}
+		}
+		select {
+		case data := <-c.in:
+			v, err := openflow.NewView(data)
+			if err != nil {
+				log.Printf("transport: dropped malformed datagram from %v", c.remote)
+				continue
+			}
+			c.buf = v
+		case <-c.done:
+			return 0, io.EOF
+		case <-timeout:
+			return 0, os.ErrDeadlineExceeded
+		}
+	}
+	n := copy(b, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+func (c *datagramConn) Write(b []byte) (int, error) {
+	select {
+	case <-c.done:
+		return 0, ErrClosed
+	default:
+	}
+	n := 0
+	for len(b) > 0 {
+		size := messageLength(b)
+		w, err := c.write(b[:size])
+		n += w
+		if err != nil {
+			return n, err
+		}
+		b = b[size:]
+	}
+	return n, nil
+}
+
+// messageLength returns the length of the message at the start of b, or
+// of b when it does not start with a whole message
+func messageLength(b []byte) int {
+	v, err := openflow.NewView(b)
+	if err != nil {
+		return len(b)
+	}
+	return len(v)
+}
+
+func (c *datagramConn) Close() error {
+	c.once.Do(func() {
+		close(c.done)
+		c.release()
+	})
+	return nil
+}
+
+func (c *datagramConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *datagramConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *datagramConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+func (c *datagramConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	return nil
+}
+
+func (c *datagramConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+// DialUDP returns a connection sending openflow messages in datagrams to
+// addr
+func DialUDP(addr string) (net.Conn, error) {
+	uc, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c := newDatagramConn(uc.LocalAddr(), uc.RemoteAddr(), uc.Write, func() { uc.Close() })
+	go func() {
+		defer c.Close()
+		buf := make([]byte, maxDatagram)
+		for {
+			n, err := uc.Read(buf)
+			if err != nil {
+				return
+			}
+			c.deliver(append([]byte(nil), buf[:n]...))
+		}
+	}()
+	return c, nil
+}
+
+// udpListener demultiplexes the datagrams of a socket by peer address
+type udpListener struct {
+	pc     net.PacketConn
+	accept chan *datagramConn
+	done   chan struct{}
+	once   sync.Once
+
+	mu    sync.Mutex
+	conns map[string]*datagramConn
+}
+
+// ListenUDP listens for openflow messages in datagrams on addr, the first
+// datagram of a peer address is the start of a connection returned by
+// Accept. Closing a connection does not tell the peer, whose next
+// datagram starts a new connection.
+func ListenUDP(addr string) (net.Listener, error) {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	l := &udpListener{
+		pc:     pc,
+		accept: make(chan *datagramConn, acceptQueueLength),
+		done:   make(chan struct{}),
+		conns:  make(map[string]*datagramConn),
+	}
+	go l.read()
+	return l, nil
+}
+
+func (l *udpListener) read() {
+	defer l.Close()
+	buf := make([]byte, maxDatagram)
+	for {
+		n, addr, err := l.pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		key := addr.String()
+		l.mu.Lock()
+		c, ok := l.conns[key]
+		if !ok {
+			c = newDatagramConn(l.pc.LocalAddr(), addr, func(b []byte) (int, error) {
+				return l.pc.WriteTo(b, addr)
+			}, nil)
+			c.release = func() {
+				l.mu.Lock()
+				if l.conns[key] == c {
+					delete(l.conns, key)
+				}
+				l.mu.Unlock()
+			}
+			l.conns[key] = c
+		}
+		l.mu.Unlock()
+		c.deliver(append([]byte(nil), buf[:n]...))
+		if !ok {
+			// a full queue drops the peer instead of stalling the others
+			select {
+			case l.accept <- c:
+			default:
+				c.Close()
+			}
+		}
+	}
+}
+
+func (l *udpListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.accept:
+		return c, nil
+	case <-l.done:
+		return nil, ErrClosed
+	}
+}
+
+// Close closes the socket and every accepted connection
+func (l *udpListener) Close() error {
+	var err error
+	l.once.Do(func() {
+		close(l.done)
+		err = l.pc.Close()
+		l.mu.Lock()
+		var conns []*datagramConn
+		for _, c := range l.conns {
+			conns = append(conns, c)
+		}
+		l.mu.Unlock()
+		for _, c := range conns {
+			c.Close()
+		}
+	})
+	return err
+}
+
+func (l *udpListener) Addr() net.Addr {
+	return l.pc.LocalAddr()
+}
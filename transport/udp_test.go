@@ -0,0 +1,123 @@
+package transport
+
+import (
+	"github.com/ksang/goflow/openflow"
+	"github.com/ksang/goflow/openflow/v10"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDatagramRead(t *testing.T) {
+	c := newDatagramConn(nil, nil, nil, func() {})
+	defer c.Close()
+	echo, _ := v10.NewEchoRequest(uint32(1)).(openflow.BinaryAppender).AppendBinary(nil)
+	barrier, _ := v10.NewBarrierRequest(uint32(2)).(openflow.BinaryAppender).AppendBinary(nil)
+	// bytes after the message of a datagram are discarded
+	c.deliver(append(append([]byte(nil), echo...), 0xff, 0xff))
+	c.deliver(barrier)
+	// datagrams without a whole message are dropped
+	c.deliver(echo[:4])
+	c.deliver(echo[:len(echo)-1])
+	c.deliver(echo)
+	for _, xid := range []uint32{1, 2, 1} {
+		data, err := openflow.ReadMessage(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg, err := v10.Parse(data); err != nil || msg.TransactionID() != xid {
+			t.Errorf("read %x, %v", data, err)
+		}
+	}
+	c.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if data, err := openflow.ReadMessage(c); err == nil {
+		t.Errorf("read %x", data)
+	}
+}
+
+func TestUDPMalformed(t *testing.T) {
+	ln, err := ListenUDP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	c, err := net.Dial("udp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	hello, _ := v10.NewHello(9).(openflow.BinaryAppender).AppendBinary(nil)
+	c.Write([]byte("garbage"))
+	c.Write(hello)
+	server, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	// the session survives the malformed datagram
+	server.SetReadDeadline(time.Now().Add(2 * time.Second))
+	data, err := openflow.ReadMessage(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, err := v10.Parse(data); err != nil || msg.MsgType() != v10.OFPT_HELLO || msg.TransactionID() != 9 {
+		t.Errorf("read %x, %v", data, err)
+	}
+}
+
+func TestDatagramWrite(t *testing.T) {
+	var datagrams [][]byte
+	c := newDatagramConn(nil, nil, func(b []byte) (int, error) {
+		datagrams = append(datagrams, append([]byte(nil), b...))
+		return len(b), nil
+	}, func() {})
+	defer c.Close()
+	w := openflow.NewWriter(c)
+	if err := w.WriteMessages(v10.NewEchoRequest(uint32(1)), v10.NewBarrierRequest(uint32(2)), v10.NewEchoRequest(uint32(3))); err != nil {
+		t.Fatal(err)
+	}
+	// messages written together are sent in a datagram each
+	if len(datagrams) != 3 {
+		t.Fatalf("%d datagrams written", len(datagrams))
+	}
+	for i, d := range datagrams {
+		if msg, err := v10.Parse(d); err != nil || msg.TransactionID() != uint32(i+1) {
+			t.Errorf("datagram %x, %v", d, err)
+		}
+	}
+}
+
+func TestUDPAccept(t *testing.T) {
+	ln, err := ListenUDP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	hello, _ := v10.NewHello(0).(openflow.BinaryAppender).AppendBinary(nil)
+	first, err := DialUDP(ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	first.Write(hello)
+	server, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// peers nobody accepts do not stall the accepted ones
+	for i := 0; i < acceptQueueLength+8; i++ {
+		c, err := net.Dial("udp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		c.Write(hello)
+	}
+	first.Write(hello)
+	server.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for i := 0; i < 2; i++ {
+		if _, err := openflow.ReadMessage(server); err != nil {
+			t.Fatalf("message %d of the accepted peer: %v", i, err)
+		}
+	}
+}
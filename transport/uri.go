@@ -0,0 +1,90 @@
+package transport
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrBadURI      = errors.New("transport: bad connection URI")
+	ErrNoTLSConfig = errors.New("transport: ssl connection without TLS configuration")
+)
+
+// ParseURI splits a connection URI such as tcp:10.0.0.1:6653 in its
+// network, tcp, ssl, unix or udp, and its address. The port defaults to
+// DefaultPort and addresses without scheme are TCP.
+func ParseURI(uri string) (network, addr string, err error) {
+	network, addr = "tcp", uri
+	if i := strings.Index(uri, ":"); i >= 0 {
+		switch uri[:i] {
+		case "tcp", "ssl", "unix", "udp":
+			network, addr = uri[:i], uri[i+1:]
+		}
+	}
+	if addr == "" {
+		return "", "", ErrBadURI
+	}
+	if network == "unix" {
+		return network, addr, nil
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		host := addr
+		if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+			host = host[1 : len(host)-1]
+		}
+		if strings.ContainsAny(host, "[]") {
+			return "", "", ErrBadURI
+		}
+		addr = net.JoinHostPort(host, strconv.Itoa(DefaultPort))
+	}
+	return network, addr, nil
+}
+
+// ListenURI listens on a connection URI, config is required by ssl. Each
+// UDP peer is accepted as a connection, see ListenUDP.
+func ListenURI(uri string, config *tls.Config) (net.Listener, error) {
+	network, addr, err := ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	switch network {
+	case "ssl":
+		if config == nil {
+			return nil, ErrNoTLSConfig
+		}
+		return Listen(addr, config)
+	case "udp":
+		return ListenUDP(addr)
+	}
+	return net.Listen(network, addr)
+}
+
+// DialURI connects to a connection URI, config is required by ssl
+func DialURI(uri string, config *tls.Config) (net.Conn, error) {
+	return URIDialer(config)(uri)
+}
+
+// URIDialer returns a function dialing connection URIs, as used by
+// agent.Agent
+func URIDialer(config *tls.Config) func(uri string) (net.Conn, error) {
+	return func(uri string) (net.Conn, error) {
+		network, addr, err := ParseURI(uri)
+		if err != nil {
+			return nil, err
+		}
+		switch network {
+		case "ssl":
+			if config == nil {
+				return nil, ErrNoTLSConfig
+			}
+			return Dialer(config)(addr)
+		case "udp":
+			return DialUDP(addr)
+		}
+		return net.DialTimeout(network, addr, 10*time.Second)
+	}
+}
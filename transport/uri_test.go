@@ -0,0 +1,79 @@
+package transport
+
+import (
+	"github.com/ksang/goflow/controller"
+	"github.com/ksang/goflow/transport/transporttest"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseURI(t *testing.T) {
+	for _, c := range []struct {
+		uri, network, addr string
+	}{
+		{"tcp:10.0.0.1:6633", "tcp", "10.0.0.1:6633"},
+		{"tcp:10.0.0.1", "tcp", "10.0.0.1:6653"},
+		{"ssl:[fe80::1]", "ssl", "[fe80::1]:6653"},
+		{"ssl:[fe80::1]:6634", "ssl", "[fe80::1]:6634"},
+		{"udp::6653", "udp", ":6653"},
+		{"unix:/var/run/openvswitch/br0.mgmt", "unix", "/var/run/openvswitch/br0.mgmt"},
+		{"localhost:6633", "tcp", "localhost:6633"},
+		{"10.0.0.1", "tcp", "10.0.0.1:6653"},
+	} {
+		network, addr, err := ParseURI(c.uri)
+		if err != nil || network != c.network || addr != c.addr {
+			t.Errorf("%s parsed as %s %s %v", c.uri, network, addr, err)
+		}
+	}
+	for _, uri := range []string{"", "tcp:", "unix:", "ssl:[::1"} {
+		if _, _, err := ParseURI(uri); err != ErrBadURI {
+			t.Errorf("%q parsed with %v", uri, err)
+		}
+	}
+	if _, err := ListenURI("ssl:127.0.0.1:0", nil); err != ErrNoTLSConfig {
+		t.Errorf("ssl without configuration answered %v", err)
+	}
+}
+
+func TestTransports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca, err := transporttest.NewCA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := certificates(t, ca, dir, "controller", "controller", "127.0.0.1")
+	client := certificates(t, ca, dir, "switch", "switch")
+
+	for i, uri := range []string{
+		"tcp:127.0.0.1:0",
+		"ssl:127.0.0.1:0",
+		"udp:127.0.0.1:0",
+		"unix:" + filepath.Join(dir, "of.sock"),
+	} {
+		network, _, _ := ParseURI(uri)
+		ln, err := ListenURI(uri, server.ServerConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := controller.New()
+		go c.Serve(ln)
+		dpid := uint64(i + 1)
+		s := dial(network+":"+ln.Addr().String(), dpid, URIDialer(client.ClientConfig()))
+		if !connected(c, dpid) {
+			t.Errorf("switch not connected over %s", network)
+		} else {
+			dp, _ := c.Datapath(dpid)
+			if err := dp.Barrier(); err != nil {
+				t.Errorf("barrier over %s: %v", network, err)
+			}
+		}
+		s.Close()
+		ln.Close()
+	}
+}